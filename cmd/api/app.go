@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"log"
-	"os"
 	"time"
 
 	"icafe-registration/internal/config"
@@ -22,34 +21,17 @@ func NewApp() (*App, error) {
 		return nil, err
 	}
 
-	if err := app.initDirectories(); err != nil {
+	app.initRepositories()
+	if err := app.initUsecases(); err != nil {
 		return nil, err
 	}
-
-	app.initRepositories()
-	app.initUsecases()
 	app.createDefaultUsers()
 	app.initRouter()
+	app.initJobs()
 
 	return app, nil
 }
 
-// initDirectories creates necessary upload directories
-func (a *App) initDirectories() error {
-	dirs := []string{
-		a.Config.Upload.Path + "/files",
-		a.Config.Upload.Path + "/videos",
-	}
-
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 // initRouter initializes HTTP router
 func (a *App) initRouter() {
 	a.Router = httpDelivery.NewRouter(
@@ -58,6 +40,24 @@ func (a *App) initRouter() {
 		a.Usecases.Auth,
 		a.Usecases.User,
 		a.Usecases.Customer,
+		a.Usecases.Activity,
+		a.Usecases.Task,
+		a.Usecases.License,
+		a.Usecases.Installation,
+		a.Usecases.Shop,
+		a.Usecases.Address,
+		a.Usecases.Plan,
+		a.Usecases.Subscription,
+		a.Usecases.Invoice,
+		a.Usecases.Payment,
+		a.Usecases.Pricing,
+		a.Usecases.Quote,
+		a.Usecases.Reseller,
+		a.Usecases.Appointment,
+		a.Usecases.Upload,
+		a.Usecases.HLS,
+		a.Usecases.Scan,
+		a.Usecases.Release,
 		a.Config,
 	)
 }
@@ -72,6 +72,10 @@ func (a *App) Run() error {
 func (a *App) Shutdown(ctx context.Context) error {
 	log.Println("Shutting down server...")
 
+	if err := a.Scheduler.Stop(ctx); err != nil {
+		log.Printf("Error stopping background jobs: %v", err)
+	}
+
 	if err := a.Database.MongoDB.Close(ctx); err != nil {
 		log.Printf("Error closing MongoDB connection: %v", err)
 		return err
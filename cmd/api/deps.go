@@ -1,9 +1,23 @@
 package main
 
 import (
+	"fmt"
 	"icafe-registration/internal/config"
+	"icafe-registration/internal/domain"
+	"icafe-registration/internal/notifier"
+	"icafe-registration/internal/payment"
 	"icafe-registration/internal/repository/mongodb"
+	"icafe-registration/internal/scanner"
+	"icafe-registration/internal/storage"
+	"icafe-registration/internal/transcoder"
 	"icafe-registration/internal/usecase"
+	"icafe-registration/pkg/license"
+	"icafe-registration/pkg/pdf"
+	"icafe-registration/pkg/signedlink"
+	"icafe-registration/pkg/update"
+	"log"
+	"os"
+	"os/exec"
 	"time" // Cần import time để sử dụng Duration
 )
 
@@ -24,30 +38,359 @@ func (a *App) initDatabase() error {
 // initRepositories initializes all repositories
 func (a *App) initRepositories() {
 	a.Repos = &RepositoryDeps{
-		Registration: mongodb.NewRegistrationRepository(a.Database.MongoDB.Database),
-		File:         mongodb.NewFileRepository(a.Database.MongoDB.Database),
-		User:         mongodb.NewUserRepository(a.Database.MongoDB.Database),
-		Customer:     mongodb.NewCustomerRepository(a.Database.MongoDB.Database),
+		Registration:   mongodb.NewRegistrationRepository(a.Database.MongoDB.Database),
+		File:           mongodb.NewFileRepository(a.Database.MongoDB.Database),
+		Blob:           mongodb.NewBlobRepository(a.Database.MongoDB.Database),
+		FileLink:       mongodb.NewFileLinkRepository(a.Database.MongoDB.Database),
+		User:           mongodb.NewUserRepository(a.Database.MongoDB.Database),
+		Customer:       mongodb.NewCustomerRepository(a.Database.MongoDB.Database),
+		Activity:       mongodb.NewActivityRepository(a.Database.MongoDB.Database),
+		Task:           mongodb.NewTaskRepository(a.Database.MongoDB.Database),
+		License:        mongodb.NewLicenseRepository(a.Database.MongoDB.Database),
+		Installation:   mongodb.NewInstallationRepository(a.Database.MongoDB.Database),
+		Shop:           mongodb.NewShopRepository(a.Database.MongoDB.Database),
+		Plan:           mongodb.NewPlanRepository(a.Database.MongoDB.Database),
+		Subscription:   mongodb.NewSubscriptionRepository(a.Database.MongoDB.Database),
+		Invoice:        mongodb.NewInvoiceRepository(a.Database.MongoDB.Database),
+		Payment:        mongodb.NewPaymentRepository(a.Database.MongoDB.Database),
+		AddOn:          mongodb.NewAddOnRepository(a.Database.MongoDB.Database),
+		PromoCode:      mongodb.NewPromoCodeRepository(a.Database.MongoDB.Database),
+		Quote:          mongodb.NewQuoteRepository(a.Database.MongoDB.Database),
+		Reseller:       mongodb.NewResellerRepository(a.Database.MongoDB.Database),
+		CommissionRule: mongodb.NewCommissionRuleRepository(a.Database.MongoDB.Database),
+		Commission:     mongodb.NewCommissionRepository(a.Database.MongoDB.Database),
+		Appointment:    mongodb.NewAppointmentRepository(a.Database.MongoDB.Database),
+		CalendarFeed:   mongodb.NewCalendarFeedRepository(a.Database.MongoDB.Database),
+		Upload:         mongodb.NewUploadRepository(a.Database.MongoDB.Database),
+		Release:        mongodb.NewReleaseRepository(a.Database.MongoDB.Database),
 	}
 }
 
 // initUsecases initializes all usecases
-func (a *App) initUsecases() {
+func (a *App) initUsecases() error {
 	// 1. Khai báo contextTimeout (Lấy từ config hoặc set mặc định)
 	// Bạn có thể dùng: contextTimeout := time.Duration(a.Config.App.ContextTimeout) * time.Second
 	contextTimeout := 10 * time.Second
 
+	licenseSigner, err := newLicenseSigner(&a.Config.License, &a.Config.Server)
+	if err != nil {
+		return err
+	}
+	linkSigner, err := newLinkSigner(&a.Config.Upload)
+	if err != nil {
+		return err
+	}
+	mailNotifier := newNotifier(&a.Config.SMTP)
+	fileStorage, err := newFileStorage(&a.Config.Storage, &a.Config.Upload)
+	if err != nil {
+		return err
+	}
+
+	// Paid invoices and online payments of referred customers earn reseller commissions
+	resellers := usecase.NewResellerUsecase(
+		a.Repos.Reseller,
+		a.Repos.CommissionRule,
+		a.Repos.Commission,
+		a.Repos.User,
+		a.Repos.Customer,
+		a.Repos.Registration,
+		a.Repos.Payment,
+		&a.Config.Reseller,
+		contextTimeout,
+	)
+
 	a.Usecases = &UsecaseDeps{
 		// 2. CẬP NHẬT: Truyền thêm a.Repos.Customer vào NewRegistrationUsecase
 		Registration: usecase.NewRegistrationUsecase(
 			a.Repos.Registration,
 			a.Repos.Customer, // Thêm tham số này để lưu data vào bảng customers
+			a.Repos.Reseller,
 			contextTimeout,
 		),
 
-		File:     usecase.NewFileUsecase(a.Repos.File, &a.Config.Upload, contextTimeout),
+		File: usecase.NewFileUsecase(
+			a.Repos.File,
+			a.Repos.Blob,
+			a.Repos.FileLink,
+			a.Repos.Customer,
+			fileStorage,
+			linkSigner,
+			&a.Config.Upload,
+			a.Config.Storage.PresignExpiry,
+			a.Config.Scan.Scanner != "",
+			contextTimeout,
+		),
 		Auth:     usecase.NewAuthUsecase(a.Repos.User, &a.Config.JWT, contextTimeout),
 		User:     usecase.NewUserUsecase(a.Repos.User, contextTimeout),
-		Customer: usecase.NewCustomerUsecase(a.Repos.Customer, contextTimeout),
+		Customer: usecase.NewCustomerUsecase(a.Repos.Customer, a.Repos.Activity, a.Repos.Shop, contextTimeout),
+		Activity: usecase.NewActivityUsecase(a.Repos.Activity, a.Repos.Customer, contextTimeout),
+		Task:     usecase.NewTaskUsecase(a.Repos.Task, a.Repos.Customer, a.Repos.User, contextTimeout),
+		License:  usecase.NewLicenseUsecase(a.Repos.License, a.Repos.Customer, a.Repos.Shop, licenseSigner, contextTimeout),
+		Installation: usecase.NewInstallationUsecase(
+			a.Repos.Installation,
+			a.Repos.Customer,
+			a.Repos.Shop,
+			&a.Config.Installation,
+			contextTimeout,
+		),
+		Shop: usecase.NewShopUsecase(
+			a.Repos.Shop,
+			a.Repos.Customer,
+			a.Repos.File,
+			a.Repos.License,
+			a.Repos.Installation,
+			contextTimeout,
+		),
+		Address: usecase.NewAddressUsecase(a.Repos.Customer, a.Repos.Registration, contextTimeout),
+		Plan:    usecase.NewPlanUsecase(a.Repos.Plan, contextTimeout),
+		Subscription: usecase.NewSubscriptionUsecase(
+			a.Repos.Subscription,
+			a.Repos.Plan,
+			a.Repos.Customer,
+			mailNotifier,
+			a.Config.Billing.ReminderDays,
+			contextTimeout,
+		),
+		Payment: usecase.NewPaymentUsecase(
+			a.Repos.Payment,
+			a.Repos.Subscription,
+			a.Repos.Plan,
+			a.Repos.Customer,
+			newPaymentGateway(&a.Config.Payment),
+			resellers,
+			a.Config.Payment.ExpireAfter,
+			contextTimeout,
+		),
+		Pricing: usecase.NewPricingUsecase(
+			a.Repos.Plan,
+			a.Repos.AddOn,
+			a.Repos.PromoCode,
+			a.Config.Invoice.VATRate,
+			a.Config.Quote.ValidFor,
+			contextTimeout,
+		),
+		Reseller: resellers,
+		Appointment: usecase.NewAppointmentUsecase(
+			a.Repos.Appointment,
+			a.Repos.CalendarFeed,
+			a.Repos.Registration,
+			a.Repos.Customer,
+			a.Repos.User,
+			&a.Config.Appointment,
+			contextTimeout,
+		),
+	}
+
+	// Resumable uploads become files through the file usecase once complete
+	a.Usecases.Upload = usecase.NewUploadUsecase(a.Repos.Upload, a.Usecases.File, fileStorage, &a.Config.Upload, contextTimeout)
+
+	// MP4 videos are packaged as HLS by the package-hls job when a transcoder is set
+	transcoder, err := newTranscoder(&a.Config.Video)
+	if err != nil {
+		return err
+	}
+	a.Usecases.HLS = usecase.NewHLSUsecase(
+		a.Repos.File,
+		fileStorage,
+		transcoder,
+		a.Config.Upload.BaseURL+"/api/v1",
+		a.Config.Video.SegmentDuration,
+		contextTimeout,
+	)
+
+	// Uploads are quarantined until the scan-files job finds them clean, when a scanner is set
+	scanner, err := newScanner(&a.Config.Scan)
+	if err != nil {
+		return err
+	}
+	a.Usecases.Scan = usecase.NewScanUsecase(
+		a.Repos.File,
+		a.Repos.User,
+		fileStorage,
+		scanner,
+		mailNotifier,
+		contextTimeout,
+	)
+
+	// Release builds are uploaded files, update manifests are signed with their own key
+	releaseSigner, err := newReleaseSigner(&a.Config.Release)
+	if err != nil {
+		return err
+	}
+	a.Usecases.Release = usecase.NewReleaseUsecase(
+		a.Repos.Release,
+		a.Usecases.File,
+		releaseSigner,
+		a.Config.Upload.BaseURL+"/api/v1/files/download-by-id/",
+		a.Config.Release.ManifestTTL,
+		contextTimeout,
+	)
+
+	// Invoices store their PDFs through the file usecase
+	regularFont, boldFont, err := newInvoiceFonts(&a.Config.Invoice)
+	if err != nil {
+		return err
+	}
+	a.Usecases.Invoice = usecase.NewInvoiceUsecase(
+		a.Repos.Invoice,
+		a.Repos.Customer,
+		a.Repos.Subscription,
+		a.Usecases.File,
+		resellers,
+		&a.Config.Invoice,
+		regularFont,
+		boldFont,
+		contextTimeout,
+	)
+
+	// Quotes are printed with the invoice seller details and fonts
+	a.Usecases.Quote = usecase.NewQuoteUsecase(
+		a.Repos.Quote,
+		a.Repos.Registration,
+		a.Repos.Customer,
+		a.Repos.Activity,
+		a.Repos.Plan,
+		a.Repos.AddOn,
+		a.Repos.PromoCode,
+		mailNotifier,
+		&a.Config.Quote,
+		&a.Config.Invoice,
+		regularFont,
+		boldFont,
+		contextTimeout,
+	)
+
+	return nil
+}
+
+// newLicenseSigner loads the license signing key, or generates a throwaway one for development.
+// Keys signed by a throwaway key stop verifying after a restart, so outside development a
+// missing key stops the server instead.
+func newLicenseSigner(cfg *config.LicenseConfig, server *config.ServerConfig) (*license.Signer, error) {
+	if cfg.SigningKey != "" {
+		return license.NewSigner(cfg.SigningKey)
+	}
+	if !server.IsDevelopment() {
+		return nil, fmt.Errorf("LICENSE_SIGNING_KEY is required when APP_ENV is %q", server.Env)
+	}
+
+	log.Println("WARNING: LICENSE_SIGNING_KEY is not set, license keys signed by this process will not verify after a restart")
+	return license.GenerateSigner()
+}
+
+// newLinkSigner loads the download link secret, or generates a throwaway one for development
+func newLinkSigner(cfg *config.UploadConfig) (*signedlink.Signer, error) {
+	if cfg.LinkSecret != "" {
+		return signedlink.NewSigner([]byte(cfg.LinkSecret))
+	}
+
+	log.Println("WARNING: FILE_LINK_SECRET is not set, signed download links will stop working after a restart")
+	return signedlink.GenerateSigner()
+}
+
+// newReleaseSigner loads the update manifest signing key, or generates a throwaway one for development
+func newReleaseSigner(cfg *config.ReleaseConfig) (*update.Signer, error) {
+	if cfg.SigningKey != "" {
+		return update.NewSigner(cfg.SigningKey)
+	}
+
+	log.Println("WARNING: RELEASE_SIGNING_KEY is not set, update manifests signed by this process will not verify after a restart")
+	return update.GenerateSigner()
+}
+
+// newNotifier emails customers through SMTP when configured, and only logs notifications otherwise
+func newNotifier(cfg *config.SMTPConfig) domain.Notifier {
+	if cfg.Host == "" {
+		log.Println("WARNING: SMTP_HOST is not set, notifications will only be logged")
+		return notifier.NewLogNotifier()
+	}
+
+	return notifier.NewSMTPNotifier(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From)
+}
+
+// newFileStorage returns the storage selected by STORAGE_DRIVER
+func newFileStorage(cfg *config.StorageConfig, uploadCfg *config.UploadConfig) (domain.FileStorage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return storage.NewLocalStorage(uploadCfg.Path)
+	case "s3":
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	}
+	return nil, fmt.Errorf("unknown STORAGE_DRIVER %q, use local or s3", cfg.Driver)
+}
+
+// newTranscoder returns the transcoder selected by VIDEO_TRANSCODER, or nil when
+// HLS packaging is disabled
+func newTranscoder(cfg *config.VideoConfig) (domain.Transcoder, error) {
+	switch cfg.Transcoder {
+	case "":
+		return nil, nil
+	case "ffmpeg":
+		if _, err := exec.LookPath(cfg.FFmpegPath); err != nil {
+			return nil, fmt.Errorf("VIDEO_TRANSCODER is ffmpeg but FFMPEG_PATH %q cannot be run: %w", cfg.FFmpegPath, err)
+		}
+		return transcoder.NewFFmpegTranscoder(cfg.FFmpegPath), nil
+	case "fake":
+		log.Println("WARNING: VIDEO_TRANSCODER is fake, HLS packages of videos cannot be played")
+		return transcoder.NewFakeTranscoder(), nil
+	}
+	return nil, fmt.Errorf("unknown VIDEO_TRANSCODER %q, use ffmpeg or fake", cfg.Transcoder)
+}
+
+// newScanner returns the malware scanner selected by FILE_SCANNER, or nil when
+// scanning is disabled
+func newScanner(cfg *config.ScanConfig) (domain.Scanner, error) {
+	switch cfg.Scanner {
+	case "":
+		log.Println("WARNING: FILE_SCANNER is not set, uploads are served without a malware scan")
+		return nil, nil
+	case "clamd":
+		return scanner.NewClamdScanner(cfg.ClamdAddress, cfg.Timeout)
+	case "fake":
+		log.Println("WARNING: FILE_SCANNER is fake, uploads are only checked for the EICAR test file")
+		return scanner.NewFakeScanner(), nil
+	}
+	return nil, fmt.Errorf("unknown FILE_SCANNER %q, use clamd or fake", cfg.Scanner)
+}
+
+// newPaymentGateway returns the VNPay gateway, or nil to disable online payment when it is not configured
+func newPaymentGateway(cfg *config.PaymentConfig) domain.PaymentGateway {
+	if cfg.VNPayTmnCode == "" || cfg.VNPayHashSecret == "" {
+		log.Println("WARNING: VNPAY_TMN_CODE or VNPAY_HASH_SECRET is not set, online payment is disabled")
+		return nil
+	}
+
+	return payment.NewVNPayGateway(cfg.VNPayTmnCode, cfg.VNPayHashSecret, cfg.VNPayURL, cfg.VNPayReturnURL)
+}
+
+// newInvoiceFonts loads the invoice and quote PDF fonts. The standard PDF fonts
+// cannot print Vietnamese diacritics, so a missing font stops the server instead
+// of silently producing invoices without them.
+func newInvoiceFonts(cfg *config.InvoiceConfig) (*pdf.Font, *pdf.Font, error) {
+	regular, err := loadFont(cfg.FontPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load INVOICE_FONT_PATH %q: %w", cfg.FontPath, err)
+	}
+
+	bold, err := loadFont(cfg.BoldFontPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load INVOICE_BOLD_FONT_PATH %q: %w", cfg.BoldFontPath, err)
+	}
+
+	return regular, bold, nil
+}
+
+func loadFont(path string) (*pdf.Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
+	return pdf.ParseTrueType(data)
 }
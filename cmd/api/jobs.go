@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"icafe-registration/pkg/scheduler"
+)
+
+// initJobs registers and starts the background jobs
+func (a *App) initJobs() {
+	a.Scheduler = scheduler.New()
+
+	if a.Config.Trash.RetentionDays > 0 {
+		a.Scheduler.Every("purge-trash", a.Config.Trash.PurgeInterval, a.purgeTrash)
+	}
+
+	if a.Config.Billing.RenewalCheckInterval > 0 {
+		a.Scheduler.Every("subscription-renewals", a.Config.Billing.RenewalCheckInterval, a.processRenewals)
+	}
+
+	a.Scheduler.Every("expire-uploads", time.Hour, a.expireUploads)
+
+	if a.Config.Video.Transcoder != "" {
+		a.Scheduler.Every("package-hls", a.Config.Video.PackageInterval, a.packageHLS)
+	}
+
+	if a.Config.Scan.Scanner != "" {
+		a.Scheduler.Every("scan-files", a.Config.Scan.Interval, a.scanFiles)
+	}
+
+	a.Scheduler.Start()
+}
+
+// purgeTrash permanently deletes records that stayed in the trash longer than the retention period
+func (a *App) purgeTrash(ctx context.Context) error {
+	before := time.Now().AddDate(0, 0, -a.Config.Trash.RetentionDays)
+
+	purges := []struct {
+		name  string
+		purge func(ctx context.Context, before time.Time) (int64, error)
+	}{
+		{"customers", a.Usecases.Customer.Purge},
+		{"registrations", a.Usecases.Registration.Purge},
+		{"users", a.Usecases.User.Purge},
+	}
+
+	for _, p := range purges {
+		n, err := p.purge(ctx, before)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("Purged %d deleted %s", n, p.name)
+		}
+	}
+
+	return nil
+}
+
+// processRenewals flags overdue subscriptions and sends the due renewal reminders
+func (a *App) processRenewals(ctx context.Context) error {
+	report, err := a.Usecases.Subscription.ProcessRenewals(ctx)
+	if err != nil {
+		return err
+	}
+
+	if report.Reminded > 0 || report.PastDue > 0 || report.Failed > 0 {
+		log.Printf("Renewal check: %d reminded, %d past due, %d notifications failed", report.Reminded, report.PastDue, report.Failed)
+	}
+	return nil
+}
+
+// expireUploads discards resumable uploads that were not completed in time
+func (a *App) expireUploads(ctx context.Context) error {
+	n, err := a.Usecases.Upload.PurgeExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("Discarded %d expired uploads", n)
+	}
+	return nil
+}
+
+// packageHLS packages newly uploaded MP4 videos as HLS
+func (a *App) packageHLS(ctx context.Context) error {
+	n, err := a.Usecases.HLS.PackagePending(ctx)
+	if n > 0 {
+		log.Printf("Packaged %d videos as HLS", n)
+	}
+	return err
+}
+
+// scanFiles scans quarantined uploads for malware
+func (a *App) scanFiles(ctx context.Context) error {
+	report, err := a.Usecases.Scan.ScanPending(ctx)
+	if report != nil && (report.Clean > 0 || report.Infected > 0 || report.Failed > 0) {
+		log.Printf("Malware scan: %d clean, %d infected, %d failed", report.Clean, report.Infected, report.Failed)
+	}
+	return err
+}
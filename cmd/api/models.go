@@ -4,6 +4,7 @@ import (
 	"icafe-registration/internal/config"
 	httpDelivery "icafe-registration/internal/delivery/http"
 	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/scheduler"
 )
 
 // =============================================================================
@@ -12,11 +13,12 @@ import (
 
 // App holds all application dependencies
 type App struct {
-	Config   *config.Config
-	Database *DatabaseDeps
-	Repos    *RepositoryDeps
-	Usecases *UsecaseDeps
-	Router   *httpDelivery.Router
+	Config    *config.Config
+	Database  *DatabaseDeps
+	Repos     *RepositoryDeps
+	Usecases  *UsecaseDeps
+	Router    *httpDelivery.Router
+	Scheduler *scheduler.Scheduler
 }
 
 // =============================================================================
@@ -30,10 +32,31 @@ type DatabaseDeps struct {
 
 // RepositoryDeps holds all repositories
 type RepositoryDeps struct {
-	Registration domain.RegistrationRepository
-	File         domain.FileRepository
-	User         domain.UserRepository
-	Customer     domain.CustomerRepository
+	Registration   domain.RegistrationRepository
+	File           domain.FileRepository
+	Blob           domain.BlobRepository
+	FileLink       domain.FileLinkRepository
+	User           domain.UserRepository
+	Customer       domain.CustomerRepository
+	Activity       domain.ActivityRepository
+	Task           domain.TaskRepository
+	License        domain.LicenseRepository
+	Installation   domain.InstallationRepository
+	Shop           domain.ShopRepository
+	Plan           domain.PlanRepository
+	Subscription   domain.SubscriptionRepository
+	Invoice        domain.InvoiceRepository
+	Payment        domain.PaymentRepository
+	AddOn          domain.AddOnRepository
+	PromoCode      domain.PromoCodeRepository
+	Quote          domain.QuoteRepository
+	Reseller       domain.ResellerRepository
+	CommissionRule domain.CommissionRuleRepository
+	Commission     domain.CommissionRepository
+	Appointment    domain.AppointmentRepository
+	CalendarFeed   domain.CalendarFeedRepository
+	Upload         domain.UploadRepository
+	Release        domain.ReleaseRepository
 }
 
 // UsecaseDeps holds all usecases
@@ -43,6 +66,24 @@ type UsecaseDeps struct {
 	Auth         domain.AuthUsecase
 	User         domain.UserUsecase
 	Customer     domain.CustomerUsecase
+	Activity     domain.ActivityUsecase
+	Task         domain.TaskUsecase
+	License      domain.LicenseUsecase
+	Installation domain.InstallationUsecase
+	Shop         domain.ShopUsecase
+	Address      domain.AddressUsecase
+	Plan         domain.PlanUsecase
+	Subscription domain.SubscriptionUsecase
+	Invoice      domain.InvoiceUsecase
+	Payment      domain.PaymentUsecase
+	Pricing      domain.PricingUsecase
+	Quote        domain.QuoteUsecase
+	Reseller     domain.ResellerUsecase
+	Appointment  domain.AppointmentUsecase
+	Upload       domain.UploadUsecase
+	HLS          domain.HLSUsecase
+	Scan         domain.ScanUsecase
+	Release      domain.ReleaseUsecase
 }
 
 // =============================================================================
@@ -0,0 +1,30 @@
+// Command fakes3 runs a local, in-memory stand-in for an S3-compatible bucket,
+// so the S3 storage driver can be tested without AWS or MinIO. Run the API with
+// STORAGE_DRIVER=s3, S3_ENDPOINT=http://localhost:9000, S3_PATH_STYLE=true and
+// the same bucket and keys. Contents are lost when it stops.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"icafe-registration/internal/storage"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	bucket := flag.String("bucket", os.Getenv("S3_BUCKET"), "bucket name")
+	region := flag.String("region", os.Getenv("S3_REGION"), "region")
+	accessKey := flag.String("access-key", os.Getenv("S3_ACCESS_KEY"), "access key")
+	secretKey := flag.String("secret-key", os.Getenv("S3_SECRET_KEY"), "secret key")
+	flag.Parse()
+
+	if *bucket == "" || *accessKey == "" || *secretKey == "" {
+		log.Fatal("the bucket, access key and secret key are required (S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY or flags)")
+	}
+
+	log.Printf("Fake S3 listening on %s, serving bucket %s", *addr, *bucket)
+	log.Fatal(http.ListenAndServe(*addr, storage.NewFakeS3(*bucket, *region, *accessKey, *secretKey)))
+}
@@ -0,0 +1,29 @@
+// Command fakevnpay runs a local stand-in for the VNPay payment page, so online
+// renewals can be tested without a merchant account. Point VNPAY_URL at
+// http://localhost:9090/paymentv2/vpcpay.html and use the same terminal code and
+// hash secret as the API.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"icafe-registration/internal/payment"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	tmnCode := flag.String("tmn-code", os.Getenv("VNPAY_TMN_CODE"), "terminal code")
+	hashSecret := flag.String("hash-secret", os.Getenv("VNPAY_HASH_SECRET"), "hash secret")
+	ipnURL := flag.String("ipn-url", "http://localhost:8080/api/v1/payments/vnpay/ipn", "IPN URL of the API")
+	flag.Parse()
+
+	if *tmnCode == "" || *hashSecret == "" {
+		log.Fatal("the terminal code and hash secret are required (VNPAY_TMN_CODE, VNPAY_HASH_SECRET or flags)")
+	}
+
+	log.Printf("Fake VNPay listening on %s, notifying %s", *addr, *ipnURL)
+	log.Fatal(http.ListenAndServe(*addr, payment.NewFakeVNPay(*tmnCode, *hashSecret, *ipnURL)))
+}
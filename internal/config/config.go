@@ -3,17 +3,40 @@ package config
 import (
 	"log"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server  ServerConfig
-	MongoDB MongoDBConfig
-	Upload  UploadConfig
-	JWT     JWTConfig
+	Server       ServerConfig
+	MongoDB      MongoDBConfig
+	Upload       UploadConfig
+	Storage      StorageConfig
+	Video        VideoConfig
+	Scan         ScanConfig
+	JWT          JWTConfig
+	Trash        TrashConfig
+	License      LicenseConfig
+	Release      ReleaseConfig
+	Installation InstallationConfig
+	Billing      BillingConfig
+	SMTP         SMTPConfig
+	Invoice      InvoiceConfig
+	Payment      PaymentConfig
+	Quote        QuoteConfig
+	Reseller     ResellerConfig
+	Appointment  AppointmentConfig
+}
+
+// TrashConfig holds soft delete retention configuration
+type TrashConfig struct {
+	RetentionDays int           // deleted records older than this are purged, 0 disables purging
+	PurgeInterval time.Duration // how often the purge job runs
 }
 
 // JWTConfig holds JWT configuration
@@ -27,6 +50,12 @@ type JWTConfig struct {
 type ServerConfig struct {
 	Port string
 	Host string
+	Env  string // "development" lets the server start without its signing keys
+}
+
+// IsDevelopment reports whether the server runs in development
+func (c *ServerConfig) IsDevelopment() bool {
+	return c.Env == "development"
 }
 
 // MongoDBConfig holds MongoDB configuration
@@ -37,10 +66,130 @@ type MongoDBConfig struct {
 
 // UploadConfig holds file upload configuration
 type UploadConfig struct {
-	Path         string
-	MaxFileSize  int64
-	AllowedTypes []string
-	BaseURL      string
+	Path           string
+	MaxFileSize    int64
+	AllowedTypes   []string // content types accepted for documents and videos
+	InstallerTypes []string // content types accepted for installers
+	BaseURL        string
+
+	ResumableExpiry time.Duration // how long a resumable upload can be resumed
+
+	LinkSecret string // HMAC secret of signed download links, at least 32 characters
+
+	ImageVariants []ImageVariant // resized copies made of uploaded images
+	ImageQuality  int            // JPEG quality of the resized copies, 1 to 100
+}
+
+// ImageVariant is a resized copy made of uploaded images
+type ImageVariant struct {
+	Name    string // lowercase letters, digits, dashes and underscores, used in its URL
+	MaxSize int    // images are scaled down to fit within this many pixels square
+}
+
+// StorageConfig holds where file contents are stored: "local" keeps them under
+// UPLOAD_PATH, "s3" in an S3-compatible bucket such as AWS S3 or MinIO
+type StorageConfig struct {
+	Driver        string
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3PathStyle   bool          // bucket in the path instead of the host name, as MinIO expects
+	PresignExpiry time.Duration // lifetime of direct download links, 0 streams downloads through the API
+}
+
+// VideoConfig holds the HLS packaging of uploaded MP4 videos. Packaging is
+// disabled when Transcoder is empty.
+type VideoConfig struct {
+	Transcoder      string        // "ffmpeg", or "fake" to try packaging without ffmpeg
+	FFmpegPath      string        // ffmpeg binary, looked up in PATH when a bare name
+	SegmentDuration time.Duration // target length of HLS segments
+	PackageInterval time.Duration // how often new videos are packaged
+}
+
+// ScanConfig holds the malware scanning of uploads. Uploads are quarantined
+// until scanned clean; scanning is disabled when Scanner is empty.
+type ScanConfig struct {
+	Scanner      string        // "clamd", or "fake" to try scanning without ClamAV
+	ClamdAddress string        // tcp://host:port or unix:///path/to/clamd.sock
+	Timeout      time.Duration // longest scan of a single file
+	Interval     time.Duration // how often quarantined uploads are scanned
+}
+
+// LicenseConfig holds license key signing configuration
+type LicenseConfig struct {
+	SigningKey string // base64 Ed25519 seed or private key
+}
+
+// ReleaseConfig holds software update manifest signing configuration
+type ReleaseConfig struct {
+	SigningKey  string        // base64 Ed25519 seed or private key
+	ManifestTTL time.Duration // how long signed update manifests are valid
+}
+
+// InstallationConfig holds cafe client heartbeat configuration
+type InstallationConfig struct {
+	HeartbeatInterval time.Duration // interval clients are asked to report at
+	StaleAfter        time.Duration // installations silent for longer are flagged as stale
+}
+
+// BillingConfig holds subscription renewal configuration
+type BillingConfig struct {
+	RenewalCheckInterval time.Duration // how often renewals are checked and reminders sent
+	ReminderDays         []int         // days before a renewal date reminders are sent
+}
+
+// SMTPConfig holds the mail server used for customer notifications.
+// Notifications are only logged when Host is empty.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// InvoiceConfig holds the seller details and numbering of VAT invoices
+type InvoiceConfig struct {
+	Series            string // invoice series, {YY} is replaced by the year of issue
+	VATRate           int    // default VAT rate in percent
+	SellerName        string
+	SellerTaxCode     string
+	SellerAddress     string
+	SellerPhone       string
+	SellerBankAccount string
+	FontPath          string // TrueType font with Vietnamese glyphs for invoice PDFs
+	BoldFontPath      string
+}
+
+// PaymentConfig holds the VNPay merchant account used for online renewals.
+// Online payment is disabled when TmnCode or HashSecret is empty.
+type PaymentConfig struct {
+	VNPayTmnCode    string
+	VNPayHashSecret string
+	VNPayURL        string        // payment page the customer is redirected to
+	VNPayReturnURL  string        // where the customer comes back after paying
+	ExpireAfter     time.Duration // how long a payment link can be paid
+}
+
+// QuoteConfig holds the validity and the link of sales quotes
+type QuoteConfig struct {
+	ValidFor time.Duration // how long a quote can be accepted
+	LinkURL  string        // the link sent to prospects is LinkURL followed by the quote token
+}
+
+// ResellerConfig holds the referral link shared by resellers
+type ResellerConfig struct {
+	LinkURL string // the referral link is LinkURL followed by the referral code
+}
+
+// AppointmentConfig holds the working hours offered for appointments and the calendar feed link
+type AppointmentConfig struct {
+	WorkdayStart time.Duration // first slot of a day, after midnight Vietnam time
+	WorkdayEnd   time.Duration // appointments offered as free slots end by then
+	SlotStep     time.Duration // free slots start on multiples of this
+	FeedURL      string        // a calendar feed link is FeedURL followed by the feed token and .ics
 }
 
 // LoadConfig loads configuration from environment variables
@@ -53,11 +202,34 @@ func LoadConfig() *Config {
 	maxFileSize, _ := strconv.ParseInt(getEnv("MAX_FILE_SIZE", "52428800"), 10, 64)                  // 50MB default
 	accessTokenDuration, _ := strconv.ParseInt(getEnv("JWT_ACCESS_TOKEN_DURATION", "15"), 10, 64)    // 15 minutes
 	refreshTokenDuration, _ := strconv.ParseInt(getEnv("JWT_REFRESH_TOKEN_DURATION", "168"), 10, 64) // 7 days
+	trashRetentionDays, _ := strconv.Atoi(getEnv("TRASH_RETENTION_DAYS", "30"))                      // 30 days
+	trashPurgeInterval, _ := strconv.Atoi(getEnv("TRASH_PURGE_INTERVAL_HOURS", "24"))                // daily
+	heartbeatInterval, _ := strconv.Atoi(getEnv("INSTALLATION_HEARTBEAT_MINUTES", "15"))             // 15 minutes
+	staleAfter, _ := strconv.Atoi(getEnv("INSTALLATION_STALE_HOURS", "24"))                          // 1 day
+	renewalCheckInterval, _ := strconv.Atoi(getEnv("BILLING_RENEWAL_CHECK_HOURS", "24"))             // daily
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	vatRate, _ := strconv.Atoi(getEnv("INVOICE_VAT_RATE", "10"))             // 10%
+	paymentExpire, _ := strconv.Atoi(getEnv("PAYMENT_EXPIRE_MINUTES", "15")) // 15 minutes
+	quoteValidDays, _ := strconv.Atoi(getEnv("QUOTE_VALID_DAYS", "30"))      // 30 days
+	workdayStart, _ := strconv.Atoi(getEnv("APPOINTMENT_WORKDAY_START_HOUR", "8"))
+	workdayEnd, _ := strconv.Atoi(getEnv("APPOINTMENT_WORKDAY_END_HOUR", "18"))
+	slotStep, _ := strconv.Atoi(getEnv("APPOINTMENT_SLOT_MINUTES", "30"))
+	uploadExpire, _ := strconv.Atoi(getEnv("UPLOAD_RESUMABLE_EXPIRE_HOURS", "24")) // 1 day
+	presignMinutes, _ := strconv.Atoi(getEnv("STORAGE_PRESIGN_MINUTES", "15"))
+	s3PathStyle, _ := strconv.ParseBool(getEnv("S3_PATH_STYLE", "true"))
+	hlsSegment, _ := strconv.Atoi(getEnv("HLS_SEGMENT_SECONDS", "6"))
+	hlsInterval, _ := strconv.Atoi(getEnv("HLS_PACKAGE_INTERVAL_MINUTES", "5"))
+	scanTimeout, _ := strconv.Atoi(getEnv("CLAMD_TIMEOUT_SECONDS", "300"))
+	scanInterval, _ := strconv.Atoi(getEnv("SCAN_INTERVAL_SECONDS", "30"))
+	manifestTTL, _ := strconv.Atoi(getEnv("RELEASE_MANIFEST_TTL_HOURS", "24"))
+	imageQuality, _ := strconv.Atoi(getEnv("IMAGE_JPEG_QUALITY", "85"))
+	baseURL := getEnv("BASE_URL", "http://localhost:8080")
 
 	return &Config{
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "8080"),
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+			Env:  getEnv("APP_ENV", "production"),
 		},
 		MongoDB: MongoDBConfig{
 			URI:      getEnv("MONGODB_URI", "mongodb://localhost:27017"),
@@ -74,16 +246,107 @@ func LoadConfig() *Config {
 				"application/msword",
 				"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
 				"application/vnd.android.package-archive", // Cho file .apk
-				"application/x-msdownload",                // Cho file .exe
+			},
+			InstallerTypes: []string{
+				"application/x-msdownload", // Cho file .exe
+				"application/x-msi",
+				"application/vnd.android.package-archive",
+				"application/zip",
 				"application/octet-stream",
 			},
-			BaseURL: getEnv("BASE_URL", "http://localhost:8080"),
+			BaseURL: baseURL,
+
+			ResumableExpiry: time.Duration(uploadExpire) * time.Hour,
+
+			LinkSecret: getEnv("FILE_LINK_SECRET", ""),
+
+			ImageVariants: getEnvImageVariants("IMAGE_VARIANTS", "thumb:200,medium:800"),
+			ImageQuality:  imageQuality,
+		},
+		Storage: StorageConfig{
+			Driver:        getEnv("STORAGE_DRIVER", "local"),
+			S3Endpoint:    getEnv("S3_ENDPOINT", ""),
+			S3Region:      getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:      getEnv("S3_BUCKET", ""),
+			S3AccessKey:   getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:   getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle:   s3PathStyle,
+			PresignExpiry: time.Duration(presignMinutes) * time.Minute,
+		},
+		Video: VideoConfig{
+			Transcoder:      getEnv("VIDEO_TRANSCODER", ""),
+			FFmpegPath:      getEnv("FFMPEG_PATH", "ffmpeg"),
+			SegmentDuration: time.Duration(hlsSegment) * time.Second,
+			PackageInterval: time.Duration(hlsInterval) * time.Minute,
+		},
+		Scan: ScanConfig{
+			Scanner:      getEnv("FILE_SCANNER", ""),
+			ClamdAddress: getEnv("CLAMD_ADDRESS", "tcp://localhost:3310"),
+			Timeout:      time.Duration(scanTimeout) * time.Second,
+			Interval:     time.Duration(scanInterval) * time.Second,
 		},
 		JWT: JWTConfig{
 			SecretKey:            getEnv("JWT_SECRET_KEY", "your-super-secret-key-change-in-production"),
 			AccessTokenDuration:  accessTokenDuration,
 			RefreshTokenDuration: refreshTokenDuration,
 		},
+		Trash: TrashConfig{
+			RetentionDays: trashRetentionDays,
+			PurgeInterval: time.Duration(trashPurgeInterval) * time.Hour,
+		},
+		License: LicenseConfig{
+			SigningKey: getEnv("LICENSE_SIGNING_KEY", ""),
+		},
+		Release: ReleaseConfig{
+			SigningKey:  getEnv("RELEASE_SIGNING_KEY", ""),
+			ManifestTTL: time.Duration(manifestTTL) * time.Hour,
+		},
+		Installation: InstallationConfig{
+			HeartbeatInterval: time.Duration(heartbeatInterval) * time.Minute,
+			StaleAfter:        time.Duration(staleAfter) * time.Hour,
+		},
+		Billing: BillingConfig{
+			RenewalCheckInterval: time.Duration(renewalCheckInterval) * time.Hour,
+			ReminderDays:         getEnvInts("BILLING_REMINDER_DAYS", "7,3,1"),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     smtpPort,
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "no-reply@localhost"),
+		},
+		Invoice: InvoiceConfig{
+			Series:            getEnv("INVOICE_SERIES", "1C{YY}TAA"),
+			VATRate:           vatRate,
+			SellerName:        getEnv("INVOICE_SELLER_NAME", ""),
+			SellerTaxCode:     getEnv("INVOICE_SELLER_TAX_CODE", ""),
+			SellerAddress:     getEnv("INVOICE_SELLER_ADDRESS", ""),
+			SellerPhone:       getEnv("INVOICE_SELLER_PHONE", ""),
+			SellerBankAccount: getEnv("INVOICE_SELLER_BANK_ACCOUNT", ""),
+			FontPath:          getEnv("INVOICE_FONT_PATH", "/usr/share/fonts/dejavu/DejaVuSans.ttf"),
+			BoldFontPath:      getEnv("INVOICE_BOLD_FONT_PATH", "/usr/share/fonts/dejavu/DejaVuSans-Bold.ttf"),
+		},
+		Payment: PaymentConfig{
+			VNPayTmnCode:    getEnv("VNPAY_TMN_CODE", ""),
+			VNPayHashSecret: getEnv("VNPAY_HASH_SECRET", ""),
+			VNPayURL:        getEnv("VNPAY_URL", "https://sandbox.vnpayment.vn/paymentv2/vpcpay.html"),
+			VNPayReturnURL:  getEnv("VNPAY_RETURN_URL", baseURL+"/api/v1/payments/vnpay/return"),
+			ExpireAfter:     time.Duration(paymentExpire) * time.Minute,
+		},
+		Quote: QuoteConfig{
+			ValidFor: time.Duration(quoteValidDays) * 24 * time.Hour,
+			LinkURL:  getEnv("QUOTE_LINK_URL", baseURL+"/api/v1/quotes/public/"),
+		},
+		Reseller: ResellerConfig{
+			LinkURL: getEnv("REFERRAL_LINK_URL", baseURL+"/?ref="),
+		},
+		Appointment: AppointmentConfig{
+			WorkdayStart: time.Duration(workdayStart) * time.Hour,
+			WorkdayEnd:   time.Duration(workdayEnd) * time.Hour,
+			SlotStep:     time.Duration(slotStep) * time.Minute,
+			FeedURL:      getEnv("CALENDAR_FEED_URL", baseURL+"/api/v1/calendar/"),
+		},
 	}
 }
 
@@ -94,3 +357,32 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInts gets a comma-separated list of positive integers, skipping invalid entries
+func getEnvInts(key, defaultValue string) []int {
+	values := []int{}
+	for _, part := range strings.Split(getEnv(key, defaultValue), ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil && n > 0 {
+			values = append(values, n)
+		}
+	}
+	return values
+}
+
+// variantNamePattern is the form of image variant names
+var variantNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// getEnvImageVariants parses comma separated name:size image variants, skipping
+// malformed ones
+func getEnvImageVariants(key, defaultValue string) []ImageVariant {
+	variants := []ImageVariant{}
+	for _, part := range strings.Split(getEnv(key, defaultValue), ",") {
+		name, size, ok := strings.Cut(strings.TrimSpace(part), ":")
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if ok && err == nil && n > 0 && variantNamePattern.MatchString(name) {
+			variants = append(variants, ImageVariant{Name: name, MaxSize: n})
+		}
+	}
+	return variants
+}
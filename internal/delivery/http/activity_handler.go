@@ -0,0 +1,204 @@
+package http
+
+import (
+	"net/http"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActivityHandler represents the HTTP handler for the customer timeline
+type ActivityHandler struct {
+	activityUsecase domain.ActivityUsecase
+	validator       *validator.CustomValidator
+}
+
+// NewActivityHandler creates a new customer activity handler (admin and sale)
+func NewActivityHandler(router *gin.RouterGroup, uc domain.ActivityUsecase) {
+	handler := &ActivityHandler{
+		activityUsecase: uc,
+		validator:       validator.NewValidator(),
+	}
+
+	activities := router.Group("/customers/:id/activities")
+	{
+		activities.GET("", handler.GetByCustomer)
+		activities.POST("", handler.Create)
+		activities.PUT("/:activityId", handler.Update)
+		activities.DELETE("/:activityId", handler.Delete)
+	}
+}
+
+// Create godoc
+// @Summary Log a customer interaction
+// @Description Add a note, call, email or demo to the timeline of a customer
+// @Tags activities
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param activity body domain.CreateActivityRequest true "Activity data"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/activities [post]
+func (h *ActivityHandler) Create(c *gin.Context) {
+	var req domain.CreateActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	activity, err := h.activityUsecase.Create(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to create activity", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Activity created successfully", activity)
+}
+
+// GetByCustomer godoc
+// @Summary Get customer timeline
+// @Description Get the interactions of a customer, newest first
+// @Tags activities
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param type query string false "Filter by type (note, call, email, demo, status_change)"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact or none"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/activities [get]
+func (h *ActivityHandler) GetByCustomer(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	activityType := domain.ActivityType(c.Query("type"))
+
+	activities, info, err := h.activityUsecase.GetByCustomer(c.Request.Context(), c.Param("id"), activityType, page)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to get activities", err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Activities retrieved successfully", activities, pageMeta(page, info))
+}
+
+// Update godoc
+// @Summary Edit a customer interaction
+// @Description Edit the content of an activity (author or admin only)
+// @Tags activities
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param activityId path string true "Activity ID"
+// @Param activity body domain.UpdateActivityRequest true "Activity data"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/activities/{activityId} [put]
+func (h *ActivityHandler) Update(c *gin.Context) {
+	var req domain.UpdateActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	activity, err := h.activityUsecase.Update(c.Request.Context(), c.Param("id"), c.Param("activityId"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Activity not found")
+		case domain.ErrForbidden:
+			response.Error(c, http.StatusForbidden, "Access denied", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to update activity", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Activity updated successfully", activity)
+}
+
+// Delete godoc
+// @Summary Delete a customer interaction
+// @Description Delete an activity (author or admin only)
+// @Tags activities
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param activityId path string true "Activity ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/activities/{activityId} [delete]
+func (h *ActivityHandler) Delete(c *gin.Context) {
+	err := h.activityUsecase.Delete(c.Request.Context(), c.Param("id"), c.Param("activityId"), currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Activity not found")
+		case domain.ErrForbidden:
+			response.Error(c, http.StatusForbidden, "Access denied", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to delete activity", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Activity deleted successfully", nil)
+}
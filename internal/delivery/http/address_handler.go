@@ -0,0 +1,147 @@
+package http
+
+import (
+	"strconv"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddressHandler represents the HTTP handler for the Vietnamese administrative units
+type AddressHandler struct {
+	addressUsecase domain.AddressUsecase
+	validator      *validator.CustomValidator
+}
+
+// NewAddressHandler creates a new address handler.
+// Lookups are public so the registration form can fill its province dropdown.
+func NewAddressHandler(public *gin.RouterGroup, protected *gin.RouterGroup, uc domain.AddressUsecase) {
+	handler := &AddressHandler{
+		addressUsecase: uc,
+		validator:      validator.NewValidator(),
+	}
+
+	// Public routes - province dropdown
+	lookups := public.Group("/addresses")
+	{
+		lookups.GET("/versions", handler.GetVersions)
+		lookups.GET("/provinces", handler.GetProvinces)
+	}
+
+	// Read operations - accessible by admin and sale
+	protected.POST("/addresses/parse", handler.Parse)
+
+	// Write operations - accessible by admin only
+	adminOnly := protected.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/addresses/convert", handler.Convert)
+	}
+}
+
+// GetVersions godoc
+// @Summary List administrative dataset versions
+// @Description List the embedded versions of the administrative units, oldest first
+// @Tags addresses
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /addresses/versions [get]
+func (h *AddressHandler) GetVersions(c *gin.Context) {
+	response.OK(c, "Versions retrieved successfully", h.addressUsecase.GetVersions(c.Request.Context()))
+}
+
+// GetProvinces godoc
+// @Summary List provinces
+// @Description List the provinces and centrally-run cities of a dataset version
+// @Tags addresses
+// @Produce json
+// @Param version query string false "Dataset version, current by default"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /addresses/provinces [get]
+func (h *AddressHandler) GetProvinces(c *gin.Context) {
+	units, err := h.addressUsecase.GetProvinces(c.Request.Context(), c.Query("version"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get provinces")
+		return
+	}
+
+	response.OK(c, "Provinces retrieved successfully", units)
+}
+
+// Parse godoc
+// @Summary Parse a free-text address
+// @Description Convert a free-text address into a structured address, without saving it
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.ParseAddressRequest true "Address"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /addresses/parse [post]
+func (h *AddressHandler) Parse(c *gin.Context) {
+	var req domain.ParseAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	parsed, err := h.addressUsecase.Parse(c.Request.Context(), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to parse address")
+		return
+	}
+
+	response.OK(c, "Address parsed successfully", parsed)
+}
+
+// Convert godoc
+// @Summary Convert free-text addresses
+// @Description Parse the free-text address of customers and registrations without a structured address (admin only)
+// @Tags addresses
+// @Produce json
+// @Security BearerAuth
+// @Param dry_run query bool false "Report only, do not save" default(false)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /addresses/convert [post]
+func (h *AddressHandler) Convert(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		response.BadRequest(c, "Invalid dry_run parameter", err.Error())
+		return
+	}
+
+	report, err := h.addressUsecase.Convert(c.Request.Context(), dryRun)
+	if err != nil {
+		response.InternalServerError(c, "Failed to convert addresses", err.Error())
+		return
+	}
+
+	response.OK(c, "Addresses converted successfully", report)
+}
+
+// writeError maps address lookup errors to HTTP responses
+func (h *AddressHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrUnknownAddressVersion:
+		response.BadRequest(c, "Unknown dataset version", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,444 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AppointmentHandler represents the HTTP handler for demo and installation appointments
+type AppointmentHandler struct {
+	appointmentUsecase domain.AppointmentUsecase
+	validator          *validator.CustomValidator
+}
+
+// NewAppointmentHandler creates a new appointment handler
+func NewAppointmentHandler(public *gin.RouterGroup, protected *gin.RouterGroup, uc domain.AppointmentUsecase) {
+	handler := &AppointmentHandler{
+		appointmentUsecase: uc,
+		validator:          validator.NewValidator(),
+	}
+
+	// Public route - polled by calendar apps, authenticated by the feed token
+	public.GET("/calendar/:token", handler.RenderFeed)
+
+	// Scheduling - accessible by admin and sale
+	protected.POST("/appointments", handler.Create)
+	protected.GET("/appointments", handler.GetAll)
+	protected.GET("/appointments/slots", handler.GetSlots)
+	protected.GET("/appointments/:id", handler.GetByID)
+	protected.PUT("/appointments/:id", handler.Update)
+	protected.POST("/appointments/:id/reschedule", handler.Reschedule)
+	protected.POST("/appointments/:id/cancel", handler.Cancel)
+	protected.POST("/appointments/:id/complete", handler.Complete)
+	protected.GET("/me/appointments", handler.GetMine)
+	protected.POST("/me/calendar-feed", handler.CreateFeed)
+}
+
+// Create godoc
+// @Summary Book an appointment
+// @Description Book a demo or installation with a registration or customer, assigned to the caller unless technician_id is set. Fails when the technician already has an appointment in the slot.
+// @Tags appointments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param appointment body domain.CreateAppointmentRequest true "Appointment data"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /appointments [post]
+func (h *AppointmentHandler) Create(c *gin.Context) {
+	var req domain.CreateAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	appointment, err := h.appointmentUsecase.Create(c.Request.Context(), &req, currentActor(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to create appointment", "Registration or customer not found")
+		return
+	}
+
+	response.Created(c, "Appointment created successfully", appointment)
+}
+
+// GetAll godoc
+// @Summary Get appointments
+// @Description Get appointments in calendar order
+// @Tags appointments
+// @Produce json
+// @Security BearerAuth
+// @Param technician_id query string false "Technician user ID"
+// @Param customer_id query string false "Customer ID"
+// @Param registration_id query string false "Registration ID"
+// @Param status query string false "scheduled, completed or cancelled"
+// @Param from query string false "Appointments ending after this date (YYYY-MM-DD) or RFC3339 time"
+// @Param to query string false "Appointments starting before this date (YYYY-MM-DD, inclusive) or RFC3339 time"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /appointments [get]
+func (h *AppointmentHandler) GetAll(c *gin.Context) {
+	filter, err := parseAppointmentFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	h.list(c, filter)
+}
+
+// GetMine godoc
+// @Summary Get my appointments
+// @Description Get the appointments assigned to the caller in calendar order, from now on unless from is set
+// @Tags appointments
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "scheduled, completed or cancelled"
+// @Param from query string false "Appointments ending after this date (YYYY-MM-DD) or RFC3339 time"
+// @Param to query string false "Appointments starting before this date (YYYY-MM-DD, inclusive) or RFC3339 time"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /me/appointments [get]
+func (h *AppointmentHandler) GetMine(c *gin.Context) {
+	filter, err := parseAppointmentFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+	filter.TechnicianID = c.GetString("user_id")
+	if filter.From == nil {
+		now := time.Now()
+		filter.From = &now
+	}
+
+	h.list(c, filter)
+}
+
+// list writes a page of appointments matching filter
+func (h *AppointmentHandler) list(c *gin.Context, filter *domain.AppointmentFilter) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	appointments, info, err := h.appointmentUsecase.GetAll(c.Request.Context(), filter, page)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidCursor:
+			response.BadRequest(c, "Cursor pagination is not supported for appointments", err.Error())
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to get appointments", err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Appointments retrieved successfully", appointments, pageMeta(page, info))
+}
+
+// GetSlots godoc
+// @Summary Get free slots
+// @Description Get the free slots of a technician on a day within the working hours
+// @Tags appointments
+// @Produce json
+// @Security BearerAuth
+// @Param date query string true "Day (YYYY-MM-DD)"
+// @Param technician_id query string false "Technician user ID, the caller by default"
+// @Param duration query int false "Slot length in minutes" default(60)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /appointments/slots [get]
+func (h *AppointmentHandler) GetSlots(c *gin.Context) {
+	day, err := time.Parse("2006-01-02", c.Query("date"))
+	if err != nil {
+		response.BadRequest(c, "Invalid date, expected YYYY-MM-DD", err.Error())
+		return
+	}
+	minutes, err := strconv.Atoi(c.DefaultQuery("duration", "60"))
+	if err != nil {
+		response.BadRequest(c, "Invalid duration", err.Error())
+		return
+	}
+	technicianID := c.DefaultQuery("technician_id", c.GetString("user_id"))
+
+	// The date is a calendar day, so noon UTC falls on the same day in Vietnam
+	day = day.Add(12 * time.Hour)
+	slots, err := h.appointmentUsecase.GetSlots(c.Request.Context(), technicianID, day, time.Duration(minutes)*time.Minute)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidInput:
+			response.BadRequest(c, "Technician must be an active staff user and duration at most 12 hours", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to get free slots", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Free slots retrieved successfully", slots)
+}
+
+// GetByID godoc
+// @Summary Get an appointment
+// @Description Get an appointment with its reschedule history
+// @Tags appointments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appointment ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /appointments/{id} [get]
+func (h *AppointmentHandler) GetByID(c *gin.Context) {
+	appointment, err := h.appointmentUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get appointment", "Appointment not found")
+		return
+	}
+
+	response.OK(c, "Appointment retrieved successfully", appointment)
+}
+
+// Update godoc
+// @Summary Update an appointment
+// @Description Update the details of a scheduled appointment (technician, creator or admin only)
+// @Tags appointments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appointment ID"
+// @Param appointment body domain.UpdateAppointmentRequest true "Appointment data"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /appointments/{id} [put]
+func (h *AppointmentHandler) Update(c *gin.Context) {
+	var req domain.UpdateAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	appointment, err := h.appointmentUsecase.Update(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to update appointment", "Appointment not found")
+		return
+	}
+
+	response.OK(c, "Appointment updated successfully", appointment)
+}
+
+// Reschedule godoc
+// @Summary Reschedule an appointment
+// @Description Move a scheduled appointment to another slot or technician (technician, creator or admin only)
+// @Tags appointments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appointment ID"
+// @Param appointment body domain.RescheduleAppointmentRequest true "New slot"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /appointments/{id}/reschedule [post]
+func (h *AppointmentHandler) Reschedule(c *gin.Context) {
+	var req domain.RescheduleAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	appointment, err := h.appointmentUsecase.Reschedule(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to reschedule appointment", "Appointment not found")
+		return
+	}
+
+	response.OK(c, "Appointment rescheduled successfully", appointment)
+}
+
+// Cancel godoc
+// @Summary Cancel an appointment
+// @Description Cancel a scheduled appointment and free its slot (technician, creator or admin only)
+// @Tags appointments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appointment ID"
+// @Param appointment body domain.CancelAppointmentRequest true "Cancellation reason"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /appointments/{id}/cancel [post]
+func (h *AppointmentHandler) Cancel(c *gin.Context) {
+	var req domain.CancelAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	appointment, err := h.appointmentUsecase.Cancel(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to cancel appointment", "Appointment not found")
+		return
+	}
+
+	response.OK(c, "Appointment cancelled successfully", appointment)
+}
+
+// Complete godoc
+// @Summary Complete an appointment
+// @Description Mark a scheduled appointment as done (technician, creator or admin only)
+// @Tags appointments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appointment ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /appointments/{id}/complete [post]
+func (h *AppointmentHandler) Complete(c *gin.Context) {
+	appointment, err := h.appointmentUsecase.Complete(c.Request.Context(), c.Param("id"), currentActor(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to complete appointment", "Appointment not found")
+		return
+	}
+
+	response.OK(c, "Appointment completed successfully", appointment)
+}
+
+// CreateFeed godoc
+// @Summary Create my calendar feed link
+// @Description Create the iCalendar link of the caller's appointments for calendar apps. The previous link stops working.
+// @Tags appointments
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /me/calendar-feed [post]
+func (h *AppointmentHandler) CreateFeed(c *gin.Context) {
+	feed, err := h.appointmentUsecase.CreateFeed(c.Request.Context(), currentActor(c))
+	if err != nil {
+		response.InternalServerError(c, "Failed to create calendar feed", err.Error())
+		return
+	}
+
+	response.Created(c, "Calendar feed created successfully", feed)
+}
+
+// RenderFeed godoc
+// @Summary Get a calendar feed
+// @Description Get the iCalendar feed of a calendar feed link
+// @Tags appointments
+// @Produce text/calendar
+// @Param token path string true "Feed token followed by .ics"
+// @Success 200 {file} file
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /calendar/{token} [get]
+func (h *AppointmentHandler) RenderFeed(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := h.appointmentUsecase.RenderFeed(c.Request.Context(), c.Param("token"), &buf); err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Calendar feed not found")
+		default:
+			response.InternalServerError(c, "Failed to render calendar feed", err.Error())
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="appointments.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// writeError maps appointment errors to HTTP responses
+func (h *AppointmentHandler) writeError(c *gin.Context, err error, message, notFound string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrInvalidInput:
+		response.BadRequest(c, "Technician must be an active staff user and appointments at most 12 hours", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, notFound)
+	case domain.ErrForbidden:
+		response.Error(c, http.StatusForbidden, "Access denied", err.Error())
+	case domain.ErrAppointmentConflict:
+		response.Conflict(c, "Technician already has an appointment at this time", err.Error())
+	case domain.ErrAppointmentClosed:
+		response.Conflict(c, "Appointment is completed or cancelled", err.Error())
+	case domain.ErrScheduleLocked:
+		response.Conflict(c, "Technician schedule is being changed, try again", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
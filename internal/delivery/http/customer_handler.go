@@ -6,6 +6,7 @@ import (
 
 	"icafe-registration/internal/domain"
 	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/spreadsheet"
 	"icafe-registration/pkg/validator"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +29,7 @@ func NewCustomerHandler(router *gin.RouterGroup, uc domain.CustomerUsecase) {
 	{
 		// Read operations - accessible by admin and sale
 		customers.GET("", handler.GetAll)
+		customers.GET("/provinces", handler.CountByProvince)
 		customers.GET("/:id", handler.GetByID)
 
 		// Write operations - accessible by admin only
@@ -35,6 +37,7 @@ func NewCustomerHandler(router *gin.RouterGroup, uc domain.CustomerUsecase) {
 		adminOnly.Use(RequireRole(domain.RoleAdmin))
 		{
 			adminOnly.POST("", handler.Create)
+			adminOnly.POST("/import", handler.Import)
 			adminOnly.PUT("/:id", handler.Update)
 			adminOnly.DELETE("/:id", handler.Delete)
 		}
@@ -75,6 +78,8 @@ func (h *CustomerHandler) Create(c *gin.Context) {
 		switch err {
 		case domain.ErrPhoneAlreadyExists:
 			response.Conflict(c, "Phone number already registered", err.Error())
+		case domain.ErrInvalidAddress, domain.ErrUnknownAddressVersion:
+			response.BadRequest(c, "Invalid address", err.Error())
 		default:
 			response.InternalServerError(c, "Failed to create customer", err.Error())
 		}
@@ -84,33 +89,135 @@ func (h *CustomerHandler) Create(c *gin.Context) {
 	response.Created(c, "Customer created successfully", customer)
 }
 
+// Import godoc
+// @Summary Import customers
+// @Description Bulk create or update customers from a CSV or XLSX file, keyed by phone number (admin only)
+// @Tags customers
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run query bool false "Validate only, do not write" default(false)
+// @Param on_duplicate query string false "update or skip existing phone numbers" default(update)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/import [post]
+func (h *CustomerHandler) Import(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file provided", err.Error())
+		return
+	}
+
+	format, err := spreadsheet.FormatFromFilename(fileHeader.Filename)
+	if err != nil {
+		response.BadRequest(c, "Invalid file type", "only .csv and .xlsx files are supported")
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	opts := &domain.ImportOptions{
+		Format:      string(format),
+		DryRun:      dryRun,
+		OnDuplicate: c.DefaultQuery("on_duplicate", domain.ImportOnDuplicateUpdate),
+	}
+
+	if err := h.validator.Validate(opts); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		response.InternalServerError(c, "Failed to read file", err.Error())
+		return
+	}
+	defer src.Close()
+
+	report, err := h.customerUsecase.Import(c.Request.Context(), src, fileHeader.Size, opts)
+	if err != nil {
+		switch err {
+		case domain.ErrImportMissingColumns, domain.ErrImportTooManyRows,
+			spreadsheet.ErrInvalidFile, spreadsheet.ErrUnsupportedFormat:
+			response.BadRequest(c, "Invalid import file", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to import customers", err.Error())
+		}
+		return
+	}
+
+	message := "Customers imported successfully"
+	if report.DryRun {
+		message = "Import validated successfully"
+	}
+	response.OK(c, message, report)
+}
+
 // GetAll godoc
 // @Summary Get all customers
-// @Description Get all customers with pagination
+// @Description Get customers with filters and pagination
 // @Tags customers
 // @Produce json
 // @Security BearerAuth
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact, estimated or none"
+// @Param q query string false "Search by name, phone or email"
+// @Param workstation_range query string false "Workstation range (1-10, 10-20, 20-50, 50+)"
+// @Param is_active query bool false "Active status"
+// @Param province query string false "Province code of the structured address"
+// @Param created_from query string false "Created from (YYYY-MM-DD or RFC3339)"
+// @Param created_to query string false "Created to, inclusive day (YYYY-MM-DD or RFC3339)"
 // @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /customers [get]
 func (h *CustomerHandler) GetAll(c *gin.Context) {
-	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
-	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	filter, err := parseCustomerFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
 
-	customers, total, err := h.customerUsecase.GetAll(c.Request.Context(), limit, offset)
+	customers, info, err := h.customerUsecase.GetAll(c.Request.Context(), filter, page)
 	if err != nil {
 		response.InternalServerError(c, "Failed to get customers", err.Error())
 		return
 	}
 
-	response.SuccessWithMeta(c, http.StatusOK, "Customers retrieved successfully", customers, &response.Meta{
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
-	})
+	response.SuccessWithMeta(c, http.StatusOK, "Customers retrieved successfully", customers, pageMeta(page, info))
+}
+
+// CountByProvince godoc
+// @Summary Count customers by province
+// @Description Count customers per province of their structured address, using the current administrative units
+// @Tags customers
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/provinces [get]
+func (h *CustomerHandler) CountByProvince(c *gin.Context) {
+	counts, err := h.customerUsecase.CountByProvince(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, "Failed to count customers", err.Error())
+		return
+	}
+
+	response.OK(c, "Customer counts retrieved successfully", counts)
 }
 
 // GetByID godoc
@@ -178,7 +285,7 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 		return
 	}
 
-	customer, err := h.customerUsecase.Update(c.Request.Context(), id, &req)
+	customer, err := h.customerUsecase.Update(c.Request.Context(), id, &req, currentActor(c))
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidID:
@@ -187,6 +294,8 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 			response.NotFound(c, "Customer not found")
 		case domain.ErrPhoneAlreadyExists:
 			response.Conflict(c, "Phone number already registered", err.Error())
+		case domain.ErrInvalidAddress, domain.ErrUnknownAddressVersion:
+			response.BadRequest(c, "Invalid address", err.Error())
 		default:
 			response.InternalServerError(c, "Failed to update customer", err.Error())
 		}
@@ -198,7 +307,7 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a customer
-// @Description Delete a customer by its ID (admin only)
+// @Description Move a customer to the trash (admin only)
 // @Tags customers
 // @Produce json
 // @Security BearerAuth
@@ -213,7 +322,7 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 func (h *CustomerHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
-	err := h.customerUsecase.Delete(c.Request.Context(), id)
+	err := h.customerUsecase.Delete(c.Request.Context(), id, c.GetString("user_id"))
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidID:
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/export"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler represents the HTTP handler for CSV/XLSX exports
+type ExportHandler struct {
+	customerUsecase     domain.CustomerUsecase
+	registrationUsecase domain.RegistrationUsecase
+	validator           *validator.CustomValidator
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(router *gin.RouterGroup, customerUC domain.CustomerUsecase, registrationUC domain.RegistrationUsecase) {
+	handler := &ExportHandler{
+		customerUsecase:     customerUC,
+		registrationUsecase: registrationUC,
+		validator:           validator.NewValidator(),
+	}
+
+	exports := router.Group("")
+	exports.Use(requireExport())
+	{
+		exports.GET("/customers/export", handler.ExportCustomers)
+		exports.GET("/registrations/export", handler.ExportRegistrations)
+	}
+}
+
+// requireExport allows admins, whose stored permissions may predate data:export,
+// and users granted the data:export permission
+func requireExport() gin.HandlerFunc {
+	requirePermission := RequirePermission(domain.PermissionExportData)
+	return func(c *gin.Context) {
+		if currentActor(c).Role == domain.RoleAdmin {
+			c.Next()
+			return
+		}
+		requirePermission(c)
+	}
+}
+
+// ExportCustomers godoc
+// @Summary Export customers
+// @Description Stream customers as CSV or XLSX, with the same filters as the list endpoint
+// @Tags customers
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param format query string false "csv or xlsx" default(csv)
+// @Param columns query string false "Comma-separated column keys"
+// @Param q query string false "Search by name, phone or email"
+// @Param workstation_range query string false "Workstation range"
+// @Param is_active query bool false "Active status"
+// @Param province query string false "Province code of the structured address"
+// @Param created_from query string false "Created from (YYYY-MM-DD or RFC3339)"
+// @Param created_to query string false "Created to, inclusive day (YYYY-MM-DD or RFC3339)"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /customers/export [get]
+func (h *ExportHandler) ExportCustomers(c *gin.Context) {
+	req, ok := h.parseExportRequest(c)
+	if !ok {
+		return
+	}
+
+	filter, err := parseCustomerFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	w := newDownloadWriter(c, "customers", export.Format(req.Format))
+	err = h.customerUsecase.Export(c.Request.Context(), filter, req, w)
+	w.finish(err, "Failed to export customers")
+}
+
+// ExportRegistrations godoc
+// @Summary Export registrations
+// @Description Stream registrations as CSV or XLSX, with the same filters as the list endpoint
+// @Tags registrations
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param format query string false "csv or xlsx" default(csv)
+// @Param columns query string false "Comma-separated column keys"
+// @Param q query string false "Search by name, phone or email"
+// @Param workstation_range query string false "Workstation range"
+// @Param created_from query string false "Created from (YYYY-MM-DD or RFC3339)"
+// @Param created_to query string false "Created to, inclusive day (YYYY-MM-DD or RFC3339)"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /registrations/export [get]
+func (h *ExportHandler) ExportRegistrations(c *gin.Context) {
+	req, ok := h.parseExportRequest(c)
+	if !ok {
+		return
+	}
+
+	filter, err := parseRegistrationFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	w := newDownloadWriter(c, "registrations", export.Format(req.Format))
+	err = h.registrationUsecase.Export(c.Request.Context(), filter, req, w)
+	w.finish(err, "Failed to export registrations")
+}
+
+// parseExportRequest reads and validates the format and columns query parameters
+func (h *ExportHandler) parseExportRequest(c *gin.Context) (*domain.ExportRequest, bool) {
+	req := &domain.ExportRequest{
+		Format: strings.ToLower(c.DefaultQuery("format", string(export.FormatCSV))),
+	}
+
+	if columns := c.Query("columns"); columns != "" {
+		for _, col := range strings.Split(columns, ",") {
+			if col = strings.TrimSpace(col); col != "" {
+				req.Columns = append(req.Columns, col)
+			}
+		}
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return nil, false
+	}
+
+	return req, true
+}
+
+// downloadWriter sets the download headers on the first write, so errors raised
+// before any data is produced can still be answered with a JSON response
+type downloadWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func newDownloadWriter(c *gin.Context, name string, format export.Format) *downloadWriter {
+	return &downloadWriter{
+		c:           c,
+		contentType: export.ContentType(format),
+		filename:    fmt.Sprintf("%s_%s.%s", name, time.Now().Format("20060102_150405"), format),
+	}
+}
+
+// Write implements io.Writer
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// finish reports err as JSON when nothing was streamed yet,
+// otherwise the response is already committed and can only be cut short
+func (w *downloadWriter) finish(err error, message string) {
+	if err == nil {
+		return
+	}
+
+	if w.started {
+		log.Printf("export %s aborted: %v", w.filename, err)
+		w.c.Abort()
+		return
+	}
+
+	switch err {
+	case domain.ErrInvalidExportColumn, export.ErrUnsupportedFormat:
+		response.BadRequest(w.c, "Invalid export parameters", err.Error())
+	default:
+		response.InternalServerError(w.c, message, err.Error())
+	}
+}
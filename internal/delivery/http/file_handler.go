@@ -1,52 +1,85 @@
 package http
 
 import (
-	"icafe-registration/internal/config"
+	"encoding/base64"
+	"encoding/hex"
 	"icafe-registration/internal/domain"
 	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+	"io"
 	"log"
+	"mime"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strconv"
+	"path"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // FileHandler represents the HTTP handler for files
 type FileHandler struct {
-	fileUsecase  domain.FileUsecase
-	uploadConfig *config.UploadConfig
+	fileUsecase domain.FileUsecase
+	validator   *validator.CustomValidator
 }
 
-// NewFileHandler creates a new file handler
-func NewFileHandler(router *gin.RouterGroup, engine *gin.Engine, uc domain.FileUsecase, uploadConfig *config.UploadConfig) {
+// NewFileHandler creates a new file handler.
+// Public files are listed and served to anyone; other files are only listed to
+// staff, who send their token on the optional routes, and served through signed
+// links, which staff mint.
+func NewFileHandler(router *gin.RouterGroup, optional *gin.RouterGroup, protected *gin.RouterGroup, uc domain.FileUsecase) {
 	handler := &FileHandler{
-		fileUsecase:  uc,
-		uploadConfig: uploadConfig,
+		fileUsecase: uc,
+		validator:   validator.NewValidator(),
 	}
 
-	// File upload and management routes
-	router.POST("/files/upload", handler.UploadFile)
-	router.POST("/videos/upload", handler.UploadVideo)
-	router.GET("/files", handler.GetAllFiles)
-	router.GET("/videos", handler.GetAllVideos)
+	// File upload and listing routes - private and customer files need a staff token
+	optional.POST("/files/upload", handler.UploadFile)
+	optional.POST("/videos/upload", handler.UploadVideo)
+	optional.GET("/files", handler.GetAllFiles)
+	optional.GET("/videos", handler.GetAllVideos)
+	optional.GET("/files/folders", handler.GetFolders)
+	optional.GET("/files/:id", handler.GetFileByID)
+
 	// Download by id
 	router.GET("/files/download-by-id/:id", handler.DownloadFileByID)
-	router.GET("/files/:id", handler.GetFileByID)
-	router.DELETE("/files/:id", handler.DeleteFile)
-
-	// Static file serving for downloads and streaming
-	filesPath := filepath.Join(uploadConfig.Path, "files")
-	videosPath := filepath.Join(uploadConfig.Path, "videos")
 
-	router.Static("/files/download", filesPath)
-	router.Static("/videos/stream", videosPath)
+	// Inline serving for downloads and streaming, from the file storage
+	router.GET("/files/download/:filename", handler.DownloadFile)
+	router.HEAD("/files/download/:filename", handler.DownloadFile)
+	router.GET("/videos/stream/:filename", handler.StreamVideo)
+	router.HEAD("/videos/stream/:filename", handler.StreamVideo)
 
 	// Alternative: serve files with custom headers for proper download/streaming
 	router.GET("/files/serve/:filename", handler.ServeFile)
 	router.GET("/videos/serve/:filename", handler.ServeVideo)
 
+	// Signed download links, for private and customer files
+	router.GET("/files/signed/:token", handler.ServeSigned)
+	router.HEAD("/files/signed/:token", handler.ServeSigned)
+
+	// Resized copies of public images
+	router.GET("/files/:id/variants/:name", handler.ServeVariant)
+	router.HEAD("/files/:id/variants/:name", handler.ServeVariant)
+
+	// Installers - accessible by admin and sale, as executables are never taken from
+	// anonymous clients and are published through releases
+	protected.POST("/installers/upload", handler.UploadInstaller)
+	protected.GET("/installers", handler.GetAllInstallers)
+
+	// Link minting - accessible by admin and sale, private files by admin only
+	protected.POST("/files/:id/links", handler.CreateLink)
+
+	// Organizing files - accessible by admin and sale
+	protected.PUT("/files/:id/metadata", handler.UpdateMetadata)
+	protected.DELETE("/files/:id", handler.DeleteFile)
+
+	// Visibility changes - accessible by admin only
+	adminOnly := protected.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.PUT("/files/:id/visibility", handler.SetVisibility)
+	}
 }
 
 // UploadFile godoc
@@ -56,8 +89,11 @@ func NewFileHandler(router *gin.RouterGroup, engine *gin.Engine, uc domain.FileU
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formance file true "File to upload"
+// @Param visibility formData string false "public (default); private or customer need a staff token"
+// @Param customer_id formData string false "Customer ID, for customer files"
 // @Success 201 {object} response.Response
 // @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /files/upload [post]
 func (h *FileHandler) UploadFile(c *gin.Context) {
@@ -67,13 +103,24 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 		return
 	}
 
-	uploadedFile, err := h.fileUsecase.Upload(c.Request.Context(), file, domain.FileTypeDocument)
+	access, err := fileAccess(c.PostForm("visibility"), c.PostForm("customer_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid visibility", err.Error())
+		return
+	}
+	if denyRestrictedUpload(c, access) {
+		return
+	}
+
+	uploadedFile, err := h.fileUsecase.Upload(c.Request.Context(), file, domain.FileTypeDocument, access)
 	if err != nil {
 		switch err {
 		case domain.ErrFileTooLarge:
 			response.BadRequest(c, "File too large", err.Error())
 		case domain.ErrInvalidFileType:
 			response.BadRequest(c, "Invalid file type", err.Error())
+		case domain.ErrFileCustomerNotFound:
+			response.BadRequest(c, "Invalid customer", err.Error())
 		default:
 			response.InternalServerError(c, "Failed to upload file", err.Error())
 		}
@@ -90,8 +137,11 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formance file true "Video file to upload"
+// @Param visibility formData string false "public (default); private or customer need a staff token"
+// @Param customer_id formData string false "Customer ID, for customer files"
 // @Success 201 {object} response.Response
 // @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /videos/upload [post]
 func (h *FileHandler) UploadVideo(c *gin.Context) {
@@ -101,13 +151,24 @@ func (h *FileHandler) UploadVideo(c *gin.Context) {
 		return
 	}
 
-	uploadedFile, err := h.fileUsecase.Upload(c.Request.Context(), file, domain.FileTypeVideo)
+	access, err := fileAccess(c.PostForm("visibility"), c.PostForm("customer_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid visibility", err.Error())
+		return
+	}
+	if denyRestrictedUpload(c, access) {
+		return
+	}
+
+	uploadedFile, err := h.fileUsecase.Upload(c.Request.Context(), file, domain.FileTypeVideo, access)
 	if err != nil {
 		switch err {
 		case domain.ErrFileTooLarge:
 			response.BadRequest(c, "File too large", err.Error())
 		case domain.ErrInvalidFileType:
 			response.BadRequest(c, "Invalid file type", err.Error())
+		case domain.ErrFileCustomerNotFound:
+			response.BadRequest(c, "Invalid customer", err.Error())
 		default:
 			response.InternalServerError(c, "Failed to upload video", err.Error())
 		}
@@ -117,6 +178,59 @@ func (h *FileHandler) UploadVideo(c *gin.Context) {
 	response.Created(c, "Video uploaded successfully", uploadedFile)
 }
 
+// UploadInstaller godoc
+// @Summary Upload an installer
+// @Description Upload a program or installer package (.exe, .msi, .apk, .zip or other binaries) (admin and sale only)
+// @Tags files
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Installer to upload"
+// @Param visibility formData string false "public (default), private or customer"
+// @Param customer_id formData string false "Customer ID, for customer files"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /installers/upload [post]
+func (h *FileHandler) UploadInstaller(c *gin.Context) {
+	// The route is staff-only; executables must never be taken from anyone else
+	if !isStaff(c) {
+		response.Error(c, http.StatusForbidden, "Access denied", "installers can only be uploaded by staff")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file provided", err.Error())
+		return
+	}
+
+	access, err := fileAccess(c.PostForm("visibility"), c.PostForm("customer_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid visibility", err.Error())
+		return
+	}
+
+	uploadedFile, err := h.fileUsecase.Upload(c.Request.Context(), file, domain.FileTypeInstaller, access)
+	if err != nil {
+		switch err {
+		case domain.ErrFileTooLarge:
+			response.BadRequest(c, "File too large", err.Error())
+		case domain.ErrInvalidFileType:
+			response.BadRequest(c, "Invalid file type", err.Error())
+		case domain.ErrFileCustomerNotFound:
+			response.BadRequest(c, "Invalid customer", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to upload installer", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Installer uploaded successfully", uploadedFile)
+}
+
 // GetAllFiles godoc
 // @Summary Get all files
 // @Description Get all document files with pagination
@@ -124,24 +238,21 @@ func (h *FileHandler) UploadVideo(c *gin.Context) {
 // @Produce json
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact, estimated or none"
+// @Param q query string false "Search in the original name and title"
+// @Param tag query []string false "Only files carrying every given tag" collectionFormat(multi)
+// @Param category query string false "Only files of this category"
+// @Param folder query string false "Only files in this folder, / for files outside any folder"
+// @Param recursive query bool false "Include the subfolders of folder"
+// @Param mime_type query string false "Only files of this MIME type, or major type such as image/*"
+// @Param visibility query string false "Staff only: public, private or customer; others only see public files"
 // @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /files [get]
 func (h *FileHandler) GetAllFiles(c *gin.Context) {
-	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
-	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
-
-	files, total, err := h.fileUsecase.GetAll(c.Request.Context(), domain.FileTypeDocument, limit, offset)
-	if err != nil {
-		response.InternalServerError(c, "Failed to get files", err.Error())
-		return
-	}
-
-	response.SuccessWithMeta(c, http.StatusOK, "Files retrieved successfully", files, &response.Meta{
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
-	})
+	h.list(c, domain.FileTypeDocument, "files")
 }
 
 // GetAllVideos godoc
@@ -151,29 +262,164 @@ func (h *FileHandler) GetAllFiles(c *gin.Context) {
 // @Produce json
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact, estimated or none"
+// @Param q query string false "Search in the original name and title"
+// @Param tag query []string false "Only files carrying every given tag" collectionFormat(multi)
+// @Param category query string false "Only files of this category"
+// @Param folder query string false "Only files in this folder, / for files outside any folder"
+// @Param recursive query bool false "Include the subfolders of folder"
+// @Param mime_type query string false "Only files of this MIME type, or major type such as image/*"
+// @Param visibility query string false "Staff only: public, private or customer; others only see public files"
 // @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /videos [get]
 func (h *FileHandler) GetAllVideos(c *gin.Context) {
-	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
-	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	h.list(c, domain.FileTypeVideo, "videos")
+}
+
+// GetAllInstallers godoc
+// @Summary Get all installers
+// @Description Get all installer files with pagination (admin and sale only)
+// @Tags files
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact, estimated or none"
+// @Param q query string false "Search in the original name and title"
+// @Param tag query []string false "Only files carrying every given tag" collectionFormat(multi)
+// @Param category query string false "Only files of this category"
+// @Param folder query string false "Only files in this folder, / for files outside any folder"
+// @Param recursive query bool false "Include the subfolders of folder"
+// @Param mime_type query string false "Only files of this MIME type, or major type such as image/*"
+// @Param visibility query string false "public, private or customer"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /installers [get]
+func (h *FileHandler) GetAllInstallers(c *gin.Context) {
+	h.list(c, domain.FileTypeInstaller, "installers")
+}
 
-	files, total, err := h.fileUsecase.GetAll(c.Request.Context(), domain.FileTypeVideo, limit, offset)
+// list writes a page of the files of a type matching the query filters
+func (h *FileHandler) list(c *gin.Context, fileType domain.FileType, noun string) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+	filter, err := parseFileFilter(c, fileType)
 	if err != nil {
-		response.InternalServerError(c, "Failed to get videos", err.Error())
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
 		return
 	}
 
-	response.SuccessWithMeta(c, http.StatusOK, "Videos retrieved successfully", files, &response.Meta{
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
-	})
+	files, info, err := h.fileUsecase.GetAll(c.Request.Context(), filter, page)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidFolder:
+			response.BadRequest(c, "Invalid folder", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to get "+noun, err.Error())
+		}
+		return
+	}
+
+	message := strings.ToUpper(noun[:1]) + noun[1:] + " retrieved successfully"
+	response.SuccessWithMeta(c, http.StatusOK, message, files, pageMeta(page, info))
+}
+
+// GetFolders godoc
+// @Summary Get file folders
+// @Description Get the virtual folders holding files, sorted by path. Folders holding only subfolders are listed with no files. Without a staff token only public files are counted.
+// @Tags files
+// @Produce json
+// @Param type query string false "Only folders holding files of this type: document, video, image or installer"
+// @Param visibility query string false "Staff only: public, private or customer"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /files/folders [get]
+func (h *FileHandler) GetFolders(c *gin.Context) {
+	fileType := domain.FileType(c.Query("type"))
+	switch fileType {
+	case "", domain.FileTypeDocument, domain.FileTypeVideo, domain.FileTypeImage, domain.FileTypeInstaller:
+	default:
+		response.BadRequest(c, "Invalid file type", domain.ErrInvalidInput.Error())
+		return
+	}
+
+	visibility, err := parseFileVisibility(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid visibility", err.Error())
+		return
+	}
+
+	folders, err := h.fileUsecase.Folders(c.Request.Context(), &domain.FileFilter{FileType: fileType, Visibility: visibility})
+	if err != nil {
+		response.InternalServerError(c, "Failed to get folders", err.Error())
+		return
+	}
+
+	response.OK(c, "Folders retrieved successfully", folders)
+}
+
+// UpdateMetadata godoc
+// @Summary Update file metadata
+// @Description Update the title, description, tags, category and folder of a file. Missing fields are kept, empty ones cleared.
+// @Tags files
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "File ID"
+// @Param metadata body domain.UpdateFileMetadataRequest true "File metadata"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /files/{id}/metadata [put]
+func (h *FileHandler) UpdateMetadata(c *gin.Context) {
+	var req domain.UpdateFileMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	file, err := h.fileUsecase.UpdateMetadata(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrInvalidFolder:
+			response.BadRequest(c, "Invalid folder", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		default:
+			response.InternalServerError(c, "Failed to update file", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "File updated successfully", file)
 }
 
 // GetFileByID godoc
 // @Summary Get a file by ID
-// @Description Get file information by ID
+// @Description Get file information by ID. Private and customer files are only shown to staff.
 // @Tags files
 // @Produce json
 // @Param id path string true "File ID"
@@ -198,6 +444,12 @@ func (h *FileHandler) GetFileByID(c *gin.Context) {
 		return
 	}
 
+	// Private and customer files are only shown to staff
+	if !file.IsPublic() && !isStaff(c) {
+		response.NotFound(c, "File not found")
+		return
+	}
+
 	response.OK(c, "File retrieved successfully", file)
 }
 
@@ -206,9 +458,12 @@ func (h *FileHandler) GetFileByID(c *gin.Context) {
 // @Description Delete a file by ID
 // @Tags files
 // @Produce json
+// @Security BearerAuth
 // @Param id path string true "File ID"
 // @Success 200 {object} response.Response
 // @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /files/{id} [delete]
@@ -233,80 +488,400 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 
 // ServeFile serves a file for download
 func (h *FileHandler) ServeFile(c *gin.Context) {
-	filename := c.Param("filename")
-	filePath := filepath.Join(h.uploadConfig.Path, "files", filename)
-
-	if info, err := os.Stat(filePath); err != nil {
-		response.NotFound(c, "File not found")
-		return
-	} else if info.IsDir() {
-		response.BadRequest(c, "Invalid file", "path points to a directory")
+	file, ok := h.fileByName(c, false)
+	if !ok {
 		return
 	}
 
 	c.Header("Content-Description", "File Transfer")
-	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
-	c.Header("Content-Type", "application/octet-stream")
 	c.Header("Content-Transfer-Encoding", "binary")
-	c.File(filePath)
+	h.serveContent(c, file, "application/octet-stream", attachment(file.FileName))
 }
 
 // ServeVideo serves a video for streaming
 func (h *FileHandler) ServeVideo(c *gin.Context) {
-	filename := c.Param("filename")
-	filePath := filepath.Join(h.uploadConfig.Path, "videos", filename)
+	if file, ok := h.fileByName(c, true); ok {
+		h.serveContent(c, file, "", "")
+	}
+}
 
-	// Set headers for video streaming
-	c.Header("Accept-Ranges", "bytes")
-	c.Header("Content-Type", "video/mp4")
+// DownloadFile serves a file inline, typed after its content
+func (h *FileHandler) DownloadFile(c *gin.Context) {
+	if file, ok := h.fileByName(c, false); ok {
+		h.serveContent(c, file, "", "")
+	}
+}
 
-	c.File(filePath)
+// StreamVideo serves a video inline, typed after its content
+func (h *FileHandler) StreamVideo(c *gin.Context) {
+	if file, ok := h.fileByName(c, true); ok {
+		h.serveContent(c, file, "", "")
+	}
+}
+
+// fileByName gets the public file named in the URL. Videos are served under
+// /videos and other files under /files. Identical files share their stored content, so
+// the name is resolved through the file records rather than the storage.
+func (h *FileHandler) fileByName(c *gin.Context, video bool) (*domain.File, bool) {
+	file, err := h.fileUsecase.GetByFileName(c.Request.Context(), c.Param("filename"))
+	if err == nil && ((file.FileType == domain.FileTypeVideo) != video || !file.IsPublic()) {
+		err = domain.ErrNotFound
+	}
+	if err == nil && file.IsQuarantined() {
+		err = domain.ErrFileQuarantined
+	}
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		case domain.ErrFileQuarantined:
+			response.Conflict(c, "File has not passed the malware scan", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to get file", err.Error())
+		}
+		return nil, false
+	}
+	return file, true
 }
 
+// DownloadFileByID godoc
+// @Summary Download a file by ID
+// @Description Download a file under its original name. Storages that support it redirect to a temporary direct link.
+// @Tags files
+// @Produce octet-stream
+// @Param id path string true "File ID"
+// @Success 200 {file} binary
+// @Success 302 {string} string "Redirect to a presigned storage link"
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /files/download-by-id/{id} [get]
 func (h *FileHandler) DownloadFileByID(c *gin.Context) {
-	log.Println("🔥 DOWNLOAD HANDLER HIT 🔥")
+	file, err := h.fileUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err == nil && !file.IsPublic() {
+		err = domain.ErrNotFound
+	}
+	if err == nil && file.IsQuarantined() {
+		err = domain.ErrFileQuarantined
+	}
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		case domain.ErrFileQuarantined:
+			response.Conflict(c, "File has not passed the malware scan", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to get file", err.Error())
+		}
+		return
+	}
 
-	id := c.Param("id")
-	log.Println("Requested File ID:", id)
+	link, err := h.fileUsecase.DownloadURL(c.Request.Context(), file)
+	if err == nil {
+		c.Redirect(http.StatusFound, link)
+		return
+	}
+	if err != domain.ErrPresignUnsupported {
+		log.Printf("Failed to presign download of file %s, streaming it: %v", file.ID.Hex(), err)
+	}
 
-	file, err := h.fileUsecase.GetByID(c.Request.Context(), id)
+	c.Header("Content-Transfer-Encoding", "binary")
+	h.serveContent(c, file, file.MimeType, attachment(file.OriginalName))
+}
+
+// ServeSigned godoc
+// @Summary Download through a signed link
+// @Description Download a file through a signed link minted by staff, until the link expires. A link may be bound to a client IP, and a single-use link works for one GET. Videos are served inline, other files as attachments.
+// @Tags files
+// @Param token path string true "Link token"
+// @Success 200 {file} binary
+// @Success 206 {file} binary
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 410 {object} response.Response
+// @Router /files/signed/{token} [get]
+func (h *FileHandler) ServeSigned(c *gin.Context) {
+	consume := c.Request.Method != http.MethodHead
+	file, err := h.fileUsecase.ResolveLink(c.Request.Context(), c.Param("token"), c.ClientIP(), consume)
 	if err != nil {
-		log.Println("ERROR fetching file from DB:", err)
-		response.NotFound(c, "File not found")
+		switch err {
+		case domain.ErrInvalidFileLink, domain.ErrFileLinkIPMismatch:
+			response.Error(c, http.StatusForbidden, "Access denied", err.Error())
+		case domain.ErrFileLinkExpired, domain.ErrFileLinkUsed:
+			response.Error(c, http.StatusGone, "Link is no longer valid", err.Error())
+		case domain.ErrFileQuarantined:
+			response.Conflict(c, "File has not passed the malware scan", err.Error())
+		case domain.ErrInvalidID, domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		default:
+			response.InternalServerError(c, "Failed to get file", err.Error())
+		}
+		return
+	}
+
+	// Signed links must not outlive their terms in shared caches
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+
+	disposition := ""
+	if file.FileType != domain.FileTypeVideo {
+		name := file.OriginalName
+		if name == "" {
+			name = file.FileName
+		}
+		disposition = attachment(name)
+	}
+	h.serveContent(c, file, "", disposition)
+}
+
+// ServeVariant godoc
+// @Summary Serve a resized copy of an image
+// @Description Serve a variant of a public JPEG, PNG or GIF image, resized to fit the configured size (by default thumb fits 200px and medium 800px). Variants are made on upload, and on first request for images uploaded before. JPEGs stay JPEGs, other images are served as PNG.
+// @Tags files
+// @Produce image/jpeg
+// @Produce image/png
+// @Param id path string true "File ID"
+// @Param name path string true "Variant name, e.g. thumb or medium"
+// @Success 200 {file} binary
+// @Success 304 {string} string "Not modified"
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /files/{id}/variants/{name} [get]
+func (h *FileHandler) ServeVariant(c *gin.Context) {
+	variant, content, object, err := h.fileUsecase.OpenVariant(c.Request.Context(), c.Param("id"), c.Param("name"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID, domain.ErrNotFound:
+			response.NotFound(c, "Variant not found")
+		default:
+			response.InternalServerError(c, "Failed to open variant", err.Error())
+		}
 		return
 	}
+	defer content.Close()
 
-	log.Println("File found in DB:")
-	log.Println("  FileName:", file.FileName)
-	log.Println("  OriginalName:", file.OriginalName)
-	log.Println("  FilePath (DB):", file.FilePath)
-	log.Println("  MimeType:", file.MimeType)
-	log.Println("  Size:", file.Size)
-	log.Println("  URL:", file.URL)
+	// A variant is replaced under a new key, which makes a stable ETag
+	served := *object
+	if served.ETag == "" {
+		served.ETag = strings.TrimSuffix(path.Base(variant.Key), path.Ext(variant.Key))
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
 
-	// ✅ file.FilePath = relative path, ví dụ: files/xxx.apk
-	relativePath := filepath.Clean(file.FilePath)
+	serveStored(c, content, &served, variant.MimeType)
+}
 
-	// ✅ build absolute path: ./uploads + relative path
-	filePath := filepath.Join(h.uploadConfig.Path, relativePath)
-	log.Println("Upload.Path (config):", h.uploadConfig.Path)
-	log.Println("Relative Path (from DB):", relativePath)
-	log.Println("Final absolute filePath:", filePath)
+// CreateLink godoc
+// @Summary Create a signed download link
+// @Description Mint a link downloading a file until it expires (60 minutes by default, at most 7 days), optionally bound to a client IP or usable once. Links to private files are minted by admins only.
+// @Tags files
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "File ID"
+// @Param link body domain.CreateFileLinkRequest false "Link terms"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /files/{id}/links [post]
+func (h *FileHandler) CreateLink(c *gin.Context) {
+	var req domain.CreateFileLinkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err.Error())
+			return
+		}
+	}
 
-	// check file existence
-	if info, err := os.Stat(filePath); err != nil {
-		log.Println("STAT ERROR:", err)
-		response.NotFound(c, "File not found on server")
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
 		return
-	} else {
-		log.Println("File exists on disk, size:", info.Size())
 	}
 
-	c.Header("Content-Disposition", `attachment; filename="`+file.OriginalName+`"`)
-	c.Header("Content-Type", file.MimeType)
-	c.Header("Content-Transfer-Encoding", "binary")
+	link, err := h.fileUsecase.CreateLink(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		case domain.ErrForbidden:
+			response.Error(c, http.StatusForbidden, "Access denied", "links to private files are minted by admins only")
+		default:
+			response.InternalServerError(c, "Failed to create link", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Link created successfully", link)
+}
+
+// SetVisibility godoc
+// @Summary Change file visibility
+// @Description Make a file public, private, or a document of a customer (admin only). Files that are not public have no URL and are only served through signed links.
+// @Tags files
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "File ID"
+// @Param visibility body domain.FileVisibilityRequest true "Visibility"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /files/{id}/visibility [put]
+func (h *FileHandler) SetVisibility(c *gin.Context) {
+	var req domain.FileVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	file, err := h.fileUsecase.SetVisibility(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrFileCustomerNotFound:
+			response.BadRequest(c, "Invalid customer", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		default:
+			response.InternalServerError(c, "Failed to change file visibility", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "File visibility changed successfully", file)
+}
 
-	log.Println("Starting file download...")
-	c.File(filePath)
-	log.Println("Download finished for:", file.OriginalName)
+// serveContent streams the stored content of a file. contentType defaults to the
+// type detected on upload. Files with a SHA-256 carry it in the Digest header and
+// as their ETag, so clients can verify downloads.
+func (h *FileHandler) serveContent(c *gin.Context, file *domain.File, contentType, disposition string) {
+	content, object, err := h.fileUsecase.Open(c.Request.Context(), file.FilePath)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		default:
+			response.InternalServerError(c, "Failed to open file", err.Error())
+		}
+		return
+	}
+	defer content.Close()
+
+	if contentType == "" {
+		contentType = file.MimeType
+	}
+	if disposition != "" {
+		c.Header("Content-Disposition", disposition)
+	}
+	served := *object
+	if sum, err := hex.DecodeString(file.SHA256); err == nil && len(sum) > 0 {
+		served.ETag = file.SHA256
+		c.Header("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum))
+	}
+
+	serveStored(c, content, &served, contentType)
+}
+
+// serveStored writes stored content, typed as contentType or else as recorded
+// by the storage. Seekable content is served with strict range support: single
+// and multiple byte ranges answer 206, unsatisfiable ones 416, and a Range is
+// ignored when its If-Range no longer matches the ETag or modification time.
+// Video players need this to seek.
+func serveStored(c *gin.Context, content io.Reader, object *domain.StoredObject, contentType string) {
+	if contentType == "" {
+		contentType = object.ContentType
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	etag := ""
+	if object.ETag != "" {
+		etag = `"` + object.ETag + `"`
+		c.Header("ETag", etag)
+	}
+
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", object.LastModified, seeker)
+		return
+	}
+
+	c.Header("Accept-Ranges", "none")
+	if etag != "" && etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.DataFromReader(http.StatusOK, object.Size, contentType, content, nil)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, weakly compared
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// fileAccess reads the visibility requested for an upload, public when empty
+func fileAccess(visibility, customerID string) (*domain.FileAccess, error) {
+	access := &domain.FileAccess{Visibility: domain.FileVisibility(visibility)}
+	switch access.Visibility {
+	case "":
+		return nil, nil
+	case domain.FileVisibilityPublic, domain.FileVisibilityPrivate:
+		return access, nil
+	case domain.FileVisibilityCustomer:
+		id, err := primitive.ObjectIDFromHex(customerID)
+		if err != nil {
+			return nil, domain.ErrInvalidInput
+		}
+		access.CustomerID = &id
+		return access, nil
+	}
+	return nil, domain.ErrInvalidInput
+}
+
+// denyRestrictedUpload answers 403 and returns true when a request without a
+// staff token asks for a private or customer file; anonymous uploads are public
+func denyRestrictedUpload(c *gin.Context, access *domain.FileAccess) bool {
+	if access == nil || access.Visibility == domain.FileVisibilityPublic || isStaff(c) {
+		return false
+	}
+	response.Error(c, http.StatusForbidden, "Access denied", "only staff can upload private and customer files")
+	return true
+}
+
+// attachment builds a Content-Disposition header for a download, quoting the
+// file name and encoding non-ASCII names as RFC 2231 says
+func attachment(filename string) string {
+	if value := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); value != "" {
+		return value
+	}
+	return "attachment"
 }
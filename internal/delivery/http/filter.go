@@ -0,0 +1,298 @@
+package http
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parseTimeParam parses a date (YYYY-MM-DD) or RFC3339 query parameter.
+// With endOfDay, a plain date is moved to the start of the next day so the
+// range includes the whole day.
+func parseTimeParam(c *gin.Context, key string, endOfDay bool) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, domain.ErrInvalidInput
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// parseCreatedRange reads the created_from and created_to query parameters
+func parseCreatedRange(c *gin.Context) (from, to *time.Time, err error) {
+	if from, err = parseTimeParam(c, "created_from", false); err != nil {
+		return nil, nil, err
+	}
+	if to, err = parseTimeParam(c, "created_to", true); err != nil {
+		return nil, nil, err
+	}
+	return from, to, nil
+}
+
+// parseCustomerFilter reads the customer list filters from the query string
+func parseCustomerFilter(c *gin.Context) (*domain.CustomerFilter, error) {
+	from, to, err := parseCreatedRange(c)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &domain.CustomerFilter{
+		Search:           strings.TrimSpace(c.Query("q")),
+		WorkstationRange: c.Query("workstation_range"),
+		CreatedFrom:      from,
+		CreatedTo:        to,
+	}
+
+	if value := c.Query("is_active"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, domain.ErrInvalidInput
+		}
+		filter.IsActive = &isActive
+	}
+
+	if value := c.Query("province"); value != "" {
+		filter.ProvinceCodes = []string{value}
+	}
+
+	return filter, nil
+}
+
+// parseRegistrationFilter reads the registration list filters from the query string
+func parseRegistrationFilter(c *gin.Context) (*domain.RegistrationFilter, error) {
+	from, to, err := parseCreatedRange(c)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &domain.RegistrationFilter{
+		Search:           strings.TrimSpace(c.Query("q")),
+		WorkstationRange: c.Query("workstation_range"),
+		CreatedFrom:      from,
+		CreatedTo:        to,
+	}
+
+	if value := c.Query("reseller_id"); value != "" {
+		resellerID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		filter.ResellerID = &resellerID
+	}
+
+	return filter, nil
+}
+
+// parseFileFilter reads the file list filters from the query string. tag may
+// be repeated to match files carrying all of the tags.
+func parseFileFilter(c *gin.Context, fileType domain.FileType) (*domain.FileFilter, error) {
+	filter := &domain.FileFilter{
+		FileType: fileType,
+		Tags:     c.QueryArray("tag"),
+		Category: strings.TrimSpace(c.Query("category")),
+		Folder:   strings.TrimSpace(c.Query("folder")),
+		MimeType: strings.ToLower(strings.TrimSpace(c.Query("mime_type"))),
+		Search:   strings.TrimSpace(c.Query("q")),
+	}
+
+	if value := c.Query("recursive"); value != "" {
+		recursive, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, domain.ErrInvalidInput
+		}
+		filter.Recursive = recursive
+	}
+
+	visibility, err := parseFileVisibility(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.Visibility = visibility
+
+	return filter, nil
+}
+
+// parseFileVisibility reads the visibility query parameter of staff requests.
+// Requests without a staff token only see public files.
+func parseFileVisibility(c *gin.Context) (domain.FileVisibility, error) {
+	if !isStaff(c) {
+		return domain.FileVisibilityPublic, nil
+	}
+
+	switch visibility := domain.FileVisibility(c.Query("visibility")); visibility {
+	case "", domain.FileVisibilityPublic, domain.FileVisibilityPrivate, domain.FileVisibilityCustomer:
+		return visibility, nil
+	default:
+		return "", domain.ErrInvalidInput
+	}
+}
+
+// parseTaskFilter reads the status and due_before query parameters.
+// defaultStatus applies when status is missing; "all" matches every status.
+func parseTaskFilter(c *gin.Context, defaultStatus string) (*domain.TaskFilter, error) {
+	dueBefore, err := parseTimeParam(c, "due_before", true)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &domain.TaskFilter{DueBefore: dueBefore}
+
+	switch status := c.DefaultQuery("status", defaultStatus); status {
+	case "all":
+	case string(domain.TaskOpen), string(domain.TaskDone):
+		filter.Status = domain.TaskStatus(status)
+	default:
+		return nil, domain.ErrInvalidInput
+	}
+
+	return filter, nil
+}
+
+// parseAppointmentFilter reads the appointment list filters from the query string.
+// from and to select appointments overlapping the range.
+func parseAppointmentFilter(c *gin.Context) (*domain.AppointmentFilter, error) {
+	from, err := parseTimeParam(c, "from", false)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseTimeParam(c, "to", true)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &domain.AppointmentFilter{
+		RegistrationID: c.Query("registration_id"),
+		CustomerID:     c.Query("customer_id"),
+		TechnicianID:   c.Query("technician_id"),
+		From:           from,
+		To:             to,
+	}
+
+	switch status := domain.AppointmentStatus(c.Query("status")); status {
+	case "":
+	case domain.AppointmentScheduled, domain.AppointmentCompleted, domain.AppointmentCancelled:
+		filter.Status = status
+	default:
+		return nil, domain.ErrInvalidInput
+	}
+
+	return filter, nil
+}
+
+// parseInvoiceFilter reads the invoice list filters from the query string
+func parseInvoiceFilter(c *gin.Context) (*domain.InvoiceFilter, error) {
+	from, to, err := parseCreatedRange(c)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &domain.InvoiceFilter{
+		Search:      strings.TrimSpace(c.Query("q")),
+		CreatedFrom: from,
+		CreatedTo:   to,
+	}
+
+	switch status := domain.InvoiceStatus(c.Query("status")); status {
+	case "":
+	case domain.InvoiceDraft, domain.InvoiceIssued, domain.InvoicePaid, domain.InvoiceVoid:
+		filter.Status = status
+	default:
+		return nil, domain.ErrInvalidInput
+	}
+
+	if value := c.Query("customer_id"); value != "" {
+		customerID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		filter.CustomerID = &customerID
+	}
+
+	return filter, nil
+}
+
+// parsePaymentFilter reads the payment list filters from the query string
+func parsePaymentFilter(c *gin.Context) (*domain.PaymentFilter, error) {
+	from, to, err := parseCreatedRange(c)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &domain.PaymentFilter{
+		CreatedFrom: from,
+		CreatedTo:   to,
+	}
+
+	switch status := domain.PaymentStatus(c.Query("status")); status {
+	case "":
+	case domain.PaymentPending, domain.PaymentSucceeded, domain.PaymentFailed:
+		filter.Status = status
+	default:
+		return nil, domain.ErrInvalidInput
+	}
+
+	if value := c.Query("customer_id"); value != "" {
+		customerID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		filter.CustomerID = &customerID
+	}
+
+	return filter, nil
+}
+
+// parseCommissionFilter reads the commission list filters from the query string
+func parseCommissionFilter(c *gin.Context) (*domain.CommissionFilter, error) {
+	from, to, err := parseCreatedRange(c)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &domain.CommissionFilter{
+		CreatedFrom: from,
+		CreatedTo:   to,
+	}
+
+	switch status := domain.CommissionStatus(c.Query("status")); status {
+	case "":
+	case domain.CommissionPending, domain.CommissionPaid, domain.CommissionCancelled:
+		filter.Status = status
+	default:
+		return nil, domain.ErrInvalidInput
+	}
+
+	if value := c.Query("reseller_id"); value != "" {
+		resellerID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		filter.ResellerID = &resellerID
+	}
+
+	if value := c.Query("customer_id"); value != "" {
+		customerID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		filter.CustomerID = &customerID
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HLSHandler represents the HTTP handler for HLS packages of videos
+type HLSHandler struct {
+	hlsUsecase domain.HLSUsecase
+}
+
+// NewHLSHandler creates a new HLS handler
+func NewHLSHandler(router *gin.RouterGroup, uc domain.HLSUsecase) {
+	handler := &HLSHandler{
+		hlsUsecase: uc,
+	}
+
+	router.GET("/videos/hls/:id/:name", handler.Serve)
+	router.HEAD("/videos/hls/:id/:name", handler.Serve)
+	router.POST("/videos/:id/hls", handler.Requeue)
+}
+
+// Serve godoc
+// @Summary Serve an HLS playlist or segment
+// @Description Serve the playlist (index.m3u8) or a segment of the HLS package of a video. The playlist URL is in the hls field of the video once its status is ready.
+// @Tags videos
+// @Produce application/vnd.apple.mpegurl
+// @Produce video/mp2t
+// @Param id path string true "File ID of the video"
+// @Param name path string true "index.m3u8 or a segment name"
+// @Success 200 {file} binary
+// @Success 206 {file} binary
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /videos/hls/{id}/{name} [get]
+func (h *HLSHandler) Serve(c *gin.Context) {
+	content, object, err := h.hlsUsecase.Open(c.Request.Context(), c.Param("id"), c.Param("name"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID, domain.ErrNotFound:
+			response.NotFound(c, "Playlist or segment not found")
+		default:
+			response.InternalServerError(c, "Failed to open playlist or segment", err.Error())
+		}
+		return
+	}
+	defer content.Close()
+
+	serveStored(c, content, object, "")
+}
+
+// Requeue godoc
+// @Summary Package a video as HLS again
+// @Description Queue an MP4 video for HLS packaging, after a failure or to package it again
+// @Tags videos
+// @Produce json
+// @Param id path string true "File ID of the video"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /videos/{id}/hls [post]
+func (h *HLSHandler) Requeue(c *gin.Context) {
+	file, err := h.hlsUsecase.Requeue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrInvalidInput:
+			response.BadRequest(c, "Only MP4 videos are packaged as HLS", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		case domain.ErrHLSInProgress:
+			response.Conflict(c, "Video is being packaged", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to queue video", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Video queued for HLS packaging", file)
+}
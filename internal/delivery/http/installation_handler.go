@@ -0,0 +1,334 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InstallationHandler represents the HTTP handler for cafe client installations
+type InstallationHandler struct {
+	installationUsecase domain.InstallationUsecase
+	validator           *validator.CustomValidator
+}
+
+// NewInstallationHandler creates a new installation handler.
+// Activation and heartbeats are public for cafe clients; management requires authentication.
+func NewInstallationHandler(public *gin.RouterGroup, protected *gin.RouterGroup, uc domain.InstallationUsecase) {
+	handler := &InstallationHandler{
+		installationUsecase: uc,
+		validator:           validator.NewValidator(),
+	}
+
+	// Public routes - used by cafe clients
+	public.POST("/installations/activate", handler.Activate)
+	public.POST("/installations/heartbeat", handler.Heartbeat)
+
+	// Read operations - accessible by admin and sale
+	protected.GET("/customers/:id/installations", handler.GetByCustomer)
+
+	// Write operations - accessible by admin only
+	adminOnly := protected.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/customers/:id/installations", handler.Create)
+		adminOnly.GET("/installations/attention", handler.GetAttention)
+		adminOnly.PUT("/installations/:id", handler.Update)
+		adminOnly.POST("/installations/:id/reset", handler.Reset)
+		adminOnly.DELETE("/installations/:id", handler.Delete)
+	}
+}
+
+// Create godoc
+// @Summary Register an installation
+// @Description Register a cafe client installation and get its activation code (admin only)
+// @Tags installations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param installation body domain.CreateInstallationRequest true "Installation data"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/installations [post]
+func (h *InstallationHandler) Create(c *gin.Context) {
+	var req domain.CreateInstallationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	installation, err := h.installationUsecase.Create(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrShopNotInCustomer:
+			response.BadRequest(c, "Invalid shop", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to create installation", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Installation created successfully", installation)
+}
+
+// GetByCustomer godoc
+// @Summary Get customer installations
+// @Description Get the installations of a customer with their seat and health status
+// @Tags installations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/installations [get]
+func (h *InstallationHandler) GetByCustomer(c *gin.Context) {
+	installations, err := h.installationUsecase.GetByCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to get installations", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Installations retrieved successfully", installations)
+}
+
+// GetAttention godoc
+// @Summary Get installations needing attention
+// @Description Get stale or over-limit installations grouped by customer (admin only)
+// @Tags installations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /installations/attention [get]
+func (h *InstallationHandler) GetAttention(c *gin.Context) {
+	groups, err := h.installationUsecase.GetAttention(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, "Failed to get installations", err.Error())
+		return
+	}
+
+	response.OK(c, "Installations retrieved successfully", groups)
+}
+
+// Update godoc
+// @Summary Update an installation
+// @Description Rename an installation or set its seat limit; seat_limit 0 follows the workstation range again (admin only)
+// @Tags installations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Installation ID"
+// @Param installation body domain.UpdateInstallationRequest true "Installation data"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /installations/{id} [put]
+func (h *InstallationHandler) Update(c *gin.Context) {
+	var req domain.UpdateInstallationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	installation, err := h.installationUsecase.Update(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update installation")
+		return
+	}
+
+	response.OK(c, "Installation updated successfully", installation)
+}
+
+// Reset godoc
+// @Summary Reset an installation
+// @Description Unbind an installation from its machine and issue a new activation code (admin only)
+// @Tags installations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Installation ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /installations/{id}/reset [post]
+func (h *InstallationHandler) Reset(c *gin.Context) {
+	installation, err := h.installationUsecase.Reset(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to reset installation")
+		return
+	}
+
+	response.OK(c, "Installation reset successfully", installation)
+}
+
+// Delete godoc
+// @Summary Delete an installation
+// @Description Delete an installation (admin only)
+// @Tags installations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Installation ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /installations/{id} [delete]
+func (h *InstallationHandler) Delete(c *gin.Context) {
+	if err := h.installationUsecase.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.writeError(c, err, "Failed to delete installation")
+		return
+	}
+
+	response.OK(c, "Installation deleted successfully", nil)
+}
+
+// Activate godoc
+// @Summary Activate an installation
+// @Description Bind an installation to a machine with its activation code (public, called by the cafe client)
+// @Tags installations
+// @Accept json
+// @Produce json
+// @Param activation body domain.ActivateInstallationRequest true "Activation data"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /installations/activate [post]
+func (h *InstallationHandler) Activate(c *gin.Context) {
+	var req domain.ActivateInstallationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	session, err := h.installationUsecase.Activate(c.Request.Context(), &req)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidActivationCode:
+			response.NotFound(c, "Invalid activation code")
+		case domain.ErrInstallationActivated:
+			response.Conflict(c, "Installation already activated on another machine", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to activate installation", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Installation activated successfully", session)
+}
+
+// Heartbeat godoc
+// @Summary Send a heartbeat
+// @Description Report the version and active workstations of an installation (public, called by the cafe client).
+// @Description Returns 409 when the active workstations exceed the allowed seats.
+// @Tags installations
+// @Accept json
+// @Produce json
+// @Param heartbeat body domain.HeartbeatRequest true "Heartbeat data"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /installations/heartbeat [post]
+func (h *InstallationHandler) Heartbeat(c *gin.Context) {
+	var req domain.HeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	session, err := h.installationUsecase.Heartbeat(c.Request.Context(), &req)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidInstallationToken:
+			response.Error(c, http.StatusUnauthorized, "Invalid installation token", err.Error())
+		case domain.ErrSeatLimitExceeded:
+			message := fmt.Sprintf("Seat limit exceeded: %d seats allowed", session.AllowedSeats)
+			response.Conflict(c, message, err.Error())
+		default:
+			response.InternalServerError(c, "Failed to record heartbeat", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Heartbeat recorded", session)
+}
+
+// writeError maps installation errors to HTTP responses
+func (h *InstallationHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrShopNotInCustomer:
+		response.BadRequest(c, "Invalid shop", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, "Installation not found")
+	case domain.ErrAlreadyExists:
+		response.Conflict(c, "Activation code collision, please retry", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,463 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceHandler represents the HTTP handler for VAT invoices
+type InvoiceHandler struct {
+	invoiceUsecase domain.InvoiceUsecase
+	validator      *validator.CustomValidator
+}
+
+// NewInvoiceHandler creates a new invoice handler
+func NewInvoiceHandler(router *gin.RouterGroup, uc domain.InvoiceUsecase) {
+	handler := &InvoiceHandler{
+		invoiceUsecase: uc,
+		validator:      validator.NewValidator(),
+	}
+
+	// Read operations - accessible by admin and sale
+	router.GET("/invoices", handler.GetAll)
+	router.GET("/invoices/:id", handler.GetByID)
+	router.GET("/invoices/:id/pdf", handler.RenderPDF)
+	router.GET("/customers/:id/invoices", handler.GetByCustomer)
+
+	// Write operations - accessible by admin only
+	adminOnly := router.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/customers/:id/invoices", handler.Create)
+		adminOnly.POST("/subscriptions/:id/invoices", handler.CreateForSubscription)
+		adminOnly.PUT("/invoices/:id", handler.Update)
+		adminOnly.DELETE("/invoices/:id", handler.Delete)
+		adminOnly.POST("/invoices/:id/issue", handler.Issue)
+		adminOnly.POST("/invoices/:id/pay", handler.MarkPaid)
+		adminOnly.POST("/invoices/:id/void", handler.Void)
+		adminOnly.POST("/invoices/:id/pdf", handler.RegeneratePDF)
+	}
+}
+
+// Create godoc
+// @Summary Draft an invoice
+// @Description Draft an invoice for a customer; empty buyer fields are copied from the customer (admin only)
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param invoice body domain.CreateInvoiceRequest true "Invoice lines"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/invoices [post]
+func (h *InvoiceHandler) Create(c *gin.Context) {
+	var req domain.CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	invoice, err := h.invoiceUsecase.Create(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			h.writeError(c, err, "Failed to create invoice")
+		}
+		return
+	}
+
+	response.Created(c, "Invoice created successfully", invoice)
+}
+
+// CreateForSubscription godoc
+// @Summary Draft a subscription invoice
+// @Description Draft the invoice of the next billing period of a subscription (admin only)
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/{id}/invoices [post]
+func (h *InvoiceHandler) CreateForSubscription(c *gin.Context) {
+	invoice, err := h.invoiceUsecase.CreateForSubscription(c.Request.Context(), c.Param("id"), currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Subscription not found")
+		default:
+			h.writeError(c, err, "Failed to create invoice")
+		}
+		return
+	}
+
+	response.Created(c, "Invoice created successfully", invoice)
+}
+
+// GetAll godoc
+// @Summary List invoices
+// @Description List invoices, newest first, with optional filters
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact, estimated or none"
+// @Param q query string false "Search by number, buyer name, company or tax code"
+// @Param status query string false "draft, issued, paid or void"
+// @Param customer_id query string false "Customer ID"
+// @Param created_from query string false "Created from (YYYY-MM-DD or RFC3339)"
+// @Param created_to query string false "Created to, inclusive day (YYYY-MM-DD or RFC3339)"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices [get]
+func (h *InvoiceHandler) GetAll(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	filter, err := parseInvoiceFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	invoices, info, err := h.invoiceUsecase.GetAll(c.Request.Context(), filter, page)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get invoices", err.Error())
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Invoices retrieved successfully", invoices, pageMeta(page, info))
+}
+
+// GetByCustomer godoc
+// @Summary Get customer invoices
+// @Description Get the invoices of a customer, newest first
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/invoices [get]
+func (h *InvoiceHandler) GetByCustomer(c *gin.Context) {
+	invoices, err := h.invoiceUsecase.GetByCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			h.writeError(c, err, "Failed to get invoices")
+		}
+		return
+	}
+
+	response.OK(c, "Invoices retrieved successfully", invoices)
+}
+
+// GetByID godoc
+// @Summary Get an invoice
+// @Description Get an invoice by its ID
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices/{id} [get]
+func (h *InvoiceHandler) GetByID(c *gin.Context) {
+	invoice, err := h.invoiceUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get invoice")
+		return
+	}
+
+	response.OK(c, "Invoice retrieved successfully", invoice)
+}
+
+// Update godoc
+// @Summary Update a draft invoice
+// @Description Update the buyer, lines, VAT rate or note of a draft invoice (admin only)
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Param invoice body domain.UpdateInvoiceRequest true "Fields to update"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices/{id} [put]
+func (h *InvoiceHandler) Update(c *gin.Context) {
+	var req domain.UpdateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	invoice, err := h.invoiceUsecase.Update(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update invoice")
+		return
+	}
+
+	response.OK(c, "Invoice updated successfully", invoice)
+}
+
+// Delete godoc
+// @Summary Delete a draft invoice
+// @Description Delete an invoice that has not been issued; issued invoices are voided instead (admin only)
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices/{id} [delete]
+func (h *InvoiceHandler) Delete(c *gin.Context) {
+	if err := h.invoiceUsecase.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.writeError(c, err, "Failed to delete invoice")
+		return
+	}
+
+	response.OK(c, "Invoice deleted successfully", nil)
+}
+
+// Issue godoc
+// @Summary Issue an invoice
+// @Description Number a draft invoice with the next number of its series and store its PDF (admin only)
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices/{id}/issue [post]
+func (h *InvoiceHandler) Issue(c *gin.Context) {
+	invoice, err := h.invoiceUsecase.Issue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to issue invoice")
+		return
+	}
+
+	response.OK(c, "Invoice issued successfully", invoice)
+}
+
+// MarkPaid godoc
+// @Summary Record an invoice payment
+// @Description Mark an issued invoice as paid, now or at the given time (admin only)
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Param payment body domain.PayInvoiceRequest false "Payment details"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices/{id}/pay [post]
+func (h *InvoiceHandler) MarkPaid(c *gin.Context) {
+	var req domain.PayInvoiceRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	invoice, err := h.invoiceUsecase.MarkPaid(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to record payment")
+		return
+	}
+
+	response.OK(c, "Invoice marked as paid successfully", invoice)
+}
+
+// Void godoc
+// @Summary Void an invoice
+// @Description Void an issued or paid invoice; it keeps its number and its PDF is marked as voided (admin only)
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Param reason body domain.VoidInvoiceRequest true "Reason"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices/{id}/void [post]
+func (h *InvoiceHandler) Void(c *gin.Context) {
+	var req domain.VoidInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	invoice, err := h.invoiceUsecase.Void(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to void invoice")
+		return
+	}
+
+	response.OK(c, "Invoice voided successfully", invoice)
+}
+
+// RenderPDF godoc
+// @Summary Preview an invoice PDF
+// @Description Render the PDF of an invoice without storing it, e.g. to preview a draft. The stored PDF of an issued invoice is downloaded through its file_id.
+// @Tags invoices
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices/{id}/pdf [get]
+func (h *InvoiceHandler) RenderPDF(c *gin.Context) {
+	var buf bytes.Buffer
+	invoice, err := h.invoiceUsecase.RenderPDF(c.Request.Context(), c.Param("id"), &buf)
+	if err != nil {
+		h.writeError(c, err, "Failed to render invoice")
+		return
+	}
+
+	filename := "invoice-draft-" + invoice.ID.Hex() + ".pdf"
+	if invoice.Number != "" {
+		filename = "invoice-" + invoice.Series + "-" + invoice.Number + ".pdf"
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// RegeneratePDF godoc
+// @Summary Regenerate an invoice PDF
+// @Description Generate and store the PDF of an issued, paid or voided invoice again, replacing the previous file (admin only)
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /invoices/{id}/pdf [post]
+func (h *InvoiceHandler) RegeneratePDF(c *gin.Context) {
+	invoice, err := h.invoiceUsecase.RegeneratePDF(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to regenerate invoice PDF")
+		return
+	}
+
+	response.OK(c, "Invoice PDF regenerated successfully", invoice)
+}
+
+// writeError maps invoice errors to HTTP responses
+func (h *InvoiceHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrInvoiceBuyerRequired:
+		response.BadRequest(c, "Invoice buyer is incomplete", err.Error())
+	case domain.ErrInvalidInput, domain.ErrInvoiceAmountTooLarge:
+		response.BadRequest(c, "Invoice amounts are invalid", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, "Invoice not found")
+	case domain.ErrInvoiceNotDraft:
+		response.Conflict(c, "Invoice is not a draft", err.Error())
+	case domain.ErrInvoiceStatus:
+		response.Conflict(c, "Invoice status does not allow this change", err.Error())
+	case domain.ErrInvoiceNumberConflict:
+		response.Conflict(c, "Invoice number is taken, try again", err.Error())
+	case domain.ErrSubscriptionCancelled:
+		response.Conflict(c, "Subscription is cancelled", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
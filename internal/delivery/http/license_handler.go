@@ -0,0 +1,355 @@
+package http
+
+import (
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LicenseHandler represents the HTTP handler for software licenses
+type LicenseHandler struct {
+	licenseUsecase domain.LicenseUsecase
+	validator      *validator.CustomValidator
+}
+
+// NewLicenseHandler creates a new license handler.
+// Key verification is public for cafe clients; management requires authentication.
+func NewLicenseHandler(public *gin.RouterGroup, protected *gin.RouterGroup, uc domain.LicenseUsecase) {
+	handler := &LicenseHandler{
+		licenseUsecase: uc,
+		validator:      validator.NewValidator(),
+	}
+
+	// Public routes - used by cafe clients
+	public.GET("/licenses/public-key", handler.PublicKey)
+	public.POST("/licenses/verify", handler.Verify)
+
+	// Read operations - accessible by admin and sale
+	protected.GET("/customers/:id/licenses", handler.GetByCustomer)
+	protected.GET("/licenses/:id", handler.GetByID)
+
+	// Write operations - accessible by admin only
+	adminOnly := protected.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/customers/:id/licenses", handler.Issue)
+		adminOnly.POST("/licenses/:id/extend", handler.Extend)
+		adminOnly.POST("/licenses/:id/suspend", handler.Suspend)
+		adminOnly.POST("/licenses/:id/resume", handler.Resume)
+		adminOnly.POST("/licenses/:id/revoke", handler.Revoke)
+	}
+}
+
+// Issue godoc
+// @Summary Issue a license
+// @Description Issue a signed license to a customer (admin only)
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param license body domain.IssueLicenseRequest true "License terms"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/licenses [post]
+func (h *LicenseHandler) Issue(c *gin.Context) {
+	var req domain.IssueLicenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	license, err := h.licenseUsecase.Issue(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrInvalidLicenseExpiry:
+			response.BadRequest(c, "Invalid expiry date", err.Error())
+		case domain.ErrShopNotInCustomer:
+			response.BadRequest(c, "Invalid shop", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to issue license", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "License issued successfully", license)
+}
+
+// GetByCustomer godoc
+// @Summary Get customer licenses
+// @Description Get the licenses of a customer, newest first
+// @Tags licenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/licenses [get]
+func (h *LicenseHandler) GetByCustomer(c *gin.Context) {
+	licenses, err := h.licenseUsecase.GetByCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to get licenses", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Licenses retrieved successfully", licenses)
+}
+
+// GetByID godoc
+// @Summary Get a license
+// @Description Get a license by its ID
+// @Tags licenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "License ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /licenses/{id} [get]
+func (h *LicenseHandler) GetByID(c *gin.Context) {
+	license, err := h.licenseUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get license")
+		return
+	}
+
+	response.OK(c, "License retrieved successfully", license)
+}
+
+// Extend godoc
+// @Summary Extend a license
+// @Description Move the expiry date and optionally change the seats; the key is re-signed (admin only)
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "License ID"
+// @Param license body domain.ExtendLicenseRequest true "New terms"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /licenses/{id}/extend [post]
+func (h *LicenseHandler) Extend(c *gin.Context) {
+	var req domain.ExtendLicenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	license, err := h.licenseUsecase.Extend(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to extend license")
+		return
+	}
+
+	response.OK(c, "License extended successfully", license)
+}
+
+// Suspend godoc
+// @Summary Suspend a license
+// @Description Temporarily disable a license (admin only)
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "License ID"
+// @Param reason body domain.LicenseStatusRequest false "Reason"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /licenses/{id}/suspend [post]
+func (h *LicenseHandler) Suspend(c *gin.Context) {
+	req, ok := h.bindStatusRequest(c)
+	if !ok {
+		return
+	}
+
+	license, err := h.licenseUsecase.Suspend(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.writeError(c, err, "Failed to suspend license")
+		return
+	}
+
+	response.OK(c, "License suspended successfully", license)
+}
+
+// Resume godoc
+// @Summary Resume a license
+// @Description Reactivate a suspended license (admin only)
+// @Tags licenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "License ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /licenses/{id}/resume [post]
+func (h *LicenseHandler) Resume(c *gin.Context) {
+	license, err := h.licenseUsecase.Resume(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to resume license")
+		return
+	}
+
+	response.OK(c, "License resumed successfully", license)
+}
+
+// Revoke godoc
+// @Summary Revoke a license
+// @Description Permanently disable a license (admin only)
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "License ID"
+// @Param reason body domain.LicenseStatusRequest false "Reason"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /licenses/{id}/revoke [post]
+func (h *LicenseHandler) Revoke(c *gin.Context) {
+	req, ok := h.bindStatusRequest(c)
+	if !ok {
+		return
+	}
+
+	license, err := h.licenseUsecase.Revoke(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.writeError(c, err, "Failed to revoke license")
+		return
+	}
+
+	response.OK(c, "License revoked successfully", license)
+}
+
+// Verify godoc
+// @Summary Verify a license key
+// @Description Check the signature, status and expiry of a license key online (public)
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param key body domain.VerifyLicenseRequest true "License key"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /licenses/verify [post]
+func (h *LicenseHandler) Verify(c *gin.Context) {
+	var req domain.VerifyLicenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	result, err := h.licenseUsecase.Verify(c.Request.Context(), req.Key)
+	if err != nil {
+		response.InternalServerError(c, "Failed to verify license", err.Error())
+		return
+	}
+
+	response.OK(c, "License verified", result)
+}
+
+// PublicKey godoc
+// @Summary Get the license public key
+// @Description Get the Ed25519 public key that verifies license keys offline (public)
+// @Tags licenses
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /licenses/public-key [get]
+func (h *LicenseHandler) PublicKey(c *gin.Context) {
+	response.OK(c, "License public key retrieved successfully", h.licenseUsecase.PublicKey())
+}
+
+// bindStatusRequest reads the optional reason of a status change
+func (h *LicenseHandler) bindStatusRequest(c *gin.Context) (*domain.LicenseStatusRequest, bool) {
+	var req domain.LicenseStatusRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err.Error())
+			return nil, false
+		}
+	}
+
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// writeError maps license errors to HTTP responses
+func (h *LicenseHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrInvalidLicenseExpiry:
+		response.BadRequest(c, "Invalid expiry date", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, "License not found")
+	case domain.ErrLicenseRevoked:
+		response.Conflict(c, "License is revoked", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
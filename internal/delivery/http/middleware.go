@@ -17,10 +17,13 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Tus-Resumable, Upload-Length, Upload-Metadata, Upload-Offset, Upload-Checksum, Upload-Defer-Length")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH, HEAD")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Tus-Checksum-Algorithm, Upload-Offset, Upload-Length, Upload-Metadata, Upload-Expires, X-File-Id")
 
-		if c.Request.Method == "OPTIONS" {
+		// Preflight requests are answered here, unless the route handles OPTIONS
+		// itself, as tus discovery does
+		if c.Request.Method == "OPTIONS" && c.FullPath() == "" {
 			c.AbortWithStatus(204)
 			return
 		}
@@ -106,6 +109,19 @@ func JWTAuthMiddleware(authUsecase domain.AuthUsecase) gin.HandlerFunc {
 	}
 }
 
+// OptionalJWTAuthMiddleware validates the JWT token like JWTAuthMiddleware when
+// one is sent, and lets requests without an Authorization header through
+func OptionalJWTAuthMiddleware(authUsecase domain.AuthUsecase) gin.HandlerFunc {
+	required := JWTAuthMiddleware(authUsecase)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
+
 // RequirePermission checks if user has required permission
 func RequirePermission(permission domain.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -163,3 +179,20 @@ func RequireRole(roles ...domain.Role) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// isStaff reports whether the request was authenticated as an admin or sale user
+func isStaff(c *gin.Context) bool {
+	return currentActor(c).IsStaff()
+}
+
+// currentActor returns the authenticated user set by JWTAuthMiddleware
+func currentActor(c *gin.Context) *domain.Actor {
+	role, _ := c.Get("role")
+	actorRole, _ := role.(domain.Role)
+
+	return &domain.Actor{
+		ID:   c.GetString("user_id"),
+		Name: c.GetString("username"),
+		Role: actorRole,
+	}
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"strconv"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePagination reads the limit, offset, cursor and count query parameters
+func parsePagination(c *gin.Context) (*domain.Pagination, error) {
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+
+	return domain.NewPagination(limit, offset, c.Query("cursor"), domain.CountMode(c.Query("count")))
+}
+
+// pageMeta builds the response metadata of a paginated list
+func pageMeta(page *domain.Pagination, info *domain.PageInfo) *response.Meta {
+	meta := &response.Meta{
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+		NextCursor: info.NextCursor,
+	}
+
+	if info.Counted {
+		total := info.Total
+		meta.Total = &total
+		meta.TotalEstimated = info.TotalEstimated
+	}
+
+	return meta
+}
@@ -0,0 +1,257 @@
+package http
+
+import (
+	"log"
+	"net/http"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentHandler represents the HTTP handler for online renewal payments
+type PaymentHandler struct {
+	paymentUsecase domain.PaymentUsecase
+	validator      *validator.CustomValidator
+}
+
+// NewPaymentHandler creates a new payment handler
+func NewPaymentHandler(public *gin.RouterGroup, protected *gin.RouterGroup, uc domain.PaymentUsecase) {
+	handler := &PaymentHandler{
+		paymentUsecase: uc,
+		validator:      validator.NewValidator(),
+	}
+
+	// Public routes - called by VNPay and by the payer's browser, authenticated by their signature
+	public.GET("/payments/vnpay/ipn", handler.VNPayIPN)
+	public.GET("/payments/vnpay/return", handler.VNPayReturn)
+
+	// Read operations - accessible by admin and sale
+	protected.GET("/payments", handler.GetAll)
+	protected.GET("/payments/:id", handler.GetByID)
+	protected.GET("/customers/:id/payments", handler.GetByCustomer)
+
+	// Write operations - accessible by admin only
+	adminOnly := protected.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/subscriptions/:id/payments", handler.Create)
+	}
+}
+
+// Create godoc
+// @Summary Create a renewal payment link
+// @Description Create a VNPay payment link for the next billing period of a subscription, priced with the current plan prices (admin only)
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Param payment body domain.CreatePaymentRequest false "Billing cycle to pay for"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /subscriptions/{id}/payments [post]
+func (h *PaymentHandler) Create(c *gin.Context) {
+	var req domain.CreatePaymentRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	payment, err := h.paymentUsecase.Create(c.Request.Context(), c.Param("id"), &req, c.ClientIP(), currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Subscription or plan not found")
+		default:
+			h.writeError(c, err, "Failed to create payment")
+		}
+		return
+	}
+
+	response.Created(c, "Payment created successfully", payment)
+}
+
+// GetAll godoc
+// @Summary List payments
+// @Description List online payments, newest first, with optional filters
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact, estimated or none"
+// @Param status query string false "pending, succeeded or failed"
+// @Param customer_id query string false "Customer ID"
+// @Param created_from query string false "Created from (YYYY-MM-DD or RFC3339)"
+// @Param created_to query string false "Created to, inclusive day (YYYY-MM-DD or RFC3339)"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /payments [get]
+func (h *PaymentHandler) GetAll(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	filter, err := parsePaymentFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	payments, info, err := h.paymentUsecase.GetAll(c.Request.Context(), filter, page)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get payments", err.Error())
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Payments retrieved successfully", payments, pageMeta(page, info))
+}
+
+// GetByCustomer godoc
+// @Summary Get customer payments
+// @Description Get the online payments of a customer, newest first
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/payments [get]
+func (h *PaymentHandler) GetByCustomer(c *gin.Context) {
+	payments, err := h.paymentUsecase.GetByCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			h.writeError(c, err, "Failed to get payments")
+		}
+		return
+	}
+
+	response.OK(c, "Payments retrieved successfully", payments)
+}
+
+// GetByID godoc
+// @Summary Get a payment
+// @Description Get an online payment by its ID
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Payment ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /payments/{id} [get]
+func (h *PaymentHandler) GetByID(c *gin.Context) {
+	payment, err := h.paymentUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get payment")
+		return
+	}
+
+	response.OK(c, "Payment retrieved successfully", payment)
+}
+
+// vnpayAck is the acknowledgement VNPay expects from the IPN endpoint
+type vnpayAck struct {
+	RspCode string `json:"RspCode"`
+	Message string `json:"Message"`
+}
+
+// VNPayIPN godoc
+// @Summary VNPay payment notification
+// @Description Called by VNPay with the signed result of a payment. The answer follows the VNPay IPN format; VNPay retries until it gets 00 or 02.
+// @Tags payments
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /payments/vnpay/ipn [get]
+func (h *PaymentHandler) VNPayIPN(c *gin.Context) {
+	_, err := h.paymentUsecase.HandleCallback(c.Request.Context(), c.Request.URL.Query())
+
+	ack := vnpayAck{RspCode: "00", Message: "Confirm Success"}
+	switch err {
+	case nil:
+	case domain.ErrInvalidSignature:
+		ack = vnpayAck{RspCode: "97", Message: "Invalid signature"}
+	case domain.ErrNotFound:
+		ack = vnpayAck{RspCode: "01", Message: "Order not found"}
+	case domain.ErrPaymentProcessed:
+		ack = vnpayAck{RspCode: "02", Message: "Order already confirmed"}
+	case domain.ErrPaymentAmountMismatch:
+		ack = vnpayAck{RspCode: "04", Message: "Invalid amount"}
+	default:
+		log.Printf("VNPay IPN failed: %v", err)
+		ack = vnpayAck{RspCode: "99", Message: "Unknown error"}
+	}
+
+	c.JSON(http.StatusOK, ack)
+}
+
+// VNPayReturn godoc
+// @Summary VNPay return page
+// @Description The payer is redirected here by VNPay after paying. Returns the recorded payment, which stays pending until VNPay's notification arrives.
+// @Tags payments
+// @Produce json
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /payments/vnpay/return [get]
+func (h *PaymentHandler) VNPayReturn(c *gin.Context) {
+	payment, err := h.paymentUsecase.GetReturnResult(c.Request.Context(), c.Request.URL.Query())
+	if err != nil {
+		h.writeError(c, err, "Failed to get payment result")
+		return
+	}
+
+	response.OK(c, "Payment result retrieved successfully", payment)
+}
+
+// writeError maps payment errors to HTTP responses
+func (h *PaymentHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrInvalidSignature, domain.ErrInvalidInput:
+		response.BadRequest(c, "Invalid payment result", err.Error())
+	case domain.ErrPlanPriceNotFound:
+		response.BadRequest(c, "Plan has no price for this workstation range", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, "Payment not found")
+	case domain.ErrSubscriptionCancelled:
+		response.Conflict(c, "Subscription is cancelled", err.Error())
+	case domain.ErrPaymentUnavailable:
+		response.Error(c, http.StatusServiceUnavailable, "Online payment is not available", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
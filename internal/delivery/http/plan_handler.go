@@ -0,0 +1,184 @@
+package http
+
+import (
+	"strconv"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlanHandler represents the HTTP handler for subscription plans
+type PlanHandler struct {
+	planUsecase domain.PlanUsecase
+	validator   *validator.CustomValidator
+}
+
+// NewPlanHandler creates a new plan handler
+func NewPlanHandler(router *gin.RouterGroup, uc domain.PlanUsecase) {
+	handler := &PlanHandler{
+		planUsecase: uc,
+		validator:   validator.NewValidator(),
+	}
+
+	plans := router.Group("/plans")
+	{
+		// Read operations - accessible by admin and sale
+		plans.GET("", handler.GetAll)
+		plans.GET("/:id", handler.GetByID)
+
+		// Write operations - accessible by admin only
+		adminOnly := plans.Group("")
+		adminOnly.Use(RequireRole(domain.RoleAdmin))
+		{
+			adminOnly.POST("", handler.Create)
+			adminOnly.PUT("/:id", handler.Update)
+		}
+	}
+}
+
+// Create godoc
+// @Summary Create a plan
+// @Description Create a subscription plan priced per workstation range (admin only)
+// @Tags plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param plan body domain.CreatePlanRequest true "Plan"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /plans [post]
+func (h *PlanHandler) Create(c *gin.Context) {
+	var req domain.CreatePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	plan, err := h.planUsecase.Create(c.Request.Context(), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to create plan")
+		return
+	}
+
+	response.Created(c, "Plan created successfully", plan)
+}
+
+// GetAll godoc
+// @Summary List plans
+// @Description List the subscription plans sorted by name
+// @Tags plans
+// @Produce json
+// @Security BearerAuth
+// @Param active query bool false "Only active plans" default(false)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /plans [get]
+func (h *PlanHandler) GetAll(c *gin.Context) {
+	activeOnly, err := strconv.ParseBool(c.DefaultQuery("active", "false"))
+	if err != nil {
+		response.BadRequest(c, "Invalid active parameter", err.Error())
+		return
+	}
+
+	plans, err := h.planUsecase.GetAll(c.Request.Context(), activeOnly)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get plans", err.Error())
+		return
+	}
+
+	response.OK(c, "Plans retrieved successfully", plans)
+}
+
+// GetByID godoc
+// @Summary Get a plan
+// @Description Get a subscription plan by its ID
+// @Tags plans
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Plan ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /plans/{id} [get]
+func (h *PlanHandler) GetByID(c *gin.Context) {
+	plan, err := h.planUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get plan")
+		return
+	}
+
+	response.OK(c, "Plan retrieved successfully", plan)
+}
+
+// Update godoc
+// @Summary Update a plan
+// @Description Update a subscription plan; prices replace the whole list and apply to subscriptions from their next renewal (admin only)
+// @Tags plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Plan ID"
+// @Param plan body domain.UpdatePlanRequest true "Plan"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /plans/{id} [put]
+func (h *PlanHandler) Update(c *gin.Context) {
+	var req domain.UpdatePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	plan, err := h.planUsecase.Update(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update plan")
+		return
+	}
+
+	response.OK(c, "Plan updated successfully", plan)
+}
+
+// writeError maps plan errors to HTTP responses
+func (h *PlanHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrDuplicatePlanPrice, domain.ErrInvalidSeatPrices:
+		response.BadRequest(c, "Invalid prices", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, "Plan not found")
+	case domain.ErrPlanCodeExists:
+		response.Conflict(c, "Plan code already exists", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,323 @@
+package http
+
+import (
+	"strconv"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PricingHandler represents the HTTP handler for the pricing catalog and the quote calculator
+type PricingHandler struct {
+	pricingUsecase domain.PricingUsecase
+	validator      *validator.CustomValidator
+}
+
+// NewPricingHandler creates a new pricing handler
+func NewPricingHandler(router *gin.RouterGroup, uc domain.PricingUsecase) {
+	handler := &PricingHandler{
+		pricingUsecase: uc,
+		validator:      validator.NewValidator(),
+	}
+
+	// Read operations and calculator - accessible by admin and sale
+	router.GET("/addons", handler.GetAddOns)
+	router.POST("/quotes/calculate", handler.Calculate)
+
+	// Catalog management - accessible by admin only
+	adminOnly := router.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/addons", handler.CreateAddOn)
+		adminOnly.PUT("/addons/:id", handler.UpdateAddOn)
+		adminOnly.GET("/promo-codes", handler.GetPromoCodes)
+		adminOnly.POST("/promo-codes", handler.CreatePromoCode)
+		adminOnly.PUT("/promo-codes/:id", handler.UpdatePromoCode)
+	}
+}
+
+// Calculate godoc
+// @Summary Calculate a quote
+// @Description Price a plan for a number of seats with add-on modules, a sales discount and a promo code, without storing a quote
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param quote body domain.QuoteRequest true "Quote terms"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /quotes/calculate [post]
+func (h *PricingHandler) Calculate(c *gin.Context) {
+	var req domain.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	quote, err := h.pricingUsecase.Calculate(c.Request.Context(), &req)
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Plan not found")
+		default:
+			writeQuoteTermsError(c, err, "Failed to calculate quote")
+		}
+		return
+	}
+
+	response.OK(c, "Quote calculated successfully", quote)
+}
+
+// CreateAddOn godoc
+// @Summary Create an add-on module
+// @Description Create an optional module priced per month, once per cafe or per seat (admin only)
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param addon body domain.CreateAddOnRequest true "Add-on"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /addons [post]
+func (h *PricingHandler) CreateAddOn(c *gin.Context) {
+	var req domain.CreateAddOnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	addOn, err := h.pricingUsecase.CreateAddOn(c.Request.Context(), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to create add-on", "Add-on not found")
+		return
+	}
+
+	response.Created(c, "Add-on created successfully", addOn)
+}
+
+// GetAddOns godoc
+// @Summary List add-on modules
+// @Description List the add-on modules sorted by name
+// @Tags pricing
+// @Produce json
+// @Security BearerAuth
+// @Param active query bool false "Only active add-ons" default(false)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /addons [get]
+func (h *PricingHandler) GetAddOns(c *gin.Context) {
+	activeOnly, err := strconv.ParseBool(c.DefaultQuery("active", "false"))
+	if err != nil {
+		response.BadRequest(c, "Invalid active parameter", err.Error())
+		return
+	}
+
+	addOns, err := h.pricingUsecase.GetAddOns(c.Request.Context(), activeOnly)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get add-ons", err.Error())
+		return
+	}
+
+	response.OK(c, "Add-ons retrieved successfully", addOns)
+}
+
+// UpdateAddOn godoc
+// @Summary Update an add-on module
+// @Description Update an add-on module; quotes already made keep their price (admin only)
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Add-on ID"
+// @Param addon body domain.UpdateAddOnRequest true "Add-on"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /addons/{id} [put]
+func (h *PricingHandler) UpdateAddOn(c *gin.Context) {
+	var req domain.UpdateAddOnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	addOn, err := h.pricingUsecase.UpdateAddOn(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update add-on", "Add-on not found")
+		return
+	}
+
+	response.OK(c, "Add-on updated successfully", addOn)
+}
+
+// CreatePromoCode godoc
+// @Summary Create a promo code
+// @Description Create a promo code taking a percentage or a fixed amount off quotes; codes are stored in upper case (admin only)
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param promo body domain.CreatePromoCodeRequest true "Promo code"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /promo-codes [post]
+func (h *PricingHandler) CreatePromoCode(c *gin.Context) {
+	var req domain.CreatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	promo, err := h.pricingUsecase.CreatePromoCode(c.Request.Context(), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to create promo code", "Promo code not found")
+		return
+	}
+
+	response.Created(c, "Promo code created successfully", promo)
+}
+
+// GetPromoCodes godoc
+// @Summary List promo codes
+// @Description List the promo codes with their redemptions, newest first (admin only)
+// @Tags pricing
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /promo-codes [get]
+func (h *PricingHandler) GetPromoCodes(c *gin.Context) {
+	promos, err := h.pricingUsecase.GetPromoCodes(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, "Failed to get promo codes", err.Error())
+		return
+	}
+
+	response.OK(c, "Promo codes retrieved successfully", promos)
+}
+
+// UpdatePromoCode godoc
+// @Summary Update a promo code
+// @Description Update the validity, redemption limit or status of a promo code; the discount cannot change (admin only)
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Promo code ID"
+// @Param promo body domain.UpdatePromoCodeRequest true "Promo code"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /promo-codes/{id} [put]
+func (h *PricingHandler) UpdatePromoCode(c *gin.Context) {
+	var req domain.UpdatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	promo, err := h.pricingUsecase.UpdatePromoCode(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update promo code", "Promo code not found")
+		return
+	}
+
+	response.OK(c, "Promo code updated successfully", promo)
+}
+
+// writeError maps pricing catalog errors to HTTP responses
+func (h *PricingHandler) writeError(c *gin.Context, err error, message, notFound string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrInvalidInput:
+		response.BadRequest(c, "Validity ends before it starts", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, notFound)
+	case domain.ErrAddOnCodeExists:
+		response.Conflict(c, "Add-on code already exists", err.Error())
+	case domain.ErrPromoCodeExists:
+		response.Conflict(c, "Promo code already exists", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
+
+// writeQuoteTermsError maps the errors of pricing quote terms to HTTP responses
+func writeQuoteTermsError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrPlanInactive:
+		response.BadRequest(c, "Plan is not active", err.Error())
+	case domain.ErrPlanPriceNotFound:
+		response.BadRequest(c, "Plan has no price for this workstation range", err.Error())
+	case domain.ErrSeatsRequired:
+		response.BadRequest(c, "Seats or workstation range is required", err.Error())
+	case domain.ErrAddOnUnavailable:
+		response.BadRequest(c, "Add-on is not available", err.Error())
+	case domain.ErrPromoCodeInvalid:
+		response.BadRequest(c, "Promo code is not valid", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,274 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuoteHandler represents the HTTP handler for sales quotes
+type QuoteHandler struct {
+	quoteUsecase domain.QuoteUsecase
+	validator    *validator.CustomValidator
+}
+
+// NewQuoteHandler creates a new quote handler
+func NewQuoteHandler(public *gin.RouterGroup, protected *gin.RouterGroup, uc domain.QuoteUsecase) {
+	handler := &QuoteHandler{
+		quoteUsecase: uc,
+		validator:    validator.NewValidator(),
+	}
+
+	// Public routes - opened by the prospect through the emailed link, authenticated by its token
+	public.GET("/quotes/public/:token", handler.GetByToken)
+	public.GET("/quotes/public/:token/pdf", handler.RenderPDFByToken)
+	public.POST("/quotes/public/:token/accept", handler.Accept)
+
+	// Quoting - accessible by admin and sale
+	protected.POST("/registrations/:id/quotes", handler.Create)
+	protected.GET("/registrations/:id/quotes", handler.GetByRegistration)
+	protected.GET("/quotes/:id", handler.GetByID)
+	protected.GET("/quotes/:id/pdf", handler.RenderPDF)
+	protected.POST("/quotes/:id/send", handler.Send)
+}
+
+// Create godoc
+// @Summary Quote a registration
+// @Description Price a new version of the quote of a registration; earlier open versions are superseded. Seats and workstation range default to the registration's range.
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registration ID"
+// @Param quote body domain.QuoteRequest true "Quote terms"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /registrations/{id}/quotes [post]
+func (h *QuoteHandler) Create(c *gin.Context) {
+	var req domain.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	quote, err := h.quoteUsecase.Create(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Registration or plan not found")
+		default:
+			h.writeError(c, err, "Failed to create quote")
+		}
+		return
+	}
+
+	response.Created(c, "Quote created successfully", quote)
+}
+
+// GetByRegistration godoc
+// @Summary Get registration quotes
+// @Description Get every version of the quote of a registration, latest first
+// @Tags quotes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registration ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /registrations/{id}/quotes [get]
+func (h *QuoteHandler) GetByRegistration(c *gin.Context) {
+	quotes, err := h.quoteUsecase.GetByRegistration(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Registration not found")
+		default:
+			h.writeError(c, err, "Failed to get quotes")
+		}
+		return
+	}
+
+	response.OK(c, "Quotes retrieved successfully", quotes)
+}
+
+// GetByID godoc
+// @Summary Get a quote
+// @Description Get a quote by its ID
+// @Tags quotes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Quote ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /quotes/{id} [get]
+func (h *QuoteHandler) GetByID(c *gin.Context) {
+	quote, err := h.quoteUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get quote")
+		return
+	}
+
+	response.OK(c, "Quote retrieved successfully", quote)
+}
+
+// RenderPDF godoc
+// @Summary Render a quote PDF
+// @Description Render a quote as a PDF
+// @Tags quotes
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path string true "Quote ID"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /quotes/{id}/pdf [get]
+func (h *QuoteHandler) RenderPDF(c *gin.Context) {
+	var buf bytes.Buffer
+	quote, err := h.quoteUsecase.RenderPDF(c.Request.Context(), c.Param("id"), &buf)
+	if err != nil {
+		h.writeError(c, err, "Failed to render quote")
+		return
+	}
+
+	writeQuotePDF(c, quote, &buf)
+}
+
+// Send godoc
+// @Summary Send a quote
+// @Description Email a draft or sent quote to its prospect with a new link to view and accept it; earlier links stop working. The link is returned as accept_url.
+// @Tags quotes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Quote ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /quotes/{id}/send [post]
+func (h *QuoteHandler) Send(c *gin.Context) {
+	sent, err := h.quoteUsecase.Send(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to send quote")
+		return
+	}
+
+	response.OK(c, "Quote sent successfully", sent)
+}
+
+// GetByToken godoc
+// @Summary View a quote
+// @Description Get the quote a link was sent for
+// @Tags quotes
+// @Produce json
+// @Param token path string true "Quote link token"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /quotes/public/{token} [get]
+func (h *QuoteHandler) GetByToken(c *gin.Context) {
+	quote, err := h.quoteUsecase.GetByToken(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get quote")
+		return
+	}
+
+	response.OK(c, "Quote retrieved successfully", quote)
+}
+
+// RenderPDFByToken godoc
+// @Summary Download a quote PDF
+// @Description Render the quote a link was sent for as a PDF
+// @Tags quotes
+// @Produce application/pdf
+// @Param token path string true "Quote link token"
+// @Success 200 {file} file
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /quotes/public/{token}/pdf [get]
+func (h *QuoteHandler) RenderPDFByToken(c *gin.Context) {
+	var buf bytes.Buffer
+	quote, err := h.quoteUsecase.RenderPDFByToken(c.Request.Context(), c.Param("token"), &buf)
+	if err != nil {
+		h.writeError(c, err, "Failed to render quote")
+		return
+	}
+
+	writeQuotePDF(c, quote, &buf)
+}
+
+// Accept godoc
+// @Summary Accept a quote
+// @Description Accept the quote a link was sent for. The prospect becomes a customer: the existing customer with the same email or phone, or a new one. Accepting again returns the accepted quote.
+// @Tags quotes
+// @Produce json
+// @Param token path string true "Quote link token"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 410 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /quotes/public/{token}/accept [post]
+func (h *QuoteHandler) Accept(c *gin.Context) {
+	quote, err := h.quoteUsecase.Accept(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.writeError(c, err, "Failed to accept quote")
+		return
+	}
+
+	response.OK(c, "Quote accepted successfully", quote)
+}
+
+// writeQuotePDF sends a rendered quote inline
+func writeQuotePDF(c *gin.Context, quote *domain.Quote, buf *bytes.Buffer) {
+	filename := fmt.Sprintf("quote-%s-v%d.pdf", quote.RegistrationID.Hex(), quote.Version)
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// writeError maps quote errors to HTTP responses
+func (h *QuoteHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrNotFound:
+		response.NotFound(c, "Quote not found")
+	case domain.ErrQuoteAccepted:
+		response.Conflict(c, "Registration has already accepted a quote", err.Error())
+	case domain.ErrQuoteNotOpen:
+		response.Conflict(c, "Quote is no longer open", err.Error())
+	case domain.ErrQuoteVersionConflict:
+		response.Conflict(c, "Quote version is taken, try again", err.Error())
+	case domain.ErrPromoCodeExhausted:
+		response.Conflict(c, "Promo code has no redemptions left, ask for a new quote", err.Error())
+	case domain.ErrPhoneAlreadyExists:
+		response.Conflict(c, "Phone number is held by a deleted customer", err.Error())
+	case domain.ErrQuoteExpired:
+		response.Error(c, http.StatusGone, "Quote has expired", err.Error())
+	default:
+		writeQuoteTermsError(c, err, message)
+	}
+}
@@ -2,7 +2,7 @@ package http
 
 import (
 	"net/http"
-	"strconv"
+	"strings"
 
 	"icafe-registration/internal/domain"
 	"icafe-registration/pkg/response"
@@ -33,11 +33,13 @@ func NewRegistrationHandler(router *gin.RouterGroup, uc domain.RegistrationUseca
 
 // Create godoc
 // @Summary Create a new registration
-// @Description Create a new registration with the provided data
+// @Description Create a new registration with the provided data. The referral code and UTM parameters
+// @Description of the source are also read from the ref and utm_* query parameters, and the referrer from the Referer header.
 // @Tags registrations
 // @Accept json
 // @Produce json
 // @Param registration body domain.CreateRegistrationRequest true "Registration data"
+// @Param ref query string false "Referral code"
 // @Success 201 {object} response.Response
 // @Failure 400 {object} response.Response
 // @Failure 409 {object} response.Response
@@ -51,6 +53,8 @@ func (h *RegistrationHandler) Create(c *gin.Context) {
 		return
 	}
 
+	req.Source = referralSource(c, req.Source)
+
 	// Đừng xóa đoạn này: Validate dữ liệu (email, phone...)
 	if err := h.validator.Validate(&req); err != nil {
 		errors := validator.GetValidationErrors(err)
@@ -68,6 +72,8 @@ func (h *RegistrationHandler) Create(c *gin.Context) {
 			response.Conflict(c, "Số điện thoại đã tồn tại trên hệ thống", err.Error())
 		case "số điện thoại này đã được đăng ký khách hàng":
 			response.Conflict(c, "Số điện thoại này đã được sử dụng", err.Error())
+		case domain.ErrInvalidAddress.Error(), domain.ErrUnknownAddressVersion.Error():
+			response.BadRequest(c, "Địa chỉ không hợp lệ", err.Error())
 		default:
 
 			response.InternalServerError(c, "Đã có lỗi xảy ra", err.Error())
@@ -80,29 +86,42 @@ func (h *RegistrationHandler) Create(c *gin.Context) {
 
 // GetAll godoc
 // @Summary Get all registrations
-// @Description Get all registrations with pagination
+// @Description Get registrations with filters and pagination
 // @Tags registrations
 // @Produce json
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact, estimated or none"
+// @Param q query string false "Search by name, phone or email"
+// @Param workstation_range query string false "Workstation range (1-10, 10-20, 20-50, 50+)"
+// @Param reseller_id query string false "Referred by reseller ID"
+// @Param created_from query string false "Created from (YYYY-MM-DD or RFC3339)"
+// @Param created_to query string false "Created to, inclusive day (YYYY-MM-DD or RFC3339)"
 // @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /registrations [get]
 func (h *RegistrationHandler) GetAll(c *gin.Context) {
-	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
-	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	filter, err := parseRegistrationFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
 
-	registrations, total, err := h.registrationUsecase.GetAll(c.Request.Context(), limit, offset)
+	registrations, info, err := h.registrationUsecase.GetAll(c.Request.Context(), filter, page)
 	if err != nil {
 		response.InternalServerError(c, "Failed to get registrations", err.Error())
 		return
 	}
 
-	response.SuccessWithMeta(c, http.StatusOK, "Registrations retrieved successfully", registrations, &response.Meta{
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
-	})
+	response.SuccessWithMeta(c, http.StatusOK, "Registrations retrieved successfully", registrations, pageMeta(page, info))
 }
 
 // GetByID godoc
@@ -174,6 +193,8 @@ func (h *RegistrationHandler) Update(c *gin.Context) {
 			response.NotFound(c, "Registration not found")
 		case domain.ErrEmailAlreadyExists:
 			response.Conflict(c, "Email already registered", err.Error())
+		case domain.ErrInvalidAddress, domain.ErrUnknownAddressVersion:
+			response.BadRequest(c, "Invalid address", err.Error())
 		default:
 			response.InternalServerError(c, "Failed to update registration", err.Error())
 		}
@@ -185,7 +206,7 @@ func (h *RegistrationHandler) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a registration
-// @Description Delete a registration by its ID
+// @Description Move a registration to the trash
 // @Tags registrations
 // @Produce json
 // @Param id path string true "Registration ID"
@@ -197,7 +218,7 @@ func (h *RegistrationHandler) Update(c *gin.Context) {
 func (h *RegistrationHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
-	err := h.registrationUsecase.Delete(c.Request.Context(), id)
+	err := h.registrationUsecase.Delete(c.Request.Context(), id, c.GetString("user_id"))
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidID:
@@ -224,3 +245,34 @@ func mapToString(m map[string]string) string {
 	}
 	return result
 }
+
+// referralSource completes the source sent by the registration page with the referral
+// code and UTM parameters of the query string and the Referer header
+func referralSource(c *gin.Context, source *domain.ReferralSource) *domain.ReferralSource {
+	if source == nil {
+		source = &domain.ReferralSource{}
+	}
+
+	// Values are cut to the validated lengths so a long campaign link does not fail the registration
+	fill := func(field *string, value string, max int) {
+		if *field == "" {
+			value = strings.TrimSpace(value)
+			if len(value) > max {
+				value = strings.ToValidUTF8(value[:max], "")
+			}
+			*field = value
+		}
+	}
+	fill(&source.ReferralCode, c.Query("ref"), 20)
+	fill(&source.UTMSource, c.Query("utm_source"), 100)
+	fill(&source.UTMMedium, c.Query("utm_medium"), 100)
+	fill(&source.UTMCampaign, c.Query("utm_campaign"), 100)
+	fill(&source.UTMTerm, c.Query("utm_term"), 100)
+	fill(&source.UTMContent, c.Query("utm_content"), 100)
+	fill(&source.Referrer, c.GetHeader("Referer"), 500)
+
+	if source.IsEmpty() {
+		return nil
+	}
+	return source
+}
@@ -0,0 +1,308 @@
+package http
+
+import (
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReleaseHandler represents the HTTP handler for software releases
+type ReleaseHandler struct {
+	releaseUsecase domain.ReleaseUsecase
+	validator      *validator.CustomValidator
+}
+
+// NewReleaseHandler creates a new release handler.
+// Update checks are public for cafe clients; management requires authentication.
+func NewReleaseHandler(public *gin.RouterGroup, protected *gin.RouterGroup, uc domain.ReleaseUsecase) {
+	handler := &ReleaseHandler{
+		releaseUsecase: uc,
+		validator:      validator.NewValidator(),
+	}
+
+	// Public routes - used by cafe clients to update themselves
+	public.GET("/releases/public-key", handler.PublicKey)
+	public.GET("/releases/:product/latest", handler.Latest)
+	public.GET("/releases/:product/manifest", handler.Manifest)
+
+	// Read operations - accessible by admin and sale
+	protected.GET("/releases", handler.GetAll)
+	protected.GET("/releases/:product/:version", handler.Get)
+
+	// Write operations - accessible by admin only
+	adminOnly := protected.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/releases", handler.Create)
+		adminOnly.PUT("/releases/:product/:version", handler.Update)
+		adminOnly.POST("/releases/:product/:version/publish", handler.Publish)
+		adminOnly.DELETE("/releases/:product/:version", handler.Delete)
+	}
+}
+
+// Create godoc
+// @Summary Create a release
+// @Description Create a draft release of a product from uploaded builds (admin only)
+// @Tags releases
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param release body domain.CreateReleaseRequest true "Release data"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /releases [post]
+func (h *ReleaseHandler) Create(c *gin.Context) {
+	var req domain.CreateReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	release, err := h.releaseUsecase.Create(c.Request.Context(), &req, currentActor(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to create release")
+		return
+	}
+
+	response.Created(c, "Release created successfully", release)
+}
+
+// GetAll godoc
+// @Summary Get releases
+// @Description Get the releases, newest first, including drafts
+// @Tags releases
+// @Produce json
+// @Security BearerAuth
+// @Param product query string false "Product"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /releases [get]
+func (h *ReleaseHandler) GetAll(c *gin.Context) {
+	releases, err := h.releaseUsecase.GetAll(c.Request.Context(), c.Query("product"))
+	if err != nil {
+		response.InternalServerError(c, "Failed to get releases", err.Error())
+		return
+	}
+
+	response.OK(c, "Releases retrieved successfully", releases)
+}
+
+// Get godoc
+// @Summary Get a release
+// @Description Get a release by its product and version
+// @Tags releases
+// @Produce json
+// @Security BearerAuth
+// @Param product path string true "Product"
+// @Param version path string true "Version"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /releases/{product}/{version} [get]
+func (h *ReleaseHandler) Get(c *gin.Context) {
+	release, err := h.releaseUsecase.Get(c.Request.Context(), c.Param("product"), c.Param("version"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get release")
+		return
+	}
+
+	response.OK(c, "Release retrieved successfully", release)
+}
+
+// Update godoc
+// @Summary Update a release
+// @Description Update a release (admin only). Only the changelog and minimum version of a published release can change.
+// @Tags releases
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param product path string true "Product"
+// @Param version path string true "Version"
+// @Param release body domain.UpdateReleaseRequest true "Release data"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /releases/{product}/{version} [put]
+func (h *ReleaseHandler) Update(c *gin.Context) {
+	var req domain.UpdateReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	release, err := h.releaseUsecase.Update(c.Request.Context(), c.Param("product"), c.Param("version"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update release")
+		return
+	}
+
+	response.OK(c, "Release updated successfully", release)
+}
+
+// Publish godoc
+// @Summary Publish a release
+// @Description Make a release available to cafe clients (admin only)
+// @Tags releases
+// @Produce json
+// @Security BearerAuth
+// @Param product path string true "Product"
+// @Param version path string true "Version"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /releases/{product}/{version}/publish [post]
+func (h *ReleaseHandler) Publish(c *gin.Context) {
+	release, err := h.releaseUsecase.Publish(c.Request.Context(), c.Param("product"), c.Param("version"))
+	if err != nil {
+		h.writeError(c, err, "Failed to publish release")
+		return
+	}
+
+	response.OK(c, "Release published successfully", release)
+}
+
+// Delete godoc
+// @Summary Delete a release
+// @Description Delete a release, withdrawing it from cafe clients (admin only). Its files are kept.
+// @Tags releases
+// @Produce json
+// @Security BearerAuth
+// @Param product path string true "Product"
+// @Param version path string true "Version"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /releases/{product}/{version} [delete]
+func (h *ReleaseHandler) Delete(c *gin.Context) {
+	if err := h.releaseUsecase.Delete(c.Request.Context(), c.Param("product"), c.Param("version")); err != nil {
+		h.writeError(c, err, "Failed to delete release")
+		return
+	}
+
+	response.OK(c, "Release deleted successfully", nil)
+}
+
+// Latest godoc
+// @Summary Check for updates
+// @Description Get the latest published release of a product for a platform (public). Beta clients also receive stable releases.
+// @Tags releases
+// @Produce json
+// @Param product path string true "Product"
+// @Param channel query string false "Channel (stable, beta)" default(stable)
+// @Param platform query string true "Platform"
+// @Param current query string false "Version installed on the client"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /releases/{product}/latest [get]
+func (h *ReleaseHandler) Latest(c *gin.Context) {
+	check, err := h.releaseUsecase.Latest(
+		c.Request.Context(),
+		c.Param("product"),
+		domain.ReleaseChannel(c.DefaultQuery("channel", string(domain.ReleaseChannelStable))),
+		domain.ReleasePlatform(c.Query("platform")),
+		c.Query("current"),
+	)
+	if err != nil {
+		h.writeError(c, err, "Failed to check for updates")
+		return
+	}
+
+	response.OK(c, "Latest release retrieved successfully", check)
+}
+
+// Manifest godoc
+// @Summary Get the signed update manifest
+// @Description Get the latest published release of a product for a platform as a manifest signed with Ed25519 (public)
+// @Tags releases
+// @Produce json
+// @Param product path string true "Product"
+// @Param channel query string false "Channel (stable, beta)" default(stable)
+// @Param platform query string true "Platform"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /releases/{product}/manifest [get]
+func (h *ReleaseHandler) Manifest(c *gin.Context) {
+	manifest, err := h.releaseUsecase.Manifest(
+		c.Request.Context(),
+		c.Param("product"),
+		domain.ReleaseChannel(c.DefaultQuery("channel", string(domain.ReleaseChannelStable))),
+		domain.ReleasePlatform(c.Query("platform")),
+	)
+	if err != nil {
+		h.writeError(c, err, "Failed to sign update manifest")
+		return
+	}
+
+	response.OK(c, "Update manifest signed successfully", manifest)
+}
+
+// PublicKey godoc
+// @Summary Get the update public key
+// @Description Get the Ed25519 public key that verifies update manifests (public)
+// @Tags releases
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /releases/public-key [get]
+func (h *ReleaseHandler) PublicKey(c *gin.Context) {
+	response.OK(c, "Update public key retrieved successfully", h.releaseUsecase.PublicKey())
+}
+
+// writeError maps release errors to HTTP responses
+func (h *ReleaseHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidInput:
+		response.BadRequest(c, "Invalid channel or platform", err.Error())
+	case domain.ErrInvalidReleaseProduct:
+		response.BadRequest(c, "Invalid product", err.Error())
+	case domain.ErrInvalidReleaseVersion:
+		response.BadRequest(c, "Invalid version", err.Error())
+	case domain.ErrDuplicateReleasePlatform:
+		response.BadRequest(c, "Duplicate platform", err.Error())
+	case domain.ErrReleaseFileNotFound:
+		response.BadRequest(c, "Invalid file", err.Error())
+	case domain.ErrFileQuarantined:
+		response.Conflict(c, "File has not passed the malware scan", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, "Release not found")
+	case domain.ErrReleaseVersionExists:
+		response.Conflict(c, "Release version already exists", err.Error())
+	case domain.ErrReleasePublished:
+		response.Conflict(c, "Release is published", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,540 @@
+package http
+
+import (
+	"net/http"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResellerHandler represents the HTTP handler for resellers, their commissions and the reseller portal
+type ResellerHandler struct {
+	resellerUsecase domain.ResellerUsecase
+	validator       *validator.CustomValidator
+}
+
+// earnings is the response body of the commissions of the reseller portal
+type earnings struct {
+	Summary     *domain.CommissionSummary `json:"summary"`
+	Commissions []*domain.Commission      `json:"commissions"`
+}
+
+// NewResellerHandler creates a new reseller handler
+func NewResellerHandler(public *gin.RouterGroup, protected *gin.RouterGroup, uc domain.ResellerUsecase) {
+	handler := &ResellerHandler{
+		resellerUsecase: uc,
+		validator:       validator.NewValidator(),
+	}
+
+	// Public routes - the registration page looks up the reseller of a referral link
+	public.GET("/referrals/:code", handler.GetReferral)
+
+	// Reseller portal - accessible by resellers, limited to their own leads and commissions
+	portal := protected.Group("/reseller")
+	portal.Use(RequireRole(domain.RoleReseller))
+	{
+		portal.GET("", handler.GetProfile)
+		portal.GET("/leads", handler.GetLeads)
+		portal.GET("/commissions", handler.GetEarnings)
+	}
+
+	// Reseller and commission management - accessible by admin only
+	adminOnly := protected.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/resellers", handler.Create)
+		adminOnly.GET("/resellers", handler.GetAll)
+		adminOnly.GET("/resellers/:id", handler.GetByID)
+		adminOnly.PUT("/resellers/:id", handler.Update)
+		adminOnly.GET("/commission-rules", handler.GetRules)
+		adminOnly.POST("/commission-rules", handler.CreateRule)
+		adminOnly.PUT("/commission-rules/:id", handler.UpdateRule)
+		adminOnly.GET("/commissions", handler.GetCommissions)
+		adminOnly.POST("/commissions/:id/pay", handler.PayCommission)
+		adminOnly.POST("/commissions/:id/cancel", handler.CancelCommission)
+	}
+}
+
+// GetReferral godoc
+// @Summary Look up a referral code
+// @Description Get the name of the active reseller of a referral code, to show on the registration page
+// @Tags resellers
+// @Produce json
+// @Param code path string true "Referral code"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /referrals/{code} [get]
+func (h *ResellerHandler) GetReferral(c *gin.Context) {
+	referral, err := h.resellerUsecase.GetReferral(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get referral", "Referral code not found")
+		return
+	}
+
+	response.OK(c, "Referral retrieved successfully", referral)
+}
+
+// Create godoc
+// @Summary Create a reseller
+// @Description Create a reseller from a user account of the reseller role; a referral code is generated when none is given (admin only)
+// @Tags resellers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param reseller body domain.CreateResellerRequest true "Reseller"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /resellers [post]
+func (h *ResellerHandler) Create(c *gin.Context) {
+	var req domain.CreateResellerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	reseller, err := h.resellerUsecase.Create(c.Request.Context(), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to create reseller", "User not found")
+		return
+	}
+
+	response.Created(c, "Reseller created successfully", reseller)
+}
+
+// GetAll godoc
+// @Summary List resellers
+// @Description List the resellers sorted by name, with their referral links (admin only)
+// @Tags resellers
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /resellers [get]
+func (h *ResellerHandler) GetAll(c *gin.Context) {
+	resellers, err := h.resellerUsecase.GetAll(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, "Failed to get resellers", err.Error())
+		return
+	}
+
+	response.OK(c, "Resellers retrieved successfully", resellers)
+}
+
+// GetByID godoc
+// @Summary Get a reseller
+// @Description Get a reseller by its ID (admin only)
+// @Tags resellers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Reseller ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /resellers/{id} [get]
+func (h *ResellerHandler) GetByID(c *gin.Context) {
+	reseller, err := h.resellerUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get reseller", "Reseller not found")
+		return
+	}
+
+	response.OK(c, "Reseller retrieved successfully", reseller)
+}
+
+// Update godoc
+// @Summary Update a reseller
+// @Description Update the profile or status of a reseller; the referral code cannot change. Deactivated resellers get no new referrals or commissions (admin only)
+// @Tags resellers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Reseller ID"
+// @Param reseller body domain.UpdateResellerRequest true "Reseller"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /resellers/{id} [put]
+func (h *ResellerHandler) Update(c *gin.Context) {
+	var req domain.UpdateResellerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	reseller, err := h.resellerUsecase.Update(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update reseller", "Reseller not found")
+		return
+	}
+
+	response.OK(c, "Reseller updated successfully", reseller)
+}
+
+// CreateRule godoc
+// @Summary Create a commission rule
+// @Description Create a commission rule for one reseller, or without reseller_id for every reseller without their own rule (admin only)
+// @Tags resellers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rule body domain.CreateCommissionRuleRequest true "Commission rule"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /commission-rules [post]
+func (h *ResellerHandler) CreateRule(c *gin.Context) {
+	var req domain.CreateCommissionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	rule, err := h.resellerUsecase.CreateRule(c.Request.Context(), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to create commission rule", "Reseller not found")
+		return
+	}
+
+	response.Created(c, "Commission rule created successfully", rule)
+}
+
+// GetRules godoc
+// @Summary List commission rules
+// @Description List the commission rules, newest first (admin only)
+// @Tags resellers
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /commission-rules [get]
+func (h *ResellerHandler) GetRules(c *gin.Context) {
+	rules, err := h.resellerUsecase.GetRules(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, "Failed to get commission rules", err.Error())
+		return
+	}
+
+	response.OK(c, "Commission rules retrieved successfully", rules)
+}
+
+// UpdateRule godoc
+// @Summary Update a commission rule
+// @Description Update a commission rule; commissions already earned keep their amount (admin only)
+// @Tags resellers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Commission rule ID"
+// @Param rule body domain.UpdateCommissionRuleRequest true "Commission rule"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /commission-rules/{id} [put]
+func (h *ResellerHandler) UpdateRule(c *gin.Context) {
+	var req domain.UpdateCommissionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	rule, err := h.resellerUsecase.UpdateRule(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update commission rule", "Commission rule not found")
+		return
+	}
+
+	response.OK(c, "Commission rule updated successfully", rule)
+}
+
+// GetCommissions godoc
+// @Summary List commissions
+// @Description List the commissions earned by resellers, newest first, with optional filters (admin only)
+// @Tags resellers
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Items per page" default(10)
+// @Param offset query int false "Items to skip" default(0)
+// @Param cursor query string false "Cursor of the next page, replaces offset"
+// @Param status query string false "pending, paid or cancelled"
+// @Param reseller_id query string false "Reseller ID"
+// @Param customer_id query string false "Customer ID"
+// @Param created_from query string false "Created from (YYYY-MM-DD or RFC3339)"
+// @Param created_to query string false "Created to, inclusive day (YYYY-MM-DD or RFC3339)"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /commissions [get]
+func (h *ResellerHandler) GetCommissions(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	filter, err := parseCommissionFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	commissions, info, err := h.resellerUsecase.GetCommissions(c.Request.Context(), filter, page)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get commissions", err.Error())
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Commissions retrieved successfully", commissions, pageMeta(page, info))
+}
+
+// PayCommission godoc
+// @Summary Pay out a commission
+// @Description Record the payout of a pending commission to its reseller (admin only)
+// @Tags resellers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Commission ID"
+// @Param payout body domain.PayCommissionRequest false "Payout"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /commissions/{id}/pay [post]
+func (h *ResellerHandler) PayCommission(c *gin.Context) {
+	var req domain.PayCommissionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	commission, err := h.resellerUsecase.PayCommission(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to pay commission", "Commission not found")
+		return
+	}
+
+	response.OK(c, "Commission paid successfully", commission)
+}
+
+// CancelCommission godoc
+// @Summary Cancel a commission
+// @Description Cancel a pending commission, for instance after a refund (admin only)
+// @Tags resellers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Commission ID"
+// @Param cancel body domain.CancelCommissionRequest true "Reason"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /commissions/{id}/cancel [post]
+func (h *ResellerHandler) CancelCommission(c *gin.Context) {
+	var req domain.CancelCommissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	commission, err := h.resellerUsecase.CancelCommission(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to cancel commission", "Commission not found")
+		return
+	}
+
+	response.OK(c, "Commission cancelled successfully", commission)
+}
+
+// GetProfile godoc
+// @Summary Get my reseller profile
+// @Description Get the reseller profile, referral code and referral link of the signed in reseller
+// @Tags reseller portal
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /reseller [get]
+func (h *ResellerHandler) GetProfile(c *gin.Context) {
+	reseller, err := h.resellerUsecase.GetProfile(c.Request.Context(), currentActor(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to get reseller profile", "Reseller profile not found")
+		return
+	}
+
+	response.OK(c, "Reseller profile retrieved successfully", reseller)
+}
+
+// GetLeads godoc
+// @Summary List my leads
+// @Description List the registrations referred by the signed in reseller, newest first
+// @Tags reseller portal
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Items per page" default(10)
+// @Param offset query int false "Items to skip" default(0)
+// @Param cursor query string false "Cursor of the next page, replaces offset"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /reseller/leads [get]
+func (h *ResellerHandler) GetLeads(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	leads, info, err := h.resellerUsecase.GetLeads(c.Request.Context(), currentActor(c), page)
+	if err != nil {
+		h.writeError(c, err, "Failed to get leads", "Reseller profile not found")
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Leads retrieved successfully", leads, pageMeta(page, info))
+}
+
+// GetEarnings godoc
+// @Summary List my commissions
+// @Description List the commissions of the signed in reseller, newest first, with their totals by status
+// @Tags reseller portal
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Items per page" default(10)
+// @Param offset query int false "Items to skip" default(0)
+// @Param cursor query string false "Cursor of the next page, replaces offset"
+// @Param status query string false "pending, paid or cancelled"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /reseller/commissions [get]
+func (h *ResellerHandler) GetEarnings(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	filter, err := parseCommissionFilter(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	commissions, summary, info, err := h.resellerUsecase.GetEarnings(c.Request.Context(), currentActor(c), filter.Status, page)
+	if err != nil {
+		h.writeError(c, err, "Failed to get commissions", "Reseller profile not found")
+		return
+	}
+
+	body := &earnings{Summary: summary, Commissions: commissions}
+	response.SuccessWithMeta(c, http.StatusOK, "Commissions retrieved successfully", body, pageMeta(page, info))
+}
+
+// writeError maps reseller errors to HTTP responses
+func (h *ResellerHandler) writeError(c *gin.Context, err error, message, notFound string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrInvalidInput:
+		response.BadRequest(c, "Commission rule needs a percent or a fixed amount", err.Error())
+	case domain.ErrResellerRoleRequired:
+		response.BadRequest(c, "User does not have the reseller role", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, notFound)
+	case domain.ErrResellerCodeExists:
+		response.Conflict(c, "Referral code already exists", err.Error())
+	case domain.ErrResellerExists:
+		response.Conflict(c, "User is already a reseller", err.Error())
+	case domain.ErrCommissionStatus:
+		response.Conflict(c, "Commission is no longer pending", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -15,6 +15,24 @@ type Router struct {
 	AuthUsecase         domain.AuthUsecase
 	UserUsecase         domain.UserUsecase
 	CustomerUsecase     domain.CustomerUsecase
+	ActivityUsecase     domain.ActivityUsecase
+	TaskUsecase         domain.TaskUsecase
+	LicenseUsecase      domain.LicenseUsecase
+	InstallationUsecase domain.InstallationUsecase
+	ShopUsecase         domain.ShopUsecase
+	AddressUsecase      domain.AddressUsecase
+	PlanUsecase         domain.PlanUsecase
+	SubscriptionUsecase domain.SubscriptionUsecase
+	InvoiceUsecase      domain.InvoiceUsecase
+	PaymentUsecase      domain.PaymentUsecase
+	PricingUsecase      domain.PricingUsecase
+	QuoteUsecase        domain.QuoteUsecase
+	ResellerUsecase     domain.ResellerUsecase
+	AppointmentUsecase  domain.AppointmentUsecase
+	UploadUsecase       domain.UploadUsecase
+	HLSUsecase          domain.HLSUsecase
+	ScanUsecase         domain.ScanUsecase
+	ReleaseUsecase      domain.ReleaseUsecase
 	Config              *config.Config
 }
 
@@ -25,6 +43,24 @@ func NewRouter(
 	authUsecase domain.AuthUsecase,
 	userUsecase domain.UserUsecase,
 	customerUsecase domain.CustomerUsecase,
+	activityUsecase domain.ActivityUsecase,
+	taskUsecase domain.TaskUsecase,
+	licenseUsecase domain.LicenseUsecase,
+	installationUsecase domain.InstallationUsecase,
+	shopUsecase domain.ShopUsecase,
+	addressUsecase domain.AddressUsecase,
+	planUsecase domain.PlanUsecase,
+	subscriptionUsecase domain.SubscriptionUsecase,
+	invoiceUsecase domain.InvoiceUsecase,
+	paymentUsecase domain.PaymentUsecase,
+	pricingUsecase domain.PricingUsecase,
+	quoteUsecase domain.QuoteUsecase,
+	resellerUsecase domain.ResellerUsecase,
+	appointmentUsecase domain.AppointmentUsecase,
+	uploadUsecase domain.UploadUsecase,
+	hlsUsecase domain.HLSUsecase,
+	scanUsecase domain.ScanUsecase,
+	releaseUsecase domain.ReleaseUsecase,
 	cfg *config.Config,
 ) *Router {
 	// Set Gin mode
@@ -37,8 +73,9 @@ func NewRouter(
 	engine.Use(RecoveryMiddleware())
 	engine.Use(CORSMiddleware())
 
-	// Set max multipart memory for file uploads
-	engine.MaxMultipartMemory = cfg.Upload.MaxFileSize
+	// Multipart uploads spool to temporary files past this size, large files
+	// should use resumable uploads instead
+	engine.MaxMultipartMemory = 8 << 20
 
 	router := &Router{
 		Engine:              engine,
@@ -47,6 +84,24 @@ func NewRouter(
 		AuthUsecase:         authUsecase,
 		UserUsecase:         userUsecase,
 		CustomerUsecase:     customerUsecase,
+		ActivityUsecase:     activityUsecase,
+		TaskUsecase:         taskUsecase,
+		LicenseUsecase:      licenseUsecase,
+		InstallationUsecase: installationUsecase,
+		ShopUsecase:         shopUsecase,
+		AddressUsecase:      addressUsecase,
+		PlanUsecase:         planUsecase,
+		SubscriptionUsecase: subscriptionUsecase,
+		InvoiceUsecase:      invoiceUsecase,
+		PaymentUsecase:      paymentUsecase,
+		PricingUsecase:      pricingUsecase,
+		QuoteUsecase:        quoteUsecase,
+		ResellerUsecase:     resellerUsecase,
+		AppointmentUsecase:  appointmentUsecase,
+		UploadUsecase:       uploadUsecase,
+		HLSUsecase:          hlsUsecase,
+		ScanUsecase:         scanUsecase,
+		ReleaseUsecase:      releaseUsecase,
 		Config:              cfg,
 	}
 
@@ -74,8 +129,15 @@ func (r *Router) setupRoutes() {
 		// Public routes - Registration (anyone can register)
 		NewRegistrationHandler(v1, r.RegistrationUsecase)
 
-		// Public file serving routes
-		NewFileHandler(v1, r.Engine, r.FileUsecase, &r.Config.Upload)
+		// HLS packages of videos, for adaptive playback on slow connections
+		NewHLSHandler(v1, r.HLSUsecase)
+
+		// Public routes that show staff more when they send their token
+		optional := v1.Group("")
+		optional.Use(OptionalJWTAuthMiddleware(r.AuthUsecase))
+
+		// Resumable uploads of large files (tus protocol)
+		NewUploadHandler(optional, r.UploadUsecase, r.Config.Upload.MaxFileSize)
 
 		// Protected routes - require authentication
 		protected := v1.Group("")
@@ -86,10 +148,67 @@ func (r *Router) setupRoutes() {
 			adminOnly.Use(RequireRole(domain.RoleAdmin))
 			{
 				NewUserHandler(adminOnly, r.UserUsecase)
+
+				// Trash listing and restore routes
+				NewTrashHandler(adminOnly, r.CustomerUsecase, r.RegistrationUsecase, r.UserUsecase)
 			}
 
-			// Customer routes (admin can CRUD, sale can only read)
-			NewCustomerHandler(protected, r.CustomerUsecase)
+			// Reseller portal (resellers only) and reseller management (admin only)
+			NewResellerHandler(v1, protected, r.ResellerUsecase)
+
+			// Staff routes - resellers only reach the reseller portal
+			staff := protected.Group("")
+			staff.Use(RequireRole(domain.RoleAdmin, domain.RoleSale))
+			{
+				// Customer routes (admin can CRUD, sale can only read)
+				NewCustomerHandler(staff, r.CustomerUsecase)
+
+				// Shop routes (admin can CRUD, sale can only read)
+				NewShopHandler(staff, r.ShopUsecase)
+
+				// Customer timeline and follow-up task routes (admin and sale)
+				NewActivityHandler(staff, r.ActivityUsecase)
+				NewTaskHandler(staff, r.TaskUsecase)
+
+				// Demo and installation appointment routes (admin and sale;
+				// calendar apps read the feeds through their public link)
+				NewAppointmentHandler(v1, staff, r.AppointmentUsecase)
+
+				// License routes (verification is public, management is admin only)
+				NewLicenseHandler(v1, staff, r.LicenseUsecase)
+
+				// Plan and subscription routes (admin can manage, sale can only read)
+				NewPlanHandler(staff, r.PlanUsecase)
+				NewSubscriptionHandler(staff, r.SubscriptionUsecase)
+
+				// Invoice routes (admin can manage, sale can read and preview PDFs)
+				NewInvoiceHandler(staff, r.InvoiceUsecase)
+
+				// Payment routes (gateway callbacks are public, payment links are admin only)
+				NewPaymentHandler(v1, staff, r.PaymentUsecase)
+
+				// Pricing catalog and quote routes (admin manages the catalog, sale can quote;
+				// prospects view and accept quotes through their public link)
+				NewPricingHandler(staff, r.PricingUsecase)
+				NewQuoteHandler(v1, staff, r.QuoteUsecase)
+
+				// File routes (public files are listed and served to anyone, staff
+				// see every file and mint signed links to private and customer files)
+				NewFileHandler(v1, optional, staff, r.FileUsecase)
+				NewScanHandler(staff, r.ScanUsecase)
+
+				// Software release routes (update checks are public, management is admin only)
+				NewReleaseHandler(v1, staff, r.ReleaseUsecase)
+
+				// Installation routes (activation and heartbeat are public, management is admin only)
+				NewInstallationHandler(v1, staff, r.InstallationUsecase)
+
+				// Administrative unit lookups (public) and address conversion
+				NewAddressHandler(v1, staff, r.AddressUsecase)
+
+				// CSV/XLSX export routes (require data:export permission)
+				NewExportHandler(staff, r.CustomerUsecase, r.RegistrationUsecase)
+			}
 		}
 	}
 }
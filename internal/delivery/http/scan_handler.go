@@ -0,0 +1,64 @@
+package http
+
+import (
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScanHandler represents the HTTP handler for malware scans of files
+type ScanHandler struct {
+	scanUsecase domain.ScanUsecase
+}
+
+// NewScanHandler creates a new scan handler. Rescans are admin only.
+func NewScanHandler(protected *gin.RouterGroup, uc domain.ScanUsecase) {
+	handler := &ScanHandler{
+		scanUsecase: uc,
+	}
+
+	adminOnly := protected.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/files/:id/scan", handler.Rescan)
+	}
+}
+
+// Rescan godoc
+// @Summary Scan a file for malware again
+// @Description Quarantine a file until the scan job scans it again, after a failed scan or to check a file uploaded before scanning was enabled
+// @Tags files
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "File ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /files/{id}/scan [post]
+func (h *ScanHandler) Rescan(c *gin.Context) {
+	file, err := h.scanUsecase.Rescan(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "File not found")
+		case domain.ErrScanInProgress:
+			response.Conflict(c, "File is being scanned", err.Error())
+		case domain.ErrScanDisabled:
+			response.Error(c, http.StatusServiceUnavailable, "Malware scanning is disabled", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to queue file", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "File queued for scanning", file)
+}
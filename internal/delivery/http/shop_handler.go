@@ -0,0 +1,297 @@
+package http
+
+import (
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShopHandler represents the HTTP handler for the shops of a customer
+type ShopHandler struct {
+	shopUsecase domain.ShopUsecase
+	validator   *validator.CustomValidator
+}
+
+// NewShopHandler creates a new shop handler
+func NewShopHandler(router *gin.RouterGroup, uc domain.ShopUsecase) {
+	handler := &ShopHandler{
+		shopUsecase: uc,
+		validator:   validator.NewValidator(),
+	}
+
+	shops := router.Group("/customers/:id/shops")
+	{
+		// Read operations - accessible by admin and sale
+		shops.GET("", handler.GetByCustomer)
+		shops.GET("/:shopId", handler.GetByID)
+		shops.GET("/:shopId/files", handler.GetFiles)
+
+		// Write operations - accessible by admin only
+		adminOnly := shops.Group("")
+		adminOnly.Use(RequireRole(domain.RoleAdmin))
+		{
+			adminOnly.POST("", handler.Create)
+			adminOnly.PUT("/:shopId", handler.Update)
+			adminOnly.DELETE("/:shopId", handler.Delete)
+			adminOnly.POST("/:shopId/files/:fileId", handler.AttachFile)
+			adminOnly.DELETE("/:shopId/files/:fileId", handler.DetachFile)
+		}
+	}
+}
+
+// Create godoc
+// @Summary Create a shop
+// @Description Add an internet cafe to a customer (admin only)
+// @Tags shops
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param shop body domain.CreateShopRequest true "Shop data"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/shops [post]
+func (h *ShopHandler) Create(c *gin.Context) {
+	var req domain.CreateShopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	shop, err := h.shopUsecase.Create(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrInvalidAddress, domain.ErrUnknownAddressVersion:
+			response.BadRequest(c, "Invalid address", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to create shop", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Shop created successfully", shop)
+}
+
+// GetByCustomer godoc
+// @Summary Get customer shops
+// @Description Get the shops of a customer ordered by name
+// @Tags shops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/shops [get]
+func (h *ShopHandler) GetByCustomer(c *gin.Context) {
+	shops, err := h.shopUsecase.GetByCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to get shops", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Shops retrieved successfully", shops)
+}
+
+// GetByID godoc
+// @Summary Get a shop
+// @Description Get a shop of a customer by its ID
+// @Tags shops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param shopId path string true "Shop ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/shops/{shopId} [get]
+func (h *ShopHandler) GetByID(c *gin.Context) {
+	shop, err := h.shopUsecase.GetByID(c.Request.Context(), c.Param("id"), c.Param("shopId"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get shop")
+		return
+	}
+
+	response.OK(c, "Shop retrieved successfully", shop)
+}
+
+// Update godoc
+// @Summary Update a shop
+// @Description Update a shop of a customer (admin only)
+// @Tags shops
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param shopId path string true "Shop ID"
+// @Param shop body domain.UpdateShopRequest true "Shop data"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/shops/{shopId} [put]
+func (h *ShopHandler) Update(c *gin.Context) {
+	var req domain.UpdateShopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	shop, err := h.shopUsecase.Update(c.Request.Context(), c.Param("id"), c.Param("shopId"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update shop")
+		return
+	}
+
+	response.OK(c, "Shop updated successfully", shop)
+}
+
+// Delete godoc
+// @Summary Delete a shop
+// @Description Delete a shop; its licenses, installations and files are detached (admin only)
+// @Tags shops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param shopId path string true "Shop ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/shops/{shopId} [delete]
+func (h *ShopHandler) Delete(c *gin.Context) {
+	if err := h.shopUsecase.Delete(c.Request.Context(), c.Param("id"), c.Param("shopId")); err != nil {
+		h.writeError(c, err, "Failed to delete shop")
+		return
+	}
+
+	response.OK(c, "Shop deleted successfully", nil)
+}
+
+// GetFiles godoc
+// @Summary Get shop files
+// @Description Get the files attached to a shop
+// @Tags shops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param shopId path string true "Shop ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/shops/{shopId}/files [get]
+func (h *ShopHandler) GetFiles(c *gin.Context) {
+	files, err := h.shopUsecase.GetFiles(c.Request.Context(), c.Param("id"), c.Param("shopId"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get shop files")
+		return
+	}
+
+	response.OK(c, "Files retrieved successfully", files)
+}
+
+// AttachFile godoc
+// @Summary Attach a file to a shop
+// @Description Attach an uploaded file to a shop (admin only)
+// @Tags shops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param shopId path string true "Shop ID"
+// @Param fileId path string true "File ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/shops/{shopId}/files/{fileId} [post]
+func (h *ShopHandler) AttachFile(c *gin.Context) {
+	file, err := h.shopUsecase.AttachFile(c.Request.Context(), c.Param("id"), c.Param("shopId"), c.Param("fileId"))
+	if err != nil {
+		h.writeError(c, err, "Failed to attach file")
+		return
+	}
+
+	response.OK(c, "File attached successfully", file)
+}
+
+// DetachFile godoc
+// @Summary Detach a file from a shop
+// @Description Detach a file from a shop without deleting it (admin only)
+// @Tags shops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param shopId path string true "Shop ID"
+// @Param fileId path string true "File ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/shops/{shopId}/files/{fileId} [delete]
+func (h *ShopHandler) DetachFile(c *gin.Context) {
+	if err := h.shopUsecase.DetachFile(c.Request.Context(), c.Param("id"), c.Param("shopId"), c.Param("fileId")); err != nil {
+		h.writeError(c, err, "Failed to detach file")
+		return
+	}
+
+	response.OK(c, "File detached successfully", nil)
+}
+
+// writeError maps shop errors to HTTP responses
+func (h *ShopHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrInvalidAddress, domain.ErrUnknownAddressVersion:
+		response.BadRequest(c, "Invalid address", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, "Shop or file not found")
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
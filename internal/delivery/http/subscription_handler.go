@@ -0,0 +1,291 @@
+package http
+
+import (
+	"strconv"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionHandler represents the HTTP handler for customer subscriptions
+type SubscriptionHandler struct {
+	subscriptionUsecase domain.SubscriptionUsecase
+	validator           *validator.CustomValidator
+}
+
+// NewSubscriptionHandler creates a new subscription handler
+func NewSubscriptionHandler(router *gin.RouterGroup, uc domain.SubscriptionUsecase) {
+	handler := &SubscriptionHandler{
+		subscriptionUsecase: uc,
+		validator:           validator.NewValidator(),
+	}
+
+	// Read operations - accessible by admin and sale
+	router.GET("/customers/:id/subscriptions", handler.GetByCustomer)
+	router.GET("/subscriptions/renewals", handler.GetUpcoming)
+	router.GET("/subscriptions/:id", handler.GetByID)
+
+	// Write operations - accessible by admin only
+	adminOnly := router.Group("")
+	adminOnly.Use(RequireRole(domain.RoleAdmin))
+	{
+		adminOnly.POST("/customers/:id/subscriptions", handler.Create)
+		adminOnly.POST("/subscriptions/:id/renew", handler.Renew)
+		adminOnly.POST("/subscriptions/:id/cancel", handler.Cancel)
+		adminOnly.POST("/subscriptions/renewals/run", handler.ProcessRenewals)
+	}
+}
+
+// Create godoc
+// @Summary Subscribe a customer
+// @Description Subscribe a customer to a plan, optionally starting with a trial (admin only)
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param subscription body domain.CreateSubscriptionRequest true "Subscription terms"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/subscriptions [post]
+func (h *SubscriptionHandler) Create(c *gin.Context) {
+	var req domain.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	subscription, err := h.subscriptionUsecase.Create(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer or plan not found")
+		default:
+			h.writeError(c, err, "Failed to create subscription")
+		}
+		return
+	}
+
+	response.Created(c, "Subscription created successfully", subscription)
+}
+
+// GetByCustomer godoc
+// @Summary Get customer subscriptions
+// @Description Get the subscriptions of a customer, newest first
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/subscriptions [get]
+func (h *SubscriptionHandler) GetByCustomer(c *gin.Context) {
+	subscriptions, err := h.subscriptionUsecase.GetByCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			h.writeError(c, err, "Failed to get subscriptions")
+		}
+		return
+	}
+
+	response.OK(c, "Subscriptions retrieved successfully", subscriptions)
+}
+
+// GetByID godoc
+// @Summary Get a subscription
+// @Description Get a subscription by its ID
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/{id} [get]
+func (h *SubscriptionHandler) GetByID(c *gin.Context) {
+	subscription, err := h.subscriptionUsecase.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get subscription")
+		return
+	}
+
+	response.OK(c, "Subscription retrieved successfully", subscription)
+}
+
+// GetUpcoming godoc
+// @Summary List upcoming renewals
+// @Description List the subscriptions renewing within the given days, including past due ones, soonest first
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Days ahead, the longest reminder period by default"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/renewals [get]
+func (h *SubscriptionHandler) GetUpcoming(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "0"))
+	if err != nil || days < 0 || days > 366 {
+		response.BadRequest(c, "Invalid days parameter", "days must be between 0 and 366")
+		return
+	}
+
+	subscriptions, err := h.subscriptionUsecase.GetUpcoming(c.Request.Context(), days)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get upcoming renewals", err.Error())
+		return
+	}
+
+	response.OK(c, "Upcoming renewals retrieved successfully", subscriptions)
+}
+
+// Renew godoc
+// @Summary Renew a subscription
+// @Description Record the payment of the next billing period, optionally changing the plan, billing cycle or workstation range (admin only)
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Param terms body domain.RenewSubscriptionRequest false "New terms"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/{id}/renew [post]
+func (h *SubscriptionHandler) Renew(c *gin.Context) {
+	var req domain.RenewSubscriptionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	subscription, err := h.subscriptionUsecase.Renew(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to renew subscription")
+		return
+	}
+
+	response.OK(c, "Subscription renewed successfully", subscription)
+}
+
+// Cancel godoc
+// @Summary Cancel a subscription
+// @Description Cancel a subscription with an optional reason (admin only)
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Param reason body domain.CancelSubscriptionRequest false "Reason"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	var req domain.CancelSubscriptionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	subscription, err := h.subscriptionUsecase.Cancel(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to cancel subscription")
+		return
+	}
+
+	response.OK(c, "Subscription cancelled successfully", subscription)
+}
+
+// ProcessRenewals godoc
+// @Summary Run the renewal check
+// @Description Mark overdue subscriptions as past due and send the due renewal reminders now, as the daily job does (admin only)
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/renewals/run [post]
+func (h *SubscriptionHandler) ProcessRenewals(c *gin.Context) {
+	report, err := h.subscriptionUsecase.ProcessRenewals(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, "Failed to process renewals", err.Error())
+		return
+	}
+
+	response.OK(c, "Renewals processed successfully", report)
+}
+
+// writeError maps subscription errors to HTTP responses
+func (h *SubscriptionHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidID:
+		response.BadRequest(c, "Invalid ID format", err.Error())
+	case domain.ErrPlanInactive:
+		response.BadRequest(c, "Plan is not active", err.Error())
+	case domain.ErrPlanPriceNotFound:
+		response.BadRequest(c, "Plan has no price for this workstation range", err.Error())
+	case domain.ErrNotFound:
+		response.NotFound(c, "Subscription not found")
+	case domain.ErrSubscriptionExists:
+		response.Conflict(c, "Customer already has a subscription", err.Error())
+	case domain.ErrSubscriptionCancelled:
+		response.Conflict(c, "Subscription is cancelled", err.Error())
+	case domain.ErrSubscriptionChanged:
+		response.Conflict(c, "Subscription was renewed by another request, reload it", err.Error())
+	default:
+		response.InternalServerError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"net/http"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+	"icafe-registration/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskHandler represents the HTTP handler for follow-up tasks
+type TaskHandler struct {
+	taskUsecase domain.TaskUsecase
+	validator   *validator.CustomValidator
+}
+
+// NewTaskHandler creates a new task handler (admin and sale)
+func NewTaskHandler(router *gin.RouterGroup, uc domain.TaskUsecase) {
+	handler := &TaskHandler{
+		taskUsecase: uc,
+		validator:   validator.NewValidator(),
+	}
+
+	router.GET("/customers/:id/tasks", handler.GetByCustomer)
+	router.POST("/customers/:id/tasks", handler.Create)
+	router.PUT("/tasks/:id", handler.Update)
+	router.DELETE("/tasks/:id", handler.Delete)
+	router.GET("/me/tasks", handler.GetMine)
+}
+
+// Create godoc
+// @Summary Schedule a follow-up task
+// @Description Create a follow-up task on a customer, assigned to the caller unless assignee_id is set
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param task body domain.CreateTaskRequest true "Task data"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/tasks [post]
+func (h *TaskHandler) Create(c *gin.Context) {
+	var req domain.CreateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	task, err := h.taskUsecase.Create(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrInvalidInput:
+			response.BadRequest(c, "Assignee must be an active user", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to create task", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Task created successfully", task)
+}
+
+// GetByCustomer godoc
+// @Summary Get customer tasks
+// @Description Get the follow-up tasks of a customer ordered by due date
+// @Tags tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param status query string false "open, done or all" default(all)
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /customers/{id}/tasks [get]
+func (h *TaskHandler) GetByCustomer(c *gin.Context) {
+	filter, err := parseTaskFilter(c, "all")
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+	filter.CustomerID = c.Param("id")
+
+	h.list(c, filter)
+}
+
+// GetMine godoc
+// @Summary Get my tasks
+// @Description Get the follow-up tasks assigned to the caller, most urgent first
+// @Tags tasks
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "open, done or all" default(open)
+// @Param due_before query string false "Only tasks due before this date (YYYY-MM-DD, inclusive) or RFC3339 time"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /me/tasks [get]
+func (h *TaskHandler) GetMine(c *gin.Context) {
+	filter, err := parseTaskFilter(c, "open")
+	if err != nil {
+		response.BadRequest(c, "Invalid filter parameters", err.Error())
+		return
+	}
+	filter.AssigneeID = c.GetString("user_id")
+
+	h.list(c, filter)
+}
+
+// list writes a page of tasks matching filter
+func (h *TaskHandler) list(c *gin.Context, filter *domain.TaskFilter) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	tasks, info, err := h.taskUsecase.GetAll(c.Request.Context(), filter, page)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidCursor:
+			response.BadRequest(c, "Cursor pagination is not supported for tasks", err.Error())
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Customer not found")
+		default:
+			response.InternalServerError(c, "Failed to get tasks", err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Tasks retrieved successfully", tasks, pageMeta(page, info))
+}
+
+// Update godoc
+// @Summary Update a task
+// @Description Update or complete a task (assignee, creator or admin only)
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Param task body domain.UpdateTaskRequest true "Task data"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /tasks/{id} [put]
+func (h *TaskHandler) Update(c *gin.Context) {
+	var req domain.UpdateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Validate(&req); err != nil {
+		errors := validator.GetValidationErrors(err)
+		response.BadRequest(c, "Validation failed", mapToString(errors))
+		return
+	}
+
+	task, err := h.taskUsecase.Update(c.Request.Context(), c.Param("id"), &req, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrInvalidInput:
+			response.BadRequest(c, "Assignee must be an active user", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Task not found")
+		case domain.ErrForbidden:
+			response.Error(c, http.StatusForbidden, "Access denied", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to update task", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Task updated successfully", task)
+}
+
+// Delete godoc
+// @Summary Delete a task
+// @Description Delete a task (creator or admin only)
+// @Tags tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /tasks/{id} [delete]
+func (h *TaskHandler) Delete(c *gin.Context) {
+	err := h.taskUsecase.Delete(c.Request.Context(), c.Param("id"), currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Task not found")
+		case domain.ErrForbidden:
+			response.Error(c, http.StatusForbidden, "Access denied", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to delete task", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Task deleted successfully", nil)
+}
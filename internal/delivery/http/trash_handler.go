@@ -0,0 +1,221 @@
+package http
+
+import (
+	"net/http"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashHandler represents the HTTP handler for soft-deleted records
+type TrashHandler struct {
+	customerUsecase     domain.CustomerUsecase
+	registrationUsecase domain.RegistrationUsecase
+	userUsecase         domain.UserUsecase
+}
+
+// NewTrashHandler creates a new trash handler (admin only routes)
+func NewTrashHandler(
+	router *gin.RouterGroup,
+	customerUC domain.CustomerUsecase,
+	registrationUC domain.RegistrationUsecase,
+	userUC domain.UserUsecase,
+) {
+	handler := &TrashHandler{
+		customerUsecase:     customerUC,
+		registrationUsecase: registrationUC,
+		userUsecase:         userUC,
+	}
+
+	router.GET("/customers/trash", handler.GetCustomerTrash)
+	router.POST("/customers/:id/restore", handler.RestoreCustomer)
+	router.GET("/registrations/trash", handler.GetRegistrationTrash)
+	router.POST("/registrations/:id/restore", handler.RestoreRegistration)
+	router.GET("/users/trash", handler.GetUserTrash)
+	router.POST("/users/:id/restore", handler.RestoreUser)
+}
+
+// GetCustomerTrash godoc
+// @Summary List deleted customers
+// @Description Get customers in the trash with pagination (admin only)
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact or none"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /customers/trash [get]
+func (h *TrashHandler) GetCustomerTrash(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	customers, info, err := h.customerUsecase.GetTrash(c.Request.Context(), page)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get deleted customers", err.Error())
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Deleted customers retrieved successfully", customers, pageMeta(page, info))
+}
+
+// RestoreCustomer godoc
+// @Summary Restore a customer
+// @Description Move a customer out of the trash (admin only)
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /customers/{id}/restore [post]
+func (h *TrashHandler) RestoreCustomer(c *gin.Context) {
+	customer, err := h.customerUsecase.Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Deleted customer not found")
+		case domain.ErrPhoneAlreadyExists:
+			response.Conflict(c, "Phone number already used by another customer", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to restore customer", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Customer restored successfully", customer)
+}
+
+// GetRegistrationTrash godoc
+// @Summary List deleted registrations
+// @Description Get registrations in the trash with pagination (admin only)
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact or none"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /registrations/trash [get]
+func (h *TrashHandler) GetRegistrationTrash(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	registrations, info, err := h.registrationUsecase.GetTrash(c.Request.Context(), page)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get deleted registrations", err.Error())
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Deleted registrations retrieved successfully", registrations, pageMeta(page, info))
+}
+
+// RestoreRegistration godoc
+// @Summary Restore a registration
+// @Description Move a registration out of the trash (admin only)
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registration ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /registrations/{id}/restore [post]
+func (h *TrashHandler) RestoreRegistration(c *gin.Context) {
+	registration, err := h.registrationUsecase.Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Deleted registration not found")
+		default:
+			response.InternalServerError(c, "Failed to restore registration", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Registration restored successfully", registration)
+}
+
+// GetUserTrash godoc
+// @Summary List deleted users
+// @Description Get users in the trash with pagination (admin only)
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact or none"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /users/trash [get]
+func (h *TrashHandler) GetUserTrash(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	users, info, err := h.userUsecase.GetTrash(c.Request.Context(), page)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get deleted users", err.Error())
+		return
+	}
+
+	response.SuccessWithMeta(c, http.StatusOK, "Deleted users retrieved successfully", users, pageMeta(page, info))
+}
+
+// RestoreUser godoc
+// @Summary Restore a user
+// @Description Move a user out of the trash (admin only)
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /users/{id}/restore [post]
+func (h *TrashHandler) RestoreUser(c *gin.Context) {
+	user, err := h.userUsecase.Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Deleted user not found")
+		case domain.ErrAlreadyExists:
+			response.Conflict(c, "Username, email or phone already used by another user", err.Error())
+		default:
+			response.InternalServerError(c, "Failed to restore user", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "User restored successfully", user)
+}
@@ -0,0 +1,359 @@
+package http
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination,checksum"
+
+	// statusChecksumMismatch is the tus status for a PATCH body not matching its checksum
+	statusChecksumMismatch = 460
+)
+
+var errInvalidMetadata = errors.New("metadata must be comma separated keys with base64 values")
+
+// UploadHandler represents the HTTP handler for resumable uploads over the tus protocol
+type UploadHandler struct {
+	uploadUsecase domain.UploadUsecase
+	maxSize       int64
+}
+
+// NewUploadHandler creates a new tus upload handler accepting uploads up to maxSize bytes.
+// Uploads of installers, private and customer files need a staff token, and an
+// upload is only resumed by its creator or staff.
+func NewUploadHandler(router *gin.RouterGroup, uc domain.UploadUsecase, maxSize int64) {
+	handler := &UploadHandler{
+		uploadUsecase: uc,
+		maxSize:       maxSize,
+	}
+
+	uploads := router.Group("/uploads")
+	uploads.Use(requireTusVersion())
+	{
+		uploads.OPTIONS("", handler.Options)
+		uploads.POST("", handler.Create)
+		uploads.HEAD("/:id", handler.Head)
+		uploads.PATCH("/:id", handler.Patch)
+		uploads.DELETE("/:id", handler.Terminate)
+	}
+	router.GET("/uploads/:id", handler.GetByID)
+}
+
+// requireTusVersion answers with the tus version and refuses requests for another
+// one. OPTIONS requests are exempt, they discover the version.
+func requireTusVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Resumable", tusVersion)
+
+		if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != tusVersion {
+			c.Header("Tus-Version", tusVersion)
+			response.Error(c, http.StatusPreconditionFailed, "Unsupported tus version", "Tus-Resumable must be "+tusVersion)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Options godoc
+// @Summary Discover the tus server
+// @Description Return the tus version, extensions, maximum size and checksum algorithms
+// @Tags uploads
+// @Success 204
+// @Router /uploads [options]
+func (h *UploadHandler) Options(c *gin.Context) {
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.maxSize, 10))
+	c.Header("Tus-Checksum-Algorithm", strings.Join(domain.UploadChecksumAlgorithms, ","))
+	c.Status(http.StatusNoContent)
+}
+
+// Create godoc
+// @Summary Create a resumable upload
+// @Description Start a tus upload of Upload-Length bytes. Upload-Metadata may carry filename, file_type (document, video or installer; document by default; installers need a staff token), visibility (public, private or customer; public by default; private and customer files need a staff token) and customer_id for customer files. The file is created when the last byte is received. Without a token, the upload URL in Location carries the key resuming the upload.
+// @Tags uploads
+// @Param Tus-Resumable header string true "1.0.0"
+// @Param Upload-Length header int true "Size of the file in bytes"
+// @Param Upload-Metadata header string false "Comma separated keys with base64 values, e.g. filename YmFvLWdpYS5wZGY=,file_type dmlkZW8="
+// @Success 201 {string} string "Location header holds the upload URL"
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 412 {object} response.Response
+// @Failure 413 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /uploads [post]
+func (h *UploadHandler) Create(c *gin.Context) {
+	if c.GetHeader("Upload-Defer-Length") != "" {
+		response.BadRequest(c, "Invalid upload", "deferred upload length is not supported")
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		response.BadRequest(c, "Invalid upload", "Upload-Length must be a non-negative integer")
+		return
+	}
+
+	header := c.GetHeader("Upload-Metadata")
+	metadata, err := parseUploadMetadata(header)
+	if err != nil {
+		response.BadRequest(c, "Invalid upload", err.Error())
+		return
+	}
+
+	fileName := metadata["filename"]
+	if fileName == "" {
+		fileName = metadata["name"]
+	}
+
+	access, err := fileAccess(metadata["visibility"], metadata["customer_id"])
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	if denyRestrictedUpload(c, access) {
+		return
+	}
+
+	upload, err := h.uploadUsecase.Create(c.Request.Context(), &domain.CreateUploadRequest{
+		Length:   length,
+		FileType: domain.FileType(metadata["file_type"]),
+		FileName: fileName,
+		Metadata: header,
+		Access:   access,
+	}, currentActor(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	ref := upload.ID.Hex()
+	if upload.Key != "" {
+		ref += "." + upload.Key
+	}
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+ref)
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// Head godoc
+// @Summary Get the offset of a resumable upload
+// @Description Return how many bytes were received in Upload-Offset, to resume from there
+// @Tags uploads
+// @Param Tus-Resumable header string true "1.0.0"
+// @Param id path string true "Upload ID, followed by .key for anonymous uploads"
+// @Success 200
+// @Failure 404
+// @Failure 410
+// @Router /uploads/{id} [head]
+func (h *UploadHandler) Head(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	id, key := uploadRef(c)
+	upload, err := h.uploadUsecase.GetByID(c.Request.Context(), id, key, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID, domain.ErrNotFound:
+			c.Status(http.StatusNotFound)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+	if upload.FileID == nil && upload.ExpiresAt.Before(time.Now()) {
+		c.Status(http.StatusGone)
+		return
+	}
+
+	h.uploadHeaders(c, upload)
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		c.Header("Upload-Metadata", upload.Metadata)
+	}
+	c.Status(http.StatusOK)
+}
+
+// Patch godoc
+// @Summary Append to a resumable upload
+// @Description Append the body at Upload-Offset, which must be the current offset. With Upload-Checksum the body is only kept when it matches. The response of the last PATCH carries the ID of the created file in X-File-Id.
+// @Tags uploads
+// @Accept application/offset+octet-stream
+// @Param Tus-Resumable header string true "1.0.0"
+// @Param Upload-Offset header int true "Current offset of the upload"
+// @Param Upload-Checksum header string false "Algorithm and base64 checksum of the body, e.g. sha1 Kq5sNclPz7QV2+lfQIuc6R7oRu0="
+// @Param id path string true "Upload ID, followed by .key for anonymous uploads"
+// @Success 204
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 410 {object} response.Response
+// @Failure 413 {object} response.Response
+// @Failure 415 {object} response.Response
+// @Failure 423 {object} response.Response
+// @Failure 460 {object} response.Response
+// @Router /uploads/{id} [patch]
+func (h *UploadHandler) Patch(c *gin.Context) {
+	if c.ContentType() != "application/offset+octet-stream" {
+		response.Error(c, http.StatusUnsupportedMediaType, "Invalid upload", "Content-Type must be application/offset+octet-stream")
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		response.BadRequest(c, "Invalid upload", "Upload-Offset must be a non-negative integer")
+		return
+	}
+
+	var checksum *domain.UploadChecksum
+	if value := c.GetHeader("Upload-Checksum"); value != "" {
+		algorithm, encoded, _ := strings.Cut(value, " ")
+		sum, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			response.BadRequest(c, "Invalid upload", "Upload-Checksum must be an algorithm and a base64 checksum")
+			return
+		}
+		checksum = &domain.UploadChecksum{Algorithm: algorithm, Sum: sum}
+	}
+
+	id, key := uploadRef(c)
+	upload, err := h.uploadUsecase.Append(c.Request.Context(), id, key, currentActor(c), offset, checksum, c.Request.Body)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	h.uploadHeaders(c, upload)
+	c.Status(http.StatusNoContent)
+}
+
+// Terminate godoc
+// @Summary Terminate a resumable upload
+// @Description Cancel an upload and discard its received bytes
+// @Tags uploads
+// @Param Tus-Resumable header string true "1.0.0"
+// @Param id path string true "Upload ID, followed by .key for anonymous uploads"
+// @Success 204
+// @Failure 404 {object} response.Response
+// @Failure 423 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /uploads/{id} [delete]
+func (h *UploadHandler) Terminate(c *gin.Context) {
+	id, key := uploadRef(c)
+	if err := h.uploadUsecase.Terminate(c.Request.Context(), id, key, currentActor(c)); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetByID godoc
+// @Summary Get a resumable upload
+// @Description Get the progress of an upload, and the ID of its file once complete
+// @Tags uploads
+// @Produce json
+// @Param id path string true "Upload ID, followed by .key for anonymous uploads"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /uploads/{id} [get]
+func (h *UploadHandler) GetByID(c *gin.Context) {
+	id, key := uploadRef(c)
+	upload, err := h.uploadUsecase.GetByID(c.Request.Context(), id, key, currentActor(c))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidID:
+			response.BadRequest(c, "Invalid ID format", err.Error())
+		case domain.ErrNotFound:
+			response.NotFound(c, "Upload not found")
+		default:
+			response.InternalServerError(c, "Failed to get upload", err.Error())
+		}
+		return
+	}
+
+	response.OK(c, "Upload retrieved successfully", upload)
+}
+
+// uploadRef splits the :id of an upload URL into the upload ID and the key of an
+// anonymous upload, which follows the ID after a dot
+func uploadRef(c *gin.Context) (string, string) {
+	id, key, _ := strings.Cut(c.Param("id"), ".")
+	return id, key
+}
+
+// uploadHeaders sets the offset and expiry of an upload, and its file once complete
+func (h *UploadHandler) uploadHeaders(c *gin.Context, upload *domain.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	if upload.FileID != nil {
+		c.Header("X-File-Id", upload.FileID.Hex())
+	} else {
+		c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+func (h *UploadHandler) writeError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrInvalidID, domain.ErrNotFound:
+		response.NotFound(c, "Upload not found")
+	case domain.ErrInvalidInput:
+		response.BadRequest(c, "Invalid upload", err.Error())
+	case domain.ErrForbidden:
+		response.Error(c, http.StatusForbidden, "Access denied", err.Error())
+	case domain.ErrInvalidFileType:
+		response.BadRequest(c, "Invalid file type", err.Error())
+	case domain.ErrFileCustomerNotFound:
+		response.BadRequest(c, "Invalid customer", err.Error())
+	case domain.ErrUploadChecksumAlgorithm:
+		response.BadRequest(c, "Invalid upload", err.Error())
+	case domain.ErrFileTooLarge:
+		response.Error(c, http.StatusRequestEntityTooLarge, "File too large", err.Error())
+	case domain.ErrUploadOffsetMismatch:
+		response.Conflict(c, "Upload offset mismatch", err.Error())
+	case domain.ErrUploadExpired:
+		response.Error(c, http.StatusGone, "Upload expired", err.Error())
+	case domain.ErrUploadLocked:
+		response.Error(c, http.StatusLocked, "Upload is locked", err.Error())
+	case domain.ErrUploadChecksumMismatch:
+		response.Error(c, statusChecksumMismatch, "Checksum mismatch", err.Error())
+	default:
+		response.InternalServerError(c, "Failed to process upload", err.Error())
+	}
+}
+
+// parseUploadMetadata decodes an Upload-Metadata header: comma separated keys,
+// each followed by a space and its base64 value when it has one
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errInvalidMetadata
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errInvalidMetadata
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
@@ -2,7 +2,6 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 
 	"icafe-registration/internal/domain"
 	"icafe-registration/pkg/response"
@@ -87,25 +86,27 @@ func (h *UserHandler) Create(c *gin.Context) {
 // @Security BearerAuth
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Opaque cursor from meta.next_cursor (replaces offset)"
+// @Param count query string false "Total count mode: exact, estimated or none"
 // @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /users [get]
 func (h *UserHandler) GetAll(c *gin.Context) {
-	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
-	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	page, err := parsePagination(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid pagination parameters", err.Error())
+		return
+	}
 
-	users, total, err := h.userUsecase.GetAll(c.Request.Context(), limit, offset)
+	users, info, err := h.userUsecase.GetAll(c.Request.Context(), page)
 	if err != nil {
 		response.InternalServerError(c, "Failed to get users", err.Error())
 		return
 	}
 
-	response.SuccessWithMeta(c, http.StatusOK, "Users retrieved successfully", users, &response.Meta{
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
-	})
+	response.SuccessWithMeta(c, http.StatusOK, "Users retrieved successfully", users, pageMeta(page, info))
 }
 
 // GetByID godoc
@@ -286,7 +287,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a user
-// @Description Delete a user by ID
+// @Description Move a user to the trash
 // @Tags users
 // @Produce json
 // @Security BearerAuth
@@ -299,7 +300,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 func (h *UserHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
-	err := h.userUsecase.Delete(c.Request.Context(), id)
+	err := h.userUsecase.Delete(c.Request.Context(), id, c.GetString("user_id"))
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidID:
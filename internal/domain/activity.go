@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ActivityType represents the kind of a customer interaction
+type ActivityType string
+
+const (
+	ActivityNote         ActivityType = "note"
+	ActivityCall         ActivityType = "call"
+	ActivityEmail        ActivityType = "email"
+	ActivityDemo         ActivityType = "demo"
+	ActivityStatusChange ActivityType = "status_change" // recorded by the system, not creatable through the API
+)
+
+// Actor identifies the authenticated user performing an action
+type Actor struct {
+	ID   string
+	Name string
+	Role Role
+}
+
+// IsAdmin reports whether the actor has the admin role
+func (a *Actor) IsAdmin() bool {
+	return a != nil && a.Role == RoleAdmin
+}
+
+// IsStaff reports whether the actor has the admin or sale role
+func (a *Actor) IsStaff() bool {
+	return a != nil && (a.Role == RoleAdmin || a.Role == RoleSale)
+}
+
+// CustomerActivity represents one entry of a customer's interaction timeline
+type CustomerActivity struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CustomerID primitive.ObjectID `json:"customer_id" bson:"customer_id"`
+	Type       ActivityType       `json:"type" bson:"type"`
+	Content    string             `json:"content" bson:"content"`
+	AuthorID   string             `json:"author_id,omitempty" bson:"author_id,omitempty"`
+	AuthorName string             `json:"author_name,omitempty" bson:"author_name,omitempty"`
+	CreatedOn  time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateActivityRequest represents the request body for logging an interaction
+type CreateActivityRequest struct {
+	Type    ActivityType `json:"type" validate:"required,oneof=note call email demo"`
+	Content string       `json:"content" validate:"required,max=2000"`
+}
+
+// UpdateActivityRequest represents the request body for editing an interaction
+type UpdateActivityRequest struct {
+	Content string `json:"content" validate:"required,max=2000"`
+}
+
+// ActivityRepository represents the customer activity repository contract
+type ActivityRepository interface {
+	Create(ctx context.Context, activity *CustomerActivity) error
+	GetByID(ctx context.Context, id string) (*CustomerActivity, error)
+	GetByCustomer(ctx context.Context, customerID string, activityType ActivityType, page *Pagination) ([]*CustomerActivity, error)
+	CountByCustomer(ctx context.Context, customerID string, activityType ActivityType) (int64, error)
+	Update(ctx context.Context, activity *CustomerActivity) error
+	Delete(ctx context.Context, id string) error
+}
+
+// ActivityUsecase represents the customer activity usecase contract
+type ActivityUsecase interface {
+	Create(ctx context.Context, customerID string, req *CreateActivityRequest, actor *Actor) (*CustomerActivity, error)
+	GetByCustomer(ctx context.Context, customerID string, activityType ActivityType, page *Pagination) ([]*CustomerActivity, *PageInfo, error)
+	Update(ctx context.Context, customerID, id string, req *UpdateActivityRequest, actor *Actor) (*CustomerActivity, error)
+	Delete(ctx context.Context, customerID, id string, actor *Actor) error
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Address represents a structured Vietnamese postal address.
+// The province can be given by code or by name; the server resolves it against
+// the administrative dataset of Version and stores both. The district and ward
+// are names, which the dataset does not list and the server does not check.
+type Address struct {
+	Street       string `json:"street,omitempty" bson:"street,omitempty" validate:"omitempty,max=255"`
+	Ward         string `json:"ward,omitempty" bson:"ward,omitempty" validate:"omitempty,max=100"`
+	District     string `json:"district,omitempty" bson:"district,omitempty" validate:"omitempty,max=100"`
+	ProvinceCode string `json:"province_code" bson:"province_code" validate:"required_without=Province,max=10"`
+	Province     string `json:"province" bson:"province" validate:"required_without=ProvinceCode,max=100"`
+	Version      string `json:"version,omitempty" bson:"version,omitempty" validate:"omitempty,max=20"`
+}
+
+// String formats the address on one line, from the street to the province
+func (a *Address) String() string {
+	parts := make([]string, 0, 4)
+	for _, part := range []string{a.Street, a.Ward, a.District, a.Province} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// AdminUnit represents a province of the administrative dataset
+type AdminUnit struct {
+	Code       string   `json:"code"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	FullName   string   `json:"full_name"`
+	MergedFrom []string `json:"merged_from,omitempty"`
+}
+
+// AddressVersion describes a version of the administrative dataset
+type AddressVersion struct {
+	Version       string `json:"version"`
+	EffectiveFrom string `json:"effective_from,omitempty"`
+	ValidUntil    string `json:"valid_until,omitempty"`
+	HasDistricts  bool   `json:"has_districts"`
+	Current       bool   `json:"current"`
+}
+
+// ParseAddressRequest represents the request body for parsing a free-text address
+type ParseAddressRequest struct {
+	Address string `json:"address" validate:"required,max=500"`
+	Version string `json:"version" validate:"omitempty,max=20"`
+}
+
+// ParsedAddress represents the result of parsing a free-text address.
+// Matched is the deepest level found in the dataset: province or empty.
+type ParsedAddress struct {
+	Address *Address `json:"address,omitempty"`
+	Matched string   `json:"matched"`
+}
+
+// AddressConversionCount counts the records seen by an address conversion
+type AddressConversionCount struct {
+	Scanned   int `json:"scanned"`
+	Converted int `json:"converted"`
+	Unmatched int `json:"unmatched"`
+}
+
+// UnmatchedAddress represents a free-text address the parser could not convert
+type UnmatchedAddress struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Address string `json:"address"`
+}
+
+// AddressConversionReport represents the result of converting free-text addresses.
+// Unmatched lists at most MaxUnmatchedAddresses records.
+type AddressConversionReport struct {
+	DryRun        bool                   `json:"dry_run"`
+	Version       string                 `json:"version"`
+	Customers     AddressConversionCount `json:"customers"`
+	Registrations AddressConversionCount `json:"registrations"`
+	Unmatched     []UnmatchedAddress     `json:"unmatched"`
+}
+
+// MaxUnmatchedAddresses bounds the unmatched addresses listed in a conversion report
+const MaxUnmatchedAddresses = 100
+
+// ProvinceCount represents the number of customers in a province.
+// Customers without a structured address are counted with an empty province code.
+type ProvinceCount struct {
+	ProvinceCode string `json:"province_code"`
+	Province     string `json:"province"`
+	Count        int64  `json:"count"`
+}
+
+var (
+	// ErrInvalidAddress is returned when an address does not match the administrative dataset
+	ErrInvalidAddress = errors.New("address does not match the administrative units")
+
+	// ErrUnknownAddressVersion is returned for an unknown administrative dataset version
+	ErrUnknownAddressVersion = errors.New("unknown administrative dataset version")
+)
+
+// AddressUsecase represents the address lookup and conversion contract
+type AddressUsecase interface {
+	GetVersions(ctx context.Context) []*AddressVersion
+	GetProvinces(ctx context.Context, version string) ([]*AdminUnit, error)
+	Parse(ctx context.Context, req *ParseAddressRequest) (*ParsedAddress, error)
+	Convert(ctx context.Context, dryRun bool) (*AddressConversionReport, error)
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AppointmentType represents what an appointment is booked for
+type AppointmentType string
+
+const (
+	AppointmentDemo         AppointmentType = "demo"
+	AppointmentInstallation AppointmentType = "installation"
+)
+
+// AppointmentMode represents where an appointment takes place
+type AppointmentMode string
+
+const (
+	AppointmentOnsite AppointmentMode = "onsite" // at the cafe
+	AppointmentRemote AppointmentMode = "remote" // over a call or remote desktop
+)
+
+// AppointmentStatus represents the state of an appointment
+type AppointmentStatus string
+
+const (
+	AppointmentScheduled AppointmentStatus = "scheduled"
+	AppointmentCompleted AppointmentStatus = "completed"
+	AppointmentCancelled AppointmentStatus = "cancelled"
+)
+
+// Appointment represents a demo or installation booked with a prospect or customer.
+// A technician has at most one scheduled appointment at a time.
+type Appointment struct {
+	ID             primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
+	Type           AppointmentType         `json:"type" bson:"type"`
+	Mode           AppointmentMode         `json:"mode" bson:"mode"`
+	Title          string                  `json:"title" bson:"title"`
+	RegistrationID *primitive.ObjectID     `json:"registration_id,omitempty" bson:"registration_id,omitempty"`
+	CustomerID     *primitive.ObjectID     `json:"customer_id,omitempty" bson:"customer_id,omitempty"`
+	TechnicianID   string                  `json:"technician_id" bson:"technician_id"`
+	StartAt        time.Time               `json:"start_at" bson:"start_at"`
+	EndAt          time.Time               `json:"end_at" bson:"end_at"`
+	Location       string                  `json:"location,omitempty" bson:"location,omitempty"`
+	MeetingURL     string                  `json:"meeting_url,omitempty" bson:"meeting_url,omitempty"`
+	ContactName    string                  `json:"contact_name,omitempty" bson:"contact_name,omitempty"`
+	ContactPhone   string                  `json:"contact_phone,omitempty" bson:"contact_phone,omitempty"`
+	Note           string                  `json:"note,omitempty" bson:"note,omitempty"`
+	Status         AppointmentStatus       `json:"status" bson:"status"`
+	Sequence       int                     `json:"sequence" bson:"sequence"` // revision number of the calendar event
+	Reschedules    []AppointmentReschedule `json:"reschedules,omitempty" bson:"reschedules,omitempty"`
+	CancelReason   string                  `json:"cancel_reason,omitempty" bson:"cancel_reason,omitempty"`
+	CancelledAt    *time.Time              `json:"cancelled_at,omitempty" bson:"cancelled_at,omitempty"`
+	CancelledBy    string                  `json:"cancelled_by,omitempty" bson:"cancelled_by,omitempty"`
+	CompletedAt    *time.Time              `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CompletedBy    string                  `json:"completed_by,omitempty" bson:"completed_by,omitempty"`
+	CreatedBy      string                  `json:"created_by" bson:"created_by"`
+	CreatedOn      time.Time               `json:"created_on" bson:"created_on"`
+	ModifiedOn     time.Time               `json:"modified_on" bson:"modified_on"`
+}
+
+// AppointmentReschedule records the slot an appointment was moved from
+type AppointmentReschedule struct {
+	StartAt      time.Time `json:"start_at" bson:"start_at"`
+	EndAt        time.Time `json:"end_at" bson:"end_at"`
+	TechnicianID string    `json:"technician_id" bson:"technician_id"`
+	Reason       string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	By           string    `json:"by" bson:"by"`
+	At           time.Time `json:"at" bson:"at"`
+}
+
+// CreateAppointmentRequest represents the request body for booking an appointment.
+// The appointment is assigned to the caller when TechnicianID is empty, and the
+// customer of a converted registration is linked automatically.
+type CreateAppointmentRequest struct {
+	Type           AppointmentType `json:"type" validate:"required,oneof=demo installation"`
+	Mode           AppointmentMode `json:"mode" validate:"required,oneof=onsite remote"`
+	Title          string          `json:"title" validate:"omitempty,min=2,max=200"`
+	RegistrationID string          `json:"registration_id" validate:"required_without=CustomerID"`
+	CustomerID     string          `json:"customer_id" validate:"required_without=RegistrationID"`
+	TechnicianID   string          `json:"technician_id" validate:"omitempty"`
+	StartAt        time.Time       `json:"start_at" validate:"required"`
+	EndAt          time.Time       `json:"end_at" validate:"required,gtfield=StartAt"`
+	Location       string          `json:"location" validate:"omitempty,max=255"`
+	MeetingURL     string          `json:"meeting_url" validate:"omitempty,url,max=500"`
+	ContactName    string          `json:"contact_name" validate:"omitempty,max=100"`
+	ContactPhone   string          `json:"contact_phone" validate:"omitempty,min=10,max=15"`
+	Note           string          `json:"note" validate:"omitempty,max=1000"`
+}
+
+// UpdateAppointmentRequest represents the request body for updating the details of an appointment.
+// The slot and the technician are changed by rescheduling.
+type UpdateAppointmentRequest struct {
+	Title        string          `json:"title" validate:"omitempty,min=2,max=200"`
+	Mode         AppointmentMode `json:"mode" validate:"omitempty,oneof=onsite remote"`
+	Location     string          `json:"location" validate:"omitempty,max=255"`
+	MeetingURL   string          `json:"meeting_url" validate:"omitempty,url,max=500"`
+	ContactName  string          `json:"contact_name" validate:"omitempty,max=100"`
+	ContactPhone string          `json:"contact_phone" validate:"omitempty,min=10,max=15"`
+	Note         string          `json:"note" validate:"omitempty,max=1000"`
+}
+
+// RescheduleAppointmentRequest represents the request body for moving an appointment.
+// The technician is kept when TechnicianID is empty.
+type RescheduleAppointmentRequest struct {
+	StartAt      time.Time `json:"start_at" validate:"required"`
+	EndAt        time.Time `json:"end_at" validate:"required,gtfield=StartAt"`
+	TechnicianID string    `json:"technician_id" validate:"omitempty"`
+	Reason       string    `json:"reason" validate:"omitempty,max=500"`
+}
+
+// CancelAppointmentRequest represents the request body for cancelling an appointment
+type CancelAppointmentRequest struct {
+	Reason string `json:"reason" validate:"required,min=2,max=500"`
+}
+
+// AppointmentFilter represents the filters of an appointment list.
+// From and To select appointments overlapping the range.
+type AppointmentFilter struct {
+	RegistrationID string
+	CustomerID     string
+	TechnicianID   string
+	Status         AppointmentStatus // empty matches every status
+	From           *time.Time
+	To             *time.Time
+}
+
+// TimeSlot represents a free slot of a technician
+type TimeSlot struct {
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+}
+
+// CalendarFeed is the secret link a user subscribes to in a calendar app.
+// Only the hash of its token is stored, so a new link replaces the previous one.
+type CalendarFeed struct {
+	ID        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	UserID    string             `json:"-" bson:"user_id"`
+	TokenHash string             `json:"-" bson:"token_hash"`
+	URL       string             `json:"url" bson:"-"` // only returned when the link is created
+	CreatedOn time.Time          `json:"created_on" bson:"created_on"`
+}
+
+var (
+	// ErrAppointmentConflict is returned when a technician already has an appointment in the slot
+	ErrAppointmentConflict = errors.New("technician already has an appointment at this time")
+
+	// ErrAppointmentClosed is returned when changing a completed or cancelled appointment
+	ErrAppointmentClosed = errors.New("appointment is completed or cancelled")
+
+	// ErrScheduleLocked is returned when another booking of the technician is still in progress
+	ErrScheduleLocked = errors.New("technician schedule is being changed, try again")
+)
+
+// AppointmentRepository represents the appointment repository contract
+type AppointmentRepository interface {
+	Create(ctx context.Context, appointment *Appointment) error
+	GetByID(ctx context.Context, id string) (*Appointment, error)
+	GetAll(ctx context.Context, filter *AppointmentFilter, page *Pagination) ([]*Appointment, error)
+	Count(ctx context.Context, filter *AppointmentFilter) (int64, error)
+	GetBusy(ctx context.Context, technicianID string, from, to time.Time) ([]*Appointment, error)
+	Update(ctx context.Context, appointment *Appointment) error
+	LockSchedule(ctx context.Context, technicianID string, ttl time.Duration) (string, error)
+	UnlockSchedule(ctx context.Context, technicianID, token string) error
+}
+
+// CalendarFeedRepository represents the calendar feed repository contract
+type CalendarFeedRepository interface {
+	Replace(ctx context.Context, feed *CalendarFeed) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*CalendarFeed, error)
+}
+
+// AppointmentUsecase represents the appointment usecase contract
+type AppointmentUsecase interface {
+	Create(ctx context.Context, req *CreateAppointmentRequest, actor *Actor) (*Appointment, error)
+	GetByID(ctx context.Context, id string) (*Appointment, error)
+	GetAll(ctx context.Context, filter *AppointmentFilter, page *Pagination) ([]*Appointment, *PageInfo, error)
+	GetSlots(ctx context.Context, technicianID string, day time.Time, length time.Duration) ([]TimeSlot, error)
+	Update(ctx context.Context, id string, req *UpdateAppointmentRequest, actor *Actor) (*Appointment, error)
+	Reschedule(ctx context.Context, id string, req *RescheduleAppointmentRequest, actor *Actor) (*Appointment, error)
+	Cancel(ctx context.Context, id string, req *CancelAppointmentRequest, actor *Actor) (*Appointment, error)
+	Complete(ctx context.Context, id string, actor *Actor) (*Appointment, error)
+	CreateFeed(ctx context.Context, actor *Actor) (*CalendarFeed, error)
+	RenderFeed(ctx context.Context, token string, w io.Writer) error
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Blob is stored file content shared by every file with the same SHA-256.
+// Its bytes are deleted from the storage when the last file goes away.
+type Blob struct {
+	SHA256    string    `json:"sha256" bson:"_id"` // hex
+	Key       string    `json:"key" bson:"key"`    // FileStorage key of the content
+	Size      int64     `json:"size" bson:"size"`
+	RefCount  int64     `json:"ref_count" bson:"ref_count"`
+	CreatedOn time.Time `json:"created_on" bson:"created_on"`
+}
+
+// BlobRepository represents the content blob repository contract
+type BlobRepository interface {
+	// Acquire adds a reference to the blob with the SHA-256 of blob, creating
+	// it as given when none exists, and returns the blob to use
+	Acquire(ctx context.Context, blob *Blob) (*Blob, error)
+
+	// Release removes a reference and returns how many remain
+	Release(ctx context.Context, sha256 string) (int64, error)
+
+	// DeleteUnused deletes a blob without references, reporting whether it did
+	DeleteUnused(ctx context.Context, sha256 string) (bool, error)
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -9,37 +10,68 @@ import (
 
 // Customer represents the customer entity
 type Customer struct {
-	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	FullName         string             `json:"full_name" bson:"full_name"`
-	PhoneNumber      string             `json:"phone_number" bson:"phone_number"`
-	Email            string             `json:"email,omitempty" bson:"email,omitempty"`
-	Address          string             `json:"address,omitempty" bson:"address,omitempty"`
-	Note             string             `json:"note,omitempty" bson:"note,omitempty"`
-	WorkstationRange string             `json:"workstation_range" bson:"workstation_range"`
-	IsActive         bool               `json:"is_active" bson:"is_active"`
-	CreatedOn        time.Time          `json:"created_on" bson:"created_on"`
-	ModifiedOn       time.Time          `json:"modified_on" bson:"modified_on"`
+	ID                primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	FullName          string              `json:"full_name" bson:"full_name"`
+	PhoneNumber       string              `json:"phone_number" bson:"phone_number"`
+	Email             string              `json:"email,omitempty" bson:"email,omitempty"`
+	Address           string              `json:"address,omitempty" bson:"address,omitempty"`
+	StructuredAddress *Address            `json:"structured_address,omitempty" bson:"structured_address,omitempty"`
+	CompanyName       string              `json:"company_name,omitempty" bson:"company_name,omitempty"`
+	TaxCode           string              `json:"tax_code,omitempty" bson:"tax_code,omitempty"`
+	Note              string              `json:"note,omitempty" bson:"note,omitempty"`
+	WorkstationRange  string              `json:"workstation_range" bson:"workstation_range"`
+	IsActive          bool                `json:"is_active" bson:"is_active"`
+	ResellerID        *primitive.ObjectID `json:"reseller_id,omitempty" bson:"reseller_id,omitempty"` // reseller who referred the customer
+	ShopCount         int                 `json:"shop_count" bson:"-"`
+	TotalWorkstations int                 `json:"total_workstations" bson:"-"`
+	CreatedOn         time.Time           `json:"created_on" bson:"created_on"`
+	ModifiedOn        time.Time           `json:"modified_on" bson:"modified_on"`
+	DeletedAt         *time.Time          `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy         string              `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
 }
 
 // CreateCustomerRequest represents the request body for creating customer
 type CreateCustomerRequest struct {
-	FullName         string `json:"full_name" validate:"required,min=2,max=100"`
-	PhoneNumber      string `json:"phone_number" validate:"required,min=10,max=15"`
-	Email            string `json:"email" validate:"omitempty,email"`
-	Address          string `json:"address" validate:"omitempty,max=255"`
-	Note             string `json:"note" validate:"omitempty,max=500"`
-	WorkstationRange string `json:"workstation_range" validate:"required,oneof=1-10 10-20 20-50 50+"`
+	FullName          string   `json:"full_name" validate:"required,min=2,max=100"`
+	PhoneNumber       string   `json:"phone_number" validate:"required,min=10,max=15"`
+	Email             string   `json:"email" validate:"omitempty,email"`
+	Address           string   `json:"address" validate:"omitempty,max=255"`
+	StructuredAddress *Address `json:"structured_address"`
+	CompanyName       string   `json:"company_name" validate:"omitempty,max=255"`
+	TaxCode           string   `json:"tax_code" validate:"omitempty,taxcode"`
+	Note              string   `json:"note" validate:"omitempty,max=500"`
+	WorkstationRange  string   `json:"workstation_range" validate:"required,oneof=1-10 10-20 20-50 50+"`
 }
 
 // UpdateCustomerRequest represents the request body for updating customer
 type UpdateCustomerRequest struct {
-	FullName         string `json:"full_name" validate:"omitempty,min=2,max=100"`
-	PhoneNumber      string `json:"phone_number" validate:"omitempty,min=10,max=15"`
-	Email            string `json:"email" validate:"omitempty,email"`
-	Address          string `json:"address" validate:"omitempty,max=255"`
-	Note             string `json:"note" validate:"omitempty,max=500"`
-	WorkstationRange string `json:"workstation_range" validate:"omitempty,oneof=1-10 10-20 20-50 50+"`
-	IsActive         *bool  `json:"is_active" validate:"omitempty"`
+	FullName          string   `json:"full_name" validate:"omitempty,min=2,max=100"`
+	PhoneNumber       string   `json:"phone_number" validate:"omitempty,min=10,max=15"`
+	Email             string   `json:"email" validate:"omitempty,email"`
+	Address           string   `json:"address" validate:"omitempty,max=255"`
+	StructuredAddress *Address `json:"structured_address"`
+	CompanyName       string   `json:"company_name" validate:"omitempty,max=255"`
+	TaxCode           string   `json:"tax_code" validate:"omitempty,taxcode"`
+	Note              string   `json:"note" validate:"omitempty,max=500"`
+	WorkstationRange  string   `json:"workstation_range" validate:"omitempty,oneof=1-10 10-20 20-50 50+"`
+	IsActive          *bool    `json:"is_active" validate:"omitempty"`
+}
+
+// CustomerFilter represents the filters shared by customer list and export.
+// ProvinceCodes matches the province code of the structured address.
+type CustomerFilter struct {
+	Search           string
+	WorkstationRange string
+	IsActive         *bool
+	ProvinceCodes    []string
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time
+}
+
+// IsEmpty reports whether no filter is set
+func (f *CustomerFilter) IsEmpty() bool {
+	return f == nil || (f.Search == "" && f.WorkstationRange == "" && f.IsActive == nil &&
+		len(f.ProvinceCodes) == 0 && f.CreatedFrom == nil && f.CreatedTo == nil)
 }
 
 // CustomerRepository represents the customer repository contract
@@ -48,17 +80,33 @@ type CustomerRepository interface {
 	GetByID(ctx context.Context, id string) (*Customer, error)
 	GetByPhone(ctx context.Context, phone string) (*Customer, error)
 	GetByEmail(ctx context.Context, email string) (*Customer, error)
-	GetAll(ctx context.Context, limit, offset int64) ([]*Customer, error)
+	GetByPhones(ctx context.Context, phones []string) ([]*Customer, error)
+	GetAll(ctx context.Context, filter *CustomerFilter, page *Pagination) ([]*Customer, error)
+	Iterate(ctx context.Context, filter *CustomerFilter, fn func(*Customer) error) error
 	Update(ctx context.Context, id string, customer *Customer) error
-	Delete(ctx context.Context, id string) error
-	Count(ctx context.Context) (int64, error)
+	SetStructuredAddress(ctx context.Context, id string, address *Address) error
+	CountByProvince(ctx context.Context) ([]*ProvinceCount, error)
+	BulkSave(ctx context.Context, customers []*Customer) (map[int]error, error)
+	Delete(ctx context.Context, id string, deletedBy string) error
+	GetDeleted(ctx context.Context, page *Pagination) ([]*Customer, error)
+	CountDeleted(ctx context.Context) (int64, error)
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	Count(ctx context.Context, filter *CustomerFilter) (int64, error)
+	EstimatedCount(ctx context.Context) (int64, error)
 }
 
 // CustomerUsecase represents the customer usecase contract
 type CustomerUsecase interface {
 	Create(ctx context.Context, req *CreateCustomerRequest) (*Customer, error)
 	GetByID(ctx context.Context, id string) (*Customer, error)
-	GetAll(ctx context.Context, limit, offset int64) ([]*Customer, int64, error)
-	Update(ctx context.Context, id string, req *UpdateCustomerRequest) (*Customer, error)
-	Delete(ctx context.Context, id string) error
+	GetAll(ctx context.Context, filter *CustomerFilter, page *Pagination) ([]*Customer, *PageInfo, error)
+	Export(ctx context.Context, filter *CustomerFilter, req *ExportRequest, w io.Writer) error
+	CountByProvince(ctx context.Context) ([]*ProvinceCount, error)
+	Import(ctx context.Context, r io.ReaderAt, size int64, opts *ImportOptions) (*ImportReport, error)
+	Update(ctx context.Context, id string, req *UpdateCustomerRequest, actor *Actor) (*Customer, error)
+	Delete(ctx context.Context, id string, deletedBy string) error
+	GetTrash(ctx context.Context, page *Pagination) ([]*Customer, *PageInfo, error)
+	Restore(ctx context.Context, id string) (*Customer, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
@@ -0,0 +1,12 @@
+package domain
+
+import "errors"
+
+// ExportRequest represents the options of a CSV/XLSX export
+type ExportRequest struct {
+	Format  string   `validate:"required,oneof=csv xlsx"`
+	Columns []string // empty means every column, in default order
+}
+
+// ErrInvalidExportColumn is returned when an unknown export column is requested
+var ErrInvalidExportColumn = errors.New("invalid export column")
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
 	"time"
 
@@ -12,9 +13,10 @@ import (
 type FileType string
 
 const (
-	FileTypeDocument FileType = "document"
-	FileTypeVideo    FileType = "video"
-	FileTypeImage    FileType = "image"
+	FileTypeDocument  FileType = "document"
+	FileTypeVideo     FileType = "video"
+	FileTypeImage     FileType = "image"
+	FileTypeInstaller FileType = "installer" // the only type accepting programs and unidentified binaries
 )
 
 // File represents the file entity
@@ -22,27 +24,73 @@ type File struct {
 	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	FileName    string             `json:"file_name" bson:"file_name"`
 	OriginalName string            `json:"original_name" bson:"original_name"`
-	FilePath    string             `json:"file_path" bson:"file_path"`
+	FilePath    string             `json:"file_path" bson:"file_path"` // FileStorage key
 	FileType    FileType           `json:"file_type" bson:"file_type"`
 	MimeType    string             `json:"mime_type" bson:"mime_type"`
 	Size        int64              `json:"size" bson:"size"`
+	SHA256      string             `json:"sha256,omitempty" bson:"sha256,omitempty"` // hex digest of the content, shared with identical files
 	URL         string             `json:"url" bson:"url"`
+	Title       string             `json:"title,omitempty" bson:"title,omitempty"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	Tags        []string           `json:"tags,omitempty" bson:"tags,omitempty"` // lowercase
+	Category    string             `json:"category,omitempty" bson:"category,omitempty"`
+	Folder      string             `json:"folder,omitempty" bson:"folder,omitempty"` // virtual folder path as /a/b, empty at the root
+	ShopID      *primitive.ObjectID `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
+	Visibility  FileVisibility     `json:"visibility,omitempty" bson:"visibility,omitempty"` // empty for files uploaded before visibility, which are public
+	CustomerID  *primitive.ObjectID `json:"customer_id,omitempty" bson:"customer_id,omitempty"` // owner of a customer file
+	HLS         *HLSPackage        `json:"hls,omitempty" bson:"hls,omitempty"` // MP4 videos, once packaging is enabled
+	Variants    map[string]*FileVariant `json:"variants,omitempty" bson:"variants,omitempty"` // resized copies of images, by name
+	Scan        *FileScan          `json:"scan,omitempty" bson:"scan,omitempty"` // uploads, while malware scanning is enabled
 	CreatedOn   time.Time          `json:"created_on" bson:"created_on"`
 }
 
+// IsPublic reports whether a file is served by its URL, without a signed link
+func (f *File) IsPublic() bool {
+	return f.Visibility == "" || f.Visibility == FileVisibilityPublic
+}
+
+// IsQuarantined reports whether a file waits for, or failed, the malware scan.
+// Files stored without a scan are not quarantined.
+func (f *File) IsQuarantined() bool {
+	return f.Scan != nil && f.Scan.Status != ScanStatusClean
+}
+
 // FileRepository represents the file repository contract
 type FileRepository interface {
 	Create(ctx context.Context, file *File) error
 	GetByID(ctx context.Context, id string) (*File, error)
-	GetAll(ctx context.Context, fileType FileType, limit, offset int64) ([]*File, error)
+	GetByFileName(ctx context.Context, fileName string) (*File, error)
+	GetAll(ctx context.Context, filter *FileFilter, page *Pagination) ([]*File, error)
 	Delete(ctx context.Context, id string) error
-	Count(ctx context.Context, fileType FileType) (int64, error)
+	Count(ctx context.Context, filter *FileFilter) (int64, error)
+	Folders(ctx context.Context, filter *FileFilter) ([]*FileFolder, error)
+	UpdateMetadata(ctx context.Context, file *File) error
+	GetByShop(ctx context.Context, shopID primitive.ObjectID) ([]*File, error)
+	SetShop(ctx context.Context, id string, shopID *primitive.ObjectID) error
+	SetAccess(ctx context.Context, id primitive.ObjectID, access *FileAccess, url string) error
+	DetachShop(ctx context.Context, shopID primitive.ObjectID) error
+	ClaimHLS(ctx context.Context, staleBefore time.Time) (*File, error)
+	SetHLS(ctx context.Context, id primitive.ObjectID, hls *HLSPackage) error
+	SetVariant(ctx context.Context, id primitive.ObjectID, name string, variant *FileVariant) error
+	ClaimScan(ctx context.Context, staleBefore time.Time) (*File, error)
+	SetScan(ctx context.Context, id primitive.ObjectID, scan *FileScan) error
 }
 
 // FileUsecase represents the file usecase contract
 type FileUsecase interface {
-	Upload(ctx context.Context, file *multipart.FileHeader, fileType FileType) (*File, error)
+	Upload(ctx context.Context, file *multipart.FileHeader, fileType FileType, access *FileAccess) (*File, error)
+	Ingest(ctx context.Context, name string, content io.Reader, size int64, fileType FileType, access *FileAccess) (*File, error)
+	Store(ctx context.Context, name string, contentType string, fileType FileType, access *FileAccess, content io.Reader) (*File, error)
 	GetByID(ctx context.Context, id string) (*File, error)
-	GetAll(ctx context.Context, fileType FileType, limit, offset int64) ([]*File, int64, error)
+	GetByFileName(ctx context.Context, fileName string) (*File, error)
+	GetAll(ctx context.Context, filter *FileFilter, page *Pagination) ([]*File, *PageInfo, error)
+	Folders(ctx context.Context, filter *FileFilter) ([]*FileFolder, error)
+	UpdateMetadata(ctx context.Context, id string, req *UpdateFileMetadataRequest) (*File, error)
 	Delete(ctx context.Context, id string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, *StoredObject, error)
+	DownloadURL(ctx context.Context, file *File) (string, error)
+	SetVisibility(ctx context.Context, id string, req *FileVisibilityRequest) (*File, error)
+	CreateLink(ctx context.Context, id string, req *CreateFileLinkRequest, actor *Actor) (*FileLink, error)
+	ResolveLink(ctx context.Context, token string, clientIP string, consume bool) (*File, error)
+	OpenVariant(ctx context.Context, id string, name string) (*FileVariant, io.ReadCloser, *StoredObject, error)
 }
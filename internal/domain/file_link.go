@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FileVisibility controls who can download a file
+type FileVisibility string
+
+const (
+	FileVisibilityPublic   FileVisibility = "public"   // served by its URL to anyone
+	FileVisibilityPrivate  FileVisibility = "private"  // served through signed links minted by admins
+	FileVisibilityCustomer FileVisibility = "customer" // a customer's document, served through signed links minted by staff
+)
+
+// FileAccess is the visibility a file is stored with. Nil means public.
+type FileAccess struct {
+	Visibility FileVisibility
+	CustomerID *primitive.ObjectID // set for customer files
+}
+
+// FileVisibilityRequest represents the request to change who can download a file
+type FileVisibilityRequest struct {
+	Visibility FileVisibility `json:"visibility" validate:"required,oneof=public private customer"`
+	CustomerID string         `json:"customer_id" validate:"required_if=Visibility customer"`
+}
+
+// CreateFileLinkRequest represents the request to mint a signed download link
+type CreateFileLinkRequest struct {
+	ExpiresInMinutes int    `json:"expires_in_minutes" validate:"omitempty,min=1,max=10080"` // 60 by default
+	IP               string `json:"ip" validate:"omitempty,ip"`                              // only this client IP can use the link
+	SingleUse        bool   `json:"single_use"`                                              // the link works for one download
+}
+
+// FileLink is a signed, expiring download link of a file
+type FileLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	IP        string    `json:"ip,omitempty"`
+	SingleUse bool      `json:"single_use"`
+}
+
+var (
+	// ErrInvalidFileLink is returned when a link token is malformed or its signature does not match
+	ErrInvalidFileLink = errors.New("invalid download link")
+
+	// ErrFileLinkExpired is returned when a link is past its expiry
+	ErrFileLinkExpired = errors.New("download link has expired")
+
+	// ErrFileLinkUsed is returned when a single-use link was used already
+	ErrFileLinkUsed = errors.New("download link was already used")
+
+	// ErrFileCustomerNotFound is returned when the customer of a customer file does not exist
+	ErrFileCustomerNotFound = errors.New("customer of the file not found")
+
+	// ErrFileLinkIPMismatch is returned when a link bound to an IP is used from another
+	ErrFileLinkIPMismatch = errors.New("download link is bound to another IP address")
+)
+
+// FileLinkRepository records the use of single-use download links
+type FileLinkRepository interface {
+	// Consume records a link as used until it expires, or returns ErrFileLinkUsed
+	Consume(ctx context.Context, nonce string, expiresAt time.Time) error
+}
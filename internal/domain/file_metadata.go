@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxFileTags bounds the tags of a file
+const MaxFileTags = 20
+
+// FileFilter represents the filters of a file list
+type FileFilter struct {
+	FileType   FileType       // empty matches every type
+	Visibility FileVisibility // public also matches files uploaded before visibility
+	Tags       []string       // files carrying all of them
+	Category   string
+	Folder     string // "/" matches files outside any folder
+	Recursive  bool   // include the subfolders of Folder
+	MimeType   string // exact, or a major type such as "image/*"
+	Search     string // substring of the original name or title
+}
+
+// UpdateFileMetadataRequest represents request to update the metadata of a file.
+// Missing fields are kept; empty ones are cleared, and an empty folder moves the
+// file out of any folder.
+type UpdateFileMetadataRequest struct {
+	Title       *string  `json:"title" validate:"omitempty,max=200"`
+	Description *string  `json:"description" validate:"omitempty,max=2000"`
+	Tags        []string `json:"tags" validate:"omitempty,max=20,dive,max=50"`
+	Category    *string  `json:"category" validate:"omitempty,max=100"`
+	Folder      *string  `json:"folder" validate:"omitempty,max=500"`
+}
+
+// FileFolder represents a virtual folder holding files, directly or through
+// its subfolders
+type FileFolder struct {
+	Path  string `json:"path"`
+	Files int64  `json:"files"` // files directly in the folder
+}
+
+// ErrInvalidFolder is returned when a folder path has empty, "." or ".." segments
+var ErrInvalidFolder = errors.New("invalid folder path")
+
+// NormalizeFolder cleans a virtual folder path to the "/a/b" form, with
+// backslashes read as slashes and empty segments dropped. The root folder is
+// empty.
+func NormalizeFolder(folder string) (string, error) {
+	segments := strings.FieldsFunc(strings.ReplaceAll(folder, `\`, "/"), func(r rune) bool {
+		return r == '/'
+	})
+
+	var b strings.Builder
+	for _, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		if segment == "." || segment == ".." {
+			return "", ErrInvalidFolder
+		}
+		b.WriteString("/")
+		b.WriteString(segment)
+	}
+	return b.String(), nil
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty and repeated ones
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
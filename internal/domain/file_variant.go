@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// FileVariant is a resized copy of an image file
+type FileVariant struct {
+	Key       string    `json:"-" bson:"key"` // FileStorage key
+	MimeType  string    `json:"mime_type" bson:"mime_type"`
+	Width     int       `json:"width" bson:"width"`
+	Height    int       `json:"height" bson:"height"`
+	Size      int64     `json:"size" bson:"size"`
+	MaxSize   int       `json:"max_size" bson:"max_size"` // the configured bound it was made for, it is made again when that changes
+	URL       string    `json:"url" bson:"url"`
+	CreatedOn time.Time `json:"created_on" bson:"created_on"`
+}
+
+// IsVariantSource reports whether resized variants can be made of a content type
+func IsVariantSource(mimeType string) bool {
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
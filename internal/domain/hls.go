@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// HLSStatus represents the state of the HLS packaging of a video
+type HLSStatus string
+
+const (
+	HLSStatusPending    HLSStatus = "pending" // waiting for the packaging job
+	HLSStatusProcessing HLSStatus = "processing"
+	HLSStatusReady      HLSStatus = "ready"
+	HLSStatusFailed     HLSStatus = "failed"
+)
+
+// HLSPlaylistName is the name of the playlist among the files of an HLS package
+const HLSPlaylistName = "index.m3u8"
+
+// HLSPackage represents the HLS playlist and segments of an MP4 video, which
+// players fetch a few seconds at a time instead of the whole file
+type HLSPackage struct {
+	Status      HLSStatus `json:"status" bson:"status"`
+	PlaylistURL string    `json:"playlist_url,omitempty" bson:"playlist_url,omitempty"`
+	Segments    int       `json:"segments,omitempty" bson:"segments,omitempty"`
+	Error       string    `json:"error,omitempty" bson:"error,omitempty"`
+	UpdatedOn   time.Time `json:"updated_on" bson:"updated_on"`
+}
+
+// HLSMimeType is the only video type packaged as HLS
+const HLSMimeType = "video/mp4"
+
+// ErrHLSInProgress is returned when requeueing a video while it is being packaged
+var ErrHLSInProgress = errors.New("video is being packaged")
+
+// Transcoder segments videos into HLS playlists
+type Transcoder interface {
+	// SegmentHLS writes HLSPlaylistName and the segments it lists into
+	// outputDir, from the video file at input
+	SegmentHLS(ctx context.Context, input string, outputDir string, segmentDuration time.Duration) error
+}
+
+// HLSUsecase represents the HLS packaging usecase contract
+type HLSUsecase interface {
+	PackagePending(ctx context.Context) (int, error)
+	Requeue(ctx context.Context, fileID string) (*File, error)
+	Open(ctx context.Context, fileID string, name string) (io.ReadCloser, *StoredObject, error)
+}
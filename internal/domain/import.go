@@ -0,0 +1,72 @@
+package domain
+
+import "errors"
+
+// ImportRowStatus represents the outcome of one imported row
+type ImportRowStatus string
+
+const (
+	ImportRowCreated ImportRowStatus = "created"
+	ImportRowUpdated ImportRowStatus = "updated"
+	ImportRowSkipped ImportRowStatus = "skipped"
+	ImportRowError   ImportRowStatus = "error"
+)
+
+// Duplicate handling for rows whose key already exists
+const (
+	ImportOnDuplicateUpdate = "update"
+	ImportOnDuplicateSkip   = "skip"
+)
+
+// ImportOptions represents the options of a bulk import
+type ImportOptions struct {
+	Format      string `validate:"required,oneof=csv xlsx"`
+	DryRun      bool
+	OnDuplicate string `validate:"required,oneof=update skip"`
+}
+
+// ImportRowResult represents the result of one row of an import
+type ImportRowResult struct {
+	Row     int             `json:"row"`
+	Status  ImportRowStatus `json:"status"`
+	Key     string          `json:"key,omitempty"`
+	ID      string          `json:"id,omitempty"`
+	Errors  []string        `json:"errors,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// ImportReport represents the result of a bulk import.
+// In dry-run mode the statuses describe what a commit would do.
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// Add records a row result and updates the counters
+func (r *ImportReport) Add(result ImportRowResult) {
+	r.Total++
+	switch result.Status {
+	case ImportRowCreated:
+		r.Created++
+	case ImportRowUpdated:
+		r.Updated++
+	case ImportRowSkipped:
+		r.Skipped++
+	case ImportRowError:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+var (
+	// ErrImportMissingColumns is returned when required columns are missing from the header row
+	ErrImportMissingColumns = errors.New("import file is missing required columns")
+
+	// ErrImportTooManyRows is returned when the file exceeds the row limit
+	ErrImportTooManyRows = errors.New("import file has too many rows")
+)
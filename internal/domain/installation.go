@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InstallationStatus represents the activation state of a cafe client installation
+type InstallationStatus string
+
+const (
+	InstallationPending InstallationStatus = "pending" // created by an admin, waiting for the client to activate
+	InstallationActive  InstallationStatus = "active"
+)
+
+// WorkstationRangeSeats maps a customer's workstation range to the seats allowed
+// when an admin has not set a limit on the installation
+var WorkstationRangeSeats = map[string]int{
+	"1-10":  10,
+	"10-20": 20,
+	"20-50": 50,
+	"50+":   100,
+}
+
+// Installation represents the iCafe client installed at a customer's cafe.
+// AllowedSeats, Stale and OverLimit are computed on read.
+type Installation struct {
+	ID                 primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	CustomerID         primitive.ObjectID  `json:"customer_id" bson:"customer_id"`
+	ShopID             *primitive.ObjectID `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
+	Name               string              `json:"name" bson:"name"`
+	Status             InstallationStatus  `json:"status" bson:"status"`
+	ActivationCode     string              `json:"activation_code,omitempty" bson:"activation_code"`
+	SeatLimit          *int                `json:"seat_limit,omitempty" bson:"seat_limit,omitempty"`
+	AllowedSeats       int                 `json:"allowed_seats" bson:"-"`
+	Fingerprint        string              `json:"fingerprint,omitempty" bson:"fingerprint,omitempty"`
+	TokenHash          string              `json:"-" bson:"token_hash,omitempty"`
+	Version            string              `json:"version,omitempty" bson:"version,omitempty"`
+	ActiveWorkstations int                 `json:"active_workstations" bson:"active_workstations"`
+	ActivatedAt        *time.Time          `json:"activated_at,omitempty" bson:"activated_at,omitempty"`
+	LastSeenAt         *time.Time          `json:"last_seen_at,omitempty" bson:"last_seen_at,omitempty"`
+	Stale              bool                `json:"stale" bson:"-"`
+	OverLimit          bool                `json:"over_limit" bson:"-"`
+	CreatedOn          time.Time           `json:"created_on" bson:"created_on"`
+	ModifiedOn         time.Time           `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateInstallationRequest represents the request body for registering an installation.
+// Without seat_limit the seats follow the customer's workstation range.
+type CreateInstallationRequest struct {
+	Name      string `json:"name" validate:"required,min=2,max=100"`
+	SeatLimit *int   `json:"seat_limit" validate:"omitempty,min=1,max=10000"`
+	ShopID    string `json:"shop_id" validate:"omitempty"`
+}
+
+// UpdateInstallationRequest represents the request body for updating an installation.
+// A seat_limit of 0 removes the admin limit and an empty shop_id detaches the shop.
+type UpdateInstallationRequest struct {
+	Name      string  `json:"name" validate:"omitempty,min=2,max=100"`
+	SeatLimit *int    `json:"seat_limit" validate:"omitempty,min=0,max=10000"`
+	ShopID    *string `json:"shop_id"`
+}
+
+// ActivateInstallationRequest represents the request body sent by a client on first start
+type ActivateInstallationRequest struct {
+	ActivationCode string `json:"activation_code" validate:"required"`
+	Fingerprint    string `json:"fingerprint" validate:"required,min=8,max=256"`
+	Version        string `json:"version" validate:"omitempty,max=50"`
+}
+
+// HeartbeatRequest represents the periodic status report of a client
+type HeartbeatRequest struct {
+	InstallationID     string `json:"installation_id" validate:"required"`
+	Token              string `json:"token" validate:"required"`
+	Version            string `json:"version" validate:"omitempty,max=50"`
+	ActiveWorkstations int    `json:"active_workstations" validate:"min=0"`
+}
+
+// InstallationSession is returned to a client after activation and on each heartbeat
+type InstallationSession struct {
+	InstallationID    string `json:"installation_id"`
+	Token             string `json:"token,omitempty"` // only returned on activation
+	AllowedSeats      int    `json:"allowed_seats"`
+	HeartbeatInterval int    `json:"heartbeat_interval"` // in seconds
+}
+
+// CustomerInstallations groups the installations needing attention by customer
+type CustomerInstallations struct {
+	CustomerID    string          `json:"customer_id"`
+	CustomerName  string          `json:"customer_name"`
+	Installations []*Installation `json:"installations"`
+}
+
+var (
+	// ErrInvalidActivationCode is returned when an activation code does not match a pending installation
+	ErrInvalidActivationCode = errors.New("invalid activation code")
+
+	// ErrInstallationActivated is returned when an installation is already bound to another machine
+	ErrInstallationActivated = errors.New("installation already activated on another machine")
+
+	// ErrInvalidInstallationToken is returned when a heartbeat token does not match
+	ErrInvalidInstallationToken = errors.New("invalid installation token")
+
+	// ErrSeatLimitExceeded is returned when a client reports more workstations than allowed
+	ErrSeatLimitExceeded = errors.New("active workstations exceed allowed seats")
+)
+
+// InstallationRepository represents the installation repository contract
+type InstallationRepository interface {
+	Create(ctx context.Context, installation *Installation) error
+	GetByID(ctx context.Context, id string) (*Installation, error)
+	GetByActivationCode(ctx context.Context, code string) (*Installation, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Installation, error)
+	GetActive(ctx context.Context) ([]*Installation, error)
+	Update(ctx context.Context, installation *Installation) error
+	RecordHeartbeat(ctx context.Context, id primitive.ObjectID, version string, activeWorkstations int, seenAt time.Time) error
+	Delete(ctx context.Context, id string) error
+	DetachShop(ctx context.Context, shopID primitive.ObjectID) error
+}
+
+// InstallationUsecase represents the installation usecase contract
+type InstallationUsecase interface {
+	Create(ctx context.Context, customerID string, req *CreateInstallationRequest) (*Installation, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Installation, error)
+	GetAttention(ctx context.Context) ([]*CustomerInstallations, error)
+	Update(ctx context.Context, id string, req *UpdateInstallationRequest) (*Installation, error)
+	Reset(ctx context.Context, id string) (*Installation, error)
+	Delete(ctx context.Context, id string) error
+	Activate(ctx context.Context, req *ActivateInstallationRequest) (*InstallationSession, error)
+	Heartbeat(ctx context.Context, req *HeartbeatRequest) (*InstallationSession, error)
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InvoiceStatus represents the state of an invoice
+type InvoiceStatus string
+
+const (
+	InvoiceDraft  InvoiceStatus = "draft"  // editable, has no number yet
+	InvoiceIssued InvoiceStatus = "issued" // numbered, awaiting payment
+	InvoicePaid   InvoiceStatus = "paid"
+	InvoiceVoid   InvoiceStatus = "void" // cancelled, keeps its number
+)
+
+// InvoiceItem represents a line of an invoice. Amounts are in VND, before VAT.
+type InvoiceItem struct {
+	Description string `json:"description" bson:"description" validate:"required,max=255"`
+	Unit        string `json:"unit,omitempty" bson:"unit,omitempty" validate:"omitempty,max=20"`
+	Quantity    int64  `json:"quantity" bson:"quantity" validate:"required,min=1,max=1000000"`
+	UnitPrice   int64  `json:"unit_price" bson:"unit_price" validate:"min=0,max=1000000000000"`
+	Amount      int64  `json:"amount" bson:"amount"`
+}
+
+// InvoiceBuyer holds the buyer details printed on an invoice. They are copied from
+// the customer when the invoice is drafted and can be edited until it is issued.
+type InvoiceBuyer struct {
+	Name        string `json:"name" bson:"name" validate:"omitempty,max=100"`
+	CompanyName string `json:"company_name,omitempty" bson:"company_name,omitempty" validate:"omitempty,max=255"`
+	TaxCode     string `json:"tax_code,omitempty" bson:"tax_code,omitempty" validate:"omitempty,taxcode"`
+	Address     string `json:"address,omitempty" bson:"address,omitempty" validate:"omitempty,max=255"`
+	Email       string `json:"email,omitempty" bson:"email,omitempty" validate:"omitempty,email"`
+	Phone       string `json:"phone,omitempty" bson:"phone,omitempty" validate:"omitempty,max=15"`
+}
+
+// Invoice represents a VAT invoice issued to a customer.
+// Issued invoices are numbered per series without gaps and are never deleted;
+// a voided invoice keeps its number.
+type Invoice struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Series         string              `json:"series,omitempty" bson:"series,omitempty"`
+	Sequence       int64               `json:"sequence,omitempty" bson:"sequence,omitempty"`
+	Number         string              `json:"number,omitempty" bson:"number,omitempty"`
+	CustomerID     primitive.ObjectID  `json:"customer_id" bson:"customer_id"`
+	SubscriptionID *primitive.ObjectID `json:"subscription_id,omitempty" bson:"subscription_id,omitempty"`
+	Buyer          InvoiceBuyer        `json:"buyer" bson:"buyer"`
+	Items          []InvoiceItem       `json:"items" bson:"items"`
+	Subtotal       int64               `json:"subtotal" bson:"subtotal"`
+	VATRate        int                 `json:"vat_rate" bson:"vat_rate"` // percent
+	VATAmount      int64               `json:"vat_amount" bson:"vat_amount"`
+	Total          int64               `json:"total" bson:"total"`
+	Status         InvoiceStatus       `json:"status" bson:"status"`
+	Note           string              `json:"note,omitempty" bson:"note,omitempty"`
+	FileID         *primitive.ObjectID `json:"file_id,omitempty" bson:"file_id,omitempty"` // PDF of the issued invoice
+	IssuedAt       *time.Time          `json:"issued_at,omitempty" bson:"issued_at,omitempty"`
+	PaidAt         *time.Time          `json:"paid_at,omitempty" bson:"paid_at,omitempty"`
+	PaymentRef     string              `json:"payment_ref,omitempty" bson:"payment_ref,omitempty"`
+	VoidedAt       *time.Time          `json:"voided_at,omitempty" bson:"voided_at,omitempty"`
+	VoidReason     string              `json:"void_reason,omitempty" bson:"void_reason,omitempty"`
+	CreatedBy      string              `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedOn      time.Time           `json:"created_on" bson:"created_on"`
+	ModifiedOn     time.Time           `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateInvoiceRequest represents the request body for drafting an invoice.
+// Buyer fields left empty are filled from the customer; VATRate defaults to the configured rate.
+type CreateInvoiceRequest struct {
+	Buyer   InvoiceBuyer  `json:"buyer"`
+	Items   []InvoiceItem `json:"items" validate:"required,min=1,max=100,dive"`
+	VATRate *int          `json:"vat_rate" validate:"omitempty,oneof=0 5 8 10"`
+	Note    string        `json:"note" validate:"omitempty,max=500"`
+}
+
+// UpdateInvoiceRequest represents the request body for editing a draft invoice
+type UpdateInvoiceRequest struct {
+	Buyer   *InvoiceBuyer `json:"buyer"`
+	Items   []InvoiceItem `json:"items" validate:"omitempty,min=1,max=100,dive"`
+	VATRate *int          `json:"vat_rate" validate:"omitempty,oneof=0 5 8 10"`
+	Note    *string       `json:"note" validate:"omitempty,max=500"`
+}
+
+// PayInvoiceRequest represents the request body for recording the payment of an invoice
+type PayInvoiceRequest struct {
+	PaidAt     *time.Time `json:"paid_at"`
+	PaymentRef string     `json:"payment_ref" validate:"omitempty,max=100"`
+}
+
+// VoidInvoiceRequest represents the request body for voiding an invoice
+type VoidInvoiceRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// InvoiceFilter represents the filters of the invoice list
+type InvoiceFilter struct {
+	Search      string // number or buyer name
+	CustomerID  *primitive.ObjectID
+	Status      InvoiceStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+var (
+	// ErrInvoiceNotDraft is returned when editing, issuing or deleting an invoice that is no longer a draft
+	ErrInvoiceNotDraft = errors.New("invoice is not a draft")
+
+	// ErrInvoiceStatus is returned when an invoice cannot move to the requested status
+	ErrInvoiceStatus = errors.New("invoice status does not allow this change")
+
+	// ErrInvoiceNumberConflict is returned when a number could not be assigned because of concurrent issues
+	ErrInvoiceNumberConflict = errors.New("could not assign an invoice number, try again")
+
+	// ErrInvoiceBuyerRequired is returned when issuing an invoice without a buyer name or company
+	ErrInvoiceBuyerRequired = errors.New("invoice buyer name or company is required")
+
+	// ErrInvoiceAmountTooLarge is returned when the amounts of an invoice are too large to compute
+	ErrInvoiceAmountTooLarge = errors.New("invoice amounts are too large")
+)
+
+// InvoiceRepository represents the invoice repository contract
+type InvoiceRepository interface {
+	Create(ctx context.Context, invoice *Invoice) error
+	GetByID(ctx context.Context, id string) (*Invoice, error)
+	GetAll(ctx context.Context, filter *InvoiceFilter, page *Pagination) ([]*Invoice, error)
+	Count(ctx context.Context, filter *InvoiceFilter) (int64, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Invoice, error)
+	UpdateDraft(ctx context.Context, invoice *Invoice) error
+	Issue(ctx context.Context, invoice *Invoice) error
+	UpdateStatus(ctx context.Context, invoice *Invoice, from InvoiceStatus) error
+	SetFile(ctx context.Context, id primitive.ObjectID, fileID primitive.ObjectID) error
+	DeleteDraft(ctx context.Context, id string) error
+}
+
+// InvoiceUsecase represents the invoice usecase contract
+type InvoiceUsecase interface {
+	Create(ctx context.Context, customerID string, req *CreateInvoiceRequest, actor *Actor) (*Invoice, error)
+	CreateForSubscription(ctx context.Context, subscriptionID string, actor *Actor) (*Invoice, error)
+	GetByID(ctx context.Context, id string) (*Invoice, error)
+	GetAll(ctx context.Context, filter *InvoiceFilter, page *Pagination) ([]*Invoice, *PageInfo, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Invoice, error)
+	Update(ctx context.Context, id string, req *UpdateInvoiceRequest) (*Invoice, error)
+	Issue(ctx context.Context, id string) (*Invoice, error)
+	MarkPaid(ctx context.Context, id string, req *PayInvoiceRequest) (*Invoice, error)
+	Void(ctx context.Context, id string, req *VoidInvoiceRequest) (*Invoice, error)
+	Delete(ctx context.Context, id string) error
+	RenderPDF(ctx context.Context, id string, w io.Writer) (*Invoice, error)
+	RegeneratePDF(ctx context.Context, id string) (*Invoice, error)
+}
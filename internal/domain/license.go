@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LicensePlan represents the edition of the iCafe software a license grants
+type LicensePlan string
+
+const (
+	LicensePlanBasic      LicensePlan = "basic"
+	LicensePlanPro        LicensePlan = "pro"
+	LicensePlanEnterprise LicensePlan = "enterprise"
+)
+
+// LicenseStatus represents the administrative state of a license
+type LicenseStatus string
+
+const (
+	LicenseActive    LicenseStatus = "active"
+	LicenseSuspended LicenseStatus = "suspended"
+	LicenseRevoked   LicenseStatus = "revoked" // final, a revoked license cannot be changed
+)
+
+// License represents a software license held by a customer.
+// Key is a signed token carrying the plan, seats and expiry; it is re-signed
+// whenever those terms change.
+type License struct {
+	ID           primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	CustomerID   primitive.ObjectID  `json:"customer_id" bson:"customer_id"`
+	ShopID       *primitive.ObjectID `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
+	Plan         LicensePlan         `json:"plan" bson:"plan"`
+	Seats        int                 `json:"seats" bson:"seats"`
+	Status       LicenseStatus       `json:"status" bson:"status"`
+	Expired      bool                `json:"expired" bson:"-"`
+	Key          string              `json:"key" bson:"key"`
+	IssuedAt     time.Time           `json:"issued_at" bson:"issued_at"`
+	ExpiresAt    time.Time           `json:"expires_at" bson:"expires_at"`
+	StatusReason string              `json:"status_reason,omitempty" bson:"status_reason,omitempty"`
+	Note         string              `json:"note,omitempty" bson:"note,omitempty"`
+	IssuedBy     string              `json:"issued_by,omitempty" bson:"issued_by,omitempty"`
+	CreatedOn    time.Time           `json:"created_on" bson:"created_on"`
+	ModifiedOn   time.Time           `json:"modified_on" bson:"modified_on"`
+}
+
+// IssueLicenseRequest represents the request body for issuing a license
+type IssueLicenseRequest struct {
+	Plan      LicensePlan `json:"plan" validate:"required,oneof=basic pro enterprise"`
+	Seats     int         `json:"seats" validate:"required,min=1,max=10000"`
+	ExpiresAt time.Time   `json:"expires_at" validate:"required"`
+	Note      string      `json:"note" validate:"omitempty,max=500"`
+	ShopID    string      `json:"shop_id" validate:"omitempty"`
+}
+
+// ExtendLicenseRequest represents the request body for extending a license
+type ExtendLicenseRequest struct {
+	ExpiresAt time.Time `json:"expires_at" validate:"required"`
+	Seats     int       `json:"seats" validate:"omitempty,min=1,max=10000"`
+}
+
+// LicenseStatusRequest represents the request body for suspending or revoking a license
+type LicenseStatusRequest struct {
+	Reason string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// VerifyLicenseRequest represents the request body for checking a license key online
+type VerifyLicenseRequest struct {
+	Key string `json:"key" validate:"required"`
+}
+
+// LicenseVerification is the result of an online license key check
+type LicenseVerification struct {
+	Valid     bool          `json:"valid"`
+	Status    LicenseStatus `json:"status,omitempty"`
+	Expired   bool          `json:"expired"`
+	LicenseID string        `json:"license_id,omitempty"`
+	Plan      LicensePlan   `json:"plan,omitempty"`
+	Seats     int           `json:"seats,omitempty"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+}
+
+// LicensePublicKey describes the key clients use to verify license keys offline
+type LicensePublicKey struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
+
+var (
+	// ErrInvalidLicenseKey is returned when a license key is malformed or not signed by us
+	ErrInvalidLicenseKey = errors.New("invalid license key")
+
+	// ErrLicenseRevoked is returned when changing a revoked license
+	ErrLicenseRevoked = errors.New("license is revoked")
+
+	// ErrInvalidLicenseExpiry is returned when an expiry date is not in the future
+	ErrInvalidLicenseExpiry = errors.New("license expiry must be in the future")
+)
+
+// LicenseRepository represents the license repository contract
+type LicenseRepository interface {
+	Create(ctx context.Context, license *License) error
+	GetByID(ctx context.Context, id string) (*License, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*License, error)
+	Update(ctx context.Context, license *License) error
+	DetachShop(ctx context.Context, shopID primitive.ObjectID) error
+}
+
+// LicenseUsecase represents the license usecase contract
+type LicenseUsecase interface {
+	Issue(ctx context.Context, customerID string, req *IssueLicenseRequest, actor *Actor) (*License, error)
+	GetByID(ctx context.Context, id string) (*License, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*License, error)
+	Extend(ctx context.Context, id string, req *ExtendLicenseRequest) (*License, error)
+	Suspend(ctx context.Context, id string, req *LicenseStatusRequest) (*License, error)
+	Resume(ctx context.Context, id string) (*License, error)
+	Revoke(ctx context.Context, id string, req *LicenseStatusRequest) (*License, error)
+	Verify(ctx context.Context, key string) (*LicenseVerification, error)
+	PublicKey() *LicensePublicKey
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// Notification represents a message sent to a customer, or to staff when
+// CustomerID is empty
+type Notification struct {
+	CustomerID string `json:"customer_id"`
+	Name       string `json:"name"`
+	Email      string `json:"email,omitempty"`
+	Phone      string `json:"phone,omitempty"`
+	Subject    string `json:"subject"`
+	Body       string `json:"body"`
+}
+
+// ErrNoRecipient is returned when a notification cannot be delivered for lack of a contact
+var ErrNoRecipient = errors.New("notification has no recipient")
+
+// Notifier delivers notifications to customers and staff
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DefaultPageSize is used when the client does not provide a limit
+	DefaultPageSize int64 = 10
+
+	// MaxPageSize is the upper bound for a single page of results
+	MaxPageSize int64 = 100
+)
+
+// CountMode controls how the total number of documents is computed for a list call
+type CountMode string
+
+const (
+	CountExact     CountMode = "exact"     // CountDocuments with the list filter
+	CountEstimated CountMode = "estimated" // collection metadata, ignores filters
+	CountNone      CountMode = "none"      // skip counting entirely
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Cursor is the keyset position of the last item of a page.
+// List queries are sorted by created_on desc, _id desc, so the pair is unique.
+type Cursor struct {
+	CreatedOn time.Time
+	ID        primitive.ObjectID
+}
+
+// Pagination holds the paging parameters of a list query.
+// When Cursor is set, Offset is ignored and the query continues after the cursor.
+type Pagination struct {
+	Limit  int64
+	Offset int64
+	Cursor *Cursor
+	Count  CountMode
+}
+
+// PageInfo holds paging information of a list result
+type PageInfo struct {
+	Total          int64
+	TotalEstimated bool
+	Counted        bool
+	NextCursor     string
+}
+
+// NewPagination builds a pagination with the limit clamped to [1, MaxPageSize].
+// The count mode defaults to exact for offset paging and none for cursor paging.
+func NewPagination(limit, offset int64, cursor string, count CountMode) (*Pagination, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	page := &Pagination{
+		Limit:  limit,
+		Offset: offset,
+		Count:  count,
+	}
+
+	if cursor != "" {
+		decoded, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		page.Cursor = decoded
+		page.Offset = 0
+	}
+
+	switch page.Count {
+	case CountExact, CountEstimated, CountNone:
+	case "":
+		page.Count = CountExact
+		if page.Cursor != nil {
+			page.Count = CountNone
+		}
+	default:
+		return nil, ErrInvalidInput
+	}
+
+	return page, nil
+}
+
+// NextCursorAfter returns the cursor for the page following a result of size n,
+// or an empty string when the result did not fill the page.
+func (p *Pagination) NextCursorAfter(n int, createdOn time.Time, id primitive.ObjectID) string {
+	if n == 0 || int64(n) < p.Limit {
+		return ""
+	}
+	return EncodeCursor(&Cursor{CreatedOn: createdOn, ID: id})
+}
+
+// cursorPayload is the JSON form of a cursor before base64 encoding
+type cursorPayload struct {
+	T  int64  `json:"t"`
+	ID string `json:"id"`
+}
+
+// EncodeCursor encodes a cursor into an opaque URL-safe string
+func EncodeCursor(c *Cursor) string {
+	data, _ := json.Marshal(cursorPayload{
+		T:  c.CreatedOn.UnixMilli(),
+		ID: c.ID.Hex(),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor produced by EncodeCursor
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := primitive.ObjectIDFromHex(payload.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{
+		CreatedOn: time.UnixMilli(payload.T),
+		ID:        id,
+	}, nil
+}
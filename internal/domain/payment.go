@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PaymentStatus represents the state of an online payment
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "pending" // waiting for the gateway callback
+	PaymentSucceeded PaymentStatus = "succeeded"
+	PaymentFailed    PaymentStatus = "failed" // cancelled by the customer or declined
+)
+
+// Payment represents an online payment of a subscription renewal. The renewal terms
+// and amount are fixed when the payment is created; the period is the one the
+// payment is expected to cover, and the one it renewed once it succeeded.
+type Payment struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TxnRef           string             `json:"txn_ref" bson:"txn_ref"` // reference sent to the gateway
+	Provider         string             `json:"provider" bson:"provider"`
+	CustomerID       primitive.ObjectID `json:"customer_id" bson:"customer_id"`
+	SubscriptionID   primitive.ObjectID `json:"subscription_id" bson:"subscription_id"`
+	PlanID           primitive.ObjectID `json:"plan_id" bson:"plan_id"`
+	PlanName         string             `json:"plan_name" bson:"plan_name"`
+	WorkstationRange string             `json:"workstation_range" bson:"workstation_range"`
+	BillingCycle     BillingCycle       `json:"billing_cycle" bson:"billing_cycle"`
+	Amount           int64              `json:"amount" bson:"amount"` // in VND
+	Description      string             `json:"description" bson:"description"`
+	Status           PaymentStatus      `json:"status" bson:"status"`
+	PaymentURL       string             `json:"payment_url,omitempty" bson:"payment_url,omitempty"`
+	PeriodStart      time.Time          `json:"period_start" bson:"period_start"`
+	PeriodEnd        time.Time          `json:"period_end" bson:"period_end"`
+	ExpiresAt        time.Time          `json:"expires_at" bson:"expires_at"`
+	TransactionNo    string             `json:"transaction_no,omitempty" bson:"transaction_no,omitempty"` // gateway transaction number
+	BankCode         string             `json:"bank_code,omitempty" bson:"bank_code,omitempty"`
+	ResponseCode     string             `json:"response_code,omitempty" bson:"response_code,omitempty"`
+	PaidAt           *time.Time         `json:"paid_at,omitempty" bson:"paid_at,omitempty"`
+	RenewedAt        *time.Time         `json:"renewed_at,omitempty" bson:"renewed_at,omitempty"` // when the subscription was renewed
+	CreatedBy        string             `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedOn        time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn       time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// CreatePaymentRequest represents the request body for creating a renewal payment link.
+// BillingCycle defaults to the cycle of the subscription.
+type CreatePaymentRequest struct {
+	BillingCycle BillingCycle `json:"billing_cycle" validate:"omitempty,oneof=monthly quarterly yearly"`
+}
+
+// PaymentFilter represents the filters of the payment list
+type PaymentFilter struct {
+	CustomerID  *primitive.ObjectID
+	Status      PaymentStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// GatewayResult represents the verified outcome of a payment reported by a gateway
+type GatewayResult struct {
+	TxnRef        string
+	Amount        int64
+	Success       bool
+	TransactionNo string
+	BankCode      string
+	ResponseCode  string
+	PaidAt        *time.Time
+}
+
+var (
+	// ErrPaymentUnavailable is returned when no payment gateway is configured
+	ErrPaymentUnavailable = errors.New("online payment is not configured")
+
+	// ErrInvalidSignature is returned when a gateway callback is not correctly signed
+	ErrInvalidSignature = errors.New("invalid payment signature")
+
+	// ErrPaymentAmountMismatch is returned when a gateway reports another amount than the payment
+	ErrPaymentAmountMismatch = errors.New("payment amount does not match")
+
+	// ErrPaymentProcessed is returned when a gateway reports the outcome of a payment already recorded
+	ErrPaymentProcessed = errors.New("payment already processed")
+)
+
+// PaymentGateway redirects customers to an online payment provider and verifies its callbacks
+type PaymentGateway interface {
+	// Name identifies the provider on recorded payments
+	Name() string
+	// PaymentURL returns the signed URL the customer pays a payment at
+	PaymentURL(payment *Payment, clientIP string) (string, error)
+	// VerifyCallback checks the signature of callback parameters and returns the outcome
+	VerifyCallback(params url.Values) (*GatewayResult, error)
+}
+
+// PaymentRepository represents the payment repository contract
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *Payment) error
+	GetByID(ctx context.Context, id string) (*Payment, error)
+	GetByTxnRef(ctx context.Context, txnRef string) (*Payment, error)
+	GetAll(ctx context.Context, filter *PaymentFilter, page *Pagination) ([]*Payment, error)
+	Count(ctx context.Context, filter *PaymentFilter) (int64, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Payment, error)
+	Complete(ctx context.Context, payment *Payment) error
+	SetRenewal(ctx context.Context, payment *Payment) error
+}
+
+// PaymentUsecase represents the payment usecase contract
+type PaymentUsecase interface {
+	Create(ctx context.Context, subscriptionID string, req *CreatePaymentRequest, clientIP string, actor *Actor) (*Payment, error)
+	GetByID(ctx context.Context, id string) (*Payment, error)
+	GetAll(ctx context.Context, filter *PaymentFilter, page *Pagination) ([]*Payment, *PageInfo, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Payment, error)
+	HandleCallback(ctx context.Context, params url.Values) (*Payment, error)
+	GetReturnResult(ctx context.Context, params url.Values) (*Payment, error)
+}
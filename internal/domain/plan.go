@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PlanPrice represents the monthly price of a plan for one workstation tier, in VND
+type PlanPrice struct {
+	WorkstationRange string `json:"workstation_range" bson:"workstation_range" validate:"required,oneof=1-10 10-20 20-50 50+"`
+	MonthlyPrice     int64  `json:"monthly_price" bson:"monthly_price" validate:"min=0"`
+}
+
+// SeatPrice represents one band of the graduated per-seat price of a plan: the monthly
+// price of each seat up to UpTo, in VND. UpTo is 0 for the last, open-ended band.
+type SeatPrice struct {
+	UpTo         int   `json:"up_to" bson:"up_to" validate:"min=0"`
+	PricePerSeat int64 `json:"price_per_seat" bson:"price_per_seat" validate:"min=0"`
+}
+
+// Plan represents a subscription plan priced per workstation tier. Quotes use the
+// per-seat bands when the plan has them, and the tier price otherwise.
+type Plan struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Code        string             `json:"code" bson:"code"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	Prices      []PlanPrice        `json:"prices" bson:"prices"`
+	SeatPrices  []SeatPrice        `json:"seat_prices,omitempty" bson:"seat_prices,omitempty"`
+	IsActive    bool               `json:"is_active" bson:"is_active"`
+	CreatedOn   time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn  time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// MonthlyPrice returns the monthly price of a workstation tier
+func (p *Plan) MonthlyPrice(workstationRange string) (int64, bool) {
+	for _, price := range p.Prices {
+		if price.WorkstationRange == workstationRange {
+			return price.MonthlyPrice, true
+		}
+	}
+	return 0, false
+}
+
+// CreatePlanRequest represents the request body for creating a plan
+type CreatePlanRequest struct {
+	Code        string      `json:"code" validate:"required,min=2,max=50,alphanum"`
+	Name        string      `json:"name" validate:"required,min=2,max=100"`
+	Description string      `json:"description" validate:"omitempty,max=500"`
+	Prices      []PlanPrice `json:"prices" validate:"required,min=1,dive"`
+	SeatPrices  []SeatPrice `json:"seat_prices" validate:"omitempty,max=20,dive"`
+}
+
+// UpdatePlanRequest represents the request body for updating a plan.
+// Prices and SeatPrices replace the whole list; existing subscriptions keep their price.
+type UpdatePlanRequest struct {
+	Name        string      `json:"name" validate:"omitempty,min=2,max=100"`
+	Description string      `json:"description" validate:"omitempty,max=500"`
+	Prices      []PlanPrice `json:"prices" validate:"omitempty,min=1,dive"`
+	SeatPrices  []SeatPrice `json:"seat_prices" validate:"omitempty,max=20,dive"`
+	IsActive    *bool       `json:"is_active" validate:"omitempty"`
+}
+
+var (
+	// ErrPlanCodeExists is returned when a plan code is already used
+	ErrPlanCodeExists = errors.New("plan code already exists")
+
+	// ErrDuplicatePlanPrice is returned when a plan lists a workstation tier twice
+	ErrDuplicatePlanPrice = errors.New("plan lists a workstation range more than once")
+
+	// ErrInvalidSeatPrices is returned when per-seat bands are not in increasing order
+	// or the open-ended band is not the last one
+	ErrInvalidSeatPrices = errors.New("seat prices must have increasing limits and end with an open band")
+)
+
+// PlanRepository represents the plan repository contract
+type PlanRepository interface {
+	Create(ctx context.Context, plan *Plan) error
+	GetByID(ctx context.Context, id string) (*Plan, error)
+	GetAll(ctx context.Context, activeOnly bool) ([]*Plan, error)
+	Update(ctx context.Context, plan *Plan) error
+}
+
+// PlanUsecase represents the plan usecase contract
+type PlanUsecase interface {
+	Create(ctx context.Context, req *CreatePlanRequest) (*Plan, error)
+	GetByID(ctx context.Context, id string) (*Plan, error)
+	GetAll(ctx context.Context, activeOnly bool) ([]*Plan, error)
+	Update(ctx context.Context, id string, req *UpdatePlanRequest) (*Plan, error)
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AddOn represents an optional module sold on top of a plan, priced per month in VND,
+// either once per cafe or for each seat
+type AddOn struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Code         string             `json:"code" bson:"code"`
+	Name         string             `json:"name" bson:"name"`
+	Description  string             `json:"description,omitempty" bson:"description,omitempty"`
+	MonthlyPrice int64              `json:"monthly_price" bson:"monthly_price"`
+	PerSeat      bool               `json:"per_seat" bson:"per_seat"`
+	IsActive     bool               `json:"is_active" bson:"is_active"`
+	CreatedOn    time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn   time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateAddOnRequest represents the request body for creating an add-on module
+type CreateAddOnRequest struct {
+	Code         string `json:"code" validate:"required,min=2,max=50,alphanum"`
+	Name         string `json:"name" validate:"required,min=2,max=100"`
+	Description  string `json:"description" validate:"omitempty,max=500"`
+	MonthlyPrice int64  `json:"monthly_price" validate:"min=0"`
+	PerSeat      bool   `json:"per_seat"`
+}
+
+// UpdateAddOnRequest represents the request body for updating an add-on module.
+// Quotes already made keep their price.
+type UpdateAddOnRequest struct {
+	Name         string `json:"name" validate:"omitempty,min=2,max=100"`
+	Description  string `json:"description" validate:"omitempty,max=500"`
+	MonthlyPrice *int64 `json:"monthly_price" validate:"omitempty,min=0"`
+	PerSeat      *bool  `json:"per_seat"`
+	IsActive     *bool  `json:"is_active"`
+}
+
+// PromoCode represents a discount code a prospect can be quoted with. It takes either
+// a percentage or a fixed amount off the discounted subtotal, and is redeemed when a
+// quote using it is accepted.
+type PromoCode struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Code           string             `json:"code" bson:"code"` // upper case
+	Description    string             `json:"description,omitempty" bson:"description,omitempty"`
+	PercentOff     int                `json:"percent_off,omitempty" bson:"percent_off,omitempty"`
+	AmountOff      int64              `json:"amount_off,omitempty" bson:"amount_off,omitempty"` // in VND
+	ValidFrom      *time.Time         `json:"valid_from,omitempty" bson:"valid_from,omitempty"`
+	ValidUntil     *time.Time         `json:"valid_until,omitempty" bson:"valid_until,omitempty"`
+	MaxRedemptions int                `json:"max_redemptions,omitempty" bson:"max_redemptions,omitempty"` // 0 for no limit
+	Redemptions    int                `json:"redemptions" bson:"redemptions"`
+	IsActive       bool               `json:"is_active" bson:"is_active"`
+	CreatedOn      time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn     time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// Usable reports whether a promo code can be applied to a new quote at now
+func (p *PromoCode) Usable(now time.Time) bool {
+	return p.IsActive &&
+		(p.ValidFrom == nil || !now.Before(*p.ValidFrom)) &&
+		(p.ValidUntil == nil || !now.After(*p.ValidUntil)) &&
+		(p.MaxRedemptions == 0 || p.Redemptions < p.MaxRedemptions)
+}
+
+// CreatePromoCodeRequest represents the request body for creating a promo code.
+// Exactly one of PercentOff and AmountOff is set.
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" validate:"required,min=3,max=30,alphanum"`
+	Description    string     `json:"description" validate:"omitempty,max=500"`
+	PercentOff     int        `json:"percent_off" validate:"required_without=AmountOff,excluded_with=AmountOff,omitempty,min=1,max=100"`
+	AmountOff      int64      `json:"amount_off" validate:"required_without=PercentOff,excluded_with=PercentOff,omitempty,min=1"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MaxRedemptions int        `json:"max_redemptions" validate:"min=0"`
+}
+
+// UpdatePromoCodeRequest represents the request body for updating a promo code.
+// The discount itself cannot change once quotes may use it.
+type UpdatePromoCodeRequest struct {
+	Description    string     `json:"description" validate:"omitempty,max=500"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MaxRedemptions *int       `json:"max_redemptions" validate:"omitempty,min=0"`
+	IsActive       *bool      `json:"is_active"`
+}
+
+// QuoteRequest represents the terms a quote is priced with. Seats default to the seats
+// of WorkstationRange, which defaults to the registration's range for registration quotes.
+type QuoteRequest struct {
+	PlanID           string       `json:"plan_id" validate:"required"`
+	Seats            int          `json:"seats" validate:"omitempty,min=1,max=1000"`
+	WorkstationRange string       `json:"workstation_range" validate:"omitempty,oneof=1-10 10-20 20-50 50+"`
+	BillingCycle     BillingCycle `json:"billing_cycle" validate:"omitempty,oneof=monthly quarterly yearly"`
+	AddOns           []string     `json:"addons" validate:"omitempty,max=20,dive,required"`
+	DiscountPercent  int          `json:"discount_percent" validate:"min=0,max=100"`
+	PromoCode        string       `json:"promo_code" validate:"omitempty,max=30"`
+	Note             string       `json:"note" validate:"omitempty,max=1000"`
+}
+
+var (
+	// ErrAddOnCodeExists is returned when an add-on code is already used
+	ErrAddOnCodeExists = errors.New("add-on code already exists")
+
+	// ErrAddOnUnavailable is returned when quoting an add-on that does not exist or is no longer offered
+	ErrAddOnUnavailable = errors.New("add-on does not exist or is not active")
+
+	// ErrPromoCodeExists is returned when a promo code is already used
+	ErrPromoCodeExists = errors.New("promo code already exists")
+
+	// ErrPromoCodeInvalid is returned when quoting with a promo code that does not exist,
+	// is inactive, outside its validity or fully redeemed
+	ErrPromoCodeInvalid = errors.New("promo code is not valid")
+
+	// ErrPromoCodeExhausted is returned when accepting a quote whose promo code has
+	// been fully redeemed since the quote was made
+	ErrPromoCodeExhausted = errors.New("promo code has no redemptions left")
+
+	// ErrSeatsRequired is returned when a quote has neither seats nor a workstation range
+	ErrSeatsRequired = errors.New("seats or workstation range is required")
+)
+
+// AddOnRepository represents the add-on module repository contract
+type AddOnRepository interface {
+	Create(ctx context.Context, addOn *AddOn) error
+	GetByID(ctx context.Context, id string) (*AddOn, error)
+	GetByCodes(ctx context.Context, codes []string) ([]*AddOn, error)
+	GetAll(ctx context.Context, activeOnly bool) ([]*AddOn, error)
+	Update(ctx context.Context, addOn *AddOn) error
+}
+
+// PromoCodeRepository represents the promo code repository contract
+type PromoCodeRepository interface {
+	Create(ctx context.Context, promo *PromoCode) error
+	GetByID(ctx context.Context, id string) (*PromoCode, error)
+	GetByCode(ctx context.Context, code string) (*PromoCode, error)
+	GetAll(ctx context.Context) ([]*PromoCode, error)
+	Update(ctx context.Context, promo *PromoCode) error
+	Redeem(ctx context.Context, code string) error
+	Release(ctx context.Context, code string) error
+}
+
+// PricingUsecase represents the pricing catalog and quote calculator contract
+type PricingUsecase interface {
+	CreateAddOn(ctx context.Context, req *CreateAddOnRequest) (*AddOn, error)
+	GetAddOns(ctx context.Context, activeOnly bool) ([]*AddOn, error)
+	UpdateAddOn(ctx context.Context, id string, req *UpdateAddOnRequest) (*AddOn, error)
+	CreatePromoCode(ctx context.Context, req *CreatePromoCodeRequest) (*PromoCode, error)
+	GetPromoCodes(ctx context.Context) ([]*PromoCode, error)
+	UpdatePromoCode(ctx context.Context, id string, req *UpdatePromoCodeRequest) (*PromoCode, error)
+	Calculate(ctx context.Context, req *QuoteRequest) (*Quote, error)
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuoteStatus represents the state of a sales quote
+type QuoteStatus string
+
+const (
+	QuoteDraft      QuoteStatus = "draft" // not sent to the prospect yet
+	QuoteSent       QuoteStatus = "sent"  // can be accepted through its link until ValidUntil
+	QuoteAccepted   QuoteStatus = "accepted"
+	QuoteSuperseded QuoteStatus = "superseded" // replaced by a newer version
+)
+
+// QuoteLineKind represents what a quote line charges for
+type QuoteLineKind string
+
+const (
+	QuoteLinePlan  QuoteLineKind = "plan"
+	QuoteLineAddOn QuoteLineKind = "addon"
+)
+
+// QuoteLine represents a monthly charge of a quote, in VND
+type QuoteLine struct {
+	Kind        QuoteLineKind `json:"kind" bson:"kind"`
+	Code        string        `json:"code" bson:"code"`
+	Description string        `json:"description" bson:"description"`
+	Quantity    int64         `json:"quantity" bson:"quantity"`
+	UnitPrice   int64         `json:"unit_price" bson:"unit_price"` // per month
+	Amount      int64         `json:"amount" bson:"amount"`         // per month
+}
+
+// QuoteProspect holds the contact details a quote is addressed to, copied from the registration
+type QuoteProspect struct {
+	FullName    string `json:"full_name" bson:"full_name"`
+	Email       string `json:"email" bson:"email"`
+	PhoneNumber string `json:"phone_number" bson:"phone_number"`
+	Address     string `json:"address,omitempty" bson:"address,omitempty"`
+}
+
+// Quote represents a priced sales proposal for a registration. Each new quote of a
+// registration is a new version that supersedes the open ones; prices are fixed
+// when the quote is made.
+//
+// Totals: Subtotal is MonthlyAmount × Months; the sales discount applies to the
+// subtotal, the promo code to what remains, and VAT to the discounted amount.
+type Quote struct {
+	ID               primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	RegistrationID   primitive.ObjectID  `json:"registration_id,omitempty" bson:"registration_id,omitempty"`
+	Version          int                 `json:"version,omitempty" bson:"version,omitempty"`
+	Status           QuoteStatus         `json:"status,omitempty" bson:"status,omitempty"`
+	Prospect         QuoteProspect       `json:"prospect" bson:"prospect"`
+	PlanID           primitive.ObjectID  `json:"plan_id" bson:"plan_id"`
+	PlanName         string              `json:"plan_name" bson:"plan_name"`
+	WorkstationRange string              `json:"workstation_range" bson:"workstation_range"`
+	Seats            int                 `json:"seats" bson:"seats"`
+	BillingCycle     BillingCycle        `json:"billing_cycle" bson:"billing_cycle"`
+	Months           int                 `json:"months" bson:"months"`
+	Lines            []QuoteLine         `json:"lines" bson:"lines"`
+	MonthlyAmount    int64               `json:"monthly_amount" bson:"monthly_amount"`
+	Subtotal         int64               `json:"subtotal" bson:"subtotal"`
+	DiscountPercent  int                 `json:"discount_percent,omitempty" bson:"discount_percent,omitempty"`
+	DiscountAmount   int64               `json:"discount_amount" bson:"discount_amount"`
+	PromoCode        string              `json:"promo_code,omitempty" bson:"promo_code,omitempty"`
+	PromoAmount      int64               `json:"promo_amount" bson:"promo_amount"`
+	VATRate          int                 `json:"vat_rate" bson:"vat_rate"` // percent
+	VATAmount        int64               `json:"vat_amount" bson:"vat_amount"`
+	Total            int64               `json:"total" bson:"total"` // per billing period
+	Note             string              `json:"note,omitempty" bson:"note,omitempty"`
+	ValidUntil       time.Time           `json:"valid_until" bson:"valid_until"`
+	Expired          bool                `json:"expired" bson:"-"`
+	TokenHash        string              `json:"-" bson:"token_hash,omitempty"`
+	SentAt           *time.Time          `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+	AcceptedAt       *time.Time          `json:"accepted_at,omitempty" bson:"accepted_at,omitempty"`
+	CustomerID       *primitive.ObjectID `json:"customer_id,omitempty" bson:"customer_id,omitempty"` // customer the accepted quote converted into
+	CreatedBy        string              `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedOn        time.Time           `json:"created_on" bson:"created_on"`
+	ModifiedOn       time.Time           `json:"modified_on" bson:"modified_on"`
+}
+
+// SentQuote is a quote sent to its prospect with the link to view and accept it.
+// The link is only returned when the quote is sent.
+type SentQuote struct {
+	*Quote
+	AcceptURL string `json:"accept_url"`
+}
+
+var (
+	// ErrQuoteNotOpen is returned when sending or accepting a quote that was accepted or superseded
+	ErrQuoteNotOpen = errors.New("quote is no longer open")
+
+	// ErrQuoteExpired is returned when accepting a quote after its validity
+	ErrQuoteExpired = errors.New("quote has expired")
+
+	// ErrQuoteAccepted is returned when quoting a registration that already accepted a quote
+	ErrQuoteAccepted = errors.New("registration has already accepted a quote")
+
+	// ErrQuoteVersionConflict is returned when a version could not be assigned because of concurrent quotes
+	ErrQuoteVersionConflict = errors.New("could not assign a quote version, try again")
+)
+
+// QuoteRepository represents the quote repository contract
+type QuoteRepository interface {
+	Create(ctx context.Context, quote *Quote) error
+	GetByID(ctx context.Context, id string) (*Quote, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*Quote, error)
+	GetByRegistration(ctx context.Context, registrationID string) ([]*Quote, error)
+	HasAccepted(ctx context.Context, registrationID primitive.ObjectID) (bool, error)
+	Supersede(ctx context.Context, registrationID primitive.ObjectID, except primitive.ObjectID) error
+	MarkSent(ctx context.Context, quote *Quote) error
+	Accept(ctx context.Context, quote *Quote) error
+}
+
+// QuoteUsecase represents the quote usecase contract
+type QuoteUsecase interface {
+	Create(ctx context.Context, registrationID string, req *QuoteRequest, actor *Actor) (*Quote, error)
+	GetByID(ctx context.Context, id string) (*Quote, error)
+	GetByRegistration(ctx context.Context, registrationID string) ([]*Quote, error)
+	Send(ctx context.Context, id string) (*SentQuote, error)
+	RenderPDF(ctx context.Context, id string, w io.Writer) (*Quote, error)
+	GetByToken(ctx context.Context, token string) (*Quote, error)
+	RenderPDFByToken(ctx context.Context, token string, w io.Writer) (*Quote, error)
+	Accept(ctx context.Context, token string) (*Quote, error)
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -9,31 +10,58 @@ import (
 
 // Registration represents the registration entity
 type Registration struct {
-	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	FullName         string             `json:"full_name" bson:"full_name" validate:"required,min=2,max=100"`
-	PhoneNumber      string             `json:"phone_number" bson:"phone_number" validate:"required,min=10,max=15"`
-	Email            string             `json:"email" bson:"email" validate:"required,email"`
-	Address          string             `json:"address" bson:"address" validate:"required,min=5,max=255"`
-	WorkstationRange string             `json:"workstation_range" bson:"workstation_range"`
-	CreatedOn        time.Time          `json:"created_on" bson:"created_on"`
-	ModifiedOn       time.Time          `json:"modified_on" bson:"modified_on"`
+	ID                primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	FullName          string              `json:"full_name" bson:"full_name" validate:"required,min=2,max=100"`
+	PhoneNumber       string              `json:"phone_number" bson:"phone_number" validate:"required,min=10,max=15"`
+	Email             string              `json:"email" bson:"email" validate:"required,email"`
+	Address           string              `json:"address" bson:"address" validate:"required,min=5,max=255"`
+	StructuredAddress *Address            `json:"structured_address,omitempty" bson:"structured_address,omitempty"`
+	WorkstationRange  string              `json:"workstation_range" bson:"workstation_range"`
+	ResellerID        *primitive.ObjectID `json:"reseller_id,omitempty" bson:"reseller_id,omitempty"` // reseller of the referral code
+	Source            *ReferralSource     `json:"source,omitempty" bson:"source,omitempty"`
+	CustomerID        *primitive.ObjectID `json:"customer_id,omitempty" bson:"customer_id,omitempty"` // set when a quote is accepted
+	ConvertedAt       *time.Time          `json:"converted_at,omitempty" bson:"converted_at,omitempty"`
+	CreatedOn         time.Time           `json:"created_on" bson:"created_on"`
+	ModifiedOn        time.Time           `json:"modified_on" bson:"modified_on"`
+	DeletedAt         *time.Time          `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy         string              `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
 }
 
+// The free-text address can be omitted when a structured address is given.
+// Source carries the referral code and campaign parameters of the registration page.
 type CreateRegistrationRequest struct {
-	FullName         string `json:"full_name" validate:"required,min=2,max=100"`
-	PhoneNumber      string `json:"phone_number" validate:"required,min=10,max=15"`
-	Email            string `json:"email" validate:"required,email"`
-	Address          string `json:"address" validate:"required,min=5,max=255"`
-	WorkstationRange string `json:"workstation_range" validate:"required,oneof='1-10' '10-20' '20-50' '50+'"`
+	FullName          string          `json:"full_name" validate:"required,min=2,max=100"`
+	PhoneNumber       string          `json:"phone_number" validate:"required,min=10,max=15"`
+	Email             string          `json:"email" validate:"required,email"`
+	Address           string          `json:"address" validate:"required_without=StructuredAddress,omitempty,min=5,max=255"`
+	StructuredAddress *Address        `json:"structured_address"`
+	WorkstationRange  string          `json:"workstation_range" validate:"required,oneof='1-10' '10-20' '20-50' '50+'"`
+	Source            *ReferralSource `json:"source"`
 }
 
 // UpdateRegistrationRequest represents the request body for updating registration
 type UpdateRegistrationRequest struct {
-	FullName         string `json:"full_name" validate:"omitempty,min=2,max=100"`
-	PhoneNumber      string `json:"phone_number" validate:"omitempty,min=10,max=15"`
-	Email            string `json:"email" validate:"omitempty,email"`
-	Address          string `json:"address" validate:"omitempty,min=5,max=255"`
-	WorkstationRange string `json:"workstation_range" validate:"required,oneof='1-10' '10-20' '20-50' '50+'"`
+	FullName          string   `json:"full_name" validate:"omitempty,min=2,max=100"`
+	PhoneNumber       string   `json:"phone_number" validate:"omitempty,min=10,max=15"`
+	Email             string   `json:"email" validate:"omitempty,email"`
+	Address           string   `json:"address" validate:"omitempty,min=5,max=255"`
+	StructuredAddress *Address `json:"structured_address"`
+	WorkstationRange  string   `json:"workstation_range" validate:"required,oneof='1-10' '10-20' '20-50' '50+'"`
+}
+
+// RegistrationFilter represents the filters shared by registration list and export
+type RegistrationFilter struct {
+	Search           string
+	WorkstationRange string
+	ResellerID       *primitive.ObjectID
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time
+}
+
+// IsEmpty reports whether no filter is set
+func (f *RegistrationFilter) IsEmpty() bool {
+	return f == nil || (f.Search == "" && f.WorkstationRange == "" && f.ResellerID == nil &&
+		f.CreatedFrom == nil && f.CreatedTo == nil)
 }
 
 // RegistrationRepository represents the registration repository contract
@@ -41,17 +69,29 @@ type RegistrationRepository interface {
 	Create(ctx context.Context, registration *Registration) error
 	GetByID(ctx context.Context, id string) (*Registration, error)
 	GetByEmail(ctx context.Context, email string) (*Registration, error)
-	GetAll(ctx context.Context, limit, offset int64) ([]*Registration, error)
+	GetAll(ctx context.Context, filter *RegistrationFilter, page *Pagination) ([]*Registration, error)
+	Iterate(ctx context.Context, filter *RegistrationFilter, fn func(*Registration) error) error
 	Update(ctx context.Context, id string, registration *Registration) error
-	Delete(ctx context.Context, id string) error
-	Count(ctx context.Context) (int64, error)
+	SetStructuredAddress(ctx context.Context, id string, address *Address) error
+	MarkConverted(ctx context.Context, id primitive.ObjectID, customerID primitive.ObjectID, convertedAt time.Time) error
+	Delete(ctx context.Context, id string, deletedBy string) error
+	GetDeleted(ctx context.Context, page *Pagination) ([]*Registration, error)
+	CountDeleted(ctx context.Context) (int64, error)
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	Count(ctx context.Context, filter *RegistrationFilter) (int64, error)
+	EstimatedCount(ctx context.Context) (int64, error)
 }
 
 // RegistrationUsecase represents the registration usecase contract
 type RegistrationUsecase interface {
 	Create(ctx context.Context, req *CreateRegistrationRequest) (*Registration, error)
 	GetByID(ctx context.Context, id string) (*Registration, error)
-	GetAll(ctx context.Context, limit, offset int64) ([]*Registration, int64, error)
+	GetAll(ctx context.Context, filter *RegistrationFilter, page *Pagination) ([]*Registration, *PageInfo, error)
+	Export(ctx context.Context, filter *RegistrationFilter, req *ExportRequest, w io.Writer) error
 	Update(ctx context.Context, id string, req *UpdateRegistrationRequest) (*Registration, error)
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, deletedBy string) error
+	GetTrash(ctx context.Context, page *Pagination) ([]*Registration, *PageInfo, error)
+	Restore(ctx context.Context, id string) (*Registration, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReleaseChannel represents who receives a release: everyone, or the cafes
+// testing upcoming builds
+type ReleaseChannel string
+
+const (
+	ReleaseChannelStable ReleaseChannel = "stable"
+	ReleaseChannelBeta   ReleaseChannel = "beta" // beta clients also receive stable releases
+)
+
+// ReleasePlatform represents the operating system and architecture a build runs on
+type ReleasePlatform string
+
+const (
+	ReleasePlatformWindowsX64   ReleasePlatform = "windows-x64"
+	ReleasePlatformWindowsX86   ReleasePlatform = "windows-x86"
+	ReleasePlatformWindowsARM64 ReleasePlatform = "windows-arm64"
+	ReleasePlatformAndroid      ReleasePlatform = "android"
+	ReleasePlatformLinuxX64     ReleasePlatform = "linux-x64"
+	ReleasePlatformMacOS        ReleasePlatform = "macos"
+)
+
+// ReleaseAsset represents the build of a release for one platform. It refers to
+// an uploaded file, whose size and SHA-256 are copied so clients can check the
+// download.
+type ReleaseAsset struct {
+	Platform ReleasePlatform    `json:"platform" bson:"platform"`
+	FileID   primitive.ObjectID `json:"file_id" bson:"file_id"`
+	FileName string             `json:"file_name" bson:"file_name"` // original name of the uploaded file
+	Size     int64              `json:"size" bson:"size"`
+	SHA256   string             `json:"sha256" bson:"sha256"` // hex
+	URL      string             `json:"url" bson:"url"`
+}
+
+// Release represents a version of a software product, such as the cafe client,
+// with its builds. Clients only see it once published.
+type Release struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Product        string             `json:"product" bson:"product"`
+	Version        string             `json:"version" bson:"version"` // semantic version, e.g. 2.4.0 or 2.5.0-beta.1
+	Channel        ReleaseChannel     `json:"channel" bson:"channel"`
+	Changelog      string             `json:"changelog,omitempty" bson:"changelog,omitempty"`
+	MinimumVersion string             `json:"minimum_version,omitempty" bson:"minimum_version,omitempty"` // clients below must update
+	Assets         []ReleaseAsset     `json:"assets" bson:"assets"`
+	PublishedAt    *time.Time         `json:"published_at,omitempty" bson:"published_at,omitempty"` // nil while a draft
+	CreatedBy      string             `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedOn      time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn     time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// ReleaseAssetRequest represents a build in a release request
+type ReleaseAssetRequest struct {
+	Platform ReleasePlatform `json:"platform" validate:"required,oneof=windows-x64 windows-x86 windows-arm64 android linux-x64 macos"`
+	FileID   string          `json:"file_id" validate:"required"`
+}
+
+// CreateReleaseRequest represents the request body for creating a draft release
+type CreateReleaseRequest struct {
+	Product        string                `json:"product" validate:"required,min=2,max=50"`
+	Version        string                `json:"version" validate:"required,max=100"`
+	Channel        ReleaseChannel        `json:"channel" validate:"required,oneof=stable beta"`
+	Changelog      string                `json:"changelog" validate:"omitempty,max=10000"`
+	MinimumVersion string                `json:"minimum_version" validate:"omitempty,max=100"`
+	Assets         []ReleaseAssetRequest `json:"assets" validate:"required,min=1,max=10,dive"`
+}
+
+// UpdateReleaseRequest represents the request body for updating a release.
+// Channel and Assets can only change while it is a draft; an empty
+// MinimumVersion removes it.
+type UpdateReleaseRequest struct {
+	Channel        ReleaseChannel        `json:"channel" validate:"omitempty,oneof=stable beta"`
+	Changelog      *string               `json:"changelog" validate:"omitempty,max=10000"`
+	MinimumVersion *string               `json:"minimum_version" validate:"omitempty,max=100"`
+	Assets         []ReleaseAssetRequest `json:"assets" validate:"omitempty,min=1,max=10,dive"`
+}
+
+// ReleaseCheck is the answer to a client asking for the latest release of its
+// product, channel and platform
+type ReleaseCheck struct {
+	Product         string          `json:"product"`
+	Channel         ReleaseChannel  `json:"channel"`
+	Platform        ReleasePlatform `json:"platform"`
+	Version         string          `json:"version"`
+	Changelog       string          `json:"changelog,omitempty"`
+	MinimumVersion  string          `json:"minimum_version,omitempty"`
+	Asset           ReleaseAsset    `json:"asset"`
+	PublishedAt     time.Time       `json:"published_at"`
+	CurrentVersion  string          `json:"current_version,omitempty"`
+	UpdateAvailable bool            `json:"update_available"` // the latest release is newer than the current version
+	Mandatory       bool            `json:"mandatory"`        // the current version is below the minimum version
+}
+
+// SignedReleaseManifest is the signed update manifest for a client, in the
+// format of pkg/update
+type SignedReleaseManifest struct {
+	Manifest  string    `json:"manifest"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ReleasePublicKey describes the key clients use to verify update manifests
+type ReleasePublicKey struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
+
+var (
+	// ErrInvalidReleaseProduct is returned for a product name that is not a lowercase slug
+	ErrInvalidReleaseProduct = errors.New("product must be lowercase letters, digits and dashes")
+
+	// ErrInvalidReleaseVersion is returned for a version that is not semantic, or a
+	// pre-release on the stable channel
+	ErrInvalidReleaseVersion = errors.New("version must be a semantic version, pre-releases only on the beta channel")
+
+	// ErrReleaseVersionExists is returned when a product already has a release of a version
+	ErrReleaseVersionExists = errors.New("release version already exists")
+
+	// ErrDuplicateReleasePlatform is returned when a release lists a platform twice
+	ErrDuplicateReleasePlatform = errors.New("release lists a platform more than once")
+
+	// ErrReleaseFileNotFound is returned when a build refers to a file that does not exist or is not public
+	ErrReleaseFileNotFound = errors.New("release file not found or not public")
+
+	// ErrReleasePublished is returned when changing the channel or builds of a published release
+	ErrReleasePublished = errors.New("release is published")
+)
+
+// ReleaseRepository represents the release repository contract
+type ReleaseRepository interface {
+	Create(ctx context.Context, release *Release) error
+	GetByVersion(ctx context.Context, product string, version string) (*Release, error)
+	GetAll(ctx context.Context, product string) ([]*Release, error)
+	GetPublished(ctx context.Context, product string, channels []ReleaseChannel, platform ReleasePlatform) ([]*Release, error)
+	Update(ctx context.Context, release *Release) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// ReleaseUsecase represents the release usecase contract
+type ReleaseUsecase interface {
+	Create(ctx context.Context, req *CreateReleaseRequest, actor *Actor) (*Release, error)
+	Get(ctx context.Context, product string, version string) (*Release, error)
+	GetAll(ctx context.Context, product string) ([]*Release, error)
+	Update(ctx context.Context, product string, version string, req *UpdateReleaseRequest) (*Release, error)
+	Publish(ctx context.Context, product string, version string) (*Release, error)
+	Delete(ctx context.Context, product string, version string) error
+	Latest(ctx context.Context, product string, channel ReleaseChannel, platform ReleasePlatform, current string) (*ReleaseCheck, error)
+	Manifest(ctx context.Context, product string, channel ReleaseChannel, platform ReleasePlatform) (*SignedReleaseManifest, error)
+	PublicKey() *ReleasePublicKey
+}
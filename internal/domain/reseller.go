@@ -0,0 +1,288 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reseller represents a local partner who refers cafes. Each reseller signs in with
+// a user account of the reseller role and shares a unique referral code.
+type Reseller struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Code         string             `json:"code" bson:"code"` // upper case
+	Name         string             `json:"name" bson:"name"`
+	CompanyName  string             `json:"company_name,omitempty" bson:"company_name,omitempty"`
+	PhoneNumber  string             `json:"phone_number" bson:"phone_number"`
+	Email        string             `json:"email,omitempty" bson:"email,omitempty"`
+	BankAccount  string             `json:"bank_account,omitempty" bson:"bank_account,omitempty"` // where commissions are paid out
+	Note         string             `json:"note,omitempty" bson:"note,omitempty"`
+	IsActive     bool               `json:"is_active" bson:"is_active"`
+	ReferralLink string             `json:"referral_link" bson:"-"`
+	CreatedOn    time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn   time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateResellerRequest represents the request body for creating a reseller from a
+// user account of the reseller role. A referral code is generated when Code is empty.
+type CreateResellerRequest struct {
+	UserID      string `json:"user_id" validate:"required"`
+	Code        string `json:"code" validate:"omitempty,min=4,max=20,alphanum"`
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	CompanyName string `json:"company_name" validate:"omitempty,max=255"`
+	PhoneNumber string `json:"phone_number" validate:"required,min=10,max=15"`
+	Email       string `json:"email" validate:"omitempty,email"`
+	BankAccount string `json:"bank_account" validate:"omitempty,max=255"`
+	Note        string `json:"note" validate:"omitempty,max=500"`
+}
+
+// UpdateResellerRequest represents the request body for updating a reseller.
+// The referral code cannot change since it is printed on shared links.
+type UpdateResellerRequest struct {
+	Name        string  `json:"name" validate:"omitempty,min=2,max=100"`
+	CompanyName *string `json:"company_name" validate:"omitempty,max=255"`
+	PhoneNumber string  `json:"phone_number" validate:"omitempty,min=10,max=15"`
+	Email       *string `json:"email" validate:"omitempty,email"`
+	BankAccount *string `json:"bank_account" validate:"omitempty,max=255"`
+	Note        *string `json:"note" validate:"omitempty,max=500"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// ReferralSource holds the referral code and the campaign parameters a registration
+// was made with, captured from the registration page
+type ReferralSource struct {
+	ReferralCode string `json:"referral_code,omitempty" bson:"referral_code,omitempty" validate:"omitempty,max=20"`
+	UTMSource    string `json:"utm_source,omitempty" bson:"utm_source,omitempty" validate:"omitempty,max=100"`
+	UTMMedium    string `json:"utm_medium,omitempty" bson:"utm_medium,omitempty" validate:"omitempty,max=100"`
+	UTMCampaign  string `json:"utm_campaign,omitempty" bson:"utm_campaign,omitempty" validate:"omitempty,max=100"`
+	UTMTerm      string `json:"utm_term,omitempty" bson:"utm_term,omitempty" validate:"omitempty,max=100"`
+	UTMContent   string `json:"utm_content,omitempty" bson:"utm_content,omitempty" validate:"omitempty,max=100"`
+	LandingPage  string `json:"landing_page,omitempty" bson:"landing_page,omitempty" validate:"omitempty,max=500"`
+	Referrer     string `json:"referrer,omitempty" bson:"referrer,omitempty" validate:"omitempty,max=500"` // page the visitor came from
+}
+
+// IsEmpty reports whether no source field is set
+func (s *ReferralSource) IsEmpty() bool {
+	return s == nil || *s == ReferralSource{}
+}
+
+// ReferralInfo is what the registration page shows about the reseller of a referral code
+type ReferralInfo struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	CompanyName string `json:"company_name,omitempty"`
+}
+
+// ReferralLead represents a registration referred by a reseller, as shown on the
+// reseller portal
+type ReferralLead struct {
+	ID               primitive.ObjectID `json:"id"`
+	FullName         string             `json:"full_name"`
+	WorkstationRange string             `json:"workstation_range"`
+	UTMCampaign      string             `json:"utm_campaign,omitempty"`
+	Converted        bool               `json:"converted"`
+	ConvertedAt      *time.Time         `json:"converted_at,omitempty"`
+	CreatedOn        time.Time          `json:"created_on"`
+}
+
+// CommissionRule sets what a reseller earns when a referred customer pays: a percentage
+// of the amount before VAT plus a fixed amount, for the first MaxPayments payments of
+// each customer. A rule without a reseller applies to resellers without their own rule.
+type CommissionRule struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Name        string              `json:"name" bson:"name"`
+	ResellerID  *primitive.ObjectID `json:"reseller_id,omitempty" bson:"reseller_id,omitempty"`
+	Percent     int                 `json:"percent" bson:"percent"`
+	FixedAmount int64               `json:"fixed_amount,omitempty" bson:"fixed_amount,omitempty"` // in VND
+	MaxPayments int                 `json:"max_payments,omitempty" bson:"max_payments,omitempty"` // 0 for every payment
+	IsActive    bool                `json:"is_active" bson:"is_active"`
+	CreatedOn   time.Time           `json:"created_on" bson:"created_on"`
+	ModifiedOn  time.Time           `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateCommissionRuleRequest represents the request body for creating a commission rule
+type CreateCommissionRuleRequest struct {
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	ResellerID  string `json:"reseller_id"`
+	Percent     int    `json:"percent" validate:"required_without=FixedAmount,omitempty,min=1,max=100"`
+	FixedAmount int64  `json:"fixed_amount" validate:"required_without=Percent,omitempty,min=1"`
+	MaxPayments int    `json:"max_payments" validate:"min=0"`
+}
+
+// UpdateCommissionRuleRequest represents the request body for updating a commission
+// rule. Commissions already earned keep their amount.
+type UpdateCommissionRuleRequest struct {
+	Name        string `json:"name" validate:"omitempty,min=2,max=100"`
+	Percent     *int   `json:"percent" validate:"omitempty,min=0,max=100"`
+	FixedAmount *int64 `json:"fixed_amount" validate:"omitempty,min=0"`
+	MaxPayments *int   `json:"max_payments" validate:"omitempty,min=0"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// CommissionStatus represents the payout state of a commission
+type CommissionStatus string
+
+const (
+	CommissionPending   CommissionStatus = "pending" // earned, not paid out yet
+	CommissionPaid      CommissionStatus = "paid"
+	CommissionCancelled CommissionStatus = "cancelled" // the payment was voided or refunded
+)
+
+// CommissionSource identifies the kind of payment a commission was earned on
+type CommissionSource string
+
+const (
+	CommissionFromInvoice CommissionSource = "invoice" // an invoice marked as paid
+	CommissionFromPayment CommissionSource = "payment" // a succeeded online payment
+)
+
+// Commission represents what a reseller earned on one payment of a referred customer.
+// Each payment earns at most one commission.
+type Commission struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ResellerID   primitive.ObjectID `json:"reseller_id" bson:"reseller_id"`
+	RuleID       primitive.ObjectID `json:"rule_id" bson:"rule_id"`
+	CustomerID   primitive.ObjectID `json:"customer_id" bson:"customer_id"`
+	CustomerName string             `json:"customer_name" bson:"customer_name"`
+	Source       CommissionSource   `json:"source" bson:"source"`
+	SourceID     primitive.ObjectID `json:"source_id" bson:"source_id"`
+	SourceRef    string             `json:"source_ref,omitempty" bson:"source_ref,omitempty"` // invoice number or payment reference
+	BaseAmount   int64              `json:"base_amount" bson:"base_amount"`                   // paid amount before VAT
+	Percent      int                `json:"percent" bson:"percent"`
+	FixedAmount  int64              `json:"fixed_amount,omitempty" bson:"fixed_amount,omitempty"`
+	Amount       int64              `json:"amount" bson:"amount"`
+	Status       CommissionStatus   `json:"status" bson:"status"`
+	EarnedAt     time.Time          `json:"earned_at" bson:"earned_at"`
+	PaidAt       *time.Time         `json:"paid_at,omitempty" bson:"paid_at,omitempty"`
+	PayoutRef    string             `json:"payout_ref,omitempty" bson:"payout_ref,omitempty"`
+	CancelReason string             `json:"cancel_reason,omitempty" bson:"cancel_reason,omitempty"`
+	PaidBy       string             `json:"paid_by,omitempty" bson:"paid_by,omitempty"`
+	CreatedOn    time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn   time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// ReferredPayment represents a payment of a customer that may earn a commission
+type ReferredPayment struct {
+	CustomerID primitive.ObjectID
+	Source     CommissionSource
+	SourceID   primitive.ObjectID
+	SourceRef  string
+	PaymentRef string // online payment an invoice was settled with, which earns the commission instead
+	BaseAmount int64  // before VAT
+	PaidAt     time.Time
+}
+
+// PayCommissionRequest represents the request body for recording the payout of a commission
+type PayCommissionRequest struct {
+	PaidAt    *time.Time `json:"paid_at"`
+	PayoutRef string     `json:"payout_ref" validate:"omitempty,max=100"`
+}
+
+// CancelCommissionRequest represents the request body for cancelling a commission
+type CancelCommissionRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// CommissionFilter represents the filters of the commission list
+type CommissionFilter struct {
+	ResellerID  *primitive.ObjectID
+	CustomerID  *primitive.ObjectID
+	Status      CommissionStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// CommissionSummary totals the commissions of a reseller by status
+type CommissionSummary struct {
+	Pending   int64 `json:"pending"`
+	Paid      int64 `json:"paid"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+var (
+	// ErrResellerCodeExists is returned when a referral code is already taken
+	ErrResellerCodeExists = errors.New("referral code already exists")
+
+	// ErrResellerExists is returned when a user account already belongs to a reseller
+	ErrResellerExists = errors.New("user is already a reseller")
+
+	// ErrResellerRoleRequired is returned when a reseller is created from a user without the reseller role
+	ErrResellerRoleRequired = errors.New("user does not have the reseller role")
+
+	// ErrCommissionStatus is returned when a commission cannot move to the requested status
+	ErrCommissionStatus = errors.New("commission status does not allow this change")
+
+	// ErrCommissionExists is returned when a payment has already earned a commission
+	ErrCommissionExists = errors.New("payment already earned a commission")
+)
+
+// ResellerRepository represents the reseller repository contract
+type ResellerRepository interface {
+	Create(ctx context.Context, reseller *Reseller) error
+	GetByID(ctx context.Context, id string) (*Reseller, error)
+	GetByUserID(ctx context.Context, userID string) (*Reseller, error)
+	GetByCode(ctx context.Context, code string) (*Reseller, error)
+	GetAll(ctx context.Context) ([]*Reseller, error)
+	Update(ctx context.Context, reseller *Reseller) error
+}
+
+// CommissionRuleRepository represents the commission rule repository contract
+type CommissionRuleRepository interface {
+	Create(ctx context.Context, rule *CommissionRule) error
+	GetByID(ctx context.Context, id string) (*CommissionRule, error)
+	GetAll(ctx context.Context) ([]*CommissionRule, error)
+	// GetActive gets the newest active rule of a reseller, or the newest active
+	// default rule when the reseller has none
+	GetActive(ctx context.Context, resellerID primitive.ObjectID) (*CommissionRule, error)
+	Update(ctx context.Context, rule *CommissionRule) error
+}
+
+// CommissionRepository represents the commission repository contract
+type CommissionRepository interface {
+	Create(ctx context.Context, commission *Commission) error
+	GetByID(ctx context.Context, id string) (*Commission, error)
+	GetAll(ctx context.Context, filter *CommissionFilter, page *Pagination) ([]*Commission, error)
+	Count(ctx context.Context, filter *CommissionFilter) (int64, error)
+	// CountEarned counts the commissions a customer earned a reseller, cancelled ones excluded
+	CountEarned(ctx context.Context, resellerID, customerID primitive.ObjectID) (int64, error)
+	Summarize(ctx context.Context, resellerID primitive.ObjectID) (*CommissionSummary, error)
+	UpdateStatus(ctx context.Context, commission *Commission, from CommissionStatus) error
+	// CancelBySource cancels the pending commission earned on a payment
+	CancelBySource(ctx context.Context, source CommissionSource, sourceID primitive.ObjectID, reason string) error
+}
+
+// CommissionRecorder records the commissions earned when referred customers pay
+type CommissionRecorder interface {
+	// RecordPayment records the commission earned on a payment, if the customer was
+	// referred by an active reseller with a commission rule. It returns nil otherwise.
+	RecordPayment(ctx context.Context, payment *ReferredPayment) (*Commission, error)
+	// CancelPayment cancels the pending commission earned on a voided payment
+	CancelPayment(ctx context.Context, source CommissionSource, sourceID primitive.ObjectID, reason string) error
+}
+
+// ResellerUsecase represents the reseller usecase contract
+type ResellerUsecase interface {
+	CommissionRecorder
+
+	Create(ctx context.Context, req *CreateResellerRequest) (*Reseller, error)
+	GetByID(ctx context.Context, id string) (*Reseller, error)
+	GetAll(ctx context.Context) ([]*Reseller, error)
+	Update(ctx context.Context, id string, req *UpdateResellerRequest) (*Reseller, error)
+	GetReferral(ctx context.Context, code string) (*ReferralInfo, error)
+
+	CreateRule(ctx context.Context, req *CreateCommissionRuleRequest) (*CommissionRule, error)
+	GetRules(ctx context.Context) ([]*CommissionRule, error)
+	UpdateRule(ctx context.Context, id string, req *UpdateCommissionRuleRequest) (*CommissionRule, error)
+
+	GetCommissions(ctx context.Context, filter *CommissionFilter, page *Pagination) ([]*Commission, *PageInfo, error)
+	PayCommission(ctx context.Context, id string, req *PayCommissionRequest, actor *Actor) (*Commission, error)
+	CancelCommission(ctx context.Context, id string, req *CancelCommissionRequest) (*Commission, error)
+
+	// Portal of the signed in reseller
+	GetProfile(ctx context.Context, actor *Actor) (*Reseller, error)
+	GetLeads(ctx context.Context, actor *Actor, page *Pagination) ([]*ReferralLead, *PageInfo, error)
+	GetEarnings(ctx context.Context, actor *Actor, status CommissionStatus, page *Pagination) ([]*Commission, *CommissionSummary, *PageInfo, error)
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ScanStatus represents the state of the malware scan of an uploaded file
+type ScanStatus string
+
+const (
+	ScanStatusPending  ScanStatus = "pending" // quarantined, waiting for the scan job
+	ScanStatusScanning ScanStatus = "scanning"
+	ScanStatusClean    ScanStatus = "clean"
+	ScanStatusInfected ScanStatus = "infected"
+	ScanStatusFailed   ScanStatus = "failed" // could not be scanned, stays quarantined until rescanned
+)
+
+// FileScan represents the malware scan of an uploaded file. The file is only
+// served once the scan found it clean.
+type FileScan struct {
+	Status    ScanStatus `json:"status" bson:"status"`
+	Signature string     `json:"signature,omitempty" bson:"signature,omitempty"` // name of the malware found
+	Error     string     `json:"error,omitempty" bson:"error,omitempty"`
+	Attempts  int        `json:"attempts,omitempty" bson:"attempts,omitempty"`
+	UpdatedOn time.Time  `json:"updated_on" bson:"updated_on"`
+}
+
+// ScanReport counts the files handled by a run of the scan job
+type ScanReport struct {
+	Clean    int `json:"clean"`
+	Infected int `json:"infected"`
+	Failed   int `json:"failed"`
+}
+
+var (
+	// ErrFileQuarantined is returned when downloading a file that was not found clean by the malware scan
+	ErrFileQuarantined = errors.New("file has not passed the malware scan")
+
+	// ErrScanInProgress is returned when rescanning a file while it is being scanned
+	ErrScanInProgress = errors.New("file is being scanned")
+
+	// ErrScanDisabled is returned when rescanning a file while no scanner is configured
+	ErrScanDisabled = errors.New("malware scanning is disabled")
+)
+
+// Scanner scans content for malware
+type Scanner interface {
+	// Scan returns the name of the malware found in content, empty when it is clean
+	Scan(ctx context.Context, content io.Reader) (string, error)
+}
+
+// ScanUsecase represents the malware scan usecase contract
+type ScanUsecase interface {
+	ScanPending(ctx context.Context) (*ScanReport, error)
+	Rescan(ctx context.Context, fileID string) (*File, error)
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OpeningHours represents the daily opening time of a shop.
+// Close may be earlier than Open for cafes open past midnight.
+type OpeningHours struct {
+	Open     string `json:"open,omitempty" bson:"open,omitempty" validate:"omitempty,datetime=15:04"`
+	Close    string `json:"close,omitempty" bson:"close,omitempty" validate:"omitempty,datetime=15:04"`
+	AllHours bool   `json:"all_hours" bson:"all_hours"` // open 24/7
+}
+
+// ShopContact represents the person to call at a shop
+type ShopContact struct {
+	Name  string `json:"name,omitempty" bson:"name,omitempty" validate:"omitempty,max=100"`
+	Phone string `json:"phone,omitempty" bson:"phone,omitempty" validate:"omitempty,min=10,max=15"`
+	Email string `json:"email,omitempty" bson:"email,omitempty" validate:"omitempty,email"`
+}
+
+// Shop represents one internet cafe run by a customer
+type Shop struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CustomerID       primitive.ObjectID `json:"customer_id" bson:"customer_id"`
+	Name             string             `json:"name" bson:"name"`
+	Address          Address            `json:"address" bson:"address"`
+	WorkstationCount int                `json:"workstation_count" bson:"workstation_count"`
+	OpeningHours     OpeningHours       `json:"opening_hours" bson:"opening_hours"`
+	Contact          ShopContact        `json:"contact" bson:"contact"`
+	Note             string             `json:"note,omitempty" bson:"note,omitempty"`
+	CreatedOn        time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn       time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateShopRequest represents the request body for creating a shop
+type CreateShopRequest struct {
+	Name             string       `json:"name" validate:"required,min=2,max=100"`
+	Address          Address      `json:"address"`
+	WorkstationCount int          `json:"workstation_count" validate:"min=0,max=10000"`
+	OpeningHours     OpeningHours `json:"opening_hours"`
+	Contact          ShopContact  `json:"contact"`
+	Note             string       `json:"note" validate:"omitempty,max=500"`
+}
+
+// UpdateShopRequest represents the request body for updating a shop.
+// Nested objects replace the stored ones when present.
+type UpdateShopRequest struct {
+	Name             string        `json:"name" validate:"omitempty,min=2,max=100"`
+	Address          *Address      `json:"address"`
+	WorkstationCount *int          `json:"workstation_count" validate:"omitempty,min=0,max=10000"`
+	OpeningHours     *OpeningHours `json:"opening_hours"`
+	Contact          *ShopContact  `json:"contact"`
+	Note             string        `json:"note" validate:"omitempty,max=500"`
+}
+
+// ShopTotals aggregates the shops of a customer
+type ShopTotals struct {
+	ShopCount    int `bson:"shop_count"`
+	Workstations int `bson:"workstations"`
+}
+
+// ErrShopNotInCustomer is returned when a shop does not belong to the customer of a resource
+var ErrShopNotInCustomer = errors.New("shop does not belong to this customer")
+
+// ShopRepository represents the shop repository contract
+type ShopRepository interface {
+	Create(ctx context.Context, shop *Shop) error
+	GetByID(ctx context.Context, id string) (*Shop, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Shop, error)
+	TotalsByCustomers(ctx context.Context, customerIDs []primitive.ObjectID) (map[primitive.ObjectID]ShopTotals, error)
+	Update(ctx context.Context, shop *Shop) error
+	Delete(ctx context.Context, id string) error
+}
+
+// ShopUsecase represents the shop usecase contract
+type ShopUsecase interface {
+	Create(ctx context.Context, customerID string, req *CreateShopRequest) (*Shop, error)
+	GetByID(ctx context.Context, customerID, id string) (*Shop, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Shop, error)
+	Update(ctx context.Context, customerID, id string, req *UpdateShopRequest) (*Shop, error)
+	Delete(ctx context.Context, customerID, id string) error
+	GetFiles(ctx context.Context, customerID, id string) ([]*File, error)
+	AttachFile(ctx context.Context, customerID, id, fileID string) (*File, error)
+	DetachFile(ctx context.Context, customerID, id, fileID string) error
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// StoredObject describes the content stored under a storage key
+type StoredObject struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+	ETag         string
+}
+
+var (
+	// ErrStorageKeyExists is returned when putting content under a key that is already used
+	ErrStorageKeyExists = errors.New("storage key already exists")
+
+	// ErrPresignUnsupported is returned by storages that cannot link to their content directly
+	ErrPresignUnsupported = errors.New("storage does not support presigned links")
+)
+
+// FileStorage stores file contents under slash separated keys such as
+// "files/bao-gia-3f9a1c2b7d4e.pdf". Missing keys are reported with ErrNotFound.
+type FileStorage interface {
+	// Put stores content under a new key, it never replaces existing content.
+	// size is -1 when unknown.
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+
+	// Get opens the content of a key. Content that can seek also implements io.Seeker.
+	Get(ctx context.Context, key string) (io.ReadCloser, *StoredObject, error)
+
+	Stat(ctx context.Context, key string) (*StoredObject, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]*StoredObject, error)
+
+	// PresignGet returns a temporary link downloading a key as filename
+	PresignGet(ctx context.Context, key string, expires time.Duration, filename string) (string, error)
+}
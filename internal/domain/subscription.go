@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BillingCycle represents how often a subscription is billed
+type BillingCycle string
+
+const (
+	BillingMonthly   BillingCycle = "monthly"
+	BillingQuarterly BillingCycle = "quarterly"
+	BillingYearly    BillingCycle = "yearly"
+)
+
+// Months returns the length of a billing period in months
+func (c BillingCycle) Months() int {
+	switch c {
+	case BillingQuarterly:
+		return 3
+	case BillingYearly:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// SubscriptionStatus represents the billing state of a subscription
+type SubscriptionStatus string
+
+const (
+	SubscriptionTrial     SubscriptionStatus = "trial"
+	SubscriptionActive    SubscriptionStatus = "active"
+	SubscriptionPastDue   SubscriptionStatus = "past_due"  // renewal date passed without a renewal
+	SubscriptionCancelled SubscriptionStatus = "cancelled" // final, a cancelled subscription cannot be renewed
+)
+
+// Subscription represents what a customer pays for a plan and when it renews.
+// The plan name and price are copied when the subscription starts or renews,
+// so later plan changes do not affect the current period.
+type Subscription struct {
+	ID                 primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CustomerID         primitive.ObjectID `json:"customer_id" bson:"customer_id"`
+	PlanID             primitive.ObjectID `json:"plan_id" bson:"plan_id"`
+	PlanName           string             `json:"plan_name" bson:"plan_name"`
+	WorkstationRange   string             `json:"workstation_range" bson:"workstation_range"`
+	BillingCycle       BillingCycle       `json:"billing_cycle" bson:"billing_cycle"`
+	Price              int64              `json:"price" bson:"price"` // per billing period, in VND
+	Status             SubscriptionStatus `json:"status" bson:"status"`
+	RenewalDue         bool               `json:"renewal_due" bson:"-"`
+	TrialEndsAt        *time.Time         `json:"trial_ends_at,omitempty" bson:"trial_ends_at,omitempty"`
+	CurrentPeriodStart time.Time          `json:"current_period_start" bson:"current_period_start"`
+	NextRenewalAt      time.Time          `json:"next_renewal_at" bson:"next_renewal_at"`
+	LastRenewedAt      *time.Time         `json:"last_renewed_at,omitempty" bson:"last_renewed_at,omitempty"`
+	RemindersSent      []int              `json:"reminders_sent,omitempty" bson:"reminders_sent,omitempty"` // days-before reminders sent for NextRenewalAt
+	CancelledAt        *time.Time         `json:"cancelled_at,omitempty" bson:"cancelled_at,omitempty"`
+	CancelReason       string             `json:"cancel_reason,omitempty" bson:"cancel_reason,omitempty"`
+	CreatedBy          string             `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedOn          time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn         time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateSubscriptionRequest represents the request body for subscribing a customer to a plan.
+// WorkstationRange defaults to the range of the customer.
+type CreateSubscriptionRequest struct {
+	PlanID           string       `json:"plan_id" validate:"required"`
+	BillingCycle     BillingCycle `json:"billing_cycle" validate:"required,oneof=monthly quarterly yearly"`
+	WorkstationRange string       `json:"workstation_range" validate:"omitempty,oneof=1-10 10-20 20-50 50+"`
+	TrialDays        int          `json:"trial_days" validate:"omitempty,min=0,max=90"`
+}
+
+// RenewSubscriptionRequest represents the request body for renewing a subscription.
+// Changed terms apply from the new period.
+type RenewSubscriptionRequest struct {
+	PlanID           string       `json:"plan_id" validate:"omitempty"`
+	BillingCycle     BillingCycle `json:"billing_cycle" validate:"omitempty,oneof=monthly quarterly yearly"`
+	WorkstationRange string       `json:"workstation_range" validate:"omitempty,oneof=1-10 10-20 20-50 50+"`
+}
+
+// CancelSubscriptionRequest represents the request body for cancelling a subscription
+type CancelSubscriptionRequest struct {
+	Reason string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// RenewalReport summarises one run of the renewal check
+type RenewalReport struct {
+	Reminded int `json:"reminded"`
+	PastDue  int `json:"past_due"`
+	Failed   int `json:"failed"`
+}
+
+var (
+	// ErrSubscriptionExists is returned when a customer already has a subscription that is not cancelled
+	ErrSubscriptionExists = errors.New("customer already has a subscription")
+
+	// ErrSubscriptionCancelled is returned when changing a cancelled subscription
+	ErrSubscriptionCancelled = errors.New("subscription is cancelled")
+
+	// ErrSubscriptionChanged is returned when a subscription was renewed or marked
+	// past due by another request since it was read
+	ErrSubscriptionChanged = errors.New("subscription was changed by another request")
+
+	// ErrPlanInactive is returned when subscribing to a plan that is no longer offered
+	ErrPlanInactive = errors.New("plan is not active")
+
+	// ErrPlanPriceNotFound is returned when a plan has no price for a workstation range
+	ErrPlanPriceNotFound = errors.New("plan has no price for this workstation range")
+)
+
+// SubscriptionRepository represents the subscription repository contract
+type SubscriptionRepository interface {
+	Create(ctx context.Context, subscription *Subscription) error
+	GetByID(ctx context.Context, id string) (*Subscription, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Subscription, error)
+	GetCurrentByCustomer(ctx context.Context, customerID primitive.ObjectID) (*Subscription, error)
+	GetRenewingBefore(ctx context.Context, before time.Time) ([]*Subscription, error)
+	StartNextPeriod(ctx context.Context, subscription *Subscription, renewalAt time.Time) error
+	MarkPastDue(ctx context.Context, id primitive.ObjectID, renewalAt time.Time) error
+	Cancel(ctx context.Context, subscription *Subscription) error
+	AddRemindersSent(ctx context.Context, id primitive.ObjectID, renewalAt time.Time, days []int) error
+}
+
+// SubscriptionUsecase represents the subscription usecase contract
+type SubscriptionUsecase interface {
+	Create(ctx context.Context, customerID string, req *CreateSubscriptionRequest, actor *Actor) (*Subscription, error)
+	GetByID(ctx context.Context, id string) (*Subscription, error)
+	GetByCustomer(ctx context.Context, customerID string) ([]*Subscription, error)
+	GetUpcoming(ctx context.Context, days int) ([]*Subscription, error)
+	Renew(ctx context.Context, id string, req *RenewSubscriptionRequest) (*Subscription, error)
+	Cancel(ctx context.Context, id string, req *CancelSubscriptionRequest) (*Subscription, error)
+	ProcessRenewals(ctx context.Context) (*RenewalReport, error)
+}
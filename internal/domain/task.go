@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskStatus represents the state of a follow-up task
+type TaskStatus string
+
+const (
+	TaskOpen TaskStatus = "open"
+	TaskDone TaskStatus = "done"
+)
+
+// Task represents a scheduled follow-up with a customer
+type Task struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CustomerID  primitive.ObjectID `json:"customer_id" bson:"customer_id"`
+	Title       string             `json:"title" bson:"title"`
+	Note        string             `json:"note,omitempty" bson:"note,omitempty"`
+	DueAt       time.Time          `json:"due_at" bson:"due_at"`
+	AssigneeID  string             `json:"assignee_id" bson:"assignee_id"`
+	Status      TaskStatus         `json:"status" bson:"status"`
+	Overdue     bool               `json:"overdue" bson:"-"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CompletedBy string             `json:"completed_by,omitempty" bson:"completed_by,omitempty"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedOn   time.Time          `json:"created_on" bson:"created_on"`
+	ModifiedOn  time.Time          `json:"modified_on" bson:"modified_on"`
+}
+
+// CreateTaskRequest represents the request body for scheduling a follow-up.
+// The task is assigned to the caller when AssigneeID is empty.
+type CreateTaskRequest struct {
+	Title      string    `json:"title" validate:"required,min=2,max=200"`
+	Note       string    `json:"note" validate:"omitempty,max=1000"`
+	DueAt      time.Time `json:"due_at" validate:"required"`
+	AssigneeID string    `json:"assignee_id" validate:"omitempty"`
+}
+
+// UpdateTaskRequest represents the request body for updating a follow-up
+type UpdateTaskRequest struct {
+	Title      string     `json:"title" validate:"omitempty,min=2,max=200"`
+	Note       string     `json:"note" validate:"omitempty,max=1000"`
+	DueAt      *time.Time `json:"due_at" validate:"omitempty"`
+	AssigneeID string     `json:"assignee_id" validate:"omitempty"`
+	Status     TaskStatus `json:"status" validate:"omitempty,oneof=open done"`
+}
+
+// TaskFilter represents the filters of a task list
+type TaskFilter struct {
+	CustomerID string
+	AssigneeID string
+	Status     TaskStatus // empty matches every status
+	DueBefore  *time.Time
+}
+
+// TaskRepository represents the task repository contract
+type TaskRepository interface {
+	Create(ctx context.Context, task *Task) error
+	GetByID(ctx context.Context, id string) (*Task, error)
+	GetAll(ctx context.Context, filter *TaskFilter, page *Pagination) ([]*Task, error)
+	Count(ctx context.Context, filter *TaskFilter) (int64, error)
+	Update(ctx context.Context, task *Task) error
+	Delete(ctx context.Context, id string) error
+}
+
+// TaskUsecase represents the task usecase contract
+type TaskUsecase interface {
+	Create(ctx context.Context, customerID string, req *CreateTaskRequest, actor *Actor) (*Task, error)
+	GetAll(ctx context.Context, filter *TaskFilter, page *Pagination) ([]*Task, *PageInfo, error)
+	Update(ctx context.Context, id string, req *UpdateTaskRequest, actor *Actor) (*Task, error)
+	Delete(ctx context.Context, id string, actor *Actor) error
+}
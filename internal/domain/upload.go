@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Upload represents a resumable upload following the tus 1.0 protocol. Its bytes
+// are staged in the file storage, one part per PATCH, until Offset reaches Length,
+// then stored as a file. Only its creator
+// and staff may resume it: a signed-in creator by user ID, an anonymous one by the
+// key given when the upload was created.
+type Upload struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	FileType  FileType            `json:"file_type" bson:"file_type"`
+	FileName  string              `json:"file_name" bson:"file_name"`  // client file name from the metadata
+	Metadata  string              `json:"-" bson:"metadata,omitempty"` // Upload-Metadata header, echoed back as sent
+	Access    *FileAccess         `json:"-" bson:"access,omitempty"`   // visibility of the file once complete, public when nil
+	Length    int64               `json:"length" bson:"length"`
+	Offset    int64               `json:"offset" bson:"offset"`
+	Parts     []UploadPart        `json:"-" bson:"parts,omitempty"`                   // staged bytes, in order
+	FileID    *primitive.ObjectID `json:"file_id,omitempty" bson:"file_id,omitempty"` // set once the upload is complete
+	ExpiresAt time.Time           `json:"expires_at" bson:"expires_at"`
+	CreatedBy string              `json:"-" bson:"created_by,omitempty"` // user ID of a signed-in creator
+	KeyHash   string              `json:"-" bson:"key_hash,omitempty"`   // hash of the key of an anonymous creator
+	Key       string              `json:"-" bson:"-"`                    // only set when the upload is created
+	CreatedOn time.Time           `json:"created_on" bson:"created_on"`
+}
+
+// UploadPart is a staged piece of an upload, stored in the file storage under Key
+type UploadPart struct {
+	Key  string `bson:"key"`
+	Size int64  `bson:"size"`
+}
+
+// CreateUploadRequest represents a tus creation request
+type CreateUploadRequest struct {
+	Length   int64
+	FileType FileType
+	FileName string
+	Metadata string
+	Access   *FileAccess
+}
+
+// UploadChecksum is the checksum of a PATCH body from the Upload-Checksum header
+type UploadChecksum struct {
+	Algorithm string // sha1, md5 or sha256
+	Sum       []byte
+}
+
+// UploadChecksumAlgorithms are the checksum algorithms accepted for PATCH bodies
+var UploadChecksumAlgorithms = []string{"sha1", "md5", "sha256"}
+
+var (
+	// ErrUploadOffsetMismatch is returned when a PATCH does not start at the current offset
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match")
+
+	// ErrUploadChecksumMismatch is returned when a PATCH body does not match its checksum
+	ErrUploadChecksumMismatch = errors.New("upload checksum mismatch")
+
+	// ErrUploadChecksumAlgorithm is returned for an unsupported checksum algorithm
+	ErrUploadChecksumAlgorithm = errors.New("unsupported checksum algorithm")
+
+	// ErrUploadExpired is returned when resuming an upload that was not completed in time
+	ErrUploadExpired = errors.New("upload expired")
+
+	// ErrUploadLocked is returned while another request is completing the same upload
+	ErrUploadLocked = errors.New("upload is being completed by another request")
+)
+
+// UploadRepository represents the resumable upload repository contract
+type UploadRepository interface {
+	Create(ctx context.Context, upload *Upload) error
+	GetByID(ctx context.Context, id string) (*Upload, error)
+	AddPart(ctx context.Context, id primitive.ObjectID, offset int64, part UploadPart) error
+	Lock(ctx context.Context, id primitive.ObjectID, until time.Time) error
+	Unlock(ctx context.Context, id primitive.ObjectID) error
+	Complete(ctx context.Context, id primitive.ObjectID, fileID primitive.ObjectID) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	GetExpired(ctx context.Context, before time.Time, limit int64) ([]*Upload, error)
+}
+
+// UploadUsecase represents the resumable upload usecase contract
+type UploadUsecase interface {
+	Create(ctx context.Context, req *CreateUploadRequest, actor *Actor) (*Upload, error)
+	GetByID(ctx context.Context, id, key string, actor *Actor) (*Upload, error)
+	Append(ctx context.Context, id, key string, actor *Actor, offset int64, checksum *UploadChecksum, body io.Reader) (*Upload, error)
+	Terminate(ctx context.Context, id, key string, actor *Actor) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
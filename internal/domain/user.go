@@ -11,8 +11,9 @@ import (
 type Role string
 
 const (
-	RoleAdmin Role = "admin"
-	RoleSale  Role = "sale"
+	RoleAdmin    Role = "admin"
+	RoleSale     Role = "sale"
+	RoleReseller Role = "reseller" // signs in to the reseller portal only
 )
 
 // Permission represents user permission
@@ -26,6 +27,7 @@ const (
 	PermissionWriteFile          Permission = "file:write"
 	PermissionDeleteFile         Permission = "file:delete"
 	PermissionManageUser         Permission = "user:manage"
+	PermissionExportData         Permission = "data:export"
 )
 
 // RolePermissions defines permissions for each role
@@ -38,11 +40,13 @@ var RolePermissions = map[Role][]Permission{
 		PermissionWriteFile,
 		PermissionDeleteFile,
 		PermissionManageUser,
+		PermissionExportData,
 	},
 	RoleSale: {
 		PermissionReadRegistration,
 		PermissionReadFile,
 	},
+	RoleReseller: {},
 }
 
 // User represents the user entity
@@ -60,6 +64,8 @@ type User struct {
 	CreatedOn         time.Time          `json:"created_on" bson:"created_on"`
 	ModifiedOn        time.Time          `json:"modified_on" bson:"modified_on"`
 	LastLogin         *time.Time         `json:"last_login,omitempty" bson:"last_login,omitempty"`
+	DeletedAt         *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy         string             `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
 }
 
 // RegisterRequest represents request to register a new user (public)
@@ -77,7 +83,7 @@ type CreateUserRequest struct {
 	Phone    string `json:"phone" validate:"required,min=10,max=15"`
 	Password string `json:"password" validate:"required,min=6,max=100"`
 	FullName string `json:"full_name" validate:"required,min=2,max=100"`
-	Role     Role   `json:"role" validate:"required,oneof=admin sale"`
+	Role     Role   `json:"role" validate:"required,oneof=admin sale reseller"`
 }
 
 // UpdateUserRequest represents request to update user
@@ -85,7 +91,7 @@ type UpdateUserRequest struct {
 	Email             string       `json:"email" validate:"omitempty,email"`
 	Phone             string       `json:"phone" validate:"omitempty,min=10,max=15"`
 	FullName          string       `json:"full_name" validate:"omitempty,min=2,max=100"`
-	Role              Role         `json:"role" validate:"omitempty,oneof=admin sale"`
+	Role              Role         `json:"role" validate:"omitempty,oneof=admin sale reseller"`
 	IsActive          *bool        `json:"is_active" validate:"omitempty"`
 	CustomPermissions []Permission `json:"custom_permissions" validate:"omitempty"` // Admin can assign custom permissions
 }
@@ -98,7 +104,7 @@ type ChangePasswordRequest struct {
 
 // UpdateUserRoleRequest represents request to update user role and permissions (admin only)
 type UpdateUserRoleRequest struct {
-	Role              Role         `json:"role" validate:"omitempty,oneof=admin sale"`
+	Role              Role         `json:"role" validate:"omitempty,oneof=admin sale reseller"`
 	CustomPermissions []Permission `json:"custom_permissions" validate:"omitempty"`
 }
 
@@ -109,22 +115,31 @@ type UserRepository interface {
 	GetByUsername(ctx context.Context, username string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByPhone(ctx context.Context, phone string) (*User, error)
-	GetAll(ctx context.Context, limit, offset int64) ([]*User, error)
+	GetAll(ctx context.Context, page *Pagination) ([]*User, error)
 	Update(ctx context.Context, id string, user *User) error
 	UpdateLastLogin(ctx context.Context, id string) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, deletedBy string) error
+	GetDeleted(ctx context.Context, page *Pagination) ([]*User, error)
+	CountDeleted(ctx context.Context) (int64, error)
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
 	Count(ctx context.Context) (int64, error)
+	EstimatedCount(ctx context.Context) (int64, error)
+	GetActiveByRole(ctx context.Context, role Role) ([]*User, error)
 }
 
 // UserUsecase represents the user usecase contract
 type UserUsecase interface {
 	Create(ctx context.Context, req *CreateUserRequest) (*User, error)
 	GetByID(ctx context.Context, id string) (*User, error)
-	GetAll(ctx context.Context, limit, offset int64) ([]*User, int64, error)
+	GetAll(ctx context.Context, page *Pagination) ([]*User, *PageInfo, error)
 	Update(ctx context.Context, id string, req *UpdateUserRequest) (*User, error)
 	UpdateRole(ctx context.Context, id string, req *UpdateUserRoleRequest) (*User, error)
 	ChangePassword(ctx context.Context, id string, req *ChangePasswordRequest) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, deletedBy string) error
+	GetTrash(ctx context.Context, page *Pagination) ([]*User, *PageInfo, error)
+	Restore(ctx context.Context, id string) (*User, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
 // HasPermission checks if user has a specific permission (from role or custom)
@@ -0,0 +1,22 @@
+package notifier
+
+import (
+	"context"
+	"log"
+
+	"icafe-registration/internal/domain"
+)
+
+type logNotifier struct{}
+
+// NewLogNotifier creates a notifier that only writes notifications to the log,
+// for development and deployments without a mail server
+func NewLogNotifier() domain.Notifier {
+	return &logNotifier{}
+}
+
+// Notify logs a notification
+func (n *logNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	log.Printf("Notification to %s <%s>: %s", notification.Name, notification.Email, notification.Subject)
+	return nil
+}
@@ -0,0 +1,165 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"icafe-registration/internal/domain"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// VNPay formats dates in Vietnam time, which has no daylight saving
+var vnpayLocation = time.FixedZone("ICT", 7*60*60)
+
+const vnpayDateLayout = "20060102150405"
+
+type vnpayGateway struct {
+	tmnCode    string
+	hashSecret string
+	payURL     string
+	returnURL  string
+}
+
+// NewVNPayGateway creates a gateway that redirects customers to a VNPay payment page
+// and verifies the HMAC-SHA512 signature of its callbacks
+func NewVNPayGateway(tmnCode, hashSecret, payURL, returnURL string) domain.PaymentGateway {
+	return &vnpayGateway{
+		tmnCode:    tmnCode,
+		hashSecret: hashSecret,
+		payURL:     payURL,
+		returnURL:  returnURL,
+	}
+}
+
+// Name implements domain.PaymentGateway
+func (g *vnpayGateway) Name() string {
+	return "vnpay"
+}
+
+// PaymentURL implements domain.PaymentGateway
+func (g *vnpayGateway) PaymentURL(payment *domain.Payment, clientIP string) (string, error) {
+	if payment.Amount <= 0 {
+		return "", domain.ErrInvalidInput
+	}
+
+	params := url.Values{}
+	params.Set("vnp_Version", "2.1.0")
+	params.Set("vnp_Command", "pay")
+	params.Set("vnp_TmnCode", g.tmnCode)
+	params.Set("vnp_Amount", strconv.FormatInt(payment.Amount*100, 10)) // in hundredths of a dong
+	params.Set("vnp_CurrCode", "VND")
+	params.Set("vnp_TxnRef", payment.TxnRef)
+	params.Set("vnp_OrderInfo", vnpayOrderInfo(payment.Description))
+	params.Set("vnp_OrderType", "other")
+	params.Set("vnp_Locale", "vn")
+	params.Set("vnp_ReturnUrl", g.returnURL)
+	params.Set("vnp_IpAddr", clientIP)
+	params.Set("vnp_CreateDate", payment.CreatedOn.In(vnpayLocation).Format(vnpayDateLayout))
+	params.Set("vnp_ExpireDate", payment.ExpiresAt.In(vnpayLocation).Format(vnpayDateLayout))
+
+	query := SignVNPay(params, g.hashSecret)
+	return g.payURL + "?" + query, nil
+}
+
+// VerifyCallback implements domain.PaymentGateway. A payment succeeded when both
+// the response code and the transaction status are "00".
+func (g *vnpayGateway) VerifyCallback(params url.Values) (*domain.GatewayResult, error) {
+	if !VerifyVNPay(params, g.hashSecret) || params.Get("vnp_TmnCode") != g.tmnCode {
+		return nil, domain.ErrInvalidSignature
+	}
+
+	amount, err := strconv.ParseInt(params.Get("vnp_Amount"), 10, 64)
+	if err != nil || amount%100 != 0 {
+		return nil, domain.ErrInvalidInput
+	}
+
+	result := &domain.GatewayResult{
+		TxnRef:        params.Get("vnp_TxnRef"),
+		Amount:        amount / 100,
+		TransactionNo: params.Get("vnp_TransactionNo"),
+		BankCode:      params.Get("vnp_BankCode"),
+		ResponseCode:  params.Get("vnp_ResponseCode"),
+	}
+	result.Success = result.ResponseCode == "00" && params.Get("vnp_TransactionStatus") == "00"
+
+	if paidAt, err := time.ParseInLocation(vnpayDateLayout, params.Get("vnp_PayDate"), vnpayLocation); err == nil {
+		result.PaidAt = &paidAt
+	}
+
+	return result, nil
+}
+
+// vnpayOrderInfo removes the Vietnamese diacritics and special characters
+// that VNPay does not accept in order descriptions
+func vnpayOrderInfo(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r == 'đ':
+			b.WriteByte('d')
+		case r == 'Đ':
+			b.WriteByte('D')
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(" -/.,:", r)):
+			b.WriteRune(r)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// SignVNPay returns the query string of params followed by its vnp_SecureHash.
+// VNPay signs the URL encoded parameters sorted by name, leaving out empty ones.
+func SignVNPay(params url.Values, hashSecret string) string {
+	query := vnpayHashData(params)
+	return query + "&vnp_SecureHash=" + vnpayHash(query, hashSecret)
+}
+
+// VerifyVNPay checks the vnp_SecureHash of callback parameters
+func VerifyVNPay(params url.Values, hashSecret string) bool {
+	expected, err := hex.DecodeString(params.Get("vnp_SecureHash"))
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	actual, _ := hex.DecodeString(vnpayHash(vnpayHashData(params), hashSecret))
+	return hmac.Equal(expected, actual)
+}
+
+// vnpayHashData builds the signed data: the vnp_ parameters other than the hash
+// itself, sorted by name and URL encoded
+func vnpayHashData(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if !strings.HasPrefix(key, "vnp_") || key == "vnp_SecureHash" || key == "vnp_SecureHashType" {
+			continue
+		}
+		if params.Get(key) == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%s", url.QueryEscape(key), url.QueryEscape(params.Get(key)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+func vnpayHash(data, hashSecret string) string {
+	mac := hmac.New(sha512.New, []byte(hashSecret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,223 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VNPay response codes used by the fake gateway
+const (
+	vnpayApproved  = "00"
+	vnpayExpired   = "11"
+	vnpayCancelled = "24"
+)
+
+// FakeVNPay is a local stand-in for the VNPay payment page, for development and
+// end-to-end tests without a merchant account. It checks the signature of payment
+// URLs, lets the payer approve or cancel, calls the IPN URL with a signed result
+// as VNPay does, then redirects the payer to the return URL.
+type FakeVNPay struct {
+	tmnCode    string
+	hashSecret string
+	ipnURL     string
+	client     *http.Client
+
+	mu       sync.Mutex
+	sequence int64
+}
+
+// IPNResponse is the acknowledgement returned by the merchant IPN endpoint
+type IPNResponse struct {
+	RspCode string `json:"RspCode"`
+	Message string `json:"Message"`
+}
+
+// NewFakeVNPay creates a fake gateway for a terminal code and hash secret that
+// reports payment results to ipnURL
+func NewFakeVNPay(tmnCode, hashSecret, ipnURL string) *FakeVNPay {
+	return &FakeVNPay{
+		tmnCode:    tmnCode,
+		hashSecret: hashSecret,
+		ipnURL:     ipnURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ServeHTTP serves the payment page at /paymentv2/vpcpay.html, the path of the
+// VNPay payment URL, and the payer's choice at /paymentv2/complete
+func (f *FakeVNPay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/paymentv2/vpcpay.html":
+		f.checkout(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/paymentv2/complete":
+		f.complete(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Pay settles a payment URL as a payer would, without a browser. It returns the
+// acknowledgement of the IPN endpoint and the URL the payer is sent back to.
+func (f *FakeVNPay) Pay(ctx context.Context, paymentURL string, approve bool) (*IPNResponse, string, error) {
+	u, err := url.Parse(paymentURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	params, err := f.verify(u.Query())
+	if err != nil {
+		return nil, "", err
+	}
+
+	return f.settle(ctx, params, approve)
+}
+
+var fakeCheckoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html lang="vi">
+<head><meta charset="utf-8"><title>VNPay (giả lập)</title></head>
+<body>
+<h1>Cổng thanh toán giả lập</h1>
+<p>Mã giao dịch: {{.TxnRef}}</p>
+<p>Nội dung: {{.OrderInfo}}</p>
+<p>Số tiền: {{.Amount}} VND</p>
+<form method="post" action="/paymentv2/complete">
+<input type="hidden" name="payment" value="{{.Payment}}">
+<button type="submit" name="action" value="approve">Thanh toán</button>
+<button type="submit" name="action" value="cancel">Hủy giao dịch</button>
+</form>
+</body>
+</html>`))
+
+func (f *FakeVNPay) checkout(w http.ResponseWriter, r *http.Request) {
+	params, err := f.verify(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	amount, _ := strconv.ParseInt(params.Get("vnp_Amount"), 10, 64)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fakeCheckoutPage.Execute(w, map[string]interface{}{
+		"TxnRef":    params.Get("vnp_TxnRef"),
+		"OrderInfo": params.Get("vnp_OrderInfo"),
+		"Amount":    amount / 100,
+		"Payment":   r.URL.RawQuery,
+	})
+}
+
+func (f *FakeVNPay) complete(w http.ResponseWriter, r *http.Request) {
+	query, err := url.ParseQuery(r.PostFormValue("payment"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params, err := f.verify(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ack, returnURL, err := f.settle(r.Context(), params, r.PostFormValue("action") == "approve")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("X-IPN-Response", ack.RspCode+" "+ack.Message)
+	http.Redirect(w, r, returnURL, http.StatusFound)
+}
+
+// verify checks that payment parameters are signed for this terminal
+func (f *FakeVNPay) verify(params url.Values) (url.Values, error) {
+	if !VerifyVNPay(params, f.hashSecret) {
+		return nil, fmt.Errorf("invalid vnp_SecureHash")
+	}
+	if params.Get("vnp_TmnCode") != f.tmnCode {
+		return nil, fmt.Errorf("unknown vnp_TmnCode %q", params.Get("vnp_TmnCode"))
+	}
+	for _, key := range []string{"vnp_TxnRef", "vnp_Amount", "vnp_ReturnUrl"} {
+		if params.Get(key) == "" {
+			return nil, fmt.Errorf("missing %s", key)
+		}
+	}
+	return params, nil
+}
+
+// settle reports the result of a payment to the IPN endpoint and returns the
+// return URL carrying the same signed result
+func (f *FakeVNPay) settle(ctx context.Context, params url.Values, approve bool) (*IPNResponse, string, error) {
+	now := time.Now().In(vnpayLocation)
+
+	f.mu.Lock()
+	f.sequence++
+	transactionNo := strconv.FormatInt(now.Unix()%100000000*100+f.sequence%100, 10)
+	f.mu.Unlock()
+
+	responseCode, transactionStatus := vnpayApproved, "00"
+	switch expireDate, err := time.ParseInLocation(vnpayDateLayout, params.Get("vnp_ExpireDate"), vnpayLocation); {
+	case err == nil && now.After(expireDate):
+		responseCode, transactionStatus = vnpayExpired, "02"
+	case !approve:
+		responseCode, transactionStatus = vnpayCancelled, "02"
+	}
+
+	result := url.Values{}
+	result.Set("vnp_TmnCode", f.tmnCode)
+	result.Set("vnp_Amount", params.Get("vnp_Amount"))
+	result.Set("vnp_BankCode", "NCB")
+	result.Set("vnp_CardType", "ATM")
+	result.Set("vnp_OrderInfo", params.Get("vnp_OrderInfo"))
+	result.Set("vnp_PayDate", now.Format(vnpayDateLayout))
+	result.Set("vnp_ResponseCode", responseCode)
+	result.Set("vnp_TransactionStatus", transactionStatus)
+	result.Set("vnp_TxnRef", params.Get("vnp_TxnRef"))
+	if responseCode == vnpayApproved {
+		result.Set("vnp_TransactionNo", transactionNo)
+		result.Set("vnp_BankTranNo", "VNP"+transactionNo)
+	} else {
+		result.Set("vnp_TransactionNo", "0")
+	}
+	query := SignVNPay(result, f.hashSecret)
+
+	ack, err := f.notify(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	returnURL := params.Get("vnp_ReturnUrl")
+	if strings.Contains(returnURL, "?") {
+		returnURL += "&" + query
+	} else {
+		returnURL += "?" + query
+	}
+	return ack, returnURL, nil
+}
+
+// notify calls the IPN endpoint with a signed result, as VNPay does server to server
+func (f *FakeVNPay) notify(ctx context.Context, query string) (*IPNResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.ipnURL+"?"+query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var ack IPNResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ack); err != nil {
+		return nil, fmt.Errorf("IPN endpoint returned %s: %w", resp.Status, err)
+	}
+	return &ack, nil
+}
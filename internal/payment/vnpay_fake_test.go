@@ -0,0 +1,465 @@
+package payment_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	delivery "icafe-registration/internal/delivery/http"
+	"icafe-registration/internal/domain"
+	"icafe-registration/internal/payment"
+	"icafe-registration/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testTmnCode    = "TESTTMN1"
+	testHashSecret = "test-hash-secret"
+)
+
+// gatewayTest wires the payment usecase and its IPN endpoint to the fake gateway,
+// with the repositories kept in memory
+type gatewayTest struct {
+	fake          *payment.FakeVNPay
+	ipnURL        string
+	payments      *memoryPayments
+	subscriptions *memorySubscriptions
+	usecase       domain.PaymentUsecase
+	subscription  *domain.Subscription
+}
+
+func newGatewayTest(t *testing.T) *gatewayTest {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	plan := &domain.Plan{
+		ID:       primitive.NewObjectID(),
+		Name:     "Standard",
+		Prices:   []domain.PlanPrice{{WorkstationRange: "10-20", MonthlyPrice: 300000}},
+		IsActive: true,
+	}
+	renewalAt := time.Now().AddDate(0, 0, 3).UTC().Truncate(time.Millisecond)
+	subscription := &domain.Subscription{
+		ID:                 primitive.NewObjectID(),
+		CustomerID:         primitive.NewObjectID(),
+		PlanID:             plan.ID,
+		PlanName:           plan.Name,
+		WorkstationRange:   "10-20",
+		BillingCycle:       domain.BillingMonthly,
+		Price:              300000,
+		Status:             domain.SubscriptionActive,
+		CurrentPeriodStart: renewalAt.AddDate(0, -1, 0),
+		NextRenewalAt:      renewalAt,
+	}
+
+	gt := &gatewayTest{
+		payments:      &memoryPayments{byID: map[primitive.ObjectID]*domain.Payment{}},
+		subscriptions: &memorySubscriptions{byID: map[primitive.ObjectID]*domain.Subscription{subscription.ID: subscription}},
+		subscription:  subscription,
+	}
+
+	// The gateway pays at the fake payment page and reports to the merchant IPN endpoint
+	fakeServer := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(fakeServer.Close)
+
+	gateway := payment.NewVNPayGateway(testTmnCode, testHashSecret,
+		fakeServer.URL+"/paymentv2/vpcpay.html", "http://merchant.test/payments/vnpay/return")
+	gt.usecase = usecase.NewPaymentUsecase(gt.payments, gt.subscriptions, &memoryPlans{plan: plan}, nil,
+		gateway, noCommissions{}, 15*time.Minute, 5*time.Second)
+
+	engine := gin.New()
+	v1 := engine.Group("/api/v1")
+	delivery.NewPaymentHandler(v1, v1.Group("/staff"), gt.usecase)
+	merchant := httptest.NewServer(engine)
+	t.Cleanup(merchant.Close)
+
+	gt.ipnURL = merchant.URL + "/api/v1/payments/vnpay/ipn"
+	gt.fake = payment.NewFakeVNPay(testTmnCode, testHashSecret, gt.ipnURL)
+	fakeServer.Config.Handler = gt.fake
+
+	return gt
+}
+
+// createPayment creates a payment link for the next period of the subscription
+func (gt *gatewayTest) createPayment(t *testing.T) *domain.Payment {
+	t.Helper()
+
+	created, err := gt.usecase.Create(context.Background(), gt.subscription.ID.Hex(),
+		&domain.CreatePaymentRequest{}, "127.0.0.1", &domain.Actor{ID: "admin"})
+	if err != nil {
+		t.Fatalf("create payment: %v", err)
+	}
+	return created
+}
+
+// callIPN calls the IPN endpoint with a query string, as VNPay would
+func (gt *gatewayTest) callIPN(t *testing.T, query string) *payment.IPNResponse {
+	t.Helper()
+
+	resp, err := http.Get(gt.ipnURL + "?" + query)
+	if err != nil {
+		t.Fatalf("call IPN: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var ack payment.IPNResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ack); err != nil {
+		t.Fatalf("decode IPN response: %v", err)
+	}
+	return &ack
+}
+
+func TestFakeVNPayApprovedPaymentRenewsOnce(t *testing.T) {
+	gt := newGatewayTest(t)
+	renewalAt := gt.subscription.NextRenewalAt
+	created := gt.createPayment(t)
+
+	ack, returnURL, err := gt.fake.Pay(context.Background(), created.PaymentURL, true)
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if ack.RspCode != "00" {
+		t.Fatalf("IPN RspCode = %s (%s), want 00", ack.RspCode, ack.Message)
+	}
+
+	paid := gt.payments.get(created.ID)
+	if paid.Status != domain.PaymentSucceeded {
+		t.Errorf("payment status = %s, want %s", paid.Status, domain.PaymentSucceeded)
+	}
+	if paid.TransactionNo == "" || paid.TransactionNo == "0" {
+		t.Errorf("payment transaction number = %q, want the gateway's", paid.TransactionNo)
+	}
+
+	want := renewalAt.AddDate(0, 1, 0)
+	if got := gt.subscriptions.get(gt.subscription.ID); !got.NextRenewalAt.Equal(want) || got.Status != domain.SubscriptionActive {
+		t.Errorf("subscription renews at %s with status %s, want %s and %s", got.NextRenewalAt, got.Status, want, domain.SubscriptionActive)
+	}
+	if !paid.PeriodStart.Equal(renewalAt) || !paid.PeriodEnd.Equal(want) {
+		t.Errorf("payment period = %s - %s, want %s - %s", paid.PeriodStart, paid.PeriodEnd, renewalAt, want)
+	}
+
+	// The payer comes back with the same signed result
+	u, err := url.Parse(returnURL)
+	if err != nil {
+		t.Fatalf("parse return URL: %v", err)
+	}
+	returned, err := gt.usecase.GetReturnResult(context.Background(), u.Query())
+	if err != nil {
+		t.Fatalf("return result: %v", err)
+	}
+	if returned.ID != created.ID {
+		t.Errorf("return result is payment %s, want %s", returned.ID.Hex(), created.ID.Hex())
+	}
+}
+
+func TestFakeVNPayReplayedIPNIsRejected(t *testing.T) {
+	gt := newGatewayTest(t)
+	created := gt.createPayment(t)
+
+	_, returnURL, err := gt.fake.Pay(context.Background(), created.PaymentURL, true)
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	renewed := gt.subscriptions.get(gt.subscription.ID).NextRenewalAt
+
+	// The return URL carries the signed result the IPN endpoint already received
+	u, err := url.Parse(returnURL)
+	if err != nil {
+		t.Fatalf("parse return URL: %v", err)
+	}
+	if ack := gt.callIPN(t, u.RawQuery); ack.RspCode != "02" {
+		t.Errorf("replayed IPN RspCode = %s (%s), want 02", ack.RspCode, ack.Message)
+	}
+
+	if got := gt.subscriptions.get(gt.subscription.ID).NextRenewalAt; !got.Equal(renewed) {
+		t.Errorf("replayed IPN moved the renewal date from %s to %s", renewed, got)
+	}
+}
+
+func TestFakeVNPayTamperedSignatureIsRejected(t *testing.T) {
+	gt := newGatewayTest(t)
+	renewalAt := gt.subscription.NextRenewalAt
+	created := gt.createPayment(t)
+
+	// A success result for the pending payment, as the gateway would sign it
+	result := url.Values{}
+	result.Set("vnp_TmnCode", testTmnCode)
+	result.Set("vnp_Amount", strconv.FormatInt(created.Amount*100, 10))
+	result.Set("vnp_ResponseCode", "00")
+	result.Set("vnp_TransactionStatus", "00")
+	result.Set("vnp_TransactionNo", "14000001")
+	result.Set("vnp_TxnRef", created.TxnRef)
+	signed, err := url.ParseQuery(payment.SignVNPay(result, testHashSecret))
+	if err != nil {
+		t.Fatalf("parse signed result: %v", err)
+	}
+
+	changedHash := url.Values{}
+	for key, values := range signed {
+		changedHash[key] = values
+	}
+	hash := []byte(signed.Get("vnp_SecureHash"))
+	if hash[0] == '0' {
+		hash[0] = '1'
+	} else {
+		hash[0] = '0'
+	}
+	changedHash.Set("vnp_SecureHash", string(hash))
+
+	otherSecret, err := url.ParseQuery(payment.SignVNPay(result, "another-secret"))
+	if err != nil {
+		t.Fatalf("parse signed result: %v", err)
+	}
+
+	for name, query := range map[string]string{
+		"changed vnp_SecureHash":     changedHash.Encode(),
+		"signed with another secret": otherSecret.Encode(),
+		"missing vnp_SecureHash":     result.Encode(),
+	} {
+		if ack := gt.callIPN(t, query); ack.RspCode != "97" {
+			t.Errorf("IPN %s: RspCode = %s (%s), want 97", name, ack.RspCode, ack.Message)
+		}
+	}
+
+	if got := gt.payments.get(created.ID).Status; got != domain.PaymentPending {
+		t.Errorf("payment status = %s, want %s", got, domain.PaymentPending)
+	}
+	if got := gt.subscriptions.get(gt.subscription.ID).NextRenewalAt; !got.Equal(renewalAt) {
+		t.Errorf("subscription renews at %s, want %s", got, renewalAt)
+	}
+
+	// The payment can still be paid
+	ack, _, err := gt.fake.Pay(context.Background(), created.PaymentURL, true)
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if ack.RspCode != "00" {
+		t.Errorf("IPN RspCode = %s (%s), want 00", ack.RspCode, ack.Message)
+	}
+}
+
+func TestFakeVNPayAmountMismatchIsRejected(t *testing.T) {
+	gt := newGatewayTest(t)
+	renewalAt := gt.subscription.NextRenewalAt
+	created := gt.createPayment(t)
+
+	// A payment URL for a lower amount, signed with the merchant secret
+	u, err := url.Parse(created.PaymentURL)
+	if err != nil {
+		t.Fatalf("parse payment URL: %v", err)
+	}
+	params := u.Query()
+	params.Set("vnp_Amount", strconv.FormatInt((created.Amount-1000)*100, 10))
+	u.RawQuery = payment.SignVNPay(params, testHashSecret)
+
+	ack, _, err := gt.fake.Pay(context.Background(), u.String(), true)
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if ack.RspCode != "04" {
+		t.Errorf("IPN RspCode = %s (%s), want 04", ack.RspCode, ack.Message)
+	}
+
+	if got := gt.payments.get(created.ID).Status; got != domain.PaymentPending {
+		t.Errorf("payment status = %s, want %s", got, domain.PaymentPending)
+	}
+	if got := gt.subscriptions.get(gt.subscription.ID).NextRenewalAt; !got.Equal(renewalAt) {
+		t.Errorf("subscription renews at %s, want %s", got, renewalAt)
+	}
+}
+
+func TestFakeVNPayFailedPaymentDoesNotRenew(t *testing.T) {
+	gt := newGatewayTest(t)
+	renewalAt := gt.subscription.NextRenewalAt
+	created := gt.createPayment(t)
+
+	ack, _, err := gt.fake.Pay(context.Background(), created.PaymentURL, false)
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if ack.RspCode != "00" {
+		t.Fatalf("IPN RspCode = %s (%s), want 00", ack.RspCode, ack.Message)
+	}
+
+	failed := gt.payments.get(created.ID)
+	if failed.Status != domain.PaymentFailed || failed.ResponseCode != "24" {
+		t.Errorf("payment status = %s with response code %s, want %s with 24", failed.Status, failed.ResponseCode, domain.PaymentFailed)
+	}
+	if failed.RenewedAt != nil {
+		t.Errorf("failed payment renewed the subscription at %s", failed.RenewedAt)
+	}
+	if got := gt.subscriptions.get(gt.subscription.ID).NextRenewalAt; !got.Equal(renewalAt) {
+		t.Errorf("subscription renews at %s, want %s", got, renewalAt)
+	}
+}
+
+func TestFakeVNPayPaymentRenewsAfterConcurrentChange(t *testing.T) {
+	gt := newGatewayTest(t)
+	renewalAt := gt.subscription.NextRenewalAt
+	created := gt.createPayment(t)
+
+	// A manual renewal of the same period is saved while the IPN is handled
+	gt.subscriptions.beforeSave = func(s *domain.Subscription) {
+		s.CurrentPeriodStart = s.NextRenewalAt
+		s.NextRenewalAt = s.NextRenewalAt.AddDate(0, 1, 0)
+	}
+
+	ack, _, err := gt.fake.Pay(context.Background(), created.PaymentURL, true)
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if ack.RspCode != "00" {
+		t.Fatalf("IPN RspCode = %s (%s), want 00", ack.RspCode, ack.Message)
+	}
+
+	// Both renewals count, the payment covers the period after the manual one
+	want := renewalAt.AddDate(0, 2, 0)
+	if got := gt.subscriptions.get(gt.subscription.ID).NextRenewalAt; !got.Equal(want) {
+		t.Errorf("subscription renews at %s, want %s", got, want)
+	}
+	if got := gt.payments.get(created.ID); !got.PeriodStart.Equal(renewalAt.AddDate(0, 1, 0)) || !got.PeriodEnd.Equal(want) {
+		t.Errorf("payment period = %s - %s, want %s - %s", got.PeriodStart, got.PeriodEnd, renewalAt.AddDate(0, 1, 0), want)
+	}
+}
+
+// memoryPayments keeps payments in memory
+type memoryPayments struct {
+	domain.PaymentRepository
+
+	mu   sync.Mutex
+	byID map[primitive.ObjectID]*domain.Payment
+}
+
+func (r *memoryPayments) get(id primitive.ObjectID) domain.Payment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.byID[id]
+}
+
+func (r *memoryPayments) Create(ctx context.Context, p *domain.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *p
+	r.byID[p.ID] = &stored
+	return nil
+}
+
+func (r *memoryPayments) GetByTxnRef(ctx context.Context, txnRef string) (*domain.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.byID {
+		if p.TxnRef == txnRef {
+			found := *p
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *memoryPayments) Complete(ctx context.Context, p *domain.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.byID[p.ID]
+	if stored.Status != domain.PaymentPending {
+		return domain.ErrPaymentProcessed
+	}
+	stored.Status = p.Status
+	stored.TransactionNo = p.TransactionNo
+	stored.BankCode = p.BankCode
+	stored.ResponseCode = p.ResponseCode
+	stored.PaidAt = p.PaidAt
+	return nil
+}
+
+func (r *memoryPayments) SetRenewal(ctx context.Context, p *domain.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.byID[p.ID]
+	stored.PeriodStart = p.PeriodStart
+	stored.PeriodEnd = p.PeriodEnd
+	stored.RenewedAt = p.RenewedAt
+	return nil
+}
+
+// memorySubscriptions keeps subscriptions in memory. beforeSave, when set, changes
+// the stored subscription once before the next renewal is saved, as a concurrent
+// request would.
+type memorySubscriptions struct {
+	domain.SubscriptionRepository
+
+	mu         sync.Mutex
+	byID       map[primitive.ObjectID]*domain.Subscription
+	beforeSave func(*domain.Subscription)
+}
+
+func (r *memorySubscriptions) get(id primitive.ObjectID) domain.Subscription {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.byID[id]
+}
+
+func (r *memorySubscriptions) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.byID[objectID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	found := *stored
+	return &found, nil
+}
+
+func (r *memorySubscriptions) StartNextPeriod(ctx context.Context, s *domain.Subscription, renewalAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.byID[s.ID]
+	if r.beforeSave != nil {
+		r.beforeSave(stored)
+		r.beforeSave = nil
+	}
+	if stored.Status == domain.SubscriptionCancelled || !stored.NextRenewalAt.Equal(renewalAt) {
+		return domain.ErrSubscriptionChanged
+	}
+
+	saved := *s
+	r.byID[s.ID] = &saved
+	return nil
+}
+
+// memoryPlans serves a single plan
+type memoryPlans struct {
+	domain.PlanRepository
+	plan *domain.Plan
+}
+
+func (r *memoryPlans) GetByID(ctx context.Context, id string) (*domain.Plan, error) {
+	if id != r.plan.ID.Hex() {
+		return nil, domain.ErrNotFound
+	}
+	return r.plan, nil
+}
+
+// noCommissions records no commission, as if no customer was referred
+type noCommissions struct{}
+
+func (noCommissions) RecordPayment(ctx context.Context, p *domain.ReferredPayment) (*domain.Commission, error) {
+	return nil, nil
+}
+
+func (noCommissions) CancelPayment(ctx context.Context, source domain.CommissionSource, sourceID primitive.ObjectID, reason string) error {
+	return nil
+}
@@ -0,0 +1,154 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const activityCollection = "customer_activities"
+
+type activityRepository struct {
+	collection *mongo.Collection
+}
+
+// NewActivityRepository creates a new customer activity repository
+func NewActivityRepository(db *mongo.Database) domain.ActivityRepository {
+	collection := db.Collection(activityCollection)
+
+	indexModels := []mongo.IndexModel{
+		// Timeline of a customer, newest first
+		{
+			Keys: bson.D{
+				{Key: "customer_id", Value: 1},
+				{Key: "created_on", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &activityRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new activity
+func (r *activityRepository) Create(ctx context.Context, activity *domain.CustomerActivity) error {
+	activity.ID = primitive.NewObjectID()
+	activity.CreatedOn = time.Now()
+	activity.ModifiedOn = activity.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, activity)
+	return err
+}
+
+// GetByID gets an activity by ID
+func (r *activityRepository) GetByID(ctx context.Context, id string) (*domain.CustomerActivity, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var activity domain.CustomerActivity
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&activity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &activity, nil
+}
+
+// activityQuery builds the timeline query of a customer
+func activityQuery(customerID string, activityType domain.ActivityType) (bson.M, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	filter := bson.M{"customer_id": objectID}
+	if activityType != "" {
+		filter["type"] = activityType
+	}
+	return filter, nil
+}
+
+// GetByCustomer gets the timeline of a customer, newest first
+func (r *activityRepository) GetByCustomer(ctx context.Context, customerID string, activityType domain.ActivityType, page *domain.Pagination) ([]*domain.CustomerActivity, error) {
+	filter, err := activityQuery(customerID, activityType)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.collection.Find(ctx, pageFilter(filter, page), pageOptions(page))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var activities []*domain.CustomerActivity
+	if err := cursor.All(ctx, &activities); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+// CountByCustomer counts the timeline entries of a customer
+func (r *activityRepository) CountByCustomer(ctx context.Context, customerID string, activityType domain.ActivityType) (int64, error) {
+	filter, err := activityQuery(customerID, activityType)
+	if err != nil {
+		return 0, err
+	}
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// Update updates the content of an activity
+func (r *activityRepository) Update(ctx context.Context, activity *domain.CustomerActivity) error {
+	activity.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"content":     activity.Content,
+			"modified_on": activity.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": activity.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes an activity
+func (r *activityRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const addOnCollection = "addons"
+
+type addOnRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAddOnRepository creates a new add-on module repository
+func NewAddOnRepository(db *mongo.Database) domain.AddOnRepository {
+	collection := db.Collection(addOnCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &addOnRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new add-on
+func (r *addOnRepository) Create(ctx context.Context, addOn *domain.AddOn) error {
+	addOn.ID = primitive.NewObjectID()
+	addOn.CreatedOn = time.Now()
+	addOn.ModifiedOn = addOn.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, addOn)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAddOnCodeExists
+	}
+	return err
+}
+
+// GetByID gets an add-on by ID
+func (r *addOnRepository) GetByID(ctx context.Context, id string) (*domain.AddOn, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var addOn domain.AddOn
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&addOn)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &addOn, nil
+}
+
+// GetByCodes gets the add-ons with the given codes; unknown codes are left out
+func (r *addOnRepository) GetByCodes(ctx context.Context, codes []string) ([]*domain.AddOn, error) {
+	return r.find(ctx, bson.M{"code": bson.M{"$in": codes}})
+}
+
+// GetAll gets the add-ons sorted by name, optionally only the active ones
+func (r *addOnRepository) GetAll(ctx context.Context, activeOnly bool) ([]*domain.AddOn, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["is_active"] = true
+	}
+
+	return r.find(ctx, filter)
+}
+
+func (r *addOnRepository) find(ctx context.Context, filter bson.M) ([]*domain.AddOn, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	addOns := []*domain.AddOn{}
+	if err := cursor.All(ctx, &addOns); err != nil {
+		return nil, err
+	}
+
+	return addOns, nil
+}
+
+// Update updates the name, description, price and status of an add-on
+func (r *addOnRepository) Update(ctx context.Context, addOn *domain.AddOn) error {
+	addOn.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":          addOn.Name,
+			"description":   addOn.Description,
+			"monthly_price": addOn.MonthlyPrice,
+			"per_seat":      addOn.PerSeat,
+			"is_active":     addOn.IsActive,
+			"modified_on":   addOn.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": addOn.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// provinceIndex backs the province filter and report on structured addresses
+var provinceIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "structured_address.province_code", Value: 1}},
+	Options: options.Index().SetSparse(true),
+}
+
+// setStructuredAddress sets the structured address of a document, leaving its other fields untouched
+func setStructuredAddress(ctx context.Context, collection *mongo.Collection, id string, address *domain.Address) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"structured_address": address,
+			"modified_on":        time.Now(),
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, notDeleted(bson.M{"_id": objectID}), update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	appointmentCollection = "appointments"
+
+	// technicianScheduleCollection holds one document per technician, locked while
+	// one of their appointments is booked or moved
+	technicianScheduleCollection = "technician_schedules"
+)
+
+// appointmentSort lists appointments in calendar order
+var appointmentSort = bson.D{{Key: "start_at", Value: 1}, {Key: "_id", Value: 1}}
+
+type appointmentRepository struct {
+	collection         *mongo.Collection
+	scheduleCollection *mongo.Collection
+}
+
+// NewAppointmentRepository creates a new appointment repository
+func NewAppointmentRepository(db *mongo.Database) domain.AppointmentRepository {
+	collection := db.Collection(appointmentCollection)
+
+	indexModels := []mongo.IndexModel{
+		// Calendar of a technician and conflict checks
+		{
+			Keys: bson.D{
+				{Key: "technician_id", Value: 1},
+				{Key: "start_at", Value: 1},
+			},
+		},
+		// Appointments of a registration
+		{
+			Keys: bson.D{
+				{Key: "registration_id", Value: 1},
+				{Key: "start_at", Value: 1},
+			},
+		},
+		// Appointments of a customer
+		{
+			Keys: bson.D{
+				{Key: "customer_id", Value: 1},
+				{Key: "start_at", Value: 1},
+			},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &appointmentRepository{
+		collection:         collection,
+		scheduleCollection: db.Collection(technicianScheduleCollection),
+	}
+}
+
+// Create creates a new appointment
+func (r *appointmentRepository) Create(ctx context.Context, appointment *domain.Appointment) error {
+	appointment.ID = primitive.NewObjectID()
+	appointment.CreatedOn = time.Now()
+	appointment.ModifiedOn = appointment.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, appointment)
+	return err
+}
+
+// GetByID gets an appointment by ID
+func (r *appointmentRepository) GetByID(ctx context.Context, id string) (*domain.Appointment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var appointment domain.Appointment
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&appointment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &appointment, nil
+}
+
+// appointmentQuery converts an appointment filter to a MongoDB query
+func appointmentQuery(f *domain.AppointmentFilter) (bson.M, error) {
+	filter := bson.M{}
+	if f == nil {
+		return filter, nil
+	}
+
+	if f.RegistrationID != "" {
+		objectID, err := primitive.ObjectIDFromHex(f.RegistrationID)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		filter["registration_id"] = objectID
+	}
+	if f.CustomerID != "" {
+		objectID, err := primitive.ObjectIDFromHex(f.CustomerID)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		filter["customer_id"] = objectID
+	}
+	if f.TechnicianID != "" {
+		filter["technician_id"] = f.TechnicianID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.From != nil {
+		filter["end_at"] = bson.M{"$gt": *f.From}
+	}
+	if f.To != nil {
+		filter["start_at"] = bson.M{"$lt": *f.To}
+	}
+
+	return filter, nil
+}
+
+// GetAll gets appointments matching filter in calendar order with offset pagination
+func (r *appointmentRepository) GetAll(ctx context.Context, f *domain.AppointmentFilter, page *domain.Pagination) ([]*domain.Appointment, error) {
+	filter, err := appointmentQuery(f)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetLimit(page.Limit).
+		SetSkip(page.Offset).
+		SetSort(appointmentSort)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	appointments := []*domain.Appointment{}
+	if err := cursor.All(ctx, &appointments); err != nil {
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+// Count counts appointments matching filter
+func (r *appointmentRepository) Count(ctx context.Context, f *domain.AppointmentFilter) (int64, error) {
+	filter, err := appointmentQuery(f)
+	if err != nil {
+		return 0, err
+	}
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// GetBusy gets the appointments of a technician overlapping [from, to) that still hold
+// their slot, in calendar order. Cancelled appointments free their slot.
+func (r *appointmentRepository) GetBusy(ctx context.Context, technicianID string, from, to time.Time) ([]*domain.Appointment, error) {
+	filter := bson.M{
+		"technician_id": technicianID,
+		"status":        bson.M{"$ne": domain.AppointmentCancelled},
+		"start_at":      bson.M{"$lt": to},
+		"end_at":        bson.M{"$gt": from},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(appointmentSort))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	appointments := []*domain.Appointment{}
+	if err := cursor.All(ctx, &appointments); err != nil {
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+// Update updates an appointment
+func (r *appointmentRepository) Update(ctx context.Context, appointment *domain.Appointment) error {
+	appointment.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"mode":          appointment.Mode,
+			"title":         appointment.Title,
+			"technician_id": appointment.TechnicianID,
+			"start_at":      appointment.StartAt,
+			"end_at":        appointment.EndAt,
+			"location":      appointment.Location,
+			"meeting_url":   appointment.MeetingURL,
+			"contact_name":  appointment.ContactName,
+			"contact_phone": appointment.ContactPhone,
+			"note":          appointment.Note,
+			"status":        appointment.Status,
+			"sequence":      appointment.Sequence,
+			"reschedules":   appointment.Reschedules,
+			"cancel_reason": appointment.CancelReason,
+			"cancelled_at":  appointment.CancelledAt,
+			"cancelled_by":  appointment.CancelledBy,
+			"completed_at":  appointment.CompletedAt,
+			"completed_by":  appointment.CompletedBy,
+			"modified_on":   appointment.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": appointment.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// LockSchedule locks the schedule of a technician until the lock is released or ttl
+// has passed, and returns the token releasing it. When another booking holds the lock,
+// the upsert collides with the existing schedule document and ErrScheduleLocked is returned.
+func (r *appointmentRepository) LockSchedule(ctx context.Context, technicianID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := primitive.NewObjectID().Hex()
+
+	filter := bson.M{
+		"_id": technicianID,
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"lock_token":   token,
+			"locked_until": now.Add(ttl),
+		},
+	}
+
+	_, err := r.scheduleCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", domain.ErrScheduleLocked
+		}
+		return "", err
+	}
+
+	return token, nil
+}
+
+// UnlockSchedule releases a schedule lock, unless it expired and was taken by another booking
+func (r *appointmentRepository) UnlockSchedule(ctx context.Context, technicianID, token string) error {
+	filter := bson.M{
+		"_id":        technicianID,
+		"lock_token": token,
+	}
+	update := bson.M{
+		"$unset": bson.M{
+			"lock_token":   "",
+			"locked_until": "",
+		},
+	}
+
+	_, err := r.scheduleCollection.UpdateOne(ctx, filter, update)
+	return err
+}
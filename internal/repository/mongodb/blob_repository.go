@@ -0,0 +1,78 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const blobCollection = "blobs"
+
+type blobRepository struct {
+	collection *mongo.Collection
+}
+
+// NewBlobRepository creates a new content blob repository. Blobs are keyed by
+// their SHA-256, so concurrent uploads of the same content share one blob.
+func NewBlobRepository(db *mongo.Database) domain.BlobRepository {
+	return &blobRepository{
+		collection: db.Collection(blobCollection),
+	}
+}
+
+// Acquire adds a reference to a blob, inserting it on first use
+func (r *blobRepository) Acquire(ctx context.Context, blob *domain.Blob) (*domain.Blob, error) {
+	update := bson.M{
+		"$inc": bson.M{"ref_count": 1},
+		"$setOnInsert": bson.M{
+			"key":        blob.Key,
+			"size":       blob.Size,
+			"created_on": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var acquired domain.Blob
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": blob.SHA256}, update, opts).Decode(&acquired)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another upload inserted the blob first, it now exists
+		err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": blob.SHA256}, update, opts).Decode(&acquired)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &acquired, nil
+}
+
+// Release removes a reference from a blob
+func (r *blobRepository) Release(ctx context.Context, sha256 string) (int64, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var blob domain.Blob
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": sha256}, bson.M{"$inc": bson.M{"ref_count": -1}}, opts).Decode(&blob)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, domain.ErrNotFound
+		}
+		return 0, err
+	}
+
+	return blob.RefCount, nil
+}
+
+// DeleteUnused deletes a blob that has no references left. A blob acquired
+// again in the meantime is kept.
+func (r *blobRepository) DeleteUnused(ctx context.Context, sha256 string) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": sha256, "ref_count": bson.M{"$lte": 0}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
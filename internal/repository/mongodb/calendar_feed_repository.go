@@ -0,0 +1,72 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const calendarFeedCollection = "calendar_feeds"
+
+type calendarFeedRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCalendarFeedRepository creates a new calendar feed repository
+func NewCalendarFeedRepository(db *mongo.Database) domain.CalendarFeedRepository {
+	collection := db.Collection(calendarFeedCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &calendarFeedRepository{
+		collection: collection,
+	}
+}
+
+// Replace stores the feed of a user, revoking the link it had before
+func (r *calendarFeedRepository) Replace(ctx context.Context, feed *domain.CalendarFeed) error {
+	feed.CreatedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"token_hash": feed.TokenHash,
+			"created_on": feed.CreatedOn,
+		},
+		"$setOnInsert": bson.M{
+			"_id": primitive.NewObjectID(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	return r.collection.FindOneAndUpdate(ctx, bson.M{"user_id": feed.UserID}, update, opts).Decode(feed)
+}
+
+// GetByTokenHash gets a feed by the hash of its token
+func (r *calendarFeedRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.CalendarFeed, error) {
+	var feed domain.CalendarFeed
+	err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&feed)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &feed, nil
+}
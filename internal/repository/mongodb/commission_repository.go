@@ -0,0 +1,216 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const commissionCollection = "commissions"
+
+type commissionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCommissionRepository creates a new commission repository
+func NewCommissionRepository(db *mongo.Database) domain.CommissionRepository {
+	collection := db.Collection(commissionCollection)
+
+	indexModels := []mongo.IndexModel{
+		pageIndex,
+		{
+			// A payment earns at most one commission
+			Keys:    bson.D{{Key: "source", Value: 1}, {Key: "source_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "reseller_id", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "reseller_id", Value: 1}, {Key: "customer_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &commissionRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new commission. A payment that already earned one gets ErrCommissionExists.
+func (r *commissionRepository) Create(ctx context.Context, commission *domain.Commission) error {
+	commission.ID = primitive.NewObjectID()
+	commission.CreatedOn = time.Now()
+	commission.ModifiedOn = commission.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, commission)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrCommissionExists
+	}
+	return err
+}
+
+// GetByID gets a commission by ID
+func (r *commissionRepository) GetByID(ctx context.Context, id string) (*domain.Commission, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var commission domain.Commission
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&commission)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &commission, nil
+}
+
+// commissionQuery builds the MongoDB filter of a commission filter
+func commissionQuery(f *domain.CommissionFilter) bson.M {
+	filter := bson.M{}
+	if f == nil {
+		return filter
+	}
+
+	if f.ResellerID != nil {
+		filter["reseller_id"] = *f.ResellerID
+	}
+	if f.CustomerID != nil {
+		filter["customer_id"] = *f.CustomerID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	addCreatedRange(filter, f.CreatedFrom, f.CreatedTo)
+
+	return filter
+}
+
+// GetAll gets commissions matching filter with offset or cursor pagination
+func (r *commissionRepository) GetAll(ctx context.Context, f *domain.CommissionFilter, page *domain.Pagination) ([]*domain.Commission, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(commissionQuery(f), page), pageOptions(page))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	commissions := []*domain.Commission{}
+	if err := cursor.All(ctx, &commissions); err != nil {
+		return nil, err
+	}
+
+	return commissions, nil
+}
+
+// Count counts commissions matching filter
+func (r *commissionRepository) Count(ctx context.Context, f *domain.CommissionFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, commissionQuery(f))
+}
+
+// CountEarned counts the commissions a customer earned a reseller, cancelled ones excluded
+func (r *commissionRepository) CountEarned(ctx context.Context, resellerID, customerID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"reseller_id": resellerID,
+		"customer_id": customerID,
+		"status":      bson.M{"$ne": domain.CommissionCancelled},
+	})
+}
+
+// Summarize totals the commission amounts of a reseller by status
+func (r *commissionRepository) Summarize(ctx context.Context, resellerID primitive.ObjectID) (*domain.CommissionSummary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"reseller_id": resellerID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$status",
+			"total": bson.M{"$sum": "$amount"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	summary := &domain.CommissionSummary{}
+	for cursor.Next(ctx) {
+		var row struct {
+			Status domain.CommissionStatus `bson:"_id"`
+			Total  int64                   `bson:"total"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		switch row.Status {
+		case domain.CommissionPending:
+			summary.Pending = row.Total
+		case domain.CommissionPaid:
+			summary.Paid = row.Total
+		case domain.CommissionCancelled:
+			summary.Cancelled = row.Total
+		}
+	}
+
+	return summary, cursor.Err()
+}
+
+// UpdateStatus records the payout or cancellation of a commission that is still in status from.
+// A commission that changed status in between gets ErrCommissionStatus.
+func (r *commissionRepository) UpdateStatus(ctx context.Context, commission *domain.Commission, from domain.CommissionStatus) error {
+	commission.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":        commission.Status,
+			"paid_at":       commission.PaidAt,
+			"payout_ref":    commission.PayoutRef,
+			"paid_by":       commission.PaidBy,
+			"cancel_reason": commission.CancelReason,
+			"modified_on":   commission.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": commission.ID, "status": from}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrCommissionStatus
+	}
+
+	return nil
+}
+
+// CancelBySource cancels the pending commission earned on a payment. It does nothing
+// when the payment earned none or its commission was already paid out.
+func (r *commissionRepository) CancelBySource(ctx context.Context, source domain.CommissionSource, sourceID primitive.ObjectID, reason string) error {
+	filter := bson.M{
+		"source":    source,
+		"source_id": sourceID,
+		"status":    domain.CommissionPending,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":        domain.CommissionCancelled,
+			"cancel_reason": reason,
+			"modified_on":   time.Now(),
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,128 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const commissionRuleCollection = "commission_rules"
+
+type commissionRuleRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCommissionRuleRepository creates a new commission rule repository
+func NewCommissionRuleRepository(db *mongo.Database) domain.CommissionRuleRepository {
+	collection := db.Collection(commissionRuleCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "reseller_id", Value: 1}, {Key: "is_active", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &commissionRuleRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new commission rule
+func (r *commissionRuleRepository) Create(ctx context.Context, rule *domain.CommissionRule) error {
+	rule.ID = primitive.NewObjectID()
+	rule.CreatedOn = time.Now()
+	rule.ModifiedOn = rule.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, rule)
+	return err
+}
+
+// GetByID gets a commission rule by ID
+func (r *commissionRuleRepository) GetByID(ctx context.Context, id string) (*domain.CommissionRule, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var rule domain.CommissionRule
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// GetAll gets the commission rules, newest first
+func (r *commissionRuleRepository) GetAll(ctx context.Context) ([]*domain.CommissionRule, error) {
+	opts := options.Find().SetSort(pageSort)
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rules := []*domain.CommissionRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// GetActive gets the newest active rule of a reseller, or the newest active default
+// rule when the reseller has none
+func (r *commissionRuleRepository) GetActive(ctx context.Context, resellerID primitive.ObjectID) (*domain.CommissionRule, error) {
+	opts := options.FindOne().SetSort(pageSort)
+
+	for _, owner := range []interface{}{resellerID, nil} {
+		var rule domain.CommissionRule
+		err := r.collection.FindOne(ctx, bson.M{"reseller_id": owner, "is_active": true}, opts).Decode(&rule)
+		if err == nil {
+			return &rule, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	return nil, domain.ErrNotFound
+}
+
+// Update updates the name, amounts, limit and status of a commission rule
+func (r *commissionRuleRepository) Update(ctx context.Context, rule *domain.CommissionRule) error {
+	rule.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":         rule.Name,
+			"percent":      rule.Percent,
+			"fixed_amount": rule.FixedAmount,
+			"max_payments": rule.MaxPayments,
+			"is_active":    rule.IsActive,
+			"modified_on":  rule.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": rule.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"icafe-registration/internal/domain"
@@ -22,12 +23,24 @@ type customerRepository struct {
 func NewCustomerRepository(db *mongo.Database) domain.CustomerRepository {
 	collection := db.Collection(customerCollection)
 
-	// Create unique index on phone_number
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "phone_number", Value: 1}},
-		Options: options.Index().SetUnique(true),
+	// The phone index used to cover customers in the trash, replaced by the one below
+	collection.Indexes().DropOne(context.Background(), "phone_number_1")
+
+	indexModels := []mongo.IndexModel{
+		// Unique phone_number among customers outside the trash. A partial index cannot
+		// select documents without deleted_at, so deleted_at is part of the key instead:
+		// live customers all index it as null, trashed ones by their deletion time.
+		{
+			Keys: bson.D{
+				{Key: "phone_number", Value: 1},
+				{Key: "deleted_at", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		pageIndex,
+		provinceIndex,
 	}
-	collection.Indexes().CreateOne(context.Background(), indexModel)
+	collection.Indexes().CreateMany(context.Background(), indexModels)
 
 	return &customerRepository{
 		collection: collection,
@@ -42,6 +55,9 @@ func (r *customerRepository) Create(ctx context.Context, customer *domain.Custom
 	customer.ModifiedOn = time.Now()
 
 	_, err := r.collection.InsertOne(ctx, customer)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrPhoneAlreadyExists
+	}
 	return err
 }
 
@@ -53,7 +69,7 @@ func (r *customerRepository) GetByID(ctx context.Context, id string) (*domain.Cu
 	}
 
 	var customer domain.Customer
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&customer)
+	err = r.collection.FindOne(ctx, notDeleted(bson.M{"_id": objectID})).Decode(&customer)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -67,7 +83,7 @@ func (r *customerRepository) GetByID(ctx context.Context, id string) (*domain.Cu
 // GetByPhone gets a customer by phone number
 func (r *customerRepository) GetByPhone(ctx context.Context, phone string) (*domain.Customer, error) {
 	var customer domain.Customer
-	err := r.collection.FindOne(ctx, bson.M{"phone_number": phone}).Decode(&customer)
+	err := r.collection.FindOne(ctx, notDeleted(bson.M{"phone_number": phone})).Decode(&customer)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -81,7 +97,7 @@ func (r *customerRepository) GetByPhone(ctx context.Context, phone string) (*dom
 // GetByEmail
 func (r *customerRepository) GetByEmail(ctx context.Context, email string) (*domain.Customer, error) {
 	var customer domain.Customer
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&customer)
+	err := r.collection.FindOne(ctx, notDeleted(bson.M{"email": email})).Decode(&customer)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -91,14 +107,47 @@ func (r *customerRepository) GetByEmail(ctx context.Context, email string) (*dom
 	return &customer, nil
 }
 
-// GetAll gets all customers with pagination
-func (r *customerRepository) GetAll(ctx context.Context, limit, offset int64) ([]*domain.Customer, error) {
-	opts := options.Find().
-		SetLimit(limit).
-		SetSkip(offset).
-		SetSort(bson.D{{Key: "created_on", Value: -1}})
+// customerQuery converts a customer filter to a MongoDB query
+func customerQuery(f *domain.CustomerFilter) bson.M {
+	filter := notDeleted(bson.M{})
+	if f == nil {
+		return filter
+	}
+
+	addSearch(filter, f.Search, "full_name", "phone_number", "email")
+	if f.WorkstationRange != "" {
+		filter["workstation_range"] = f.WorkstationRange
+	}
+	if f.IsActive != nil {
+		filter["is_active"] = *f.IsActive
+	}
+	if len(f.ProvinceCodes) > 0 {
+		filter["structured_address.province_code"] = bson.M{"$in": f.ProvinceCodes}
+	}
+	addCreatedRange(filter, f.CreatedFrom, f.CreatedTo)
+
+	return filter
+}
+
+// GetByPhones gets the customers whose phone number is in phones
+func (r *customerRepository) GetByPhones(ctx context.Context, phones []string) ([]*domain.Customer, error) {
+	cursor, err := r.collection.Find(ctx, notDeleted(bson.M{"phone_number": bson.M{"$in": phones}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var customers []*domain.Customer
+	if err := cursor.All(ctx, &customers); err != nil {
+		return nil, err
+	}
+
+	return customers, nil
+}
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+// GetAll gets customers matching filter with offset or cursor pagination
+func (r *customerRepository) GetAll(ctx context.Context, f *domain.CustomerFilter, page *domain.Pagination) ([]*domain.Customer, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(customerQuery(f), page), pageOptions(page))
 	if err != nil {
 		return nil, err
 	}
@@ -112,6 +161,29 @@ func (r *customerRepository) GetAll(ctx context.Context, limit, offset int64) ([
 	return customers, nil
 }
 
+// Iterate calls fn for every customer matching filter without loading them all in memory
+func (r *customerRepository) Iterate(ctx context.Context, f *domain.CustomerFilter, fn func(*domain.Customer) error) error {
+	opts := options.Find().SetSort(pageSort)
+
+	cursor, err := r.collection.Find(ctx, customerQuery(f), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var customer domain.Customer
+		if err := cursor.Decode(&customer); err != nil {
+			return err
+		}
+		if err := fn(&customer); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
 // Update updates a customer
 func (r *customerRepository) Update(ctx context.Context, id string, customer *domain.Customer) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -123,17 +195,20 @@ func (r *customerRepository) Update(ctx context.Context, id string, customer *do
 
 	update := bson.M{
 		"$set": bson.M{
-			"full_name":    customer.FullName,
-			"phone_number": customer.PhoneNumber,
-			"email":        customer.Email,
-			"address":      customer.Address,
-			"note":         customer.Note,
-			"is_active":    customer.IsActive,
-			"modified_on":  customer.ModifiedOn,
+			"full_name":          customer.FullName,
+			"phone_number":       customer.PhoneNumber,
+			"email":              customer.Email,
+			"address":            customer.Address,
+			"structured_address": customer.StructuredAddress,
+			"company_name":       customer.CompanyName,
+			"tax_code":           customer.TaxCode,
+			"note":               customer.Note,
+			"is_active":          customer.IsActive,
+			"modified_on":        customer.ModifiedOn,
 		},
 	}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	result, err := r.collection.UpdateOne(ctx, notDeleted(bson.M{"_id": objectID}), update)
 	if err != nil {
 		return err
 	}
@@ -145,26 +220,148 @@ func (r *customerRepository) Update(ctx context.Context, id string, customer *do
 	return nil
 }
 
-// Delete deletes a customer
-func (r *customerRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+// SetStructuredAddress sets the structured address of a customer
+func (r *customerRepository) SetStructuredAddress(ctx context.Context, id string, address *domain.Address) error {
+	return setStructuredAddress(ctx, r.collection, id, address)
+}
+
+// CountByProvince counts customers per province code of their structured address.
+// Customers without one are counted under an empty code.
+func (r *customerRepository) CountByProvince(ctx context.Context) ([]*domain.ProvinceCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: notDeleted(bson.M{})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$structured_address.province_code", ""}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return domain.ErrInvalidID
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	var counts []*domain.ProvinceCount
+	for cursor.Next(ctx) {
+		var row struct {
+			ProvinceCode string `bson:"_id"`
+			Count        int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &domain.ProvinceCount{ProvinceCode: row.ProvinceCode, Count: row.Count})
+	}
+
+	return counts, cursor.Err()
+}
+
+// BulkSave inserts customers without ID and updates customers with ID in one unordered batch.
+// Failed writes are returned keyed by their index in customers.
+func (r *customerRepository) BulkSave(ctx context.Context, customers []*domain.Customer) (map[int]error, error) {
+	if len(customers) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(customers))
+	for _, customer := range customers {
+		customer.ModifiedOn = now
+
+		if customer.ID.IsZero() {
+			customer.ID = primitive.NewObjectID()
+			customer.IsActive = true
+			customer.CreatedOn = now
+			models = append(models, mongo.NewInsertOneModel().SetDocument(customer))
+			continue
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(notDeleted(bson.M{"_id": customer.ID})).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"full_name":         customer.FullName,
+					"phone_number":      customer.PhoneNumber,
+					"email":             customer.Email,
+					"address":           customer.Address,
+					"note":              customer.Note,
+					"workstation_range": customer.WorkstationRange,
+					"modified_on":       customer.ModifiedOn,
+				},
+			}))
+	}
+
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err == nil {
+		return nil, nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return nil, err
+	}
+
+	failed := make(map[int]error, len(bulkErr.WriteErrors))
+	for _, writeErr := range bulkErr.WriteErrors {
+		if mongo.IsDuplicateKeyError(writeErr) {
+			failed[writeErr.Index] = domain.ErrPhoneAlreadyExists
+			continue
+		}
+		failed[writeErr.Index] = errors.New(writeErr.Message)
+	}
+
+	return failed, nil
+}
+
+// Delete moves a customer to the trash
+func (r *customerRepository) Delete(ctx context.Context, id string, deletedBy string) error {
+	return softDelete(ctx, r.collection, id, deletedBy)
+}
+
+// GetDeleted gets customers in the trash with pagination
+func (r *customerRepository) GetDeleted(ctx context.Context, page *domain.Pagination) ([]*domain.Customer, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(onlyDeleted(bson.M{}), page), pageOptions(page))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	if result.DeletedCount == 0 {
-		return domain.ErrNotFound
+	var customers []*domain.Customer
+	if err := cursor.All(ctx, &customers); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return customers, nil
+}
+
+// CountDeleted counts customers in the trash
+func (r *customerRepository) CountDeleted(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, onlyDeleted(bson.M{}))
+}
+
+// Restore moves a customer out of the trash. It fails with ErrPhoneAlreadyExists
+// when another customer has taken its phone number since.
+func (r *customerRepository) Restore(ctx context.Context, id string) error {
+	err := restoreDeleted(ctx, r.collection, id)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrPhoneAlreadyExists
+	}
+	return err
+}
+
+// Purge permanently deletes customers trashed before the given time
+func (r *customerRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, r.collection, before)
+}
+
+// Count counts customers matching filter
+func (r *customerRepository) Count(ctx context.Context, f *domain.CustomerFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, customerQuery(f))
 }
 
-// Count counts all customers
-func (r *customerRepository) Count(ctx context.Context) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{})
+// EstimatedCount returns the approximate number of customers from collection metadata.
+// Customers in the trash are included in the estimate.
+func (r *customerRepository) EstimatedCount(ctx context.Context) (int64, error) {
+	return r.collection.EstimatedDocumentCount(ctx)
 }
@@ -0,0 +1,48 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const fileLinkCollection = "file_link_uses"
+
+type fileLinkRepository struct {
+	collection *mongo.Collection
+}
+
+// NewFileLinkRepository creates a new repository of used single-use download
+// links. MongoDB removes them once the links have expired.
+func NewFileLinkRepository(db *mongo.Database) domain.FileLinkRepository {
+	collection := db.Collection(fileLinkCollection)
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+
+	return &fileLinkRepository{
+		collection: collection,
+	}
+}
+
+// Consume records a link as used. The nonce is the document ID, so only the
+// first of concurrent uses succeeds.
+func (r *fileLinkRepository) Consume(ctx context.Context, nonce string, expiresAt time.Time) error {
+	_, err := r.collection.InsertOne(ctx, bson.M{
+		"_id":        nonce,
+		"expires_at": expiresAt,
+		"used_on":    time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrFileLinkUsed
+	}
+	return err
+}
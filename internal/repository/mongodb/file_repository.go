@@ -2,6 +2,8 @@ package mongodb
 
 import (
 	"context"
+	"regexp"
+	"strings"
 	"time"
 
 	"icafe-registration/internal/domain"
@@ -20,8 +22,36 @@ type fileRepository struct {
 
 // NewFileRepository creates a new file repository
 func NewFileRepository(db *mongo.Database) domain.FileRepository {
+	collection := db.Collection(fileCollection)
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "file_type", Value: 1}, {Key: "created_on", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "shop_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "file_name", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "file_type", Value: 1}, {Key: "hls.status", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "scan.status", Value: 1}, {Key: "created_on", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "folder", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "tags", Value: 1}},
+		},
+	})
+
 	return &fileRepository{
-		collection: db.Collection(fileCollection),
+		collection: collection,
 	}
 }
 
@@ -53,19 +83,23 @@ func (r *fileRepository) GetByID(ctx context.Context, id string) (*domain.File,
 	return &file, nil
 }
 
-// GetAll gets all files with pagination and optional type filter
-func (r *fileRepository) GetAll(ctx context.Context, fileType domain.FileType, limit, offset int64) ([]*domain.File, error) {
-	filter := bson.M{}
-	if fileType != "" {
-		filter["file_type"] = fileType
+// GetByFileName gets a file by its stored file name
+func (r *fileRepository) GetByFileName(ctx context.Context, fileName string) (*domain.File, error) {
+	var file domain.File
+	err := r.collection.FindOne(ctx, bson.M{"file_name": fileName}).Decode(&file)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
 	}
 
-	opts := options.Find().
-		SetLimit(limit).
-		SetSkip(offset).
-		SetSort(bson.D{{Key: "created_on", Value: -1}})
+	return &file, nil
+}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+// GetAll gets all files with pagination and optional type filter
+func (r *fileRepository) GetAll(ctx context.Context, filter *domain.FileFilter, page *domain.Pagination) ([]*domain.File, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(fileQuery(filter), page), pageOptions(page))
 	if err != nil {
 		return nil, err
 	}
@@ -99,10 +133,314 @@ func (r *fileRepository) Delete(ctx context.Context, id string) error {
 }
 
 // Count counts files with optional type filter
-func (r *fileRepository) Count(ctx context.Context, fileType domain.FileType) (int64, error) {
+func (r *fileRepository) Count(ctx context.Context, filter *domain.FileFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, fileQuery(filter))
+}
+
+// fileQuery converts a file filter to a MongoDB query
+func fileQuery(f *domain.FileFilter) bson.M {
 	filter := bson.M{}
-	if fileType != "" {
-		filter["file_type"] = fileType
+	if f == nil {
+		return filter
+	}
+
+	if f.FileType != "" {
+		filter["file_type"] = f.FileType
+	}
+	switch f.Visibility {
+	case "":
+	case domain.FileVisibilityPublic:
+		filter["visibility"] = bson.M{"$in": bson.A{nil, domain.FileVisibilityPublic}}
+	default:
+		filter["visibility"] = f.Visibility
+	}
+	if len(f.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": f.Tags}
+	}
+	if f.Category != "" {
+		filter["category"] = f.Category
+	}
+	switch {
+	case f.Folder == "/" && !f.Recursive:
+		filter["folder"] = nil
+	case f.Folder != "" && f.Folder != "/" && f.Recursive:
+		filter["folder"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(f.Folder) + "(/|$)"}
+	case f.Folder != "" && f.Folder != "/":
+		filter["folder"] = f.Folder
+	}
+	if major, ok := strings.CutSuffix(f.MimeType, "/*"); ok {
+		filter["mime_type"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(major) + "/"}
+	} else if f.MimeType != "" {
+		filter["mime_type"] = f.MimeType
+	}
+	addSearch(filter, f.Search, "original_name", "title")
+
+	return filter
+}
+
+// Folders counts the files of each folder holding files matching the type and
+// visibility of filter, sorted by path
+func (r *fileRepository) Folders(ctx context.Context, filter *domain.FileFilter) ([]*domain.FileFolder, error) {
+	match := fileQuery(&domain.FileFilter{FileType: filter.FileType, Visibility: filter.Visibility})
+	match["folder"] = bson.M{"$exists": true}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$folder", "files": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Path  string `bson:"_id"`
+		Files int64  `bson:"files"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	folders := make([]*domain.FileFolder, 0, len(groups))
+	for _, group := range groups {
+		folders = append(folders, &domain.FileFolder{Path: group.Path, Files: group.Files})
+	}
+	return folders, nil
+}
+
+// UpdateMetadata updates the title, description, tags, category and folder of a file
+func (r *fileRepository) UpdateMetadata(ctx context.Context, file *domain.File) error {
+	set := bson.M{}
+	unset := bson.M{}
+	fields := bson.M{
+		"title":       file.Title,
+		"description": file.Description,
+		"category":    file.Category,
+		"folder":      file.Folder,
+	}
+	for field, value := range fields {
+		if value == "" {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	if len(file.Tags) > 0 {
+		set["tags"] = file.Tags
+	} else {
+		unset["tags"] = ""
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": file.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetByShop gets the files attached to a shop, newest first
+func (r *fileRepository) GetByShop(ctx context.Context, shopID primitive.ObjectID) ([]*domain.File, error) {
+	opts := options.Find().SetSort(pageSort)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"shop_id": shopID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	files := []*domain.File{}
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// SetShop attaches a file to a shop, or detaches it when shopID is nil
+func (r *fileRepository) SetShop(ctx context.Context, id string, shopID *primitive.ObjectID) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	update := bson.M{"$unset": bson.M{"shop_id": ""}}
+	if shopID != nil {
+		update = bson.M{"$set": bson.M{"shop_id": *shopID}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetAccess changes who can download a file, with its URL. Files that are no
+// longer public lose their variants.
+func (r *fileRepository) SetAccess(ctx context.Context, id primitive.ObjectID, access *domain.FileAccess, url string) error {
+	set := bson.M{"visibility": access.Visibility, "url": url}
+	unset := bson.M{}
+	if access.CustomerID != nil {
+		set["customer_id"] = *access.CustomerID
+	} else {
+		unset["customer_id"] = ""
+	}
+	if access.Visibility != domain.FileVisibilityPublic {
+		unset["variants"] = ""
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DetachShop detaches every file from a deleted shop
+func (r *fileRepository) DetachShop(ctx context.Context, shopID primitive.ObjectID) error {
+	return detachShop(ctx, r.collection, shopID)
+}
+
+// ClaimHLS marks the oldest MP4 video waiting for HLS packaging as processing
+// and returns it. Videos left processing since before staleBefore, by a run that
+// did not finish, are claimed again. Returns ErrNotFound when none is waiting.
+func (r *fileRepository) ClaimHLS(ctx context.Context, staleBefore time.Time) (*domain.File, error) {
+	filter := bson.M{
+		"file_type": domain.FileTypeVideo,
+		"mime_type": domain.HLSMimeType,
+		// Packages are served without signed links, so only public videos are packaged
+		"visibility": bson.M{"$in": bson.A{nil, domain.FileVisibilityPublic}},
+		// and only once they passed the malware scan
+		"scan.status": bson.M{"$in": bson.A{nil, domain.ScanStatusClean}},
+		"$or": bson.A{
+			bson.M{"hls": bson.M{"$exists": false}},
+			bson.M{"hls.status": domain.HLSStatusPending},
+			bson.M{"hls.status": domain.HLSStatusProcessing, "hls.updated_on": bson.M{"$lt": staleBefore}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"hls": domain.HLSPackage{
+		Status:    domain.HLSStatusProcessing,
+		UpdatedOn: time.Now(),
+	}}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_on", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var file domain.File
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&file)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// SetHLS records the HLS packaging state of a video
+func (r *fileRepository) SetHLS(ctx context.Context, id primitive.ObjectID, hls *domain.HLSPackage) error {
+	hls.UpdatedOn = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"hls": hls}})
+	if err != nil {
+		return err
 	}
-	return r.collection.CountDocuments(ctx, filter)
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetVariant records a resized copy of an image
+func (r *fileRepository) SetVariant(ctx context.Context, id primitive.ObjectID, name string, variant *domain.FileVariant) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"variants." + name: variant}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ClaimScan marks the oldest file waiting for the malware scan as scanning,
+// counting the attempt, and returns it. Files left scanning since before
+// staleBefore, by a run that did not finish, are claimed again. Returns
+// ErrNotFound when none is waiting.
+func (r *fileRepository) ClaimScan(ctx context.Context, staleBefore time.Time) (*domain.File, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"scan.status": domain.ScanStatusPending},
+			bson.M{"scan.status": domain.ScanStatusScanning, "scan.updated_on": bson.M{"$lt": staleBefore}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"scan.status": domain.ScanStatusScanning, "scan.updated_on": time.Now()},
+		"$inc": bson.M{"scan.attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_on", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var file domain.File
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&file)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// SetScan records the malware scan state of a file
+func (r *fileRepository) SetScan(ctx context.Context, id primitive.ObjectID, scan *domain.FileScan) error {
+	scan.UpdatedOn = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"scan": scan}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
 }
@@ -0,0 +1,39 @@
+package mongodb
+
+import (
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// addSearch matches a case-insensitive substring on any of the given fields
+func addSearch(filter bson.M, search string, fields ...string) {
+	if search == "" {
+		return
+	}
+
+	pattern := primitive.Regex{Pattern: regexp.QuoteMeta(search), Options: "i"}
+	or := make(bson.A, 0, len(fields))
+	for _, field := range fields {
+		or = append(or, bson.M{field: pattern})
+	}
+	filter["$or"] = or
+}
+
+// addCreatedRange restricts created_on to [from, to)
+func addCreatedRange(filter bson.M, from, to *time.Time) {
+	if from == nil && to == nil {
+		return
+	}
+
+	createdOn := bson.M{}
+	if from != nil {
+		createdOn["$gte"] = *from
+	}
+	if to != nil {
+		createdOn["$lt"] = *to
+	}
+	filter["created_on"] = createdOn
+}
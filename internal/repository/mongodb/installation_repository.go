@@ -0,0 +1,213 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const installationCollection = "installations"
+
+type installationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewInstallationRepository creates a new installation repository
+func NewInstallationRepository(db *mongo.Database) domain.InstallationRepository {
+	collection := db.Collection(installationCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "activation_code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "customer_id", Value: 1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &installationRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new installation
+func (r *installationRepository) Create(ctx context.Context, installation *domain.Installation) error {
+	installation.ID = primitive.NewObjectID()
+	installation.CreatedOn = time.Now()
+	installation.ModifiedOn = installation.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, installation)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAlreadyExists
+	}
+	return err
+}
+
+// findOne gets the installation matching filter
+func (r *installationRepository) findOne(ctx context.Context, filter bson.M) (*domain.Installation, error) {
+	var installation domain.Installation
+	err := r.collection.FindOne(ctx, filter).Decode(&installation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &installation, nil
+}
+
+// GetByID gets an installation by ID
+func (r *installationRepository) GetByID(ctx context.Context, id string) (*domain.Installation, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+// GetByActivationCode gets an installation by its activation code
+func (r *installationRepository) GetByActivationCode(ctx context.Context, code string) (*domain.Installation, error) {
+	return r.findOne(ctx, bson.M{"activation_code": code})
+}
+
+// find gets the installations matching filter, grouped by customer
+func (r *installationRepository) find(ctx context.Context, filter bson.M) ([]*domain.Installation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "customer_id", Value: 1}, {Key: "created_on", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	installations := []*domain.Installation{}
+	if err := cursor.All(ctx, &installations); err != nil {
+		return nil, err
+	}
+
+	return installations, nil
+}
+
+// GetByCustomer gets the installations of a customer, newest first
+func (r *installationRepository) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Installation, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+	return r.find(ctx, bson.M{"customer_id": objectID})
+}
+
+// GetActive gets every activated installation, grouped by customer
+func (r *installationRepository) GetActive(ctx context.Context) ([]*domain.Installation, error) {
+	return r.find(ctx, bson.M{"status": domain.InstallationActive})
+}
+
+// Update updates an installation
+func (r *installationRepository) Update(ctx context.Context, installation *domain.Installation) error {
+	installation.ModifiedOn = time.Now()
+
+	set := bson.M{
+		"name":            installation.Name,
+		"status":          installation.Status,
+		"activation_code": installation.ActivationCode,
+		"modified_on":     installation.ModifiedOn,
+	}
+	unset := bson.M{}
+
+	if installation.ShopID != nil {
+		set["shop_id"] = *installation.ShopID
+	} else {
+		unset["shop_id"] = ""
+	}
+	if installation.SeatLimit != nil {
+		set["seat_limit"] = *installation.SeatLimit
+	} else {
+		unset["seat_limit"] = ""
+	}
+	if installation.Fingerprint != "" {
+		set["fingerprint"] = installation.Fingerprint
+		set["token_hash"] = installation.TokenHash
+		set["activated_at"] = installation.ActivatedAt
+	} else {
+		unset["fingerprint"] = ""
+		unset["token_hash"] = ""
+		unset["activated_at"] = ""
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": installation.ID}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordHeartbeat stores the last status reported by a client
+func (r *installationRepository) RecordHeartbeat(ctx context.Context, id primitive.ObjectID, version string, activeWorkstations int, seenAt time.Time) error {
+	set := bson.M{
+		"active_workstations": activeWorkstations,
+		"last_seen_at":        seenAt,
+	}
+	if version != "" {
+		set["version"] = version
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes an installation
+func (r *installationRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DetachShop detaches every installation from a deleted shop
+func (r *installationRepository) DetachShop(ctx context.Context, shopID primitive.ObjectID) error {
+	return detachShop(ctx, r.collection, shopID)
+}
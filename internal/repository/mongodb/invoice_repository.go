@@ -0,0 +1,313 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const invoiceCollection = "invoices"
+
+// maxIssueAttempts bounds the retries when concurrent issues race for the same number
+const maxIssueAttempts = 20
+
+type invoiceRepository struct {
+	collection *mongo.Collection
+}
+
+// NewInvoiceRepository creates a new invoice repository
+func NewInvoiceRepository(db *mongo.Database) domain.InvoiceRepository {
+	collection := db.Collection(invoiceCollection)
+
+	indexModels := []mongo.IndexModel{
+		pageIndex,
+		{
+			// One invoice per number of a series; drafts have no number yet
+			Keys: bson.D{{Key: "series", Value: 1}, {Key: "sequence", Value: -1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"sequence": bson.M{"$exists": true},
+			}),
+		},
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &invoiceRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new draft invoice
+func (r *invoiceRepository) Create(ctx context.Context, invoice *domain.Invoice) error {
+	invoice.ID = primitive.NewObjectID()
+	invoice.CreatedOn = time.Now()
+	invoice.ModifiedOn = invoice.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, invoice)
+	return err
+}
+
+// GetByID gets an invoice by ID
+func (r *invoiceRepository) GetByID(ctx context.Context, id string) (*domain.Invoice, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var invoice domain.Invoice
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&invoice)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+// invoiceQuery builds the MongoDB filter of an invoice filter
+func invoiceQuery(f *domain.InvoiceFilter) bson.M {
+	filter := bson.M{}
+	if f == nil {
+		return filter
+	}
+
+	addSearch(filter, f.Search, "number", "buyer.name", "buyer.company_name", "buyer.tax_code")
+	if f.CustomerID != nil {
+		filter["customer_id"] = *f.CustomerID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	addCreatedRange(filter, f.CreatedFrom, f.CreatedTo)
+
+	return filter
+}
+
+// GetAll gets invoices matching filter with offset or cursor pagination
+func (r *invoiceRepository) GetAll(ctx context.Context, f *domain.InvoiceFilter, page *domain.Pagination) ([]*domain.Invoice, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(invoiceQuery(f), page), pageOptions(page))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invoices := []*domain.Invoice{}
+	if err := cursor.All(ctx, &invoices); err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// Count counts invoices matching filter
+func (r *invoiceRepository) Count(ctx context.Context, f *domain.InvoiceFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, invoiceQuery(f))
+}
+
+// GetByCustomer gets the invoices of a customer, newest first
+func (r *invoiceRepository) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Invoice, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	opts := options.Find().SetSort(pageSort)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"customer_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invoices := []*domain.Invoice{}
+	if err := cursor.All(ctx, &invoices); err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// UpdateDraft updates the buyer, lines and totals of a draft invoice
+func (r *invoiceRepository) UpdateDraft(ctx context.Context, invoice *domain.Invoice) error {
+	invoice.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"buyer":       invoice.Buyer,
+			"items":       invoice.Items,
+			"subtotal":    invoice.Subtotal,
+			"vat_rate":    invoice.VATRate,
+			"vat_amount":  invoice.VATAmount,
+			"total":       invoice.Total,
+			"note":        invoice.Note,
+			"modified_on": invoice.ModifiedOn,
+		},
+	}
+
+	return r.updateIfStatus(ctx, invoice.ID, domain.InvoiceDraft, update)
+}
+
+// Issue numbers a draft invoice with the next sequence of its series and marks it issued.
+// The number is derived from the highest issued one, and the unique series/sequence
+// index rejects a number taken concurrently, in which case the next one is tried.
+// Numbers are only taken by invoices that are saved as issued, so there are no gaps.
+func (r *invoiceRepository) Issue(ctx context.Context, invoice *domain.Invoice) error {
+	for attempt := 0; attempt < maxIssueAttempts; attempt++ {
+		sequence, err := r.lastSequence(ctx, invoice.Series)
+		if err != nil {
+			return err
+		}
+		sequence++
+
+		modifiedOn := time.Now()
+		number := fmt.Sprintf("%07d", sequence)
+		update := bson.M{
+			"$set": bson.M{
+				"series":      invoice.Series,
+				"sequence":    sequence,
+				"number":      number,
+				"status":      domain.InvoiceIssued,
+				"issued_at":   invoice.IssuedAt,
+				"buyer":       invoice.Buyer,
+				"modified_on": modifiedOn,
+			},
+		}
+
+		err = r.updateIfStatus(ctx, invoice.ID, domain.InvoiceDraft, update)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		invoice.Sequence = sequence
+		invoice.Number = number
+		invoice.Status = domain.InvoiceIssued
+		invoice.ModifiedOn = modifiedOn
+		return nil
+	}
+
+	return domain.ErrInvoiceNumberConflict
+}
+
+// lastSequence returns the highest number issued in a series, or 0
+func (r *invoiceRepository) lastSequence(ctx context.Context, series string) (int64, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "sequence", Value: -1}}).
+		SetProjection(bson.M{"sequence": 1})
+
+	var last struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"series": series, "sequence": bson.M{"$exists": true}}, opts).Decode(&last)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return last.Sequence, nil
+}
+
+// UpdateStatus moves an invoice from one status to the status set on it, recording
+// the payment or void details. It fails with ErrInvoiceStatus when the invoice
+// was changed concurrently.
+func (r *invoiceRepository) UpdateStatus(ctx context.Context, invoice *domain.Invoice, from domain.InvoiceStatus) error {
+	invoice.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":      invoice.Status,
+			"paid_at":     invoice.PaidAt,
+			"payment_ref": invoice.PaymentRef,
+			"voided_at":   invoice.VoidedAt,
+			"void_reason": invoice.VoidReason,
+			"modified_on": invoice.ModifiedOn,
+		},
+	}
+
+	err := r.updateIfStatus(ctx, invoice.ID, from, update)
+	if err == domain.ErrInvoiceNotDraft {
+		return domain.ErrInvoiceStatus
+	}
+	return err
+}
+
+// SetFile records the PDF of an invoice
+func (r *invoiceRepository) SetFile(ctx context.Context, id primitive.ObjectID, fileID primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{"file_id": fileID},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteDraft deletes an invoice that has not been issued
+func (r *invoiceRepository) DeleteDraft(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID, "status": domain.InvoiceDraft})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return r.missingOrChanged(ctx, objectID)
+	}
+
+	return nil
+}
+
+// updateIfStatus applies update to an invoice only while it has the given status
+func (r *invoiceRepository) updateIfStatus(ctx context.Context, id primitive.ObjectID, status domain.InvoiceStatus, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": status}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return r.missingOrChanged(ctx, id)
+	}
+
+	return nil
+}
+
+// missingOrChanged tells apart a missing invoice from one that no longer has the expected status
+func (r *invoiceRepository) missingOrChanged(ctx context.Context, id primitive.ObjectID) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+	return domain.ErrInvoiceNotDraft
+}
@@ -0,0 +1,124 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const licenseCollection = "licenses"
+
+type licenseRepository struct {
+	collection *mongo.Collection
+}
+
+// NewLicenseRepository creates a new license repository
+func NewLicenseRepository(db *mongo.Database) domain.LicenseRepository {
+	collection := db.Collection(licenseCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &licenseRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new license. The ID is kept when already set, because it is signed into the key.
+func (r *licenseRepository) Create(ctx context.Context, license *domain.License) error {
+	if license.ID.IsZero() {
+		license.ID = primitive.NewObjectID()
+	}
+	license.CreatedOn = time.Now()
+	license.ModifiedOn = license.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, license)
+	return err
+}
+
+// GetByID gets a license by ID
+func (r *licenseRepository) GetByID(ctx context.Context, id string) (*domain.License, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var license domain.License
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&license)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &license, nil
+}
+
+// GetByCustomer gets the licenses of a customer, newest first
+func (r *licenseRepository) GetByCustomer(ctx context.Context, customerID string) ([]*domain.License, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_on", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"customer_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	licenses := []*domain.License{}
+	if err := cursor.All(ctx, &licenses); err != nil {
+		return nil, err
+	}
+
+	return licenses, nil
+}
+
+// Update updates the terms, key and status of a license
+func (r *licenseRepository) Update(ctx context.Context, license *domain.License) error {
+	license.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"seats":         license.Seats,
+			"status":        license.Status,
+			"key":           license.Key,
+			"expires_at":    license.ExpiresAt,
+			"status_reason": license.StatusReason,
+			"modified_on":   license.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": license.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DetachShop detaches every license from a deleted shop
+func (r *licenseRepository) DetachShop(ctx context.Context, shopID primitive.ObjectID) error {
+	return detachShop(ctx, r.collection, shopID)
+}
@@ -0,0 +1,53 @@
+package mongodb
+
+import (
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// pageSort is the sort order shared by every paginated list.
+// The _id tie-breaker keeps keyset cursors stable for equal timestamps.
+var pageSort = bson.D{{Key: "created_on", Value: -1}, {Key: "_id", Value: -1}}
+
+// pageIndex is the index that backs pageSort
+var pageIndex = mongo.IndexModel{
+	Keys: pageSort,
+}
+
+// pageFilter restricts filter to the documents after the page cursor
+func pageFilter(filter bson.M, page *domain.Pagination) bson.M {
+	if page.Cursor == nil {
+		return filter
+	}
+
+	keyset := bson.M{
+		"$or": bson.A{
+			bson.M{"created_on": bson.M{"$lt": page.Cursor.CreatedOn}},
+			bson.M{
+				"created_on": page.Cursor.CreatedOn,
+				"_id":        bson.M{"$lt": page.Cursor.ID},
+			},
+		},
+	}
+
+	if len(filter) == 0 {
+		return keyset
+	}
+	return bson.M{"$and": bson.A{filter, keyset}}
+}
+
+// pageOptions returns the find options for a page
+func pageOptions(page *domain.Pagination) *options.FindOptions {
+	opts := options.Find().
+		SetLimit(page.Limit).
+		SetSort(pageSort)
+
+	if page.Cursor == nil && page.Offset > 0 {
+		opts.SetSkip(page.Offset)
+	}
+
+	return opts
+}
@@ -0,0 +1,197 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const paymentCollection = "payments"
+
+type paymentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPaymentRepository creates a new payment repository
+func NewPaymentRepository(db *mongo.Database) domain.PaymentRepository {
+	collection := db.Collection(paymentCollection)
+
+	indexModels := []mongo.IndexModel{
+		pageIndex,
+		{
+			Keys:    bson.D{{Key: "txn_ref", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &paymentRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new pending payment
+func (r *paymentRepository) Create(ctx context.Context, payment *domain.Payment) error {
+	if payment.ID.IsZero() {
+		payment.ID = primitive.NewObjectID()
+	}
+	payment.ModifiedOn = payment.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, payment)
+	return err
+}
+
+func (r *paymentRepository) findOne(ctx context.Context, filter bson.M) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.collection.FindOne(ctx, filter).Decode(&payment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// GetByID gets a payment by ID
+func (r *paymentRepository) GetByID(ctx context.Context, id string) (*domain.Payment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+// GetByTxnRef gets a payment by the reference sent to the gateway
+func (r *paymentRepository) GetByTxnRef(ctx context.Context, txnRef string) (*domain.Payment, error) {
+	return r.findOne(ctx, bson.M{"txn_ref": txnRef})
+}
+
+// paymentQuery builds the MongoDB filter of a payment filter
+func paymentQuery(f *domain.PaymentFilter) bson.M {
+	filter := bson.M{}
+	if f == nil {
+		return filter
+	}
+
+	if f.CustomerID != nil {
+		filter["customer_id"] = *f.CustomerID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	addCreatedRange(filter, f.CreatedFrom, f.CreatedTo)
+
+	return filter
+}
+
+// GetAll gets payments matching filter with offset or cursor pagination
+func (r *paymentRepository) GetAll(ctx context.Context, f *domain.PaymentFilter, page *domain.Pagination) ([]*domain.Payment, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(paymentQuery(f), page), pageOptions(page))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []*domain.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// Count counts payments matching filter
+func (r *paymentRepository) Count(ctx context.Context, f *domain.PaymentFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, paymentQuery(f))
+}
+
+// GetByCustomer gets the payments of a customer, newest first
+func (r *paymentRepository) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Payment, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	opts := options.Find().SetSort(pageSort)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"customer_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []*domain.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// Complete records the gateway outcome of a pending payment. Only one of concurrent
+// or repeated callbacks can complete a payment, the others get ErrPaymentProcessed.
+func (r *paymentRepository) Complete(ctx context.Context, payment *domain.Payment) error {
+	payment.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":         payment.Status,
+			"transaction_no": payment.TransactionNo,
+			"bank_code":      payment.BankCode,
+			"response_code":  payment.ResponseCode,
+			"paid_at":        payment.PaidAt,
+			"modified_on":    payment.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": payment.ID, "status": domain.PaymentPending}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrPaymentProcessed
+	}
+
+	return nil
+}
+
+// SetRenewal records the period a succeeded payment renewed
+func (r *paymentRepository) SetRenewal(ctx context.Context, payment *domain.Payment) error {
+	payment.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"period_start": payment.PeriodStart,
+			"period_end":   payment.PeriodEnd,
+			"renewed_at":   payment.RenewedAt,
+			"modified_on":  payment.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": payment.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const planCollection = "plans"
+
+type planRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPlanRepository creates a new plan repository
+func NewPlanRepository(db *mongo.Database) domain.PlanRepository {
+	collection := db.Collection(planCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &planRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new plan
+func (r *planRepository) Create(ctx context.Context, plan *domain.Plan) error {
+	plan.ID = primitive.NewObjectID()
+	plan.CreatedOn = time.Now()
+	plan.ModifiedOn = plan.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, plan)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrPlanCodeExists
+	}
+	return err
+}
+
+// GetByID gets a plan by ID
+func (r *planRepository) GetByID(ctx context.Context, id string) (*domain.Plan, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var plan domain.Plan
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&plan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// GetAll gets the plans sorted by name, optionally only the active ones
+func (r *planRepository) GetAll(ctx context.Context, activeOnly bool) ([]*domain.Plan, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["is_active"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	plans := []*domain.Plan{}
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// Update updates the name, description, prices, seat prices and status of a plan
+func (r *planRepository) Update(ctx context.Context, plan *domain.Plan) error {
+	plan.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":        plan.Name,
+			"description": plan.Description,
+			"prices":      plan.Prices,
+			"seat_prices": plan.SeatPrices,
+			"is_active":   plan.IsActive,
+			"modified_on": plan.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": plan.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const promoCodeCollection = "promo_codes"
+
+type promoCodeRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPromoCodeRepository creates a new promo code repository
+func NewPromoCodeRepository(db *mongo.Database) domain.PromoCodeRepository {
+	collection := db.Collection(promoCodeCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &promoCodeRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new promo code
+func (r *promoCodeRepository) Create(ctx context.Context, promo *domain.PromoCode) error {
+	promo.ID = primitive.NewObjectID()
+	promo.CreatedOn = time.Now()
+	promo.ModifiedOn = promo.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, promo)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrPromoCodeExists
+	}
+	return err
+}
+
+func (r *promoCodeRepository) findOne(ctx context.Context, filter bson.M) (*domain.PromoCode, error) {
+	var promo domain.PromoCode
+	err := r.collection.FindOne(ctx, filter).Decode(&promo)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &promo, nil
+}
+
+// GetByID gets a promo code by ID
+func (r *promoCodeRepository) GetByID(ctx context.Context, id string) (*domain.PromoCode, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+// GetByCode gets a promo code by its upper case code
+func (r *promoCodeRepository) GetByCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	return r.findOne(ctx, bson.M{"code": code})
+}
+
+// GetAll gets the promo codes, newest first
+func (r *promoCodeRepository) GetAll(ctx context.Context) ([]*domain.PromoCode, error) {
+	opts := options.Find().SetSort(pageSort)
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	promos := []*domain.PromoCode{}
+	if err := cursor.All(ctx, &promos); err != nil {
+		return nil, err
+	}
+
+	return promos, nil
+}
+
+// Update updates the description, validity, redemption limit and status of a promo code
+func (r *promoCodeRepository) Update(ctx context.Context, promo *domain.PromoCode) error {
+	promo.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"description":     promo.Description,
+			"valid_from":      promo.ValidFrom,
+			"valid_until":     promo.ValidUntil,
+			"max_redemptions": promo.MaxRedemptions,
+			"is_active":       promo.IsActive,
+			"modified_on":     promo.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": promo.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Redeem counts one use of a promo code. The count never goes past the limit,
+// even under concurrent redemptions; ErrPromoCodeExhausted is returned then.
+func (r *promoCodeRepository) Redeem(ctx context.Context, code string) error {
+	filter := bson.M{
+		"code": code,
+		"$or": bson.A{
+			bson.M{"max_redemptions": bson.M{"$in": bson.A{nil, 0}}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$redemptions", "$max_redemptions"}}},
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{"redemptions": 1},
+		"$set": bson.M{"modified_on": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrPromoCodeExhausted
+	}
+
+	return nil
+}
+
+// Release takes back a redemption that could not be completed
+func (r *promoCodeRepository) Release(ctx context.Context, code string) error {
+	update := bson.M{
+		"$inc": bson.M{"redemptions": -1},
+		"$set": bson.M{"modified_on": time.Now()},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"code": code, "redemptions": bson.M{"$gt": 0}}, update)
+	return err
+}
@@ -0,0 +1,232 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const quoteCollection = "quotes"
+
+// maxQuoteAttempts bounds the retries when concurrent quotes race for the same version
+const maxQuoteAttempts = 10
+
+// openQuoteStatuses are the statuses a quote can still be sent or superseded from
+var openQuoteStatuses = bson.M{"$in": bson.A{domain.QuoteDraft, domain.QuoteSent}}
+
+type quoteRepository struct {
+	collection *mongo.Collection
+}
+
+// NewQuoteRepository creates a new quote repository
+func NewQuoteRepository(db *mongo.Database) domain.QuoteRepository {
+	collection := db.Collection(quoteCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			// One quote per version of a registration
+			Keys:    bson.D{{Key: "registration_id", Value: 1}, {Key: "version", Value: -1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"token_hash": bson.M{"$exists": true},
+			}),
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &quoteRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a quote as the next version of its registration
+func (r *quoteRepository) Create(ctx context.Context, quote *domain.Quote) error {
+	quote.CreatedOn = time.Now()
+	quote.ModifiedOn = quote.CreatedOn
+
+	for attempt := 0; attempt < maxQuoteAttempts; attempt++ {
+		version, err := r.lastVersion(ctx, quote.RegistrationID)
+		if err != nil {
+			return err
+		}
+
+		quote.ID = primitive.NewObjectID()
+		quote.Version = version + 1
+
+		_, err = r.collection.InsertOne(ctx, quote)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		return err
+	}
+
+	return domain.ErrQuoteVersionConflict
+}
+
+// lastVersion returns the highest quote version of a registration, or 0
+func (r *quoteRepository) lastVersion(ctx context.Context, registrationID primitive.ObjectID) (int, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"version": 1})
+
+	var last struct {
+		Version int `bson:"version"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"registration_id": registrationID}, opts).Decode(&last)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return last.Version, nil
+}
+
+func (r *quoteRepository) findOne(ctx context.Context, filter bson.M) (*domain.Quote, error) {
+	var quote domain.Quote
+	err := r.collection.FindOne(ctx, filter).Decode(&quote)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+// GetByID gets a quote by ID
+func (r *quoteRepository) GetByID(ctx context.Context, id string) (*domain.Quote, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+// GetByTokenHash gets a quote by the hash of its link token
+func (r *quoteRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.Quote, error) {
+	return r.findOne(ctx, bson.M{"token_hash": tokenHash})
+}
+
+// GetByRegistration gets the quotes of a registration, latest version first
+func (r *quoteRepository) GetByRegistration(ctx context.Context, registrationID string) ([]*domain.Quote, error) {
+	objectID, err := primitive.ObjectIDFromHex(registrationID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"registration_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	quotes := []*domain.Quote{}
+	if err := cursor.All(ctx, &quotes); err != nil {
+		return nil, err
+	}
+
+	return quotes, nil
+}
+
+// HasAccepted reports whether a registration has accepted one of its quotes
+func (r *quoteRepository) HasAccepted(ctx context.Context, registrationID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"registration_id": registrationID,
+		"status":          domain.QuoteAccepted,
+	})
+	return count > 0, err
+}
+
+// Supersede marks the open quotes of a registration other than except as superseded
+func (r *quoteRepository) Supersede(ctx context.Context, registrationID primitive.ObjectID, except primitive.ObjectID) error {
+	filter := bson.M{
+		"registration_id": registrationID,
+		"_id":             bson.M{"$ne": except},
+		"status":          openQuoteStatuses,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      domain.QuoteSuperseded,
+			"modified_on": time.Now(),
+		},
+	}
+
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// MarkSent stores the link token hash of an open quote and marks it sent.
+// Sending again replaces the token, so earlier links stop working.
+func (r *quoteRepository) MarkSent(ctx context.Context, quote *domain.Quote) error {
+	quote.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":      domain.QuoteSent,
+			"token_hash":  quote.TokenHash,
+			"sent_at":     quote.SentAt,
+			"modified_on": quote.ModifiedOn,
+		},
+	}
+
+	return r.updateIfOpen(ctx, bson.M{"_id": quote.ID, "status": openQuoteStatuses}, quote.ID, update)
+}
+
+// Accept marks a sent quote accepted by the customer it converted into. Only one of
+// concurrent acceptances succeeds, and only before the quote expires.
+func (r *quoteRepository) Accept(ctx context.Context, quote *domain.Quote) error {
+	quote.ModifiedOn = time.Now()
+
+	filter := bson.M{
+		"_id":         quote.ID,
+		"status":      domain.QuoteSent,
+		"valid_until": bson.M{"$gt": quote.ModifiedOn},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      domain.QuoteAccepted,
+			"accepted_at": quote.AcceptedAt,
+			"customer_id": quote.CustomerID,
+			"modified_on": quote.ModifiedOn,
+		},
+	}
+
+	return r.updateIfOpen(ctx, filter, quote.ID, update)
+}
+
+// updateIfOpen applies update when filter matches, and tells apart a missing quote
+// from one that can no longer change
+func (r *quoteRepository) updateIfOpen(ctx context.Context, filter bson.M, id primitive.ObjectID, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+	return domain.ErrQuoteNotOpen
+}
@@ -20,8 +20,17 @@ type registrationRepository struct {
 
 // NewRegistrationRepository creates a new registration repository
 func NewRegistrationRepository(db *mongo.Database) domain.RegistrationRepository {
+	collection := db.Collection(registrationCollection)
+	indexModels := []mongo.IndexModel{
+		pageIndex,
+		{
+			Keys: bson.D{{Key: "reseller_id", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
 	return &registrationRepository{
-		collection: db.Collection(registrationCollection),
+		collection: collection,
 	}
 }
 
@@ -43,7 +52,7 @@ func (r *registrationRepository) GetByID(ctx context.Context, id string) (*domai
 	}
 
 	var registration domain.Registration
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&registration)
+	err = r.collection.FindOne(ctx, notDeleted(bson.M{"_id": objectID})).Decode(&registration)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -57,7 +66,7 @@ func (r *registrationRepository) GetByID(ctx context.Context, id string) (*domai
 // GetByEmail gets a registration by email
 func (r *registrationRepository) GetByEmail(ctx context.Context, email string) (*domain.Registration, error) {
 	var registration domain.Registration
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&registration)
+	err := r.collection.FindOne(ctx, notDeleted(bson.M{"email": email})).Decode(&registration)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -68,14 +77,28 @@ func (r *registrationRepository) GetByEmail(ctx context.Context, email string) (
 	return &registration, nil
 }
 
-// GetAll gets all registrations with pagination
-func (r *registrationRepository) GetAll(ctx context.Context, limit, offset int64) ([]*domain.Registration, error) {
-	opts := options.Find().
-		SetLimit(limit).
-		SetSkip(offset).
-		SetSort(bson.D{{Key: "created_on", Value: -1}})
+// registrationQuery converts a registration filter to a MongoDB query
+func registrationQuery(f *domain.RegistrationFilter) bson.M {
+	filter := notDeleted(bson.M{})
+	if f == nil {
+		return filter
+	}
+
+	addSearch(filter, f.Search, "full_name", "phone_number", "email")
+	if f.WorkstationRange != "" {
+		filter["workstation_range"] = f.WorkstationRange
+	}
+	if f.ResellerID != nil {
+		filter["reseller_id"] = *f.ResellerID
+	}
+	addCreatedRange(filter, f.CreatedFrom, f.CreatedTo)
+
+	return filter
+}
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+// GetAll gets registrations matching filter with offset or cursor pagination
+func (r *registrationRepository) GetAll(ctx context.Context, f *domain.RegistrationFilter, page *domain.Pagination) ([]*domain.Registration, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(registrationQuery(f), page), pageOptions(page))
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +112,29 @@ func (r *registrationRepository) GetAll(ctx context.Context, limit, offset int64
 	return registrations, nil
 }
 
+// Iterate calls fn for every registration matching filter without loading them all in memory
+func (r *registrationRepository) Iterate(ctx context.Context, f *domain.RegistrationFilter, fn func(*domain.Registration) error) error {
+	opts := options.Find().SetSort(pageSort)
+
+	cursor, err := r.collection.Find(ctx, registrationQuery(f), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var registration domain.Registration
+		if err := cursor.Decode(&registration); err != nil {
+			return err
+		}
+		if err := fn(&registration); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
 // Update updates a registration
 func (r *registrationRepository) Update(ctx context.Context, id string, registration *domain.Registration) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -100,16 +146,17 @@ func (r *registrationRepository) Update(ctx context.Context, id string, registra
 
 	update := bson.M{
 		"$set": bson.M{
-			"full_name":         registration.FullName,
-			"phone_number":      registration.PhoneNumber,
-			"email":             registration.Email,
-			"address":           registration.Address,
-			"workstation_range": registration.WorkstationRange,
-			"modified_on":       registration.ModifiedOn,
+			"full_name":          registration.FullName,
+			"phone_number":       registration.PhoneNumber,
+			"email":              registration.Email,
+			"address":            registration.Address,
+			"structured_address": registration.StructuredAddress,
+			"workstation_range":  registration.WorkstationRange,
+			"modified_on":        registration.ModifiedOn,
 		},
 	}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	result, err := r.collection.UpdateOne(ctx, notDeleted(bson.M{"_id": objectID}), update)
 	if err != nil {
 		return err
 	}
@@ -121,26 +168,76 @@ func (r *registrationRepository) Update(ctx context.Context, id string, registra
 	return nil
 }
 
-// Delete deletes a registration
-func (r *registrationRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return domain.ErrInvalidID
+// SetStructuredAddress sets the structured address of a registration
+func (r *registrationRepository) SetStructuredAddress(ctx context.Context, id string, address *domain.Address) error {
+	return setStructuredAddress(ctx, r.collection, id, address)
+}
+
+// MarkConverted records the customer a registration was converted into
+func (r *registrationRepository) MarkConverted(ctx context.Context, id primitive.ObjectID, customerID primitive.ObjectID, convertedAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"customer_id":  customerID,
+			"converted_at": convertedAt,
+			"modified_on":  time.Now(),
+		},
 	}
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
 	if err != nil {
 		return err
 	}
 
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
 		return domain.ErrNotFound
 	}
 
 	return nil
 }
 
-// Count counts all registrations
-func (r *registrationRepository) Count(ctx context.Context) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{})
+// Delete moves a registration to the trash
+func (r *registrationRepository) Delete(ctx context.Context, id string, deletedBy string) error {
+	return softDelete(ctx, r.collection, id, deletedBy)
+}
+
+// GetDeleted gets registrations in the trash with pagination
+func (r *registrationRepository) GetDeleted(ctx context.Context, page *domain.Pagination) ([]*domain.Registration, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(onlyDeleted(bson.M{}), page), pageOptions(page))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var registrations []*domain.Registration
+	if err := cursor.All(ctx, &registrations); err != nil {
+		return nil, err
+	}
+
+	return registrations, nil
+}
+
+// CountDeleted counts registrations in the trash
+func (r *registrationRepository) CountDeleted(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, onlyDeleted(bson.M{}))
+}
+
+// Restore moves a registration out of the trash
+func (r *registrationRepository) Restore(ctx context.Context, id string) error {
+	return restoreDeleted(ctx, r.collection, id)
+}
+
+// Purge permanently deletes registrations trashed before the given time
+func (r *registrationRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, r.collection, before)
+}
+
+// Count counts registrations matching filter
+func (r *registrationRepository) Count(ctx context.Context, f *domain.RegistrationFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, registrationQuery(f))
+}
+
+// EstimatedCount returns the approximate number of registrations from collection metadata.
+// Registrations in the trash are included in the estimate.
+func (r *registrationRepository) EstimatedCount(ctx context.Context) (int64, error) {
+	return r.collection.EstimatedDocumentCount(ctx)
 }
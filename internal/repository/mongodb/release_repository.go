@@ -0,0 +1,150 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const releaseCollection = "releases"
+
+type releaseRepository struct {
+	collection *mongo.Collection
+}
+
+// NewReleaseRepository creates a new release repository
+func NewReleaseRepository(db *mongo.Database) domain.ReleaseRepository {
+	collection := db.Collection(releaseCollection)
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "product", Value: 1}, {Key: "assets.platform", Value: 1}, {Key: "published_at", Value: 1}},
+		},
+	})
+
+	return &releaseRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new release
+func (r *releaseRepository) Create(ctx context.Context, release *domain.Release) error {
+	release.ID = primitive.NewObjectID()
+	release.CreatedOn = time.Now()
+	release.ModifiedOn = release.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, release)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrReleaseVersionExists
+	}
+	return err
+}
+
+// GetByVersion gets the release of a product version
+func (r *releaseRepository) GetByVersion(ctx context.Context, product string, version string) (*domain.Release, error) {
+	var release domain.Release
+	err := r.collection.FindOne(ctx, bson.M{"product": product, "version": version}).Decode(&release)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &release, nil
+}
+
+// GetAll gets the releases, newest first, optionally of one product
+func (r *releaseRepository) GetAll(ctx context.Context, product string) ([]*domain.Release, error) {
+	filter := bson.M{}
+	if product != "" {
+		filter["product"] = product
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_on", Value: -1}})
+	return r.find(ctx, filter, opts)
+}
+
+// GetPublished gets the published releases of a product on the given channels
+// with a build for platform. They are not ordered: versions only order as
+// semantic versions.
+func (r *releaseRepository) GetPublished(
+	ctx context.Context,
+	product string,
+	channels []domain.ReleaseChannel,
+	platform domain.ReleasePlatform,
+) ([]*domain.Release, error) {
+
+	filter := bson.M{
+		"product":         product,
+		"channel":         bson.M{"$in": channels},
+		"assets.platform": platform,
+		"published_at":    bson.M{"$ne": nil},
+	}
+	return r.find(ctx, filter, nil)
+}
+
+func (r *releaseRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*domain.Release, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	releases := []*domain.Release{}
+	if err := cursor.All(ctx, &releases); err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+// Update updates the channel, changelog, minimum version, builds and publication of a release
+func (r *releaseRepository) Update(ctx context.Context, release *domain.Release) error {
+	release.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"channel":         release.Channel,
+			"changelog":       release.Changelog,
+			"minimum_version": release.MinimumVersion,
+			"assets":          release.Assets,
+			"published_at":    release.PublishedAt,
+			"modified_on":     release.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": release.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a release
+func (r *releaseRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const resellerCollection = "resellers"
+
+type resellerRepository struct {
+	collection *mongo.Collection
+}
+
+// NewResellerRepository creates a new reseller repository
+func NewResellerRepository(db *mongo.Database) domain.ResellerRepository {
+	collection := db.Collection(resellerCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &resellerRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new reseller
+func (r *resellerRepository) Create(ctx context.Context, reseller *domain.Reseller) error {
+	reseller.ID = primitive.NewObjectID()
+	reseller.CreatedOn = time.Now()
+	reseller.ModifiedOn = reseller.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, reseller)
+	if mongo.IsDuplicateKeyError(err) {
+		if strings.Contains(err.Error(), "user_id") {
+			return domain.ErrResellerExists
+		}
+		return domain.ErrResellerCodeExists
+	}
+	return err
+}
+
+func (r *resellerRepository) findOne(ctx context.Context, filter bson.M) (*domain.Reseller, error) {
+	var reseller domain.Reseller
+	err := r.collection.FindOne(ctx, filter).Decode(&reseller)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &reseller, nil
+}
+
+// GetByID gets a reseller by ID
+func (r *resellerRepository) GetByID(ctx context.Context, id string) (*domain.Reseller, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+// GetByUserID gets the reseller of a user account
+func (r *resellerRepository) GetByUserID(ctx context.Context, userID string) (*domain.Reseller, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	return r.findOne(ctx, bson.M{"user_id": objectID})
+}
+
+// GetByCode gets a reseller by its upper case referral code
+func (r *resellerRepository) GetByCode(ctx context.Context, code string) (*domain.Reseller, error) {
+	return r.findOne(ctx, bson.M{"code": code})
+}
+
+// GetAll gets the resellers sorted by name
+func (r *resellerRepository) GetAll(ctx context.Context) ([]*domain.Reseller, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	resellers := []*domain.Reseller{}
+	if err := cursor.All(ctx, &resellers); err != nil {
+		return nil, err
+	}
+
+	return resellers, nil
+}
+
+// Update updates the profile and status of a reseller
+func (r *resellerRepository) Update(ctx context.Context, reseller *domain.Reseller) error {
+	reseller.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":         reseller.Name,
+			"company_name": reseller.CompanyName,
+			"phone_number": reseller.PhoneNumber,
+			"email":        reseller.Email,
+			"bank_account": reseller.BankAccount,
+			"note":         reseller.Note,
+			"is_active":    reseller.IsActive,
+			"modified_on":  reseller.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": reseller.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
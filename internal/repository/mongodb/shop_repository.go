@@ -0,0 +1,176 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const shopCollection = "shops"
+
+type shopRepository struct {
+	collection *mongo.Collection
+}
+
+// NewShopRepository creates a new shop repository
+func NewShopRepository(db *mongo.Database) domain.ShopRepository {
+	collection := db.Collection(shopCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "name", Value: 1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &shopRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new shop
+func (r *shopRepository) Create(ctx context.Context, shop *domain.Shop) error {
+	shop.ID = primitive.NewObjectID()
+	shop.CreatedOn = time.Now()
+	shop.ModifiedOn = shop.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, shop)
+	return err
+}
+
+// GetByID gets a shop by ID
+func (r *shopRepository) GetByID(ctx context.Context, id string) (*domain.Shop, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var shop domain.Shop
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&shop)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &shop, nil
+}
+
+// GetByCustomer gets the shops of a customer ordered by name
+func (r *shopRepository) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Shop, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"customer_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	shops := []*domain.Shop{}
+	if err := cursor.All(ctx, &shops); err != nil {
+		return nil, err
+	}
+
+	return shops, nil
+}
+
+// TotalsByCustomers counts the shops and sums the workstations of each customer
+func (r *shopRepository) TotalsByCustomers(ctx context.Context, customerIDs []primitive.ObjectID) (map[primitive.ObjectID]domain.ShopTotals, error) {
+	totals := make(map[primitive.ObjectID]domain.ShopTotals)
+	if len(customerIDs) == 0 {
+		return totals, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"customer_id": bson.M{"$in": customerIDs}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$customer_id",
+			"shop_count":   bson.M{"$sum": 1},
+			"workstations": bson.M{"$sum": "$workstation_count"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			CustomerID        primitive.ObjectID `bson:"_id"`
+			domain.ShopTotals `bson:",inline"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		totals[row.CustomerID] = row.ShopTotals
+	}
+
+	return totals, cursor.Err()
+}
+
+// Update updates a shop
+func (r *shopRepository) Update(ctx context.Context, shop *domain.Shop) error {
+	shop.ModifiedOn = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":              shop.Name,
+			"address":           shop.Address,
+			"workstation_count": shop.WorkstationCount,
+			"opening_hours":     shop.OpeningHours,
+			"contact":           shop.Contact,
+			"note":              shop.Note,
+			"modified_on":       shop.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": shop.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a shop
+func (r *shopRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// detachShop removes the shop reference of every document attached to a deleted shop
+func detachShop(ctx context.Context, collection *mongo.Collection, shopID primitive.ObjectID) error {
+	_, err := collection.UpdateMany(ctx, bson.M{"shop_id": shopID}, bson.M{"$unset": bson.M{"shop_id": ""}})
+	return err
+}
@@ -0,0 +1,240 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const subscriptionCollection = "subscriptions"
+
+type subscriptionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSubscriptionRepository creates a new subscription repository
+func NewSubscriptionRepository(db *mongo.Database) domain.SubscriptionRepository {
+	collection := db.Collection(subscriptionCollection)
+
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_on", Value: -1}},
+		},
+		{
+			// One subscription per customer that is not cancelled
+			Keys: bson.D{{Key: "customer_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"status": bson.M{"$in": bson.A{
+					domain.SubscriptionTrial,
+					domain.SubscriptionActive,
+					domain.SubscriptionPastDue,
+				}},
+			}),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_renewal_at", Value: 1}},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &subscriptionRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new subscription. It fails with ErrSubscriptionExists when the
+// customer has a subscription that is not cancelled.
+func (r *subscriptionRepository) Create(ctx context.Context, subscription *domain.Subscription) error {
+	subscription.ID = primitive.NewObjectID()
+	subscription.CreatedOn = time.Now()
+	subscription.ModifiedOn = subscription.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, subscription)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrSubscriptionExists
+	}
+	return err
+}
+
+func (r *subscriptionRepository) findOne(ctx context.Context, filter bson.M) (*domain.Subscription, error) {
+	var subscription domain.Subscription
+	err := r.collection.FindOne(ctx, filter).Decode(&subscription)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+func (r *subscriptionRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*domain.Subscription, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := []*domain.Subscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// GetByID gets a subscription by ID
+func (r *subscriptionRepository) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+// GetByCustomer gets the subscriptions of a customer, newest first
+func (r *subscriptionRepository) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Subscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_on", Value: -1}})
+	return r.find(ctx, bson.M{"customer_id": objectID}, opts)
+}
+
+// GetCurrentByCustomer gets the subscription of a customer that is not cancelled
+func (r *subscriptionRepository) GetCurrentByCustomer(ctx context.Context, customerID primitive.ObjectID) (*domain.Subscription, error) {
+	return r.findOne(ctx, bson.M{
+		"customer_id": customerID,
+		"status":      bson.M{"$ne": domain.SubscriptionCancelled},
+	})
+}
+
+// GetRenewingBefore gets the subscriptions that are not cancelled and renew before
+// the given time, soonest first
+func (r *subscriptionRepository) GetRenewingBefore(ctx context.Context, before time.Time) ([]*domain.Subscription, error) {
+	filter := bson.M{
+		"status":          bson.M{"$ne": domain.SubscriptionCancelled},
+		"next_renewal_at": bson.M{"$lt": before},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "next_renewal_at", Value: 1}})
+	return r.find(ctx, filter, opts)
+}
+
+// StartNextPeriod saves the terms, period and status of a renewed subscription.
+// It only applies while the subscription is not cancelled and still renews at
+// renewalAt, so two renewals of the same period cannot both succeed.
+func (r *subscriptionRepository) StartNextPeriod(ctx context.Context, subscription *domain.Subscription, renewalAt time.Time) error {
+	subscription.ModifiedOn = time.Now()
+
+	filter := bson.M{
+		"_id":             subscription.ID,
+		"status":          bson.M{"$ne": domain.SubscriptionCancelled},
+		"next_renewal_at": renewalAt,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"plan_id":              subscription.PlanID,
+			"plan_name":            subscription.PlanName,
+			"workstation_range":    subscription.WorkstationRange,
+			"billing_cycle":        subscription.BillingCycle,
+			"price":                subscription.Price,
+			"status":               subscription.Status,
+			"current_period_start": subscription.CurrentPeriodStart,
+			"next_renewal_at":      subscription.NextRenewalAt,
+			"last_renewed_at":      subscription.LastRenewedAt,
+			"modified_on":          subscription.ModifiedOn,
+		},
+		"$unset": bson.M{"reminders_sent": ""},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrSubscriptionChanged
+	}
+
+	return nil
+}
+
+// MarkPastDue marks a trial or active subscription past due, unless it was renewed
+// since it was read, i.e. no longer renews at renewalAt
+func (r *subscriptionRepository) MarkPastDue(ctx context.Context, id primitive.ObjectID, renewalAt time.Time) error {
+	filter := bson.M{
+		"_id":             id,
+		"status":          bson.M{"$in": bson.A{domain.SubscriptionTrial, domain.SubscriptionActive}},
+		"next_renewal_at": renewalAt,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      domain.SubscriptionPastDue,
+			"modified_on": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrSubscriptionChanged
+	}
+
+	return nil
+}
+
+// Cancel saves the cancellation of a subscription that is not cancelled yet
+func (r *subscriptionRepository) Cancel(ctx context.Context, subscription *domain.Subscription) error {
+	subscription.ModifiedOn = time.Now()
+
+	filter := bson.M{
+		"_id":    subscription.ID,
+		"status": bson.M{"$ne": domain.SubscriptionCancelled},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":        domain.SubscriptionCancelled,
+			"cancelled_at":  subscription.CancelledAt,
+			"cancel_reason": subscription.CancelReason,
+			"modified_on":   subscription.ModifiedOn,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrSubscriptionCancelled
+	}
+
+	return nil
+}
+
+// AddRemindersSent records the renewal reminders sent for the period ending at
+// renewalAt. Nothing is recorded when the subscription was renewed meanwhile, as
+// the reminders were for the previous period.
+func (r *subscriptionRepository) AddRemindersSent(ctx context.Context, id primitive.ObjectID, renewalAt time.Time, days []int) error {
+	filter := bson.M{"_id": id, "next_renewal_at": renewalAt}
+	update := bson.M{
+		"$addToSet": bson.M{"reminders_sent": bson.M{"$each": days}},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
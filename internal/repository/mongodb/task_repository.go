@@ -0,0 +1,192 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const taskCollection = "tasks"
+
+// taskSort lists the most urgent tasks first
+var taskSort = bson.D{{Key: "due_at", Value: 1}, {Key: "_id", Value: 1}}
+
+type taskRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTaskRepository creates a new task repository
+func NewTaskRepository(db *mongo.Database) domain.TaskRepository {
+	collection := db.Collection(taskCollection)
+
+	indexModels := []mongo.IndexModel{
+		// Task list of a rep
+		{
+			Keys: bson.D{
+				{Key: "assignee_id", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "due_at", Value: 1},
+			},
+		},
+		// Tasks of a customer
+		{
+			Keys: bson.D{
+				{Key: "customer_id", Value: 1},
+				{Key: "due_at", Value: 1},
+			},
+		},
+	}
+	collection.Indexes().CreateMany(context.Background(), indexModels)
+
+	return &taskRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new task
+func (r *taskRepository) Create(ctx context.Context, task *domain.Task) error {
+	task.ID = primitive.NewObjectID()
+	task.CreatedOn = time.Now()
+	task.ModifiedOn = task.CreatedOn
+
+	_, err := r.collection.InsertOne(ctx, task)
+	return err
+}
+
+// GetByID gets a task by ID
+func (r *taskRepository) GetByID(ctx context.Context, id string) (*domain.Task, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var task domain.Task
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// taskQuery converts a task filter to a MongoDB query
+func taskQuery(f *domain.TaskFilter) (bson.M, error) {
+	filter := bson.M{}
+	if f == nil {
+		return filter, nil
+	}
+
+	if f.CustomerID != "" {
+		objectID, err := primitive.ObjectIDFromHex(f.CustomerID)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		filter["customer_id"] = objectID
+	}
+	if f.AssigneeID != "" {
+		filter["assignee_id"] = f.AssigneeID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.DueBefore != nil {
+		filter["due_at"] = bson.M{"$lt": *f.DueBefore}
+	}
+
+	return filter, nil
+}
+
+// GetAll gets tasks matching filter ordered by due date with offset pagination
+func (r *taskRepository) GetAll(ctx context.Context, f *domain.TaskFilter, page *domain.Pagination) ([]*domain.Task, error) {
+	filter, err := taskQuery(f)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetLimit(page.Limit).
+		SetSkip(page.Offset).
+		SetSort(taskSort)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*domain.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Count counts tasks matching filter
+func (r *taskRepository) Count(ctx context.Context, f *domain.TaskFilter) (int64, error) {
+	filter, err := taskQuery(f)
+	if err != nil {
+		return 0, err
+	}
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// Update updates a task
+func (r *taskRepository) Update(ctx context.Context, task *domain.Task) error {
+	task.ModifiedOn = time.Now()
+
+	set := bson.M{
+		"title":       task.Title,
+		"note":        task.Note,
+		"due_at":      task.DueAt,
+		"assignee_id": task.AssigneeID,
+		"status":      task.Status,
+		"modified_on": task.ModifiedOn,
+	}
+	update := bson.M{"$set": set}
+	if task.CompletedAt != nil {
+		set["completed_at"] = task.CompletedAt
+		set["completed_by"] = task.CompletedBy
+	} else {
+		update["$unset"] = bson.M{"completed_at": "", "completed_by": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": task.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a task
+func (r *taskRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
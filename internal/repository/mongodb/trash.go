@@ -0,0 +1,82 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// notDeleted excludes soft-deleted documents from filter.
+// A null match also covers documents written before soft delete existed.
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return filter
+}
+
+// onlyDeleted restricts filter to soft-deleted documents
+func onlyDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$ne": nil}
+	return filter
+}
+
+// softDelete marks a document as deleted
+func softDelete(ctx context.Context, collection *mongo.Collection, id, deletedBy string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	set := bson.M{"deleted_at": time.Now()}
+	if deletedBy != "" {
+		set["deleted_by"] = deletedBy
+	}
+
+	result, err := collection.UpdateOne(ctx, notDeleted(bson.M{"_id": objectID}), bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// restoreDeleted clears the deletion mark of a soft-deleted document
+func restoreDeleted(ctx context.Context, collection *mongo.Collection, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+		"$set":   bson.M{"modified_on": time.Now()},
+	}
+
+	result, err := collection.UpdateOne(ctx, onlyDeleted(bson.M{"_id": objectID}), update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// purgeDeleted hard-deletes documents soft-deleted before the given time
+func purgeDeleted(ctx context.Context, collection *mongo.Collection, before time.Time) (int64, error) {
+	result, err := collection.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
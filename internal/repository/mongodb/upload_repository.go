@@ -0,0 +1,181 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const uploadCollection = "uploads"
+
+type uploadRepository struct {
+	collection *mongo.Collection
+}
+
+// NewUploadRepository creates a new resumable upload repository
+func NewUploadRepository(db *mongo.Database) domain.UploadRepository {
+	collection := db.Collection(uploadCollection)
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "expires_at", Value: 1}},
+		},
+	})
+
+	return &uploadRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new upload
+func (r *uploadRepository) Create(ctx context.Context, upload *domain.Upload) error {
+	upload.ID = primitive.NewObjectID()
+	upload.CreatedOn = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, upload)
+	return err
+}
+
+// GetByID gets an upload by ID
+func (r *uploadRepository) GetByID(ctx context.Context, id string) (*domain.Upload, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	var upload domain.Upload
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&upload)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// AddPart records a staged part starting at offset and moves the offset past it.
+// The update only applies while the offset is still offset, so of concurrent
+// requests appending at the same offset exactly one records its part.
+func (r *uploadRepository) AddPart(ctx context.Context, id primitive.ObjectID, offset int64, part domain.UploadPart) error {
+	filter := bson.M{
+		"_id":     id,
+		"offset":  offset,
+		"file_id": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$push": bson.M{"parts": part},
+		"$set":  bson.M{"offset": offset + part.Size},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return r.missOr(ctx, id, domain.ErrUploadOffsetMismatch)
+	}
+	return nil
+}
+
+// Lock takes the lease held while an upload is being completed, until it is
+// unlocked or the given time has passed
+func (r *uploadRepository) Lock(ctx context.Context, id primitive.ObjectID, until time.Time) error {
+	filter := bson.M{
+		"_id":     id,
+		"file_id": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lte": time.Now()}},
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"locked_until": until}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return r.missOr(ctx, id, domain.ErrUploadLocked)
+	}
+	return nil
+}
+
+// Unlock releases the completion lease of an upload
+func (r *uploadRepository) Unlock(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"locked_until": ""}})
+	return err
+}
+
+// Complete links an upload to the file created from it and forgets its parts
+func (r *uploadRepository) Complete(ctx context.Context, id primitive.ObjectID, fileID primitive.ObjectID) error {
+	update := bson.M{
+		"$set":   bson.M{"file_id": fileID},
+		"$unset": bson.M{"parts": "", "locked_until": ""},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// missOr returns ErrNotFound when the upload is gone, else err
+func (r *uploadRepository) missOr(ctx context.Context, id primitive.ObjectID, err error) error {
+	count, countErr := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if countErr != nil {
+		return countErr
+	}
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+// Delete deletes an upload
+func (r *uploadRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// GetExpired gets uploads that expired before the given time, oldest first.
+// Uploads being completed are left alone.
+func (r *uploadRepository) GetExpired(ctx context.Context, before time.Time, limit int64) ([]*domain.Upload, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}}).SetLimit(limit)
+
+	filter := bson.M{
+		"expires_at": bson.M{"$lt": before},
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lte": time.Now()}},
+		},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var uploads []*domain.Upload
+	if err := cursor.All(ctx, &uploads); err != nil {
+		return nil, err
+	}
+
+	return uploads, nil
+}
@@ -36,6 +36,7 @@ func NewUserRepository(db *mongo.Database) domain.UserRepository {
 			Keys:    bson.D{{Key: "phone", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		},
+		pageIndex,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -73,7 +74,7 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*domain.User,
 	}
 
 	var user domain.User
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+	err = r.collection.FindOne(ctx, notDeleted(bson.M{"_id": objectID})).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -87,7 +88,7 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*domain.User,
 // GetByUsername gets a user by username
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	var user domain.User
-	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	err := r.collection.FindOne(ctx, notDeleted(bson.M{"username": username})).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -101,7 +102,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 // GetByEmail gets a user by email
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	err := r.collection.FindOne(ctx, notDeleted(bson.M{"email": email})).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -115,7 +116,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 // GetByPhone gets a user by phone
 func (r *userRepository) GetByPhone(ctx context.Context, phone string) (*domain.User, error) {
 	var user domain.User
-	err := r.collection.FindOne(ctx, bson.M{"phone": phone}).Decode(&user)
+	err := r.collection.FindOne(ctx, notDeleted(bson.M{"phone": phone})).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrNotFound
@@ -126,14 +127,9 @@ func (r *userRepository) GetByPhone(ctx context.Context, phone string) (*domain.
 	return &user, nil
 }
 
-// GetAll gets all users with pagination
-func (r *userRepository) GetAll(ctx context.Context, limit, offset int64) ([]*domain.User, error) {
-	opts := options.Find().
-		SetLimit(limit).
-		SetSkip(offset).
-		SetSort(bson.D{{Key: "created_on", Value: -1}})
-
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+// GetAll gets all users with offset or cursor pagination
+func (r *userRepository) GetAll(ctx context.Context, page *domain.Pagination) ([]*domain.User, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(notDeleted(bson.M{}), page), pageOptions(page))
 	if err != nil {
 		return nil, err
 	}
@@ -172,7 +168,7 @@ func (r *userRepository) Update(ctx context.Context, id string, user *domain.Use
 		},
 	}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	result, err := r.collection.UpdateOne(ctx, notDeleted(bson.M{"_id": objectID}), update)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
 			return domain.ErrAlreadyExists
@@ -201,30 +197,73 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, id string) error {
 		},
 	}
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	_, err = r.collection.UpdateOne(ctx, notDeleted(bson.M{"_id": objectID}), update)
 	return err
 }
 
-// Delete deletes a user
-func (r *userRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+// Delete moves a user to the trash
+func (r *userRepository) Delete(ctx context.Context, id string, deletedBy string) error {
+	return softDelete(ctx, r.collection, id, deletedBy)
+}
+
+// GetDeleted gets users in the trash with pagination
+func (r *userRepository) GetDeleted(ctx context.Context, page *domain.Pagination) ([]*domain.User, error) {
+	cursor, err := r.collection.Find(ctx, pageFilter(onlyDeleted(bson.M{}), page), pageOptions(page))
 	if err != nil {
-		return domain.ErrInvalidID
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
-	if err != nil {
-		return err
+	var users []*domain.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
 	}
 
-	if result.DeletedCount == 0 {
-		return domain.ErrNotFound
+	return users, nil
+}
+
+// CountDeleted counts users in the trash
+func (r *userRepository) CountDeleted(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, onlyDeleted(bson.M{}))
+}
+
+// Restore moves a user out of the trash
+func (r *userRepository) Restore(ctx context.Context, id string) error {
+	err := restoreDeleted(ctx, r.collection, id)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAlreadyExists
 	}
+	return err
+}
 
-	return nil
+// Purge permanently deletes users trashed before the given time
+func (r *userRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, r.collection, before)
 }
 
 // Count counts all users
 func (r *userRepository) Count(ctx context.Context) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{})
+	return r.collection.CountDocuments(ctx, notDeleted(bson.M{}))
+}
+
+// EstimatedCount returns the approximate number of users from collection metadata.
+// Users in the trash are included in the estimate.
+func (r *userRepository) EstimatedCount(ctx context.Context) (int64, error) {
+	return r.collection.EstimatedDocumentCount(ctx)
+}
+
+// GetActiveByRole gets the active users of a role, to notify them
+func (r *userRepository) GetActiveByRole(ctx context.Context, role domain.Role) ([]*domain.User, error) {
+	cursor, err := r.collection.Find(ctx, notDeleted(bson.M{"role": role, "is_active": true}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*domain.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
 }
@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+// clamdChunkSize is the size of the chunks content is streamed to clamd in
+const clamdChunkSize = 64 << 10
+
+type clamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a scanner streaming content to the ClamAV daemon at
+// address, as tcp://host:port or unix:///path/to/clamd.sock. A scan fails when
+// it takes longer than timeout; zero means no limit.
+func NewClamdScanner(address string, timeout time.Duration) (domain.Scanner, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clamd address %q: %w", address, err)
+	}
+
+	s := &clamdScanner{network: u.Scheme, timeout: timeout}
+	switch u.Scheme {
+	case "tcp":
+		s.address = u.Host
+	case "unix":
+		s.address = u.Path
+	}
+	if s.address == "" {
+		return nil, fmt.Errorf("invalid clamd address %q, use tcp://host:port or unix:///path", address)
+	}
+	return s, nil
+}
+
+// Scan implements domain.Scanner with the INSTREAM command: content is sent in
+// length-prefixed chunks, ended by an empty one, and clamd replies with OK,
+// "<signature> FOUND" or "<message> ERROR". clamd rejects streams longer than
+// its StreamMaxLength.
+func (s *clamdScanner) Scan(ctx context.Context, content io.Reader) (string, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes once ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := s.stream(conn, content); err != nil {
+		// clamd closes the connection on errors such as the size limit, its
+		// reply tells why
+		if reply, replyErr := readReply(conn); replyErr == nil {
+			if _, parsed := parseReply(reply); parsed != nil {
+				return "", parsed
+			}
+		}
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("clamd: %w", ctx.Err())
+		}
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("clamd: %w", ctx.Err())
+		}
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	return parseReply(reply)
+}
+
+// stream sends the INSTREAM command and content to clamd
+func (s *clamdScanner) stream(conn net.Conn, content io.Reader) error {
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, clamdChunkSize)
+	length := make([]byte, 4)
+	for {
+		n, err := io.ReadFull(content, chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(length, uint32(n))
+			if _, err := w.Write(length); err != nil {
+				return err
+			}
+			if _, err := w.Write(chunk[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	binary.BigEndian.PutUint32(length, 0)
+	if _, err := w.Write(length); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// readReply reads the null-terminated reply of clamd
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimRight(reply, "\x00")), nil
+}
+
+// parseReply returns the signature found in a reply to INSTREAM, or the error it reports
+func parseReply(reply string) (string, error) {
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	case strings.HasSuffix(result, " ERROR"):
+		return "", fmt.Errorf("clamd: %s", strings.TrimSuffix(result, " ERROR"))
+	}
+	return "", fmt.Errorf("clamd: unexpected reply %q", reply)
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+type localStorage struct {
+	root string
+}
+
+// NewLocalStorage creates a storage keeping contents as files under root.
+// It only suits a single API instance.
+func NewLocalStorage(root string) (domain.FileStorage, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &localStorage{root: root}, nil
+}
+
+// path returns the file of a key, refusing keys that would escape the root
+func (s *localStorage) path(key string) (string, bool) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return "", false
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), true
+}
+
+// Put implements domain.FileStorage
+func (s *localStorage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	name, ok := s.path(key)
+	if !ok {
+		return domain.ErrInvalidInput
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return domain.ErrStorageKeyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, content); err != nil {
+		f.Close()
+		os.Remove(name)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(name)
+		return err
+	}
+	return nil
+}
+
+// Get implements domain.FileStorage. The returned file is an io.ReadSeeker.
+func (s *localStorage) Get(ctx context.Context, key string) (io.ReadCloser, *domain.StoredObject, error) {
+	name, ok := s.path(key)
+	if !ok {
+		return nil, nil, domain.ErrNotFound
+	}
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, domain.ErrNotFound
+	}
+
+	return f, localObject(key, info), nil
+}
+
+// Stat implements domain.FileStorage
+func (s *localStorage) Stat(ctx context.Context, key string) (*domain.StoredObject, error) {
+	name, ok := s.path(key)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	info, err := os.Stat(name)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return localObject(key, info), nil
+}
+
+// Delete implements domain.FileStorage
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	name, ok := s.path(key)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	err := os.Remove(name)
+	if os.IsNotExist(err) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+// List implements domain.FileStorage, listing the keys starting with prefix in key order
+func (s *localStorage) List(ctx context.Context, prefix string) ([]*domain.StoredObject, error) {
+	objects := []*domain.StoredObject{}
+
+	err := filepath.WalkDir(s.root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, localObject(key, info))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// PresignGet implements domain.FileStorage. Local files are only served through the API.
+func (s *localStorage) PresignGet(ctx context.Context, key string, expires time.Duration, filename string) (string, error) {
+	return "", domain.ErrPresignUnsupported
+}
+
+func localObject(key string, info fs.FileInfo) *domain.StoredObject {
+	return &domain.StoredObject{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		LastModified: info.ModTime(),
+	}
+}
@@ -0,0 +1,515 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+const (
+	amzDateLayout   = "20060102T150405Z"
+	amzAlgorithm    = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// S3Config holds how to reach an S3-compatible bucket such as AWS S3 or MinIO
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.ap-southeast-1.amazonaws.com or http://localhost:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // bucket in the path instead of the host name, as MinIO expects
+}
+
+type s3Storage struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	signer    *signer
+	client    *http.Client
+}
+
+// NewS3Storage creates a storage keeping contents in an S3-compatible bucket.
+// Requests are signed with AWS Signature Version 4.
+func NewS3Storage(cfg S3Config) (domain.FileStorage, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("the S3 bucket, access key and secret key are required")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &s3Storage{
+		endpoint:  endpoint,
+		bucket:    cfg.Bucket,
+		pathStyle: cfg.PathStyle,
+		signer:    &signer{accessKey: cfg.AccessKey, secretKey: cfg.SecretKey, region: region},
+		client:    &http.Client{},
+	}, nil
+}
+
+// objectURL returns the URL of a key, or of the bucket when key is empty
+func (s *s3Storage) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = u.Path + "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path += "/" + key
+	u.RawPath = ""
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	return &u
+}
+
+func (s *s3Storage) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+
+	s.signer.signRequest(req, unsignedPayload, time.Now())
+	return s.client.Do(req)
+}
+
+// Put implements domain.FileStorage. The upload is conditional on the key being
+// unused; content of unknown size is spooled to a temporary file first, because
+// S3 needs its length.
+func (s *s3Storage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	if key == "" {
+		return domain.ErrInvalidInput
+	}
+
+	if size < 0 {
+		tmp, err := os.CreateTemp("", "s3-put-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if size, err = io.Copy(tmp, content); err != nil {
+			return err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		content = tmp
+	}
+
+	header := http.Header{}
+	header.Set("If-None-Match", "*")
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	var body io.Reader = content
+	if size == 0 {
+		body = http.NoBody
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, nil, body, size, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return domain.ErrStorageKeyExists
+	}
+	return s3Error(http.MethodPut, key, resp)
+}
+
+// Get implements domain.FileStorage. The returned content is an io.ReadSeeker
+// when S3 reports its size, so range requests can be served from it.
+func (s *s3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *domain.StoredObject, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, nil, s3Error(http.MethodGet, key, resp)
+	}
+
+	object := s3Object(key, resp)
+	if object.Size < 0 {
+		return resp.Body, object, nil
+	}
+	return &s3Reader{ctx: ctx, storage: s, object: object, body: resp.Body}, object, nil
+}
+
+// s3Reader reads an object from one GET response while read sequentially. After
+// a seek, the next read issues a ranged GET from the new position; ranged GETs
+// are conditional on the ETag, so bytes of a replaced object are never mixed in.
+type s3Reader struct {
+	ctx     context.Context
+	storage *s3Storage
+	object  *domain.StoredObject
+	body    io.ReadCloser
+	bodyPos int64 // position of the next byte of body
+	pos     int64
+}
+
+func (r *s3Reader) Read(p []byte) (int, error) {
+	if r.pos >= r.object.Size {
+		return 0, io.EOF
+	}
+	if r.body == nil || r.bodyPos != r.pos {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.body.Read(p)
+	r.pos += int64(n)
+	r.bodyPos += int64(n)
+	if err == io.EOF && r.pos < r.object.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// open replaces the body with a ranged GET from the current position
+func (r *s3Reader) open() error {
+	if r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+
+	header := http.Header{}
+	header.Set("Range", "bytes="+strconv.FormatInt(r.pos, 10)+"-")
+	if r.object.ETag != "" {
+		header.Set("If-Match", `"`+r.object.ETag+`"`)
+	}
+	resp, err := r.storage.do(r.ctx, http.MethodGet, r.object.Key, nil, nil, 0, header)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		r.body, r.bodyPos = resp.Body, r.pos
+		return nil
+	case http.StatusPreconditionFailed:
+		resp.Body.Close()
+		return fmt.Errorf("s3 GET %s: object changed while reading", r.object.Key)
+	}
+	defer resp.Body.Close()
+	return s3Error(http.MethodGet, r.object.Key, resp)
+}
+
+func (r *s3Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.object.Size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("s3 seek %s: negative position", r.object.Key)
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+func (r *s3Reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
+}
+
+// Stat implements domain.FileStorage
+func (s *s3Storage) Stat(ctx context.Context, key string) (*domain.StoredObject, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3Error(http.MethodHead, key, resp)
+	}
+	return s3Object(key, resp), nil
+}
+
+// Delete implements domain.FileStorage. S3 does not report missing keys on
+// delete, so they are looked up first.
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	if _, err := s.Stat(ctx, key); err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s3Error(http.MethodDelete, key, resp)
+	}
+	return nil
+}
+
+// listBucketResult is the ListObjectsV2 response
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List implements domain.FileStorage, following continuation tokens until every key is listed
+func (s *s3Storage) List(ctx context.Context, prefix string) ([]*domain.StoredObject, error) {
+	objects := []*domain.StoredObject{}
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error("LIST", prefix, resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Contents {
+			objects = append(objects, &domain.StoredObject{
+				Key:          item.Key,
+				Size:         item.Size,
+				ContentType:  mime.TypeByExtension(path.Ext(item.Key)),
+				LastModified: item.LastModified,
+				ETag:         strings.Trim(item.ETag, `"`),
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// PresignGet implements domain.FileStorage with a query-signed URL that
+// downloads the key as an attachment named filename
+func (s *s3Storage) PresignGet(ctx context.Context, key string, expires time.Duration, filename string) (string, error) {
+	if expires <= 0 || expires > 7*24*time.Hour {
+		return "", domain.ErrInvalidInput
+	}
+
+	query := url.Values{}
+	if filename != "" {
+		query.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+
+	u := s.objectURL(key, query)
+	return s.signer.presign(http.MethodGet, u, expires, time.Now()), nil
+}
+
+// s3Error turns an S3 error response into ErrNotFound or a descriptive error
+func s3Error(op, key string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return domain.ErrNotFound
+	}
+
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+	if body.Code == "NoSuchKey" {
+		return domain.ErrNotFound
+	}
+	if body.Code == "" {
+		body.Code = resp.Status
+	}
+	return fmt.Errorf("s3 %s %s: %s %s", op, key, body.Code, body.Message)
+}
+
+func s3Object(key string, resp *http.Response) *domain.StoredObject {
+	object := &domain.StoredObject{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		object.LastModified = modified
+	}
+	return object
+}
+
+// signer signs S3 requests with AWS Signature Version 4
+type signer struct {
+	accessKey string
+	secretKey string
+	region    string
+}
+
+// signRequest adds the x-amz-date, x-amz-content-sha256 and Authorization headers
+func (s *signer) signRequest(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateLayout)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("If-None-Match") != "" {
+		headers = append(headers, "if-none-match")
+	}
+	sort.Strings(headers)
+
+	scope := s.scope(amzDate)
+	signature := s.signature(amzDate, canonicalRequest(req.Method, req.URL, req.URL.Host, req.Header, headers, payloadHash))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		amzAlgorithm, s.accessKey, scope, strings.Join(headers, ";"), signature))
+}
+
+// presign returns u with query string authentication valid for expires
+func (s *signer) presign(method string, u *url.URL, expires time.Duration, now time.Time) string {
+	amzDate := now.UTC().Format(amzDateLayout)
+
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", amzAlgorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(amzDate))
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	signed := *u
+	signed.RawQuery = canonicalQuery(query)
+	signature := s.signature(amzDate, canonicalRequest(method, &signed, u.Host, nil, []string{"host"}, unsignedPayload))
+
+	signed.RawQuery += "&X-Amz-Signature=" + signature
+	return signed.String()
+}
+
+func (s *signer) scope(amzDate string) string {
+	return amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *signer) signature(amzDate, canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	stringToSign := amzAlgorithm + "\n" + amzDate + "\n" + s.scope(amzDate) + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), amzDate[:8])
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalRequest builds the SigV4 canonical request over the given lower case header names
+func canonicalRequest(method string, u *url.URL, host string, header http.Header, headers []string, payloadHash string) string {
+	var b strings.Builder
+	b.WriteString(method + "\n")
+	b.WriteString(uriEncode(u.Path, false) + "\n")
+	b.WriteString(canonicalQuery(u.Query()) + "\n")
+	for _, name := range headers {
+		value := host
+		if name != "host" {
+			value = strings.TrimSpace(header.Get(name))
+		}
+		b.WriteString(name + ":" + value + "\n")
+	}
+	b.WriteString("\n" + strings.Join(headers, ";") + "\n")
+	b.WriteString(payloadHash)
+	return b.String()
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := []string{}
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and slashes
+// unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
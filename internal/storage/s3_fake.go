@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeS3PageSize is how many keys the fake returns per ListObjectsV2 page
+const fakeS3PageSize = 1000
+
+// FakeS3 is an in-memory, MinIO-like stand-in for an S3-compatible bucket, for
+// development and end-to-end tests without object storage. It serves path-style
+// requests for one bucket, checks their Signature Version 4 headers or presigned
+// query, and implements the object calls the API uses: conditional PUT, GET,
+// HEAD, DELETE and ListObjectsV2.
+type FakeS3 struct {
+	bucket string
+	signer *signer
+
+	mu      sync.RWMutex
+	objects map[string]*fakeObject
+}
+
+type fakeObject struct {
+	data         []byte
+	contentType  string
+	etag         string
+	lastModified time.Time
+}
+
+// NewFakeS3 creates an empty fake bucket accepting requests signed with the given keys
+func NewFakeS3(bucket, region, accessKey, secretKey string) *FakeS3 {
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &FakeS3{
+		bucket:  bucket,
+		signer:  &signer{accessKey: accessKey, secretKey: secretKey, region: region},
+		objects: map[string]*fakeObject{},
+	}
+}
+
+// ServeHTTP serves /<bucket> and /<bucket>/<key>
+func (f *FakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		fakeS3Error(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	key := strings.TrimPrefix(rest, "/")
+
+	if code, message := f.authenticate(r); code != "" {
+		fakeS3Error(w, http.StatusForbidden, code, message)
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r)
+	case key == "":
+		fakeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource")
+	case r.Method == http.MethodPut:
+		f.put(w, r, key)
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		f.get(w, r, key)
+	case r.Method == http.MethodDelete:
+		f.mu.Lock()
+		delete(f.objects, key)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fakeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource")
+	}
+}
+
+// authenticate checks the Authorization header or the presigned query of a
+// request, returning an S3 error code when it is not validly signed
+func (f *FakeS3) authenticate(r *http.Request) (string, string) {
+	query := r.URL.Query()
+	if signature := query.Get("X-Amz-Signature"); signature != "" {
+		amzDate := query.Get("X-Amz-Date")
+		issued, err := time.Parse(amzDateLayout, amzDate)
+		if err != nil || query.Get("X-Amz-Credential") != f.signer.accessKey+"/"+f.signer.scope(amzDate) {
+			return "AuthorizationQueryParametersError", "Invalid credential or date"
+		}
+		expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if err != nil || time.Now().After(issued.Add(time.Duration(expires)*time.Second)) {
+			return "AccessDenied", "Request has expired"
+		}
+
+		query.Del("X-Amz-Signature")
+		u := *r.URL
+		u.RawQuery = query.Encode()
+		headers := strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+		expected := f.signer.signature(amzDate, canonicalRequest(r.Method, &u, r.Host, r.Header, headers, unsignedPayload))
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			return "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided"
+		}
+		return "", ""
+	}
+
+	credential, headers, signature, ok := parseAuthorization(r.Header.Get("Authorization"))
+	if !ok {
+		return "AccessDenied", "Access Denied"
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if len(amzDate) < 8 || credential != f.signer.accessKey+"/"+f.signer.scope(amzDate) {
+		return "InvalidAccessKeyId", "The access key or credential scope is not valid"
+	}
+
+	expected := f.signer.signature(amzDate, canonicalRequest(r.Method, r.URL, r.Host, r.Header, headers, r.Header.Get("X-Amz-Content-Sha256")))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided"
+	}
+	return "", ""
+}
+
+// parseAuthorization splits an "AWS4-HMAC-SHA256 Credential=..., SignedHeaders=..., Signature=..." header
+func parseAuthorization(value string) (string, []string, string, bool) {
+	rest, ok := strings.CutPrefix(value, amzAlgorithm+" ")
+	if !ok {
+		return "", nil, "", false
+	}
+
+	fields := map[string]string{}
+	for _, part := range strings.Split(rest, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		fields[name] = value
+	}
+	if fields["Credential"] == "" || fields["SignedHeaders"] == "" || fields["Signature"] == "" {
+		return "", nil, "", false
+	}
+	return fields["Credential"], strings.Split(fields["SignedHeaders"], ";"), fields["Signature"], true
+}
+
+func (f *FakeS3) put(w http.ResponseWriter, r *http.Request, key string) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fakeS3Error(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	sum := md5.Sum(data)
+	object := &fakeObject{
+		data:         data,
+		contentType:  r.Header.Get("Content-Type"),
+		etag:         hex.EncodeToString(sum[:]),
+		lastModified: time.Now().UTC().Truncate(time.Second),
+	}
+	if object.contentType == "" {
+		object.contentType = "binary/octet-stream"
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.objects[key]; exists && r.Header.Get("If-None-Match") == "*" {
+		fakeS3Error(w, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+		return
+	}
+	f.objects[key] = object
+
+	w.Header().Set("ETag", `"`+object.etag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (f *FakeS3) get(w http.ResponseWriter, r *http.Request, key string) {
+	f.mu.RLock()
+	object, ok := f.objects[key]
+	f.mu.RUnlock()
+	if !ok {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fakeS3Error(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	w.Header().Set("Content-Type", object.contentType)
+	w.Header().Set("ETag", `"`+object.etag+`"`)
+	if disposition := r.URL.Query().Get("response-content-disposition"); disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	http.ServeContent(w, r, "", object.lastModified, bytes.NewReader(object.data))
+}
+
+// fakeListResult is the ListObjectsV2 response of the fake
+type fakeListResult struct {
+	XMLName               xml.Name           `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string             `xml:"Name"`
+	Prefix                string             `xml:"Prefix"`
+	KeyCount              int                `xml:"KeyCount"`
+	MaxKeys               int                `xml:"MaxKeys"`
+	IsTruncated           bool               `xml:"IsTruncated"`
+	ContinuationToken     string             `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string             `xml:"NextContinuationToken,omitempty"`
+	Contents              []fakeListedObject `xml:"Contents"`
+}
+
+type fakeListedObject struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+// list implements ListObjectsV2. Continuation tokens are the last key of the previous page.
+func (f *FakeS3) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("list-type") != "2" {
+		fakeS3Error(w, http.StatusNotImplemented, "NotImplemented", "Only ListObjectsV2 is implemented")
+		return
+	}
+	prefix := query.Get("prefix")
+	after := query.Get("continuation-token")
+
+	maxKeys := fakeS3PageSize
+	if value, err := strconv.Atoi(query.Get("max-keys")); err == nil && value > 0 && value < maxKeys {
+		maxKeys = value
+	}
+
+	f.mu.RLock()
+	keys := []string{}
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := fakeListResult{
+		Name:              f.bucket,
+		Prefix:            prefix,
+		MaxKeys:           maxKeys,
+		ContinuationToken: after,
+	}
+	if len(keys) > maxKeys {
+		keys = keys[:maxKeys]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		object := f.objects[key]
+		result.Contents = append(result.Contents, fakeListedObject{
+			Key:          key,
+			LastModified: object.lastModified.Format(time.RFC3339),
+			ETag:         `"` + object.etag + `"`,
+			Size:         len(object.data),
+			StorageClass: "STANDARD",
+		})
+	}
+	f.mu.RUnlock()
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(result)
+}
+
+// fakeS3Error writes an S3 XML error response
+func fakeS3Error(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, escapeXML(message))
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
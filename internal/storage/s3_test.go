@@ -0,0 +1,414 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/internal/storage"
+)
+
+const (
+	testBucket    = "icafe-test"
+	testAccessKey = "test-access-key"
+	testSecretKey = "test-secret-key"
+)
+
+// s3Test runs the S3 storage against the fake bucket, counting the GET requests
+// that reach it
+type s3Test struct {
+	storage domain.FileStorage
+	fake    *storage.FakeS3
+	gets    atomic.Int64
+}
+
+func newS3Test(t *testing.T) *s3Test {
+	t.Helper()
+
+	st := &s3Test{fake: storage.NewFakeS3(testBucket, "", testAccessKey, testSecretKey)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			st.gets.Add(1)
+		}
+		st.fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	s3, err := storage.NewS3Storage(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    testBucket,
+		AccessKey: testAccessKey,
+		SecretKey: testSecretKey,
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	st.storage = s3
+	return st
+}
+
+func (st *s3Test) put(t *testing.T, key string, content []byte) {
+	t.Helper()
+	if err := st.storage.Put(context.Background(), key, bytes.NewReader(content), int64(len(content)), "application/octet-stream"); err != nil {
+		t.Fatalf("Put %s: %v", key, err)
+	}
+}
+
+// testContent returns n bytes that differ at every position of a short period,
+// so misplaced reads are caught
+func testContent(n int) []byte {
+	content := make([]byte, n)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func TestS3PutGetStatDelete(t *testing.T) {
+	st := newS3Test(t)
+	ctx := context.Background()
+	content := []byte("%PDF-1.4 bao gia")
+
+	if err := st.storage.Put(ctx, "files/bao-gia.pdf", bytes.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	object, err := st.storage.Stat(ctx, "files/bao-gia.pdf")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if object.Size != int64(len(content)) || object.ContentType != "application/pdf" || object.ETag == "" {
+		t.Fatalf("Stat = %+v", object)
+	}
+
+	body, got, err := st.storage.Get(ctx, "files/bao-gia.pdf")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("Get read %q, %v", data, err)
+	}
+	if got.Size != object.Size || got.ETag != object.ETag {
+		t.Fatalf("Get object = %+v, Stat object = %+v", got, object)
+	}
+
+	if err := st.storage.Delete(ctx, "files/bao-gia.pdf"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := st.storage.Stat(ctx, "files/bao-gia.pdf"); err != domain.ErrNotFound {
+		t.Fatalf("Stat after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3PutNeverReplaces(t *testing.T) {
+	st := newS3Test(t)
+	ctx := context.Background()
+	st.put(t, "files/a.bin", []byte("first"))
+
+	err := st.storage.Put(ctx, "files/a.bin", strings.NewReader("second"), 6, "")
+	if err != domain.ErrStorageKeyExists {
+		t.Fatalf("Put over an existing key = %v, want ErrStorageKeyExists", err)
+	}
+
+	body, _, err := st.storage.Get(ctx, "files/a.bin")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer body.Close()
+	if data, _ := io.ReadAll(body); string(data) != "first" {
+		t.Fatalf("content = %q, want the first upload", data)
+	}
+}
+
+func TestS3PutUnknownAndEmptySize(t *testing.T) {
+	st := newS3Test(t)
+	ctx := context.Background()
+	content := testContent(10000)
+
+	// An io.Reader without a known size is spooled before the PUT
+	if err := st.storage.Put(ctx, "files/unknown.bin", io.MultiReader(bytes.NewReader(content)), -1, ""); err != nil {
+		t.Fatalf("Put of unknown size: %v", err)
+	}
+	if err := st.storage.Put(ctx, "files/empty.bin", bytes.NewReader(nil), 0, ""); err != nil {
+		t.Fatalf("Put of empty content: %v", err)
+	}
+
+	for key, size := range map[string]int64{"files/unknown.bin": 10000, "files/empty.bin": 0} {
+		object, err := st.storage.Stat(ctx, key)
+		if err != nil || object.Size != size {
+			t.Fatalf("Stat %s = %+v, %v, want size %d", key, object, err, size)
+		}
+	}
+}
+
+func TestS3MissingKeys(t *testing.T) {
+	st := newS3Test(t)
+	ctx := context.Background()
+
+	if _, _, err := st.storage.Get(ctx, "files/missing.pdf"); err != domain.ErrNotFound {
+		t.Fatalf("Get = %v, want ErrNotFound", err)
+	}
+	if _, err := st.storage.Stat(ctx, "files/missing.pdf"); err != domain.ErrNotFound {
+		t.Fatalf("Stat = %v, want ErrNotFound", err)
+	}
+	if err := st.storage.Delete(ctx, "files/missing.pdf"); err != domain.ErrNotFound {
+		t.Fatalf("Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3BadCredentials(t *testing.T) {
+	st := newS3Test(t)
+	server := httptest.NewServer(st.fake)
+	defer server.Close()
+
+	s3, err := storage.NewS3Storage(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    testBucket,
+		AccessKey: testAccessKey,
+		SecretKey: "wrong-secret",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+
+	err = s3.Put(context.Background(), "files/a.bin", strings.NewReader("a"), 1, "")
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("Put with a wrong secret = %v, want SignatureDoesNotMatch", err)
+	}
+}
+
+func TestS3List(t *testing.T) {
+	st := newS3Test(t)
+	ctx := context.Background()
+
+	// More keys than one ListObjectsV2 page, so continuation tokens are followed
+	for i := 0; i < 1005; i++ {
+		st.put(t, fmt.Sprintf("uploads/%04d", i), []byte{byte(i)})
+	}
+	st.put(t, "files/other.bin", []byte("other"))
+
+	objects, err := st.storage.List(ctx, "uploads/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(objects) != 1005 {
+		t.Fatalf("List returned %d keys, want 1005", len(objects))
+	}
+	for i, object := range objects {
+		if want := fmt.Sprintf("uploads/%04d", i); object.Key != want || object.Size != 1 {
+			t.Fatalf("objects[%d] = %+v, want key %s of size 1", i, object, want)
+		}
+	}
+
+	objects, err = st.storage.List(ctx, "videos/")
+	if err != nil || len(objects) != 0 {
+		t.Fatalf("List of an empty prefix = %d keys, %v", len(objects), err)
+	}
+}
+
+func TestS3PresignGet(t *testing.T) {
+	st := newS3Test(t)
+	ctx := context.Background()
+	st.put(t, "files/hop-dong-3f9a.pdf", []byte("hop dong"))
+
+	link, err := st.storage.PresignGet(ctx, "files/hop-dong-3f9a.pdf", time.Minute, "Hợp đồng.pdf")
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+
+	resp, err := http.Get(link)
+	if err != nil {
+		t.Fatalf("GET presigned link: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(data) != "hop dong" {
+		t.Fatalf("presigned GET = %d %q", resp.StatusCode, data)
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment") {
+		t.Fatalf("Content-Disposition = %q, want an attachment", disposition)
+	}
+
+	// The link is only valid for the signed key
+	resp, err = http.Get(strings.Replace(link, "hop-dong-3f9a.pdf", "other.pdf", 1))
+	if err != nil {
+		t.Fatalf("GET tampered link: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("tampered link answered %d, want 403", resp.StatusCode)
+	}
+
+	for _, expires := range []time.Duration{0, 8 * 24 * time.Hour} {
+		if _, err := st.storage.PresignGet(ctx, "files/hop-dong-3f9a.pdf", expires, ""); err != domain.ErrInvalidInput {
+			t.Fatalf("PresignGet for %v = %v, want ErrInvalidInput", expires, err)
+		}
+	}
+}
+
+// openSeeker gets a key and requires the content to seek, as range requests need
+func (st *s3Test) openSeeker(t *testing.T, key string) io.ReadSeekCloser {
+	t.Helper()
+	body, _, err := st.storage.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	seeker, ok := body.(io.ReadSeekCloser)
+	if !ok {
+		body.Close()
+		t.Fatalf("Get returned %T, which cannot seek", body)
+	}
+	t.Cleanup(func() { seeker.Close() })
+	return seeker
+}
+
+func TestS3ReaderSeek(t *testing.T) {
+	st := newS3Test(t)
+	content := testContent(100000)
+	st.put(t, "videos/huong-dan.mp4", content)
+
+	r := st.openSeeker(t, "videos/huong-dan.mp4")
+	gets := st.gets.Load()
+
+	// Sequential reads stay on the first response
+	head := make([]byte, 1000)
+	if _, err := io.ReadFull(r, head); err != nil || !bytes.Equal(head, content[:1000]) {
+		t.Fatalf("first read: %v", err)
+	}
+	rest := make([]byte, 1000)
+	if _, err := io.ReadFull(r, rest); err != nil || !bytes.Equal(rest, content[1000:2000]) {
+		t.Fatalf("sequential read: %v", err)
+	}
+	if n := st.gets.Load() - gets; n != 0 {
+		t.Fatalf("sequential reads issued %d more GETs", n)
+	}
+
+	cases := []struct {
+		name   string
+		offset int64
+		whence int
+		pos    int64
+		length int
+	}{
+		{"forward across the read position", 50000, io.SeekStart, 50000, 3000},
+		{"backward", 10, io.SeekStart, 10, 20},
+		{"relative to the current position", 5000, io.SeekCurrent, 5030, 4096},
+		{"relative to the end", -100, io.SeekEnd, 99900, 100},
+	}
+	for _, c := range cases {
+		pos, err := r.Seek(c.offset, c.whence)
+		if err != nil || pos != c.pos {
+			t.Fatalf("%s: Seek = %d, %v, want %d", c.name, pos, err, c.pos)
+		}
+
+		gets := st.gets.Load()
+		buf := make([]byte, c.length)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatalf("%s: read: %v", c.name, err)
+		}
+		if !bytes.Equal(buf, content[c.pos:c.pos+int64(c.length)]) {
+			t.Fatalf("%s: read bytes from the wrong position", c.name)
+		}
+		if n := st.gets.Load() - gets; n != 1 {
+			t.Fatalf("%s: issued %d ranged GETs, want 1", c.name, n)
+		}
+	}
+
+	// Seeking to where the body already is keeps the open range
+	gets = st.gets.Load()
+	if _, err := r.Seek(0, io.SeekCurrent); err != nil {
+		t.Fatalf("Seek to the current position: %v", err)
+	}
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("read at the end = %v, want EOF", err)
+	}
+	if n := st.gets.Load() - gets; n != 0 {
+		t.Fatalf("read at the end issued %d GETs", n)
+	}
+}
+
+func TestS3ReaderPastEOF(t *testing.T) {
+	st := newS3Test(t)
+	content := testContent(4096)
+	st.put(t, "files/setup.zip", content)
+
+	r := st.openSeeker(t, "files/setup.zip")
+
+	// A read running into the end returns the remaining bytes, then EOF
+	if _, err := r.Seek(4000, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(data, content[4000:]) {
+		t.Fatalf("read to the end = %d bytes, %v", len(data), err)
+	}
+	if n, err := r.Read(make([]byte, 10)); n != 0 || err != io.EOF {
+		t.Fatalf("read after the end = %d, %v, want EOF", n, err)
+	}
+
+	// Seeking past the end is allowed, reading there is EOF without a request
+	gets := st.gets.Load()
+	if pos, err := r.Seek(10, io.SeekEnd); err != nil || pos != 4106 {
+		t.Fatalf("Seek past the end = %d, %v", pos, err)
+	}
+	if n, err := r.Read(make([]byte, 10)); n != 0 || err != io.EOF {
+		t.Fatalf("read past the end = %d, %v, want EOF", n, err)
+	}
+	if n := st.gets.Load() - gets; n != 0 {
+		t.Fatalf("read past the end issued %d GETs", n)
+	}
+
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Fatal("Seek to a negative position succeeded")
+	}
+
+	// The reader still works after the errors
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek to the start: %v", err)
+	}
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, content[:16]) {
+		t.Fatalf("read from the start: %v", err)
+	}
+}
+
+func TestS3ReaderObjectReplaced(t *testing.T) {
+	st := newS3Test(t)
+	ctx := context.Background()
+	st.put(t, "files/setup.exe", testContent(8192))
+
+	r := st.openSeeker(t, "files/setup.exe")
+	if _, err := io.ReadFull(r, make([]byte, 100)); err != nil {
+		t.Fatalf("first read: %v", err)
+	}
+
+	// Another content under the same key must not be mixed into the read
+	if err := st.storage.Delete(ctx, "files/setup.exe"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	st.put(t, "files/setup.exe", bytes.Repeat([]byte{0xff}, 8192))
+
+	if _, err := r.Seek(4096, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	_, err := r.Read(make([]byte, 100))
+	if err == nil || !strings.Contains(err.Error(), "object changed") {
+		t.Fatalf("read after the object changed = %v, want an error", err)
+	}
+	if errors.Is(err, io.EOF) {
+		t.Fatal("read after the object changed reported EOF")
+	}
+}
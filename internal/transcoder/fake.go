@@ -0,0 +1,85 @@
+package transcoder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+// fakeSegmentSize is the size of the chunks the fake cuts videos into
+const fakeSegmentSize = 1 << 20
+
+type fakeTranscoder struct{}
+
+// NewFakeTranscoder creates a stand-in for ffmpeg, for development and tests on
+// machines without it. It cuts videos into 1 MB chunks listed in a well-formed
+// playlist; the chunks are not MPEG-TS, so players cannot play them.
+func NewFakeTranscoder() domain.Transcoder {
+	return fakeTranscoder{}
+}
+
+// SegmentHLS implements domain.Transcoder
+func (fakeTranscoder) SegmentHLS(ctx context.Context, input string, outputDir string, segmentDuration time.Duration) error {
+	src, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	seconds := segmentDuration.Seconds()
+	playlist := &strings.Builder{}
+	fmt.Fprintf(playlist, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%.0f\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n", seconds)
+
+	for i := 0; ; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("segment%05d.ts", i)
+		n, err := copyChunk(filepath.Join(outputDir, name), src)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			break
+		}
+		fmt.Fprintf(playlist, "#EXTINF:%.3f,\n%s\n", seconds, name)
+		if n < fakeSegmentSize {
+			break
+		}
+	}
+	playlist.WriteString("#EXT-X-ENDLIST\n")
+
+	return os.WriteFile(filepath.Join(outputDir, domain.HLSPlaylistName), []byte(playlist.String()), 0644)
+}
+
+// copyChunk writes the next chunk of src to name, creating nothing at the end of src
+func copyChunk(name string, src io.Reader) (int64, error) {
+	head := make([]byte, 1)
+	if _, err := io.ReadFull(src, head); err == io.EOF {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	dst, err := os.Create(name)
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+
+	if _, err := dst.Write(head); err != nil {
+		return 0, err
+	}
+	n, err := io.CopyN(dst, src, fakeSegmentSize-1)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	return n + 1, dst.Close()
+}
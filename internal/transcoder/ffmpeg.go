@@ -0,0 +1,78 @@
+package transcoder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+// ffmpegStderrLimit is how much of the ffmpeg output is kept for error messages
+const ffmpegStderrLimit = 2 << 10
+
+type ffmpegTranscoder struct {
+	path string
+}
+
+// NewFFmpegTranscoder creates a transcoder running the ffmpeg binary at path, or
+// found in PATH when path is a bare name
+func NewFFmpegTranscoder(path string) domain.Transcoder {
+	if path == "" {
+		path = "ffmpeg"
+	}
+	return &ffmpegTranscoder{path: path}
+}
+
+// SegmentHLS implements domain.Transcoder. The streams are copied rather than
+// re-encoded, so packaging is fast; segments are cut on the nearest keyframe.
+func (t *ffmpegTranscoder) SegmentHLS(ctx context.Context, input string, outputDir string, segmentDuration time.Duration) error {
+	seconds := int(segmentDuration / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	cmd := exec.CommandContext(ctx, t.path,
+		"-nostdin", "-hide_banner", "-loglevel", "error",
+		"-i", input,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-c", "copy",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(seconds),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(outputDir, "segment%05d.ts"),
+		filepath.Join(outputDir, domain.HLSPlaylistName),
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &limitedBuffer{buf: &stderr, limit: ffmpegStderrLimit}
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("ffmpeg: %v: %s", err, message)
+		}
+		return fmt.Errorf("ffmpeg: %v", err)
+	}
+	return nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
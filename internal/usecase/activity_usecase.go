@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+type activityUsecase struct {
+	activityRepo   domain.ActivityRepository
+	customerRepo   domain.CustomerRepository
+	contextTimeout time.Duration
+}
+
+// NewActivityUsecase creates a new customer activity usecase
+func NewActivityUsecase(repo domain.ActivityRepository, customerRepo domain.CustomerRepository, timeout time.Duration) domain.ActivityUsecase {
+	return &activityUsecase{
+		activityRepo:   repo,
+		customerRepo:   customerRepo,
+		contextTimeout: timeout,
+	}
+}
+
+// Create logs an interaction on the timeline of a customer
+func (u *activityUsecase) Create(ctx context.Context, customerID string, req *domain.CreateActivityRequest, actor *domain.Actor) (*domain.CustomerActivity, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := &domain.CustomerActivity{
+		CustomerID: customer.ID,
+		Type:       req.Type,
+		Content:    req.Content,
+		AuthorID:   actor.ID,
+		AuthorName: actor.Name,
+	}
+
+	if err := u.activityRepo.Create(ctx, activity); err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+// GetByCustomer gets the timeline of a customer, newest first
+func (u *activityUsecase) GetByCustomer(ctx context.Context, customerID string, activityType domain.ActivityType, page *domain.Pagination) ([]*domain.CustomerActivity, *domain.PageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, nil, err
+	}
+
+	activities, err := u.activityRepo.GetByCustomer(ctx, customerID, activityType, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(activities); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, activities[n-1].CreatedOn, activities[n-1].ID)
+	}
+
+	count := func(ctx context.Context) (int64, error) {
+		return u.activityRepo.CountByCustomer(ctx, customerID, activityType)
+	}
+	if err := countPage(ctx, page, info, count, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return activities, info, nil
+}
+
+// Update edits the content of an activity. Only its author or an admin may edit it,
+// and system entries such as status changes cannot be edited.
+func (u *activityUsecase) Update(ctx context.Context, customerID, id string, req *domain.UpdateActivityRequest, actor *domain.Actor) (*domain.CustomerActivity, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	activity, err := u.getOfCustomer(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canModifyActivity(activity, actor) {
+		return nil, domain.ErrForbidden
+	}
+
+	activity.Content = req.Content
+	if err := u.activityRepo.Update(ctx, activity); err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+// Delete removes an activity. Only its author or an admin may delete it.
+func (u *activityUsecase) Delete(ctx context.Context, customerID, id string, actor *domain.Actor) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	activity, err := u.getOfCustomer(ctx, customerID, id)
+	if err != nil {
+		return err
+	}
+	if !canModifyActivity(activity, actor) {
+		return domain.ErrForbidden
+	}
+
+	return u.activityRepo.Delete(ctx, id)
+}
+
+// getOfCustomer gets an activity of a customer. An activity of another customer is
+// reported as not found.
+func (u *activityUsecase) getOfCustomer(ctx context.Context, customerID, id string) (*domain.CustomerActivity, error) {
+	activity, err := u.activityRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if activity.CustomerID.Hex() != customerID {
+		return nil, domain.ErrNotFound
+	}
+	return activity, nil
+}
+
+// canModifyActivity reports whether actor may edit or delete activity
+func canModifyActivity(activity *domain.CustomerActivity, actor *domain.Actor) bool {
+	if activity.Type == domain.ActivityStatusChange {
+		return false
+	}
+	return actor.IsAdmin() || activity.AuthorID == actor.ID
+}
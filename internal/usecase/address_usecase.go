@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/vnaddress"
+)
+
+type addressUsecase struct {
+	customerRepo     domain.CustomerRepository
+	registrationRepo domain.RegistrationRepository
+	contextTimeout   time.Duration
+}
+
+// NewAddressUsecase creates a new address usecase
+func NewAddressUsecase(
+	customerRepo domain.CustomerRepository,
+	registrationRepo domain.RegistrationRepository,
+	timeout time.Duration,
+) domain.AddressUsecase {
+	return &addressUsecase{
+		customerRepo:     customerRepo,
+		registrationRepo: registrationRepo,
+		contextTimeout:   timeout,
+	}
+}
+
+// GetVersions lists the administrative dataset versions, oldest first
+func (u *addressUsecase) GetVersions(ctx context.Context) []*domain.AddressVersion {
+	latest := vnaddress.Latest()
+
+	versions := make([]*domain.AddressVersion, 0, len(vnaddress.Versions()))
+	for _, d := range vnaddress.Versions() {
+		versions = append(versions, &domain.AddressVersion{
+			Version:       d.Version,
+			EffectiveFrom: d.EffectiveFrom,
+			ValidUntil:    d.ValidUntil,
+			HasDistricts:  d.HasDistricts(),
+			Current:       d == latest,
+		})
+	}
+	return versions
+}
+
+// GetProvinces lists the provinces of a dataset version
+func (u *addressUsecase) GetProvinces(ctx context.Context, version string) ([]*domain.AdminUnit, error) {
+	d, err := getAddressDataset(version)
+	if err != nil {
+		return nil, err
+	}
+
+	units := make([]*domain.AdminUnit, 0, len(d.Provinces))
+	for i := range d.Provinces {
+		unit := toAdminUnit(&d.Provinces[i].Unit)
+		unit.MergedFrom = d.Provinces[i].MergedFrom
+		units = append(units, unit)
+	}
+	return units, nil
+}
+
+// Parse converts a free-text address into a structured address without saving it
+func (u *addressUsecase) Parse(ctx context.Context, req *domain.ParseAddressRequest) (*domain.ParsedAddress, error) {
+	d, err := getAddressDataset(req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	result := d.Parse(req.Address)
+	parsed := &domain.ParsedAddress{Matched: result.Matched.String()}
+	if result.Matched != vnaddress.LevelNone {
+		parsed.Address = fromDatasetAddress(&result.Address)
+	}
+	return parsed, nil
+}
+
+// Convert parses the free-text address of the customers and registrations that
+// have no structured address yet, with the latest dataset. Addresses whose province
+// cannot be found are left untouched and reported. In dry-run mode nothing is saved.
+// Like Export, it is bound to the request context only.
+func (u *addressUsecase) Convert(ctx context.Context, dryRun bool) (*domain.AddressConversionReport, error) {
+	d := vnaddress.Latest()
+	report := &domain.AddressConversionReport{
+		DryRun:    dryRun,
+		Version:   d.Version,
+		Unmatched: []domain.UnmatchedAddress{},
+	}
+
+	err := u.customerRepo.Iterate(ctx, nil, func(customer *domain.Customer) error {
+		if customer.StructuredAddress != nil || customer.Address == "" {
+			return nil
+		}
+		return u.convert(ctx, d, report, &report.Customers, "customer", customer.ID.Hex(), customer.Address, dryRun,
+			u.customerRepo.SetStructuredAddress)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.registrationRepo.Iterate(ctx, nil, func(registration *domain.Registration) error {
+		if registration.StructuredAddress != nil || registration.Address == "" {
+			return nil
+		}
+		return u.convert(ctx, d, report, &report.Registrations, "registration", registration.ID.Hex(), registration.Address, dryRun,
+			u.registrationRepo.SetStructuredAddress)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// convert parses one free-text address and saves the result with save
+func (u *addressUsecase) convert(
+	ctx context.Context,
+	d *vnaddress.Dataset,
+	report *domain.AddressConversionReport,
+	count *domain.AddressConversionCount,
+	kind, id, text string,
+	dryRun bool,
+	save func(ctx context.Context, id string, address *domain.Address) error,
+) error {
+	count.Scanned++
+
+	result := d.Parse(text)
+	if result.Matched == vnaddress.LevelNone {
+		count.Unmatched++
+		if len(report.Unmatched) < domain.MaxUnmatchedAddresses {
+			report.Unmatched = append(report.Unmatched, domain.UnmatchedAddress{Kind: kind, ID: id, Address: text})
+		}
+		return nil
+	}
+
+	count.Converted++
+	if dryRun {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return save(ctx, id, fromDatasetAddress(&result.Address))
+}
+
+// resolveAddress checks the province of a structured address against its dataset
+// version, the latest by default, and fills in its code and full name
+func resolveAddress(address *domain.Address) error {
+	if address == nil {
+		return nil
+	}
+
+	d, err := getAddressDataset(address.Version)
+	if err != nil {
+		return err
+	}
+
+	resolved := toDatasetAddress(address)
+	if err := d.Resolve(&resolved); err != nil {
+		return domain.ErrInvalidAddress
+	}
+	*address = *fromDatasetAddress(&resolved)
+
+	return nil
+}
+
+// currentProvinceCodes expands province codes of the latest dataset with the older
+// codes of the provinces merged into them, so filters also match addresses saved
+// with an older dataset
+func currentProvinceCodes(codes []string) []string {
+	if len(codes) == 0 {
+		return codes
+	}
+
+	latest := vnaddress.Latest()
+	expanded := make([]string, 0, len(codes))
+	for _, code := range codes {
+		expanded = append(expanded, latest.HistoricalCodes(code)...)
+	}
+	return expanded
+}
+
+// getAddressDataset returns the dataset of a version, or the latest for an empty version
+func getAddressDataset(version string) (*vnaddress.Dataset, error) {
+	d, err := vnaddress.Get(version)
+	if err != nil {
+		return nil, domain.ErrUnknownAddressVersion
+	}
+	return d, nil
+}
+
+func toAdminUnit(u *vnaddress.Unit) *domain.AdminUnit {
+	return &domain.AdminUnit{
+		Code:     u.Code,
+		Name:     u.Name,
+		Type:     u.Type,
+		FullName: u.FullName(),
+	}
+}
+
+func toDatasetAddress(a *domain.Address) vnaddress.Address {
+	return vnaddress.Address{
+		Street:       a.Street,
+		Ward:         a.Ward,
+		District:     a.District,
+		ProvinceCode: a.ProvinceCode,
+		Province:     a.Province,
+		Version:      a.Version,
+	}
+}
+
+func fromDatasetAddress(a *vnaddress.Address) *domain.Address {
+	return &domain.Address{
+		Street:       a.Street,
+		Ward:         a.Ward,
+		District:     a.District,
+		ProvinceCode: a.ProvinceCode,
+		Province:     a.Province,
+		Version:      a.Version,
+	}
+}
@@ -0,0 +1,552 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/config"
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/ical"
+)
+
+const (
+	// maxAppointmentLength bounds a single appointment, longer jobs are booked over several days
+	maxAppointmentLength = 12 * time.Hour
+
+	// feedPastDays is how far back a calendar feed lists appointments
+	feedPastDays = 30
+
+	// feedMaxEvents bounds the appointments of one calendar feed
+	feedMaxEvents = 1000
+
+	// scheduleLockAttempts and scheduleLockWait bound how long a booking waits for
+	// another booking of the same technician to finish
+	scheduleLockAttempts = 10
+	scheduleLockWait     = 100 * time.Millisecond
+)
+
+type appointmentUsecase struct {
+	appointmentRepo  domain.AppointmentRepository
+	feedRepo         domain.CalendarFeedRepository
+	registrationRepo domain.RegistrationRepository
+	customerRepo     domain.CustomerRepository
+	userRepo         domain.UserRepository
+	config           *config.AppointmentConfig
+	contextTimeout   time.Duration
+}
+
+// NewAppointmentUsecase creates a new appointment usecase
+func NewAppointmentUsecase(
+	repo domain.AppointmentRepository,
+	feedRepo domain.CalendarFeedRepository,
+	registrationRepo domain.RegistrationRepository,
+	customerRepo domain.CustomerRepository,
+	userRepo domain.UserRepository,
+	cfg *config.AppointmentConfig,
+	timeout time.Duration,
+) domain.AppointmentUsecase {
+	return &appointmentUsecase{
+		appointmentRepo:  repo,
+		feedRepo:         feedRepo,
+		registrationRepo: registrationRepo,
+		customerRepo:     customerRepo,
+		userRepo:         userRepo,
+		config:           cfg,
+		contextTimeout:   timeout,
+	}
+}
+
+// Create books an appointment with a registration or a customer. The contact, location
+// and title default to the prospect's details.
+func (u *appointmentUsecase) Create(ctx context.Context, req *domain.CreateAppointmentRequest, actor *domain.Actor) (*domain.Appointment, error) {
+	if req.EndAt.Sub(req.StartAt) > maxAppointmentLength {
+		return nil, domain.ErrInvalidInput
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	appointment := &domain.Appointment{
+		Type:         req.Type,
+		Mode:         req.Mode,
+		Title:        req.Title,
+		TechnicianID: req.TechnicianID,
+		StartAt:      req.StartAt,
+		EndAt:        req.EndAt,
+		Location:     req.Location,
+		MeetingURL:   req.MeetingURL,
+		ContactName:  req.ContactName,
+		ContactPhone: req.ContactPhone,
+		Note:         req.Note,
+		Status:       domain.AppointmentScheduled,
+		CreatedBy:    actor.ID,
+	}
+
+	var name, phone, address string
+	if req.RegistrationID != "" {
+		registration, err := u.registrationRepo.GetByID(ctx, req.RegistrationID)
+		if err != nil {
+			return nil, err
+		}
+		appointment.RegistrationID = &registration.ID
+		appointment.CustomerID = registration.CustomerID
+		name, phone, address = registration.FullName, registration.PhoneNumber, registration.Address
+	}
+	if req.CustomerID != "" {
+		customer, err := u.customerRepo.GetByID(ctx, req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		appointment.CustomerID = &customer.ID
+		name, phone, address = customer.FullName, customer.PhoneNumber, customer.Address
+	}
+
+	if appointment.Title == "" {
+		appointment.Title = appointmentTypeLabel(req.Type) + " - " + name
+	}
+	if appointment.ContactName == "" {
+		appointment.ContactName = name
+	}
+	if appointment.ContactPhone == "" {
+		appointment.ContactPhone = phone
+	}
+	if appointment.Location == "" && req.Mode == domain.AppointmentOnsite {
+		appointment.Location = address
+	}
+
+	if appointment.TechnicianID == "" {
+		appointment.TechnicianID = actor.ID
+	} else if err := u.checkTechnician(ctx, appointment.TechnicianID); err != nil {
+		return nil, err
+	}
+	err := u.bookSlot(ctx, appointment, func() error {
+		return u.appointmentRepo.Create(ctx, appointment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// GetByID gets an appointment by ID
+func (u *appointmentUsecase) GetByID(ctx context.Context, id string) (*domain.Appointment, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.appointmentRepo.GetByID(ctx, id)
+}
+
+// GetAll gets appointments matching filter in calendar order.
+// Appointments are ordered by start time, so only offset pagination is supported.
+func (u *appointmentUsecase) GetAll(ctx context.Context, filter *domain.AppointmentFilter, page *domain.Pagination) ([]*domain.Appointment, *domain.PageInfo, error) {
+	if page.Cursor != nil {
+		return nil, nil, domain.ErrInvalidCursor
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	appointments, err := u.appointmentRepo.GetAll(ctx, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	count := func(ctx context.Context) (int64, error) {
+		return u.appointmentRepo.Count(ctx, filter)
+	}
+	if err := countPage(ctx, page, info, count, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return appointments, info, nil
+}
+
+// GetSlots lists the free slots of the given length a technician has on a day, within
+// the working hours. Slots start on multiples of the slot step and past slots are skipped.
+func (u *appointmentUsecase) GetSlots(ctx context.Context, technicianID string, day time.Time, length time.Duration) ([]domain.TimeSlot, error) {
+	if length <= 0 || length > maxAppointmentLength {
+		return nil, domain.ErrInvalidInput
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if err := u.checkTechnician(ctx, technicianID); err != nil {
+		return nil, err
+	}
+
+	year, month, date := day.In(exportLocation).Date()
+	midnight := time.Date(year, month, date, 0, 0, 0, 0, exportLocation)
+	dayStart := midnight.Add(u.config.WorkdayStart)
+	dayEnd := midnight.Add(u.config.WorkdayEnd)
+
+	busy, err := u.appointmentRepo.GetBusy(ctx, technicianID, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	step := u.config.SlotStep
+	if step <= 0 {
+		step = 30 * time.Minute
+	}
+
+	now := time.Now()
+	slots := []domain.TimeSlot{}
+	for start := dayStart; !start.Add(length).After(dayEnd); start = start.Add(step) {
+		end := start.Add(length)
+		if start.Before(now) || overlapsAny(busy, start, end) {
+			continue
+		}
+		slots = append(slots, domain.TimeSlot{StartAt: start, EndAt: end})
+	}
+
+	return slots, nil
+}
+
+// Update updates the details of a scheduled appointment.
+// Only its technician, its creator or an admin may update it.
+func (u *appointmentUsecase) Update(ctx context.Context, id string, req *domain.UpdateAppointmentRequest, actor *domain.Actor) (*domain.Appointment, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	appointment, err := u.getOpen(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != "" {
+		appointment.Title = req.Title
+	}
+	if req.Mode != "" {
+		appointment.Mode = req.Mode
+	}
+	if req.Location != "" {
+		appointment.Location = req.Location
+	}
+	if req.MeetingURL != "" {
+		appointment.MeetingURL = req.MeetingURL
+	}
+	if req.ContactName != "" {
+		appointment.ContactName = req.ContactName
+	}
+	if req.ContactPhone != "" {
+		appointment.ContactPhone = req.ContactPhone
+	}
+	if req.Note != "" {
+		appointment.Note = req.Note
+	}
+	appointment.Sequence++
+
+	if err := u.appointmentRepo.Update(ctx, appointment); err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// Reschedule moves a scheduled appointment to another slot or technician, keeping
+// the previous slot in its history
+func (u *appointmentUsecase) Reschedule(ctx context.Context, id string, req *domain.RescheduleAppointmentRequest, actor *domain.Actor) (*domain.Appointment, error) {
+	if req.EndAt.Sub(req.StartAt) > maxAppointmentLength {
+		return nil, domain.ErrInvalidInput
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	appointment, err := u.getOpen(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	appointment.Reschedules = append(appointment.Reschedules, domain.AppointmentReschedule{
+		StartAt:      appointment.StartAt,
+		EndAt:        appointment.EndAt,
+		TechnicianID: appointment.TechnicianID,
+		Reason:       req.Reason,
+		By:           actor.ID,
+		At:           time.Now(),
+	})
+
+	if req.TechnicianID != "" && req.TechnicianID != appointment.TechnicianID {
+		if err := u.checkTechnician(ctx, req.TechnicianID); err != nil {
+			return nil, err
+		}
+		appointment.TechnicianID = req.TechnicianID
+	}
+	appointment.StartAt = req.StartAt
+	appointment.EndAt = req.EndAt
+
+	appointment.Sequence++
+	err = u.bookSlot(ctx, appointment, func() error {
+		return u.appointmentRepo.Update(ctx, appointment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// Cancel cancels a scheduled appointment, freeing its slot
+func (u *appointmentUsecase) Cancel(ctx context.Context, id string, req *domain.CancelAppointmentRequest, actor *domain.Actor) (*domain.Appointment, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	appointment, err := u.getOpen(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	appointment.Status = domain.AppointmentCancelled
+	appointment.CancelReason = req.Reason
+	appointment.CancelledAt = &now
+	appointment.CancelledBy = actor.ID
+	appointment.Sequence++
+
+	if err := u.appointmentRepo.Update(ctx, appointment); err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// Complete marks a scheduled appointment as done
+func (u *appointmentUsecase) Complete(ctx context.Context, id string, actor *domain.Actor) (*domain.Appointment, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	appointment, err := u.getOpen(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	appointment.Status = domain.AppointmentCompleted
+	appointment.CompletedAt = &now
+	appointment.CompletedBy = actor.ID
+
+	if err := u.appointmentRepo.Update(ctx, appointment); err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// CreateFeed creates the calendar feed link of the caller. The link of a previous
+// feed stops working.
+func (u *appointmentUsecase) CreateFeed(ctx context.Context, actor *domain.Actor) (*domain.CalendarFeed, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &domain.CalendarFeed{
+		UserID:    actor.ID,
+		TokenHash: hashToken(token),
+	}
+	if err := u.feedRepo.Replace(ctx, feed); err != nil {
+		return nil, err
+	}
+
+	feed.URL = u.config.FeedURL + token + ".ics"
+	return feed, nil
+}
+
+// RenderFeed writes the iCalendar feed of the user a feed token belongs to: the
+// appointments assigned to them from the last 30 days on. Cancelled appointments
+// are kept so calendar apps remove them. Feeds of deactivated users are not found.
+func (u *appointmentUsecase) RenderFeed(ctx context.Context, token string, w io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	feed, err := u.feedRepo.GetByTokenHash(ctx, hashToken(strings.TrimSuffix(token, ".ics")))
+	if err != nil {
+		return err
+	}
+
+	user, err := u.userRepo.GetByID(ctx, feed.UserID)
+	if err == domain.ErrInvalidID {
+		return domain.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return domain.ErrNotFound
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -feedPastDays)
+	filter := &domain.AppointmentFilter{TechnicianID: feed.UserID, From: &from}
+	appointments, err := u.appointmentRepo.GetAll(ctx, filter, &domain.Pagination{Limit: feedMaxEvents})
+	if err != nil {
+		return err
+	}
+
+	cal := &ical.Calendar{
+		ProdID: "-//iCafe Registration//Appointments//VI",
+		Name:   "Lịch hẹn - " + user.FullName,
+	}
+	for _, appointment := range appointments {
+		cal.Events = append(cal.Events, appointmentEvent(appointment))
+	}
+
+	return ical.Write(w, cal, now)
+}
+
+// getOpen gets a scheduled appointment the actor may change: its technician,
+// its creator or an admin
+func (u *appointmentUsecase) getOpen(ctx context.Context, id string, actor *domain.Actor) (*domain.Appointment, error) {
+	appointment, err := u.appointmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.IsAdmin() && appointment.TechnicianID != actor.ID && appointment.CreatedBy != actor.ID {
+		return nil, domain.ErrForbidden
+	}
+	if appointment.Status != domain.AppointmentScheduled {
+		return nil, domain.ErrAppointmentClosed
+	}
+	return appointment, nil
+}
+
+// checkTechnician ensures an appointment is assigned to an active staff user
+func (u *appointmentUsecase) checkTechnician(ctx context.Context, userID string) error {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err == domain.ErrNotFound || err == domain.ErrInvalidID {
+		return domain.ErrInvalidInput
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive || (user.Role != domain.RoleAdmin && user.Role != domain.RoleSale) {
+		return domain.ErrInvalidInput
+	}
+	return nil
+}
+
+// bookSlot saves an appointment with save while holding the schedule lock of its
+// technician, so bookings of the same technician are serialized and each one checks
+// the slot after the previous one was saved
+func (u *appointmentUsecase) bookSlot(ctx context.Context, appointment *domain.Appointment, save func() error) error {
+	token, err := u.lockSchedule(ctx, appointment.TechnicianID)
+	if err != nil {
+		return err
+	}
+	defer u.appointmentRepo.UnlockSchedule(context.WithoutCancel(ctx), appointment.TechnicianID, token)
+
+	if err := u.checkSlot(ctx, appointment); err != nil {
+		return err
+	}
+	return save()
+}
+
+// lockSchedule locks the schedule of a technician, waiting for a booking in progress.
+// The lock lasts as long as the request timeout, so a booking has ended before its
+// lock expires and may be taken over.
+func (u *appointmentUsecase) lockSchedule(ctx context.Context, technicianID string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		token, err := u.appointmentRepo.LockSchedule(ctx, technicianID, u.contextTimeout)
+		if err != domain.ErrScheduleLocked || attempt == scheduleLockAttempts {
+			return token, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(scheduleLockWait):
+		}
+	}
+}
+
+// checkSlot ensures the technician of an appointment has no other appointment in its slot
+func (u *appointmentUsecase) checkSlot(ctx context.Context, appointment *domain.Appointment) error {
+	busy, err := u.appointmentRepo.GetBusy(ctx, appointment.TechnicianID, appointment.StartAt, appointment.EndAt)
+	if err != nil {
+		return err
+	}
+	for _, other := range busy {
+		if other.ID != appointment.ID {
+			return domain.ErrAppointmentConflict
+		}
+	}
+	return nil
+}
+
+// overlapsAny reports whether [start, end) overlaps one of the appointments
+func overlapsAny(appointments []*domain.Appointment, start, end time.Time) bool {
+	for _, appointment := range appointments {
+		if appointment.StartAt.Before(end) && appointment.EndAt.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// appointmentEvent converts an appointment to a calendar event
+func appointmentEvent(appointment *domain.Appointment) ical.Event {
+	status := ical.StatusConfirmed
+	if appointment.Status == domain.AppointmentCancelled {
+		status = ical.StatusCancelled
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s (%s)", appointmentTypeLabel(appointment.Type), appointmentModeLabel(appointment.Mode)),
+	}
+	if appointment.ContactName != "" || appointment.ContactPhone != "" {
+		lines = append(lines, "Liên hệ: "+strings.TrimSpace(appointment.ContactName+" "+appointment.ContactPhone))
+	}
+	if appointment.MeetingURL != "" {
+		lines = append(lines, "Link: "+appointment.MeetingURL)
+	}
+	if appointment.Note != "" {
+		lines = append(lines, appointment.Note)
+	}
+	if appointment.Status == domain.AppointmentCancelled && appointment.CancelReason != "" {
+		lines = append(lines, "Lý do hủy: "+appointment.CancelReason)
+	}
+
+	location := appointment.Location
+	if location == "" {
+		location = appointment.MeetingURL
+	}
+
+	return ical.Event{
+		UID:          appointment.ID.Hex() + "@icafe-registration",
+		Sequence:     appointment.Sequence,
+		Status:       status,
+		Start:        appointment.StartAt,
+		End:          appointment.EndAt,
+		Summary:      appointment.Title,
+		Description:  strings.Join(lines, "\n"),
+		Location:     location,
+		URL:          appointment.MeetingURL,
+		LastModified: appointment.ModifiedOn,
+	}
+}
+
+// appointmentTypeLabel returns the Vietnamese name of an appointment type
+func appointmentTypeLabel(t domain.AppointmentType) string {
+	if t == domain.AppointmentInstallation {
+		return "Lắp đặt"
+	}
+	return "Demo"
+}
+
+// appointmentModeLabel returns the Vietnamese name of an appointment mode
+func appointmentModeLabel(mode domain.AppointmentMode) string {
+	if mode == domain.AppointmentRemote {
+		return "Từ xa"
+	}
+	return "Tại quán"
+}
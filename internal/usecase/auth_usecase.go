@@ -132,7 +132,7 @@ func (u *authUsecase) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 			Email:       user.Email,
 			FullName:    user.FullName,
 			Role:        user.Role,
-			Permissions: user.Permissions,
+			Permissions: user.GetAllPermissions(),
 		},
 	}, nil
 }
@@ -181,7 +181,7 @@ func (u *authUsecase) RefreshToken(ctx context.Context, refreshToken string) (*d
 			Email:       user.Email,
 			FullName:    user.FullName,
 			Role:        user.Role,
-			Permissions: user.Permissions,
+			Permissions: user.GetAllPermissions(),
 		},
 	}, nil
 }
@@ -218,7 +218,7 @@ func (u *authUsecase) generateAccessToken(user *domain.User) (string, error) {
 		Username:    user.Username,
 		Email:       user.Email,
 		Role:        user.Role,
-		Permissions: user.Permissions,
+		Permissions: user.GetAllPermissions(),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/spreadsheet"
+	"icafe-registration/pkg/validator"
+)
+
+const (
+	// importBatchSize is the number of rows looked up and written per round trip
+	importBatchSize = 200
+
+	// maxImportRows bounds the size of a single import file
+	maxImportRows = 10000
+)
+
+// customerImportFields lists the columns that can be imported, keyed by column key
+var customerImportFields = map[string]func(req *domain.CreateCustomerRequest, value string){
+	"full_name":         func(req *domain.CreateCustomerRequest, v string) { req.FullName = v },
+	"phone_number":      func(req *domain.CreateCustomerRequest, v string) { req.PhoneNumber = normalizePhone(v) },
+	"email":             func(req *domain.CreateCustomerRequest, v string) { req.Email = v },
+	"address":           func(req *domain.CreateCustomerRequest, v string) { req.Address = v },
+	"note":              func(req *domain.CreateCustomerRequest, v string) { req.Note = v },
+	"workstation_range": func(req *domain.CreateCustomerRequest, v string) { req.WorkstationRange = v },
+}
+
+// customerImportRequired lists the columns that must be present in the header row
+var customerImportRequired = []string{"full_name", "phone_number", "workstation_range"}
+
+// customerImportHeaders maps accepted header names to column keys.
+// Both the column keys and the export headers are accepted, so an exported
+// file can be edited and imported back.
+var customerImportHeaders = buildCustomerImportHeaders()
+
+func buildCustomerImportHeaders() map[string]string {
+	headers := make(map[string]string)
+	for _, col := range customerExportColumns {
+		if _, ok := customerImportFields[col.Key]; ok {
+			headers[col.Key] = col.Key
+			headers[strings.ToLower(col.Header)] = col.Key
+		}
+	}
+	return headers
+}
+
+// phoneSeparators matches the separators commonly typed inside phone numbers
+var phoneSeparators = regexp.MustCompile(`[\s.\-()]`)
+
+// localPhoneWithoutZero matches a 9-digit local number whose leading 0 was
+// dropped by a spreadsheet storing the phone as a number
+var localPhoneWithoutZero = regexp.MustCompile(`^[1-9][0-9]{8}$`)
+
+// normalizePhone strips separators and restores a dropped leading zero
+func normalizePhone(phone string) string {
+	phone = phoneSeparators.ReplaceAllString(phone, "")
+	if localPhoneWithoutZero.MatchString(phone) {
+		phone = "0" + phone
+	}
+	return phone
+}
+
+// pendingImportRow is a validated row waiting for its batch to be written
+type pendingImportRow struct {
+	row int
+	req *domain.CreateCustomerRequest
+}
+
+// customerImport holds the state of one import run
+type customerImport struct {
+	usecase   *customerUsecase
+	opts      *domain.ImportOptions
+	report    *domain.ImportReport
+	columns   map[int]string
+	seen      map[string]int
+	pending   []pendingImportRow
+	validator *validator.CustomValidator
+}
+
+// Import validates and upserts customers from a CSV or XLSX file, keyed by phone number.
+// Rows are written in batches; in dry-run mode nothing is written and the report
+// describes what a commit would do.
+func (u *customerUsecase) Import(ctx context.Context, r io.ReaderAt, size int64, opts *domain.ImportOptions) (*domain.ImportReport, error) {
+	run := &customerImport{
+		usecase:   u,
+		opts:      opts,
+		report:    &domain.ImportReport{DryRun: opts.DryRun, Rows: []domain.ImportRowResult{}},
+		seen:      make(map[string]int),
+		validator: validator.NewValidator(),
+	}
+
+	err := spreadsheet.ReadRows(spreadsheet.Format(opts.Format), r, size, func(num int, values []string) error {
+		return run.readRow(ctx, num, values)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if run.columns == nil {
+		return nil, domain.ErrImportMissingColumns
+	}
+	if err := run.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(run.report.Rows, func(i, j int) bool {
+		return run.report.Rows[i].Row < run.report.Rows[j].Row
+	})
+
+	return run.report, nil
+}
+
+// readRow handles the header row, then validates each data row
+func (run *customerImport) readRow(ctx context.Context, num int, values []string) error {
+	if isBlankRow(values) {
+		return nil
+	}
+
+	if run.columns == nil {
+		return run.readHeader(values)
+	}
+
+	if run.report.Total+len(run.pending) >= maxImportRows {
+		return domain.ErrImportTooManyRows
+	}
+
+	req := &domain.CreateCustomerRequest{}
+	for i, value := range values {
+		if key, ok := run.columns[i]; ok {
+			customerImportFields[key](req, strings.TrimSpace(value))
+		}
+	}
+
+	result := domain.ImportRowResult{Row: num, Key: req.PhoneNumber}
+
+	if err := run.validator.Validate(req); err != nil {
+		result.Status = domain.ImportRowError
+		result.Errors = sortedMessages(validator.GetValidationErrors(err))
+		run.report.Add(result)
+		return nil
+	}
+
+	if first, ok := run.seen[req.PhoneNumber]; ok {
+		result.Status = domain.ImportRowError
+		result.Errors = []string{fmt.Sprintf("phone number duplicates row %d", first)}
+		run.report.Add(result)
+		return nil
+	}
+	run.seen[req.PhoneNumber] = num
+
+	run.pending = append(run.pending, pendingImportRow{row: num, req: req})
+	if len(run.pending) >= importBatchSize {
+		return run.flush(ctx)
+	}
+	return nil
+}
+
+// readHeader maps the header cells to column keys
+func (run *customerImport) readHeader(values []string) error {
+	run.columns = make(map[int]string)
+	found := make(map[string]bool)
+	for i, value := range values {
+		if key, ok := customerImportHeaders[strings.ToLower(strings.TrimSpace(value))]; ok && !found[key] {
+			run.columns[i] = key
+			found[key] = true
+		}
+	}
+
+	for _, key := range customerImportRequired {
+		if !found[key] {
+			return domain.ErrImportMissingColumns
+		}
+	}
+	return nil
+}
+
+// flush looks up the pending rows by phone and writes them in one batch
+func (run *customerImport) flush(ctx context.Context) error {
+	if len(run.pending) == 0 {
+		return nil
+	}
+
+	u := run.usecase
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	phones := make([]string, len(run.pending))
+	for i, p := range run.pending {
+		phones[i] = p.req.PhoneNumber
+	}
+
+	existing, err := u.customerRepo.GetByPhones(ctx, phones)
+	if err != nil {
+		return err
+	}
+	byPhone := make(map[string]*domain.Customer, len(existing))
+	for _, c := range existing {
+		byPhone[c.PhoneNumber] = c
+	}
+
+	var (
+		toSave  []*domain.Customer
+		results []domain.ImportRowResult
+	)
+	for _, p := range run.pending {
+		result := domain.ImportRowResult{Row: p.row, Key: p.req.PhoneNumber}
+		customer, exists := byPhone[p.req.PhoneNumber]
+
+		switch {
+		case exists && run.opts.OnDuplicate == domain.ImportOnDuplicateSkip:
+			result.Status = domain.ImportRowSkipped
+			result.ID = customer.ID.Hex()
+			result.Message = domain.ErrPhoneAlreadyExists.Error()
+			run.report.Add(result)
+			continue
+		case exists:
+			mergeImportedCustomer(customer, p.req)
+			result.Status = domain.ImportRowUpdated
+			result.ID = customer.ID.Hex()
+		default:
+			customer = &domain.Customer{
+				FullName:         p.req.FullName,
+				PhoneNumber:      p.req.PhoneNumber,
+				Email:            p.req.Email,
+				Address:          p.req.Address,
+				Note:             p.req.Note,
+				WorkstationRange: p.req.WorkstationRange,
+			}
+			result.Status = domain.ImportRowCreated
+		}
+
+		toSave = append(toSave, customer)
+		results = append(results, result)
+	}
+	run.pending = run.pending[:0]
+
+	var failed map[int]error
+	if !run.opts.DryRun {
+		if failed, err = u.customerRepo.BulkSave(ctx, toSave); err != nil {
+			return err
+		}
+	}
+
+	for i, result := range results {
+		if err, ok := failed[i]; ok {
+			result.Status = domain.ImportRowError
+			result.ID = ""
+			result.Errors = []string{err.Error()}
+		} else if !run.opts.DryRun {
+			result.ID = toSave[i].ID.Hex()
+		}
+		run.report.Add(result)
+	}
+
+	return nil
+}
+
+// mergeImportedCustomer applies the non-empty fields of an imported row, like Update does
+func mergeImportedCustomer(customer *domain.Customer, req *domain.CreateCustomerRequest) {
+	customer.FullName = req.FullName
+	customer.WorkstationRange = req.WorkstationRange
+	if req.Email != "" {
+		customer.Email = req.Email
+	}
+	if req.Address != "" {
+		customer.Address = req.Address
+	}
+	if req.Note != "" {
+		customer.Note = req.Note
+	}
+}
+
+// isBlankRow reports whether every cell of a row is empty
+func isBlankRow(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedMessages returns the values of a validation error map in a stable order
+func sortedMessages(errors map[string]string) []string {
+	messages := make([]string, 0, len(errors))
+	for _, msg := range errors {
+		messages = append(messages, msg)
+	}
+	sort.Strings(messages)
+	return messages
+}
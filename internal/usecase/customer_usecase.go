@@ -2,20 +2,36 @@ package usecase
 
 import (
 	"context"
+	"io"
+	"log"
+	"sort"
 	"time"
 
 	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/export"
+	"icafe-registration/pkg/vnaddress"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type customerUsecase struct {
 	customerRepo   domain.CustomerRepository
+	activityRepo   domain.ActivityRepository
+	shopRepo       domain.ShopRepository
 	contextTimeout time.Duration
 }
 
 // NewCustomerUsecase creates a new customer usecase
-func NewCustomerUsecase(repo domain.CustomerRepository, timeout time.Duration) domain.CustomerUsecase {
+func NewCustomerUsecase(
+	repo domain.CustomerRepository,
+	activityRepo domain.ActivityRepository,
+	shopRepo domain.ShopRepository,
+	timeout time.Duration,
+) domain.CustomerUsecase {
 	return &customerUsecase{
 		customerRepo:   repo,
+		activityRepo:   activityRepo,
+		shopRepo:       shopRepo,
 		contextTimeout: timeout,
 	}
 }
@@ -34,16 +50,23 @@ func (u *customerUsecase) Create(ctx context.Context, req *domain.CreateCustomer
 		return nil, domain.ErrPhoneAlreadyExists
 	}
 
+	if err := resolveAddress(req.StructuredAddress); err != nil {
+		return nil, err
+	}
+
 	customer := &domain.Customer{
-		FullName:         req.FullName,
-		PhoneNumber:      req.PhoneNumber,
-		Email:            req.Email,
-		Address:          req.Address,
-		Note:             req.Note,
-		WorkstationRange: req.WorkstationRange,
-		IsActive:         true,
-		CreatedOn:        time.Now(),
-		ModifiedOn:       time.Now(),
+		FullName:          req.FullName,
+		PhoneNumber:       req.PhoneNumber,
+		Email:             req.Email,
+		Address:           addressText(req.Address, req.StructuredAddress),
+		StructuredAddress: req.StructuredAddress,
+		CompanyName:       req.CompanyName,
+		TaxCode:           req.TaxCode,
+		Note:              req.Note,
+		WorkstationRange:  req.WorkstationRange,
+		IsActive:          true,
+		CreatedOn:         time.Now(),
+		ModifiedOn:        time.Now(),
 	}
 
 	if err := u.customerRepo.Create(ctx, customer); err != nil {
@@ -58,29 +81,112 @@ func (u *customerUsecase) GetByID(ctx context.Context, id string) (*domain.Custo
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	return u.customerRepo.GetByID(ctx, id)
+	customer, err := u.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.addShopTotals(ctx, customer); err != nil {
+		return nil, err
+	}
+
+	return customer, nil
 }
 
-// GetAll gets all customers with pagination
-func (u *customerUsecase) GetAll(ctx context.Context, limit, offset int64) ([]*domain.Customer, int64, error) {
+// GetAll gets customers matching filter with pagination
+func (u *customerUsecase) GetAll(ctx context.Context, filter *domain.CustomerFilter, page *domain.Pagination) ([]*domain.Customer, *domain.PageInfo, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	customers, err := u.customerRepo.GetAll(ctx, limit, offset)
+	filter.ProvinceCodes = currentProvinceCodes(filter.ProvinceCodes)
+	customers, err := u.customerRepo.GetAll(ctx, filter, page)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
+	}
+
+	if err := u.addShopTotals(ctx, customers...); err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(customers); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, customers[n-1].CreatedOn, customers[n-1].ID)
+	}
+
+	count := func(ctx context.Context) (int64, error) {
+		return u.customerRepo.Count(ctx, filter)
+	}
+	var estimated countFunc
+	if filter.IsEmpty() {
+		estimated = u.customerRepo.EstimatedCount
+	}
+	if err := countPage(ctx, page, info, count, estimated); err != nil {
+		return nil, nil, err
 	}
 
-	total, err := u.customerRepo.Count(ctx)
+	return customers, info, nil
+}
+
+// Export streams customers matching filter to w as CSV or XLSX.
+// It is bound to the request context only: large exports outlive contextTimeout.
+func (u *customerUsecase) Export(ctx context.Context, filter *domain.CustomerFilter, req *domain.ExportRequest, w io.Writer) error {
+	columns, err := selectColumns(customerExportColumns, req.Columns)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
 
-	return customers, total, nil
+	writer, err := export.NewWriter(export.Format(req.Format), w)
+	if err != nil {
+		return err
+	}
+
+	filter.ProvinceCodes = currentProvinceCodes(filter.ProvinceCodes)
+
+	return exportRows(writer, columns, func(fn func(*domain.Customer) error) error {
+		return u.customerRepo.Iterate(ctx, filter, fn)
+	})
 }
 
-// Update updates a customer
-func (u *customerUsecase) Update(ctx context.Context, id string, req *domain.UpdateCustomerRequest) (*domain.Customer, error) {
+// CountByProvince counts customers per province of the latest administrative dataset.
+// Provinces of older datasets are added to the province they were merged into.
+func (u *customerUsecase) CountByProvince(ctx context.Context) ([]*domain.ProvinceCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	counts, err := u.customerRepo.CountByProvince(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byCode := make(map[string]*domain.ProvinceCount)
+	result := []*domain.ProvinceCount{}
+	for _, count := range counts {
+		key := domain.ProvinceCount{ProvinceCode: count.ProvinceCode}
+		if p := vnaddress.CurrentProvince(count.ProvinceCode); p != nil {
+			key.ProvinceCode, key.Province = p.Code, p.FullName()
+		}
+
+		total, ok := byCode[key.ProvinceCode]
+		if !ok {
+			total = &key
+			byCode[key.ProvinceCode] = total
+			result = append(result, total)
+		}
+		total.Count += count.Count
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].ProvinceCode < result[j].ProvinceCode
+	})
+
+	return result, nil
+}
+
+// Update updates a customer and records activation changes on its timeline
+func (u *customerUsecase) Update(ctx context.Context, id string, req *domain.UpdateCustomerRequest, actor *domain.Actor) (*domain.Customer, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
@@ -109,12 +215,25 @@ func (u *customerUsecase) Update(ctx context.Context, id string, req *domain.Upd
 	if req.Email != "" {
 		existing.Email = req.Email
 	}
-	if req.Address != "" {
+	if req.StructuredAddress != nil {
+		if err := resolveAddress(req.StructuredAddress); err != nil {
+			return nil, err
+		}
+		existing.StructuredAddress = req.StructuredAddress
+		existing.Address = addressText(req.Address, req.StructuredAddress)
+	} else if req.Address != "" {
 		existing.Address = req.Address
 	}
+	if req.CompanyName != "" {
+		existing.CompanyName = req.CompanyName
+	}
+	if req.TaxCode != "" {
+		existing.TaxCode = req.TaxCode
+	}
 	if req.Note != "" {
 		existing.Note = req.Note
 	}
+	statusChanged := req.IsActive != nil && *req.IsActive != existing.IsActive
 	if req.IsActive != nil {
 		existing.IsActive = *req.IsActive
 	}
@@ -123,13 +242,110 @@ func (u *customerUsecase) Update(ctx context.Context, id string, req *domain.Upd
 		return nil, err
 	}
 
+	if statusChanged {
+		u.recordStatusChange(ctx, existing, actor)
+	}
+
 	return existing, nil
 }
 
-// Delete deletes a customer
-func (u *customerUsecase) Delete(ctx context.Context, id string) error {
+// recordStatusChange adds a status change entry to the customer timeline.
+// The update has already been saved, so a failure is only logged.
+func (u *customerUsecase) recordStatusChange(ctx context.Context, customer *domain.Customer, actor *domain.Actor) {
+	content := "Customer deactivated"
+	if customer.IsActive {
+		content = "Customer activated"
+	}
+
+	activity := &domain.CustomerActivity{
+		CustomerID: customer.ID,
+		Type:       domain.ActivityStatusChange,
+		Content:    content,
+	}
+	if actor != nil {
+		activity.AuthorID = actor.ID
+		activity.AuthorName = actor.Name
+	}
+
+	if err := u.activityRepo.Create(ctx, activity); err != nil {
+		log.Printf("Failed to record status change of customer %s: %v", customer.ID.Hex(), err)
+	}
+}
+
+// Delete moves a customer to the trash
+func (u *customerUsecase) Delete(ctx context.Context, id string, deletedBy string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.customerRepo.Delete(ctx, id, deletedBy)
+}
+
+// GetTrash gets customers in the trash with pagination
+func (u *customerUsecase) GetTrash(ctx context.Context, page *domain.Pagination) ([]*domain.Customer, *domain.PageInfo, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	return u.customerRepo.Delete(ctx, id)
+	items, err := u.customerRepo.GetDeleted(ctx, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(items); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, items[n-1].CreatedOn, items[n-1].ID)
+	}
+
+	if err := countPage(ctx, page, info, u.customerRepo.CountDeleted, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return items, info, nil
+}
+
+// Restore moves a customer out of the trash
+func (u *customerUsecase) Restore(ctx context.Context, id string) (*domain.Customer, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if err := u.customerRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return u.customerRepo.GetByID(ctx, id)
+}
+
+// Purge permanently deletes customers trashed before the given time
+func (u *customerUsecase) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.customerRepo.Purge(ctx, before)
+}
+
+// addressText returns the free-text address, or the structured address on one
+// line when no free text is given
+func addressText(text string, structured *domain.Address) string {
+	if text == "" && structured != nil {
+		return structured.String()
+	}
+	return text
+}
+
+// addShopTotals fills the shop count and workstation total of customers
+func (u *customerUsecase) addShopTotals(ctx context.Context, customers ...*domain.Customer) error {
+	ids := make([]primitive.ObjectID, len(customers))
+	for i, customer := range customers {
+		ids[i] = customer.ID
+	}
+
+	totals, err := u.shopRepo.TotalsByCustomers(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, customer := range customers {
+		customer.ShopCount = totals[customer.ID].ShopCount
+		customer.TotalWorkstations = totals[customer.ID].Workstations
+	}
+	return nil
 }
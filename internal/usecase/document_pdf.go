@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"strings"
+
+	"icafe-registration/internal/config"
+	"icafe-registration/pkg/pdf"
+)
+
+// Layout of the PDF documents sent to customers, in points
+const (
+	pageMargin     = 40.0
+	pageLineHeight = 14.0
+	pageRowPadding = 4.0
+)
+
+// tableColumn is a column of a document table: title, width and alignment
+type tableColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+// documentPage draws a document with a single table, moving to a new page when
+// the current one is full
+type documentPage struct {
+	doc     *pdf.Document
+	y       float64
+	columns []tableColumn
+}
+
+// ensure starts a new page when height does not fit on the current one
+func (p *documentPage) ensure(height float64) {
+	if p.y+height > p.doc.Height()-pageMargin {
+		p.doc.AddPage()
+		p.y = pageMargin
+	}
+}
+
+// line writes a label in bold followed by its value, wrapping long values
+func (p *documentPage) line(label, value string) {
+	if value == "" {
+		return
+	}
+
+	p.doc.SetFont(true, 10)
+	p.ensure(pageLineHeight)
+	p.doc.Text(pageMargin, p.y+10, label)
+	x := pageMargin + p.doc.TextWidth(label) + 4
+
+	p.doc.SetFont(false, 10)
+	for _, text := range p.doc.Wrap(value, p.doc.Width()-pageMargin-x) {
+		p.ensure(pageLineHeight)
+		p.doc.Text(x, p.y+10, text)
+		p.y += pageLineHeight
+	}
+}
+
+// seller draws the seller details heading invoices and quotes
+func (p *documentPage) seller(cfg *config.InvoiceConfig) {
+	if cfg.SellerName != "" {
+		p.doc.SetFont(true, 12)
+		p.doc.Text(pageMargin, p.y+12, strings.ToUpper(cfg.SellerName))
+		p.y += 18
+	}
+	p.line("Mã số thuế:", cfg.SellerTaxCode)
+	p.line("Địa chỉ:", cfg.SellerAddress)
+	p.line("Điện thoại:", cfg.SellerPhone)
+	p.line("Số tài khoản:", cfg.SellerBankAccount)
+	p.y += 8
+	p.doc.Line(pageMargin, p.y, p.doc.Width()-pageMargin, p.y, 0.5)
+	p.y += 16
+}
+
+// amount writes a right-aligned total line
+func (p *documentPage) amount(label, value string, bold bool) {
+	p.ensure(pageLineHeight)
+	p.doc.SetFont(bold, 10)
+	p.doc.Text(pageMargin+250, p.y+10, label)
+	p.doc.TextRight(p.doc.Width()-pageMargin, p.y+10, value)
+	p.y += pageLineHeight
+}
+
+// header draws the header of the table, with room for a first row below it
+func (p *documentPage) header() {
+	titles := make([]string, len(p.columns))
+	for i, column := range p.columns {
+		titles[i] = column.title
+	}
+
+	p.ensure(4 * pageLineHeight)
+	p.row(true, titles)
+}
+
+// row draws a row of the table; the table header is repeated on new pages
+func (p *documentPage) row(header bool, cells []string) {
+	p.doc.SetFont(header, 9)
+
+	lines := make([][]string, len(cells))
+	height := pageLineHeight
+	for i, cell := range cells {
+		lines[i] = p.doc.Wrap(cell, p.columns[i].width-2*pageRowPadding)
+		if h := float64(len(lines[i])) * 12; h > height {
+			height = h
+		}
+	}
+	height += 2 * pageRowPadding
+
+	if !header && p.y+height > p.doc.Height()-pageMargin {
+		p.doc.AddPage()
+		p.y = pageMargin
+		p.header()
+		p.doc.SetFont(false, 9)
+	}
+
+	x := pageMargin
+	for i, column := range p.columns {
+		p.doc.Rect(x, p.y, column.width, height, 0.5)
+		for j, text := range lines[i] {
+			baseline := p.y + pageRowPadding + 9 + float64(j)*12
+			switch {
+			case header:
+				p.doc.TextCenter(x+column.width/2, baseline, text)
+			case column.right:
+				p.doc.TextRight(x+column.width-pageRowPadding, baseline, text)
+			default:
+				p.doc.Text(x+pageRowPadding, baseline, text)
+			}
+		}
+		x += column.width
+	}
+	p.y += height
+}
+
+// signatures draws the signature blocks of the buyer and the seller
+func (p *documentPage) signatures(buyer, seller string) {
+	p.ensure(60)
+	left := pageMargin + 100
+	right := p.doc.Width() - pageMargin - 100
+
+	p.doc.SetFont(true, 10)
+	p.doc.TextCenter(left, p.y+10, buyer)
+	p.doc.TextCenter(right, p.y+10, seller)
+	p.y += pageLineHeight
+
+	p.doc.SetFont(false, 9)
+	p.doc.TextCenter(left, p.y+9, "(Ký, ghi rõ họ tên)")
+	p.doc.TextCenter(right, p.y+9, "(Ký, đóng dấu, ghi rõ họ tên)")
+	p.y += pageLineHeight
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"time"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/export"
+)
+
+// exportLocation is the timezone used for dates in export files
+var exportLocation = loadExportLocation()
+
+func loadExportLocation() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Ho_Chi_Minh"); err == nil {
+		return loc
+	}
+	return time.FixedZone("ICT", 7*60*60)
+}
+
+// exportColumn describes one column of an export file
+type exportColumn[T any] struct {
+	Key    string
+	Header string
+	Value  func(item *T) string
+}
+
+// selectColumns returns the requested columns in the requested order,
+// or all columns when none are requested
+func selectColumns[T any](all []exportColumn[T], keys []string) ([]exportColumn[T], error) {
+	if len(keys) == 0 {
+		return all, nil
+	}
+
+	byKey := make(map[string]exportColumn[T], len(all))
+	for _, col := range all {
+		byKey[col.Key] = col
+	}
+
+	selected := make([]exportColumn[T], 0, len(keys))
+	for _, key := range keys {
+		col, ok := byKey[key]
+		if !ok {
+			return nil, domain.ErrInvalidExportColumn
+		}
+		selected = append(selected, col)
+	}
+
+	return selected, nil
+}
+
+// exportRows writes the header row, then one row per item produced by iterate
+func exportRows[T any](w export.Writer, columns []exportColumn[T], iterate func(fn func(*T) error) error) error {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Header
+	}
+	if err := w.WriteRow(header); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	err := iterate(func(item *T) error {
+		for i, col := range columns {
+			row[i] = col.Value(item)
+		}
+		return w.WriteRow(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// formatExportTime formats a timestamp for export files
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(exportLocation).Format("2006-01-02 15:04:05")
+}
+
+// customerExportColumns lists the exportable customer columns in default order
+var customerExportColumns = []exportColumn[domain.Customer]{
+	{Key: "id", Header: "ID", Value: func(c *domain.Customer) string { return c.ID.Hex() }},
+	{Key: "full_name", Header: "Họ tên", Value: func(c *domain.Customer) string { return c.FullName }},
+	{Key: "phone_number", Header: "Số điện thoại", Value: func(c *domain.Customer) string { return c.PhoneNumber }},
+	{Key: "email", Header: "Email", Value: func(c *domain.Customer) string { return c.Email }},
+	{Key: "address", Header: "Địa chỉ", Value: func(c *domain.Customer) string { return c.Address }},
+	{Key: "province", Header: "Tỉnh/Thành phố", Value: func(c *domain.Customer) string {
+		if c.StructuredAddress == nil {
+			return ""
+		}
+		return c.StructuredAddress.Province
+	}},
+	{Key: "workstation_range", Header: "Số máy", Value: func(c *domain.Customer) string { return c.WorkstationRange }},
+	{Key: "is_active", Header: "Hoạt động", Value: func(c *domain.Customer) string {
+		if c.IsActive {
+			return "Có"
+		}
+		return "Không"
+	}},
+	{Key: "note", Header: "Ghi chú", Value: func(c *domain.Customer) string { return c.Note }},
+	{Key: "created_on", Header: "Ngày tạo", Value: func(c *domain.Customer) string { return formatExportTime(c.CreatedOn) }},
+	{Key: "modified_on", Header: "Ngày cập nhật", Value: func(c *domain.Customer) string { return formatExportTime(c.ModifiedOn) }},
+}
+
+// registrationExportColumns lists the exportable registration columns in default order
+var registrationExportColumns = []exportColumn[domain.Registration]{
+	{Key: "id", Header: "ID", Value: func(r *domain.Registration) string { return r.ID.Hex() }},
+	{Key: "full_name", Header: "Họ tên", Value: func(r *domain.Registration) string { return r.FullName }},
+	{Key: "phone_number", Header: "Số điện thoại", Value: func(r *domain.Registration) string { return r.PhoneNumber }},
+	{Key: "email", Header: "Email", Value: func(r *domain.Registration) string { return r.Email }},
+	{Key: "address", Header: "Địa chỉ", Value: func(r *domain.Registration) string { return r.Address }},
+	{Key: "workstation_range", Header: "Số máy", Value: func(r *domain.Registration) string { return r.WorkstationRange }},
+	{Key: "created_on", Header: "Ngày đăng ký", Value: func(r *domain.Registration) string { return formatExportTime(r.CreatedOn) }},
+}
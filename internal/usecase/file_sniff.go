@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// sniffLength is how many leading bytes are read to detect the type of an upload
+const sniffLength = 512
+
+// oleMagic starts OLE2 compound files: legacy Office documents and MSI installers
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// installerOnlyTypes are programs and unidentified binaries, only accepted as installers
+var installerOnlyTypes = map[string]bool{
+	"application/octet-stream": true,
+	"application/x-msdownload": true,
+	"application/x-msi":        true,
+}
+
+// executableExts are only accepted as installers
+var executableExts = map[string]bool{
+	".exe": true, ".msi": true, ".com": true, ".scr": true, ".dll": true,
+	".bat": true, ".cmd": true, ".ps1": true, ".vbs": true, ".sh": true,
+}
+
+// detectContentType detects the MIME type of a file from its leading bytes.
+// Container formats are told apart by the extension: a zip may be a .docx
+// or an .apk, an OLE2 file a .doc or an .msi.
+func detectContentType(head []byte, name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	switch {
+	case bytes.HasPrefix(head, []byte("MZ")):
+		return "application/x-msdownload"
+	case bytes.HasPrefix(head, oleMagic):
+		switch ext {
+		case ".doc":
+			return "application/msword"
+		case ".xls":
+			return "application/vnd.ms-excel"
+		case ".msi":
+			return "application/x-msi"
+		}
+		return "application/x-ole-storage"
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		// ISO base media file: the major brand tells the format
+		switch string(head[8:12]) {
+		case "qt  ":
+			return "video/quicktime"
+		case "heic", "heix", "mif1":
+			return "image/heic"
+		case "avif":
+			return "image/avif"
+		}
+		return "video/mp4"
+	case bytes.HasPrefix(head, []byte{0x00, 0x00, 0x01, 0xBA}), bytes.HasPrefix(head, []byte{0x00, 0x00, 0x01, 0xB3}):
+		return "video/mpeg"
+	}
+
+	contentType := http.DetectContentType(head)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+
+	if contentType == "application/zip" {
+		switch ext {
+		case ".docx":
+			return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		case ".xlsx":
+			return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		case ".apk":
+			return "application/vnd.android.package-archive"
+		}
+	}
+
+	return contentType
+}
+
+// isExecutableName reports whether a file name has the extension of a program or script
+func isExecutableName(name string) bool {
+	return executableExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// safeFileName turns a file name into a lower case ASCII slug that is safe in
+// paths and URLs, keeping its extension: "Báo giá (1).PDF" becomes "bao-gia-1.pdf"
+func safeFileName(name string) string {
+	name = originalFileName(name)
+	ext := filepath.Ext(name)
+
+	stem := slugify(strings.TrimSuffix(name, ext), 80)
+	if stem == "" {
+		stem = "file"
+	}
+	ext = slugify(ext, 10)
+	if ext != "" {
+		ext = "." + strings.ReplaceAll(ext, "-", "")
+	}
+
+	return stem + ext
+}
+
+// originalFileName keeps the last element of a client file name, without
+// control characters, for Content-Disposition
+func originalFileName(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+	return name
+}
+
+// slugify lower-cases s, removes diacritics and joins its letters and digits with dashes
+func slugify(s string, maxLen int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			r = 'd'
+		}
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+		if b.Len() >= maxLen {
+			break
+		}
+	}
+	return b.String()
+}
@@ -1,68 +1,172 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
-	"os"
-	"path/filepath"
+	"net"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
 	"icafe-registration/internal/config"
 	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/imaging"
+	"icafe-registration/pkg/signedlink"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// defaultFileLinkExpiry is how long a signed download link works unless asked otherwise
+const defaultFileLinkExpiry = time.Hour
+
 type fileUsecase struct {
 	fileRepo       domain.FileRepository
+	blobRepo       domain.BlobRepository
+	linkRepo       domain.FileLinkRepository
+	customerRepo   domain.CustomerRepository
+	storage        domain.FileStorage
+	linkSigner     *signedlink.Signer
 	uploadConfig   *config.UploadConfig
+	presignExpiry  time.Duration
+	quarantine     bool
 	contextTimeout time.Duration
 }
 
-// NewFileUsecase creates a new file usecase
+// NewFileUsecase creates a new file usecase keeping file contents in storage.
+// Identical contents are stored once and shared through blobRepo.
+// Files that are not public are downloaded through links signed by linkSigner.
+// Downloads are redirected to presigned links valid for presignExpiry when the
+// storage supports them and presignExpiry is positive.
+// With quarantine set, uploads are only served once the malware scan finds them clean.
 func NewFileUsecase(
 	repo domain.FileRepository,
+	blobRepo domain.BlobRepository,
+	linkRepo domain.FileLinkRepository,
+	customerRepo domain.CustomerRepository,
+	storage domain.FileStorage,
+	linkSigner *signedlink.Signer,
 	uploadConfig *config.UploadConfig,
+	presignExpiry time.Duration,
+	quarantine bool,
 	timeout time.Duration,
 ) domain.FileUsecase {
 	return &fileUsecase{
 		fileRepo:       repo,
+		blobRepo:       blobRepo,
+		linkRepo:       linkRepo,
+		customerRepo:   customerRepo,
+		storage:        storage,
+		linkSigner:     linkSigner,
 		uploadConfig:   uploadConfig,
+		presignExpiry:  presignExpiry,
+		quarantine:     quarantine,
 		contextTimeout: timeout,
 	}
 }
 
-// Upload uploads a file (DOCUMENT / VIDEO) với TÊN GỐC, KHÔNG UUID
+// Upload uploads a file (DOCUMENT / VIDEO / INSTALLER). The type is detected from
+// the content, the client's Content-Type header is ignored. A nil access makes
+// the file public.
 func (u *fileUsecase) Upload(
 	ctx context.Context,
 	fileHeader *multipart.FileHeader,
 	fileType domain.FileType,
+	access *domain.FileAccess,
 ) (*domain.File, error) {
 
-	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
-	defer cancel()
+	// Open source file
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return u.Ingest(ctx, fileHeader.Filename, src, fileHeader.Size, fileType, access)
+}
+
+// Ingest stores uploaded content of a known size as a file, checking it like
+// Upload does. Writing to the storage is not bound to the usecase timeout, as
+// large files take longer.
+func (u *fileUsecase) Ingest(
+	ctx context.Context,
+	name string,
+	content io.Reader,
+	size int64,
+	fileType domain.FileType,
+	access *domain.FileAccess,
+) (*domain.File, error) {
 
 	// Validate file size
-	if fileHeader.Size > u.uploadConfig.MaxFileSize {
+	if size > u.uploadConfig.MaxFileSize {
 		return nil, domain.ErrFileTooLarge
 	}
 
-	// Validate content type
-	contentType := fileHeader.Header.Get("Content-Type")
-	if !u.isAllowedType(contentType) {
+	// Detect content type from the magic bytes
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := detectContentType(head, name)
+	if !isAllowedType(u.uploadConfig, contentType, name, fileType) {
 		return nil, domain.ErrInvalidFileType
 	}
 
-	// Open source file
-	src, err := fileHeader.Open()
+	src := io.MultiReader(bytes.NewReader(head), content)
+	if contentType == "image/jpeg" || contentType == "image/png" {
+		// Photos often carry GPS positions, so the metadata is not kept
+		if src, err = imaging.StripMetadata(src, contentType); err != nil {
+			return nil, err
+		}
+		size = -1
+	}
+
+	return u.save(ctx, name, contentType, fileType, access, src, size, u.quarantine)
+}
+
+// Store saves generated content, such as an invoice PDF, as a file under the
+// given name. The content type is trusted.
+func (u *fileUsecase) Store(
+	ctx context.Context,
+	name string,
+	contentType string,
+	fileType domain.FileType,
+	access *domain.FileAccess,
+	content io.Reader,
+) (*domain.File, error) {
+
+	return u.save(ctx, name, contentType, fileType, access, content, -1, false)
+}
+
+// save puts content in the storage under a unique slug of its name and records
+// the file with its original name and SHA-256. When identical content is stored
+// already, the file shares it and the new copy is deleted. size is -1 when unknown.
+// A quarantined file waits for the malware scan.
+func (u *fileUsecase) save(
+	ctx context.Context,
+	fileName string,
+	contentType string,
+	fileType domain.FileType,
+	access *domain.FileAccess,
+	src io.Reader,
+	size int64,
+	quarantine bool,
+) (*domain.File, error) {
+
+	// Check the owner before taking the content
+	access, err := u.checkAccess(ctx, access)
 	if err != nil {
 		return nil, err
 	}
-	defer src.Close()
-
-	// Sử dụng tên gốc
-	fileName := fileHeader.Filename
 
 	// Determine sub directory
 	subDir := "files"
@@ -70,53 +174,59 @@ func (u *fileUsecase) Upload(
 		subDir = "videos"
 	}
 
-	// ===== ABSOLUTE PATH (ghi file ra disk) =====
-	uploadDir := filepath.Join(u.uploadConfig.Path, subDir)
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+	// Hash while streaming, the content is only read once
+	hasher := sha256.New()
+	content := &countingReader{r: io.TeeReader(src, hasher)}
+	key, err := u.putUnique(ctx, subDir, safeFileName(fileName), content, size, contentType)
+	if err != nil {
 		return nil, err
 	}
+	storedName := path.Base(key)
 
-	diskPath := filepath.Join(uploadDir, fileName)
+	dbCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
 
-	// Nếu file đã tồn tại, ghi đè
-	dst, err := os.Create(diskPath)
+	blob, err := u.blobRepo.Acquire(dbCtx, &domain.Blob{
+		SHA256: hex.EncodeToString(hasher.Sum(nil)),
+		Key:    key,
+		Size:   content.n,
+	})
 	if err != nil {
+		_ = u.storage.Delete(context.WithoutCancel(ctx), key)
 		return nil, err
 	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, src); err != nil {
-		return nil, err
+	if blob.Key != key {
+		// Identical content is stored already, keep a single copy
+		_ = u.storage.Delete(context.WithoutCancel(ctx), key)
 	}
 
-	// ===== RELATIVE PATH (lưu DB) =====
-	dbPath := filepath.Join(subDir, fileName)
-
-	// Build file URL
-	fileURL := fmt.Sprintf(
-		"%s/%s/serve/%s",
-		strings.TrimRight(u.uploadConfig.BaseURL, "/"),
-		subDir,
-		fileName,
-	)
-
 	// Create domain file
 	file := &domain.File{
-		FileName:     fileName,
-		OriginalName: fileHeader.Filename,
-		FilePath:     dbPath, // ✅ chỉ lưu relative path
+		FileName:     storedName,
+		OriginalName: originalFileName(fileName),
+		FilePath:     blob.Key,
 		FileType:     fileType,
 		MimeType:     contentType,
-		Size:         fileHeader.Size,
-		URL:          fileURL,
+		Size:         content.n,
+		SHA256:       blob.SHA256,
+		Visibility:   access.Visibility,
+		CustomerID:   access.CustomerID,
+	}
+	file.URL = u.fileURL(file)
+	if quarantine {
+		file.Scan = &domain.FileScan{Status: domain.ScanStatusPending, UpdatedOn: time.Now()}
 	}
 
 	// Lưu vào DB
-	if err := u.fileRepo.Create(ctx, file); err != nil {
-		_ = os.Remove(diskPath)
+	if err := u.fileRepo.Create(dbCtx, file); err != nil {
+		u.releaseContent(context.WithoutCancel(ctx), file)
 		return nil, err
 	}
 
+	if file.IsPublic() && !file.IsQuarantined() {
+		u.makeAllVariants(ctx, file)
+	}
+
 	return file, nil
 }
 
@@ -128,30 +238,125 @@ func (u *fileUsecase) GetByID(ctx context.Context, id string) (*domain.File, err
 	return u.fileRepo.GetByID(ctx, id)
 }
 
-// GetAll gets all files with pagination
+// GetByFileName gets a file by its stored file name, as used in its URL
+func (u *fileUsecase) GetByFileName(ctx context.Context, fileName string) (*domain.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.fileRepo.GetByFileName(ctx, fileName)
+}
+
+// GetAll gets the files matching filter with pagination
 func (u *fileUsecase) GetAll(
 	ctx context.Context,
-	fileType domain.FileType,
-	limit, offset int64,
-) ([]*domain.File, int64, error) {
+	filter *domain.FileFilter,
+	page *domain.Pagination,
+) ([]*domain.File, *domain.PageInfo, error) {
 
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	files, err := u.fileRepo.GetAll(ctx, fileType, limit, offset)
+	// Filters are matched like the stored metadata
+	filter.Tags = domain.NormalizeTags(filter.Tags)
+	if filter.Folder != "" && filter.Folder != "/" {
+		folder, err := domain.NormalizeFolder(filter.Folder)
+		if err != nil {
+			return nil, nil, err
+		}
+		if folder == "" {
+			folder = "/"
+		}
+		filter.Folder = folder
+	}
+
+	files, err := u.fileRepo.GetAll(ctx, filter, page)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(files); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, files[n-1].CreatedOn, files[n-1].ID)
+	}
+
+	// Files are always filtered by type, so an estimated count is not meaningful
+	count := func(ctx context.Context) (int64, error) {
+		return u.fileRepo.Count(ctx, filter)
+	}
+	if err := countPage(ctx, page, info, count, nil); err != nil {
+		return nil, nil, err
 	}
 
-	total, err := u.fileRepo.Count(ctx, fileType)
+	return files, info, nil
+}
+
+// Folders lists the folders holding files matching the type and visibility of
+// filter, sorted by path. Folders holding only subfolders are listed too, with
+// no files, so clients can draw the whole tree.
+func (u *fileUsecase) Folders(ctx context.Context, filter *domain.FileFilter) ([]*domain.FileFolder, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	folders, err := u.fileRepo.Folders(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		listed[folder.Path] = true
+	}
+	for _, folder := range folders {
+		for parent := path.Dir(folder.Path); parent != "/" && parent != "." && !listed[parent]; parent = path.Dir(parent) {
+			listed[parent] = true
+			folders = append(folders, &domain.FileFolder{Path: parent})
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].Path < folders[j].Path
+	})
+
+	return folders, nil
+}
+
+// UpdateMetadata updates the title, description, tags, category and folder of
+// a file. Tags are lowercased and folders cleaned to the /a/b form.
+func (u *fileUsecase) UpdateMetadata(ctx context.Context, id string, req *domain.UpdateFileMetadataRequest) (*domain.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	file, err := u.fileRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+
+	if req.Title != nil {
+		file.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		file.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Tags != nil {
+		file.Tags = domain.NormalizeTags(req.Tags)
+	}
+	if req.Category != nil {
+		file.Category = strings.TrimSpace(*req.Category)
+	}
+	if req.Folder != nil {
+		if file.Folder, err = domain.NormalizeFolder(*req.Folder); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.fileRepo.UpdateMetadata(ctx, file); err != nil {
+		return nil, err
 	}
 
-	return files, total, nil
+	return file, nil
 }
 
-// Delete deletes file (DB + physical file)
+// Delete deletes file (DB + physical file). Content shared with other files
+// is kept until the last of them is deleted.
 func (u *fileUsecase) Delete(ctx context.Context, id string) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
@@ -165,17 +370,302 @@ func (u *fileUsecase) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	// build absolute path before delete
-	absPath := filepath.Join(u.uploadConfig.Path, file.FilePath)
-	_ = os.Remove(absPath)
+	u.releaseContent(ctx, file)
+	if file.HLS != nil {
+		if err := deleteHLSPackage(ctx, u.storage, file.ID); err != nil {
+			log.Printf("Failed to delete the HLS package of video %s: %v", file.ID.Hex(), err)
+		}
+	}
+	if len(file.Variants) > 0 {
+		if err := u.deleteVariants(ctx, file.ID); err != nil {
+			log.Printf("Failed to delete the variants of image %s: %v", file.ID.Hex(), err)
+		}
+	}
 
 	return nil
 }
 
-// isAllowedType checks if content type is allowed
-func (u *fileUsecase) isAllowedType(contentType string) bool {
-	for _, allowed := range u.uploadConfig.AllowedTypes {
-		if strings.EqualFold(contentType, allowed) {
+// releaseContent drops the reference of a deleted file to its content, and
+// deletes the content when no file uses it anymore
+func (u *fileUsecase) releaseContent(ctx context.Context, file *domain.File) {
+	// Files stored before deduplication own their content
+	if file.SHA256 == "" {
+		_ = u.storage.Delete(ctx, file.FilePath)
+		return
+	}
+
+	remaining, err := u.blobRepo.Release(ctx, file.SHA256)
+	if err != nil {
+		log.Printf("Failed to release content %s of file %s: %v", file.SHA256, file.ID.Hex(), err)
+		return
+	}
+	if remaining > 0 {
+		return
+	}
+
+	// Another upload may have acquired the content since, then it stays
+	deleted, err := u.blobRepo.DeleteUnused(ctx, file.SHA256)
+	if err != nil {
+		log.Printf("Failed to delete unused content %s: %v", file.SHA256, err)
+		return
+	}
+	if deleted {
+		_ = u.storage.Delete(ctx, file.FilePath)
+	}
+}
+
+// Open opens the stored content of a key. The content outlives the call, so
+// it is not bound to the usecase timeout; the caller closes it.
+func (u *fileUsecase) Open(ctx context.Context, key string) (io.ReadCloser, *domain.StoredObject, error) {
+	return u.storage.Get(ctx, key)
+}
+
+// DownloadURL returns a temporary link downloading a file straight from the
+// storage, or domain.ErrPresignUnsupported when it has to go through the API
+func (u *fileUsecase) DownloadURL(ctx context.Context, file *domain.File) (string, error) {
+	if u.presignExpiry <= 0 {
+		return "", domain.ErrPresignUnsupported
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	name := file.OriginalName
+	if name == "" {
+		name = file.FileName
+	}
+	return u.storage.PresignGet(ctx, file.FilePath, u.presignExpiry, name)
+}
+
+// SetVisibility changes who can download a file. Files that are not public
+// have no URL, they are downloaded through signed links.
+func (u *fileUsecase) SetVisibility(ctx context.Context, id string, req *domain.FileVisibilityRequest) (*domain.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	access := &domain.FileAccess{Visibility: req.Visibility}
+	if req.Visibility == domain.FileVisibilityCustomer {
+		customerID, err := primitive.ObjectIDFromHex(req.CustomerID)
+		if err != nil {
+			return nil, domain.ErrInvalidID
+		}
+		access.CustomerID = &customerID
+	}
+	access, err := u.checkAccess(ctx, access)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := u.fileRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	file.Visibility = access.Visibility
+	file.CustomerID = access.CustomerID
+	file.URL = u.fileURL(file)
+	if err := u.fileRepo.SetAccess(ctx, file.ID, access, file.URL); err != nil {
+		return nil, err
+	}
+
+	// Variants are public, the ones of a private file are dropped
+	if !file.IsPublic() && len(file.Variants) > 0 {
+		file.Variants = nil
+		if err := u.deleteVariants(ctx, file.ID); err != nil {
+			log.Printf("Failed to delete the variants of image %s: %v", file.ID.Hex(), err)
+		}
+	}
+
+	return file, nil
+}
+
+// CreateLink mints a signed link downloading a file until it expires, optionally
+// bound to a client IP or usable once. Only admins mint links to private files.
+func (u *fileUsecase) CreateLink(
+	ctx context.Context,
+	id string,
+	req *domain.CreateFileLinkRequest,
+	actor *domain.Actor,
+) (*domain.FileLink, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	file, err := u.fileRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if file.Visibility == domain.FileVisibilityPrivate && !actor.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+
+	expiry := defaultFileLinkExpiry
+	if req.ExpiresInMinutes > 0 {
+		expiry = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+	expiresAt := time.Now().Add(expiry).Truncate(time.Second)
+
+	claims := &signedlink.Claims{
+		Subject:   file.ID.Hex(),
+		ExpiresAt: expiresAt.Unix(),
+		IP:        req.IP,
+	}
+	if req.SingleUse {
+		if claims.Nonce, err = randomHex(16); err != nil {
+			return nil, err
+		}
+	}
+	token, err := u.linkSigner.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.FileLink{
+		URL:       strings.TrimRight(u.uploadConfig.BaseURL, "/") + "/api/v1/files/signed/" + token,
+		ExpiresAt: expiresAt,
+		IP:        req.IP,
+		SingleUse: req.SingleUse,
+	}, nil
+}
+
+// ResolveLink checks a signed link used from clientIP and returns its file.
+// A single-use link is used up when consume is set, so HEAD requests can
+// inspect it without using it.
+func (u *fileUsecase) ResolveLink(ctx context.Context, token string, clientIP string, consume bool) (*domain.File, error) {
+	claims, err := u.linkSigner.Verify(token, time.Now())
+	switch err {
+	case nil:
+	case signedlink.ErrExpired:
+		return nil, domain.ErrFileLinkExpired
+	default:
+		return nil, domain.ErrInvalidFileLink
+	}
+	if claims.IP != "" && !net.ParseIP(claims.IP).Equal(net.ParseIP(clientIP)) {
+		return nil, domain.ErrFileLinkIPMismatch
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	file, err := u.fileRepo.GetByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsQuarantined() {
+		return nil, domain.ErrFileQuarantined
+	}
+	if claims.Nonce != "" && consume {
+		if err := u.linkRepo.Consume(ctx, claims.Nonce, time.Unix(claims.ExpiresAt, 0)); err != nil {
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
+// checkAccess completes the visibility of a new or changed file, checking that
+// the customer of a customer file exists. A nil access is public.
+func (u *fileUsecase) checkAccess(ctx context.Context, access *domain.FileAccess) (*domain.FileAccess, error) {
+	if access == nil || access.Visibility == "" {
+		return &domain.FileAccess{Visibility: domain.FileVisibilityPublic}, nil
+	}
+
+	switch access.Visibility {
+	case domain.FileVisibilityPublic, domain.FileVisibilityPrivate:
+		return &domain.FileAccess{Visibility: access.Visibility}, nil
+	case domain.FileVisibilityCustomer:
+		if access.CustomerID == nil {
+			return nil, domain.ErrInvalidInput
+		}
+		ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+		defer cancel()
+		if _, err := u.customerRepo.GetByID(ctx, access.CustomerID.Hex()); err != nil {
+			if err == domain.ErrNotFound {
+				return nil, domain.ErrFileCustomerNotFound
+			}
+			return nil, err
+		}
+		return access, nil
+	}
+	return nil, domain.ErrInvalidInput
+}
+
+// fileURL builds the URL serving a public file. Other files have none.
+func (u *fileUsecase) fileURL(file *domain.File) string {
+	if !file.IsPublic() {
+		return ""
+	}
+
+	subDir := "files"
+	if file.FileType == domain.FileTypeVideo {
+		subDir = "videos"
+	}
+	return fmt.Sprintf(
+		"%s/%s/serve/%s",
+		strings.TrimRight(u.uploadConfig.BaseURL, "/"),
+		subDir,
+		file.FileName,
+	)
+}
+
+// isAllowedType checks a detected content type against the allowed types of a file type.
+// Programs and unidentified binaries are only accepted as installers.
+func isAllowedType(cfg *config.UploadConfig, contentType, name string, fileType domain.FileType) bool {
+	if fileType == domain.FileTypeInstaller {
+		return containsFold(cfg.InstallerTypes, contentType)
+	}
+	if isExecutableName(name) || installerOnlyTypes[contentType] {
+		return false
+	}
+	return containsFold(cfg.AllowedTypes, contentType)
+}
+
+// putUnique puts content under dir/name with a random suffix, so existing
+// content is never overwritten, and returns the key used
+func (u *fileUsecase) putUnique(
+	ctx context.Context,
+	dir string,
+	name string,
+	content *countingReader,
+	size int64,
+	contentType string,
+) (string, error) {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	for attempt := 1; ; attempt++ {
+		suffix, err := randomHex(6)
+		if err != nil {
+			return "", err
+		}
+
+		key := path.Join(dir, stem+"-"+suffix+ext)
+		err = u.storage.Put(ctx, key, content, size, contentType)
+		if err == nil {
+			return key, nil
+		}
+		// Retrying is only possible while nothing was consumed
+		if err != domain.ErrStorageKeyExists || content.n > 0 || attempt == 3 {
+			return "", err
+		}
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
 			return true
 		}
 	}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/config"
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OpenVariant opens a resized copy of a public image. Variants missing, or made
+// for another size than configured, are made on demand, so images uploaded
+// before variants existed get them too. The content outlives the call; the
+// caller closes it.
+func (u *fileUsecase) OpenVariant(ctx context.Context, id string, name string) (*domain.FileVariant, io.ReadCloser, *domain.StoredObject, error) {
+	spec, ok := u.variantSpec(name)
+	if !ok {
+		return nil, nil, nil, domain.ErrNotFound
+	}
+
+	file, err := u.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !file.IsPublic() || file.IsQuarantined() || !domain.IsVariantSource(file.MimeType) {
+		return nil, nil, nil, domain.ErrNotFound
+	}
+
+	variant := file.Variants[name]
+	for attempt := 1; ; attempt++ {
+		if variant == nil || variant.MaxSize != spec.MaxSize {
+			variants, err := u.makeVariants(ctx, file, []config.ImageVariant{spec})
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			variant = variants[name]
+		}
+
+		content, object, err := u.storage.Get(ctx, variant.Key)
+		if err == domain.ErrNotFound && attempt == 1 {
+			// Lost from the storage, make it again
+			variant = nil
+			continue
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		object.ContentType = variant.MimeType
+		return variant, content, object, nil
+	}
+}
+
+// makeAllVariants makes every configured variant of a new image. Failures are
+// only logged, the variants are made on demand later.
+func (u *fileUsecase) makeAllVariants(ctx context.Context, file *domain.File) {
+	if len(u.uploadConfig.ImageVariants) == 0 || !domain.IsVariantSource(file.MimeType) {
+		return
+	}
+	if _, err := u.makeVariants(ctx, file, u.uploadConfig.ImageVariants); err != nil && err != domain.ErrNotFound {
+		log.Printf("Failed to make the variants of image %s: %v", file.ID.Hex(), err)
+	}
+}
+
+// makeVariants resizes an image to the given variants, stores them next to it
+// and records them on the file, replacing previous ones
+func (u *fileUsecase) makeVariants(ctx context.Context, file *domain.File, specs []config.ImageVariant) (map[string]*domain.FileVariant, error) {
+	original, _, err := u.storage.Get(ctx, file.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(original)
+	original.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Images too large or broken to decode have no variants
+	img, err := imaging.Decode(data)
+	if err != nil {
+		log.Printf("Failed to decode image %s: %v", file.ID.Hex(), err)
+		return nil, domain.ErrNotFound
+	}
+
+	variants := make(map[string]*domain.FileVariant, len(specs))
+	for _, spec := range specs {
+		variant, err := u.storeVariant(ctx, file, spec, img)
+		if err != nil {
+			return nil, err
+		}
+		variants[spec.Name] = variant
+	}
+	return variants, nil
+}
+
+// storeVariant encodes one variant of a decoded image and records it. JPEGs
+// stay JPEGs; PNGs and GIFs become PNGs, which keep transparency.
+func (u *fileUsecase) storeVariant(ctx context.Context, file *domain.File, spec config.ImageVariant, img *image.RGBA) (*domain.FileVariant, error) {
+	resized := imaging.Fit(img, spec.MaxSize)
+
+	var buf bytes.Buffer
+	mimeType, ext := "image/png", ".png"
+	if file.MimeType == "image/jpeg" {
+		mimeType, ext = "image/jpeg", ".jpg"
+		if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: u.imageQuality()}); err != nil {
+			return nil, err
+		}
+	} else if err := png.Encode(&buf, resized); err != nil {
+		return nil, err
+	}
+
+	suffix, err := randomHex(6)
+	if err != nil {
+		return nil, err
+	}
+	key := variantKey(file.ID, spec.Name+"-"+suffix+ext)
+	size := int64(buf.Len())
+	if err := u.storage.Put(ctx, key, &buf, size, mimeType); err != nil {
+		return nil, err
+	}
+
+	variant := &domain.FileVariant{
+		Key:       key,
+		MimeType:  mimeType,
+		Width:     resized.Rect.Dx(),
+		Height:    resized.Rect.Dy(),
+		Size:      size,
+		MaxSize:   spec.MaxSize,
+		URL:       fmt.Sprintf("%s/api/v1/files/%s/variants/%s", strings.TrimRight(u.uploadConfig.BaseURL, "/"), file.ID.Hex(), spec.Name),
+		CreatedOn: time.Now(),
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	if err := u.fileRepo.SetVariant(dbCtx, file.ID, spec.Name, variant); err != nil {
+		_ = u.storage.Delete(context.WithoutCancel(ctx), key)
+		return nil, err
+	}
+
+	if previous := file.Variants[spec.Name]; previous != nil {
+		_ = u.storage.Delete(context.WithoutCancel(ctx), previous.Key)
+	}
+	if file.Variants == nil {
+		file.Variants = map[string]*domain.FileVariant{}
+	}
+	file.Variants[spec.Name] = variant
+
+	return variant, nil
+}
+
+// deleteVariants deletes every stored variant of a file, including any left
+// over by concurrent requests making the same variant
+func (u *fileUsecase) deleteVariants(ctx context.Context, id primitive.ObjectID) error {
+	objects, err := u.storage.List(ctx, variantKey(id, ""))
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := u.storage.Delete(ctx, object.Key); err != nil && err != domain.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// variantSpec returns the configured variant of a name
+func (u *fileUsecase) variantSpec(name string) (config.ImageVariant, bool) {
+	for _, spec := range u.uploadConfig.ImageVariants {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return config.ImageVariant{}, false
+}
+
+func (u *fileUsecase) imageQuality() int {
+	if q := u.uploadConfig.ImageQuality; q >= 1 && q <= 100 {
+		return q
+	}
+	return jpeg.DefaultQuality
+}
+
+// variantKey returns the storage key of a variant of a file
+func variantKey(fileID primitive.ObjectID, name string) string {
+	return "variants/" + fileID.Hex() + "/" + name
+}
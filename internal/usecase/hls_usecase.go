@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// hlsPackageBatch is how many videos a packaging run handles at most
+	hlsPackageBatch = 10
+
+	// hlsStaleAfter is how long a video can stay processing before another run
+	// assumes the first one died and packages it again
+	hlsStaleAfter = 2 * time.Hour
+)
+
+var errNoPlaylist = errors.New("transcoder wrote no playlist")
+
+type hlsUsecase struct {
+	fileRepo        domain.FileRepository
+	storage         domain.FileStorage
+	transcoder      domain.Transcoder
+	baseURL         string
+	segmentDuration time.Duration
+	contextTimeout  time.Duration
+}
+
+// NewHLSUsecase creates a new usecase packaging MP4 videos as HLS with
+// transcoder. Packages are stored next to the videos, under hls/<file id>/.
+func NewHLSUsecase(
+	fileRepo domain.FileRepository,
+	storage domain.FileStorage,
+	transcoder domain.Transcoder,
+	baseURL string,
+	segmentDuration time.Duration,
+	timeout time.Duration,
+) domain.HLSUsecase {
+	return &hlsUsecase{
+		fileRepo:        fileRepo,
+		storage:         storage,
+		transcoder:      transcoder,
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		segmentDuration: segmentDuration,
+		contextTimeout:  timeout,
+	}
+}
+
+// PackagePending packages the MP4 videos waiting for it, oldest first, and
+// returns how many are ready. A video that cannot be packaged is marked failed
+// and can be requeued.
+func (u *hlsUsecase) PackagePending(ctx context.Context) (int, error) {
+	ready := 0
+	for i := 0; i < hlsPackageBatch; i++ {
+		claimCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+		file, err := u.fileRepo.ClaimHLS(claimCtx, time.Now().Add(-hlsStaleAfter))
+		cancel()
+		if err == domain.ErrNotFound {
+			return ready, nil
+		}
+		if err != nil {
+			return ready, err
+		}
+
+		hls := &domain.HLSPackage{Status: domain.HLSStatusReady}
+		hls.Segments, err = u.packageFile(ctx, file)
+		switch {
+		case err == nil:
+			hls.PlaylistURL = fmt.Sprintf("%s/videos/hls/%s/%s", u.baseURL, file.ID.Hex(), domain.HLSPlaylistName)
+		case ctx.Err() != nil:
+			// Stopped by a shutdown, the next run starts over
+			hls = &domain.HLSPackage{Status: domain.HLSStatusPending}
+		default:
+			log.Printf("Failed to package video %s as HLS: %v", file.ID.Hex(), err)
+			hls = &domain.HLSPackage{Status: domain.HLSStatusFailed, Error: err.Error()}
+		}
+		if hls.Status != domain.HLSStatusReady {
+			u.deletePackage(context.WithoutCancel(ctx), file.ID)
+		}
+
+		setCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), u.contextTimeout)
+		err = u.fileRepo.SetHLS(setCtx, file.ID, hls)
+		if err == domain.ErrNotFound {
+			// Deleted while packaging
+			u.deletePackage(setCtx, file.ID)
+		}
+		cancel()
+		if err != nil && err != domain.ErrNotFound {
+			return ready, err
+		}
+		if ctx.Err() != nil {
+			return ready, ctx.Err()
+		}
+		if hls.Status == domain.HLSStatusReady {
+			ready++
+		}
+	}
+	return ready, nil
+}
+
+// packageFile segments a video in a temporary directory and stores the package,
+// the playlist last. Returns the number of segments.
+func (u *hlsUsecase) packageFile(ctx context.Context, file *domain.File) (int, error) {
+	// Drop what an earlier run left behind, keys are never overwritten
+	u.deletePackage(ctx, file.ID)
+
+	workDir, err := os.MkdirTemp("", "hls-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "source.mp4")
+	if err := u.download(ctx, file.FilePath, input); err != nil {
+		return 0, err
+	}
+
+	outputDir := filepath.Join(workDir, "hls")
+	if err := os.Mkdir(outputDir, 0755); err != nil {
+		return 0, err
+	}
+	if err := u.transcoder.SegmentHLS(ctx, input, outputDir, u.segmentDuration); err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return 0, err
+	}
+	segments := 0
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == domain.HLSPlaylistName {
+			continue
+		}
+		if err := u.put(ctx, file.ID, outputDir, entry.Name()); err != nil {
+			return 0, err
+		}
+		segments++
+	}
+
+	if err := u.put(ctx, file.ID, outputDir, domain.HLSPlaylistName); err != nil {
+		if os.IsNotExist(err) {
+			return 0, errNoPlaylist
+		}
+		return 0, err
+	}
+	return segments, nil
+}
+
+// download copies the stored content of a key to a local file for the transcoder
+func (u *hlsUsecase) download(ctx context.Context, key string, name string) error {
+	content, _, err := u.storage.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	dst, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, content); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// put stores a file of the package
+func (u *hlsUsecase) put(ctx context.Context, fileID primitive.ObjectID, dir string, name string) error {
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return u.storage.Put(ctx, hlsKey(fileID, name), f, info.Size(), hlsContentType(name))
+}
+
+// deletePackage deletes the stored package of a video
+func (u *hlsUsecase) deletePackage(ctx context.Context, fileID primitive.ObjectID) {
+	if err := deleteHLSPackage(ctx, u.storage, fileID); err != nil {
+		log.Printf("Failed to delete the HLS package of video %s: %v", fileID.Hex(), err)
+	}
+}
+
+// Requeue packages a video again on the next run, after a failure or to pick
+// up a new transcoder
+func (u *hlsUsecase) Requeue(ctx context.Context, fileID string) (*domain.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	file, err := u.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.FileType != domain.FileTypeVideo || file.MimeType != domain.HLSMimeType {
+		return nil, domain.ErrInvalidInput
+	}
+	if file.HLS != nil && file.HLS.Status == domain.HLSStatusProcessing {
+		return nil, domain.ErrHLSInProgress
+	}
+
+	file.HLS = &domain.HLSPackage{Status: domain.HLSStatusPending}
+	if err := u.fileRepo.SetHLS(ctx, file.ID, file.HLS); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Open opens a playlist or segment of the package of a video. The content
+// outlives the call, so it is not bound to the usecase timeout.
+func (u *hlsUsecase) Open(ctx context.Context, fileID string, name string) (io.ReadCloser, *domain.StoredObject, error) {
+	id, err := primitive.ObjectIDFromHex(fileID)
+	if err != nil {
+		return nil, nil, domain.ErrInvalidID
+	}
+	if name == "" || path.Base(name) != name || strings.HasPrefix(name, ".") {
+		return nil, nil, domain.ErrNotFound
+	}
+
+	// Packages of videos made private or rescanned stay stored, but are not served
+	lookupCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	file, err := u.fileRepo.GetByID(lookupCtx, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !file.IsPublic() || file.IsQuarantined() {
+		return nil, nil, domain.ErrNotFound
+	}
+
+	content, object, err := u.storage.Get(ctx, hlsKey(id, name))
+	if err != nil {
+		return nil, nil, err
+	}
+	object.ContentType = hlsContentType(name)
+	return content, object, nil
+}
+
+// hlsKey returns the storage key of a file of the HLS package of a video
+func hlsKey(fileID primitive.ObjectID, name string) string {
+	return "hls/" + fileID.Hex() + "/" + name
+}
+
+// deleteHLSPackage deletes every stored file of the HLS package of a video
+func deleteHLSPackage(ctx context.Context, storage domain.FileStorage, fileID primitive.ObjectID) error {
+	objects, err := storage.List(ctx, hlsKey(fileID, ""))
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := storage.Delete(ctx, object.Key); err != nil && err != domain.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// hlsContentType returns the content type of a file of an HLS package
+func hlsContentType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".mp4":
+		return "video/mp4"
+	}
+	return "application/octet-stream"
+}
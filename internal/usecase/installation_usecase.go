@@ -0,0 +1,394 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/config"
+	"icafe-registration/internal/domain"
+)
+
+// activationCodeAlphabet leaves out characters that are easily misread (0/O, 1/I)
+const activationCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type installationUsecase struct {
+	installationRepo domain.InstallationRepository
+	customerRepo     domain.CustomerRepository
+	shopRepo         domain.ShopRepository
+	config           *config.InstallationConfig
+	contextTimeout   time.Duration
+}
+
+// NewInstallationUsecase creates a new installation usecase
+func NewInstallationUsecase(
+	repo domain.InstallationRepository,
+	customerRepo domain.CustomerRepository,
+	shopRepo domain.ShopRepository,
+	cfg *config.InstallationConfig,
+	timeout time.Duration,
+) domain.InstallationUsecase {
+	return &installationUsecase{
+		installationRepo: repo,
+		customerRepo:     customerRepo,
+		shopRepo:         shopRepo,
+		config:           cfg,
+		contextTimeout:   timeout,
+	}
+}
+
+// Create registers an installation for a customer and generates its activation code
+func (u *installationUsecase) Create(ctx context.Context, customerID string, req *domain.CreateInstallationRequest) (*domain.Installation, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	shopID, err := resolveShop(ctx, u.shopRepo, customer.ID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	installation := &domain.Installation{
+		CustomerID: customer.ID,
+		ShopID:     shopID,
+		Name:       req.Name,
+		Status:     domain.InstallationPending,
+		SeatLimit:  req.SeatLimit,
+	}
+
+	// Retry on the rare activation code collision
+	for attempt := 0; ; attempt++ {
+		if installation.ActivationCode, err = newActivationCode(); err != nil {
+			return nil, err
+		}
+		err = u.installationRepo.Create(ctx, installation)
+		if err != domain.ErrAlreadyExists || attempt == 2 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u.markHealth(installation, customer, time.Now())
+	return installation, nil
+}
+
+// GetByCustomer gets the installations of a customer with their health flags
+func (u *installationUsecase) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Installation, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	installations, err := u.installationRepo.GetByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, installation := range installations {
+		u.markHealth(installation, customer, now)
+	}
+
+	return installations, nil
+}
+
+// GetAttention lists the active installations that are stale or over their seat limit, by customer
+func (u *installationUsecase) GetAttention(ctx context.Context) ([]*domain.CustomerInstallations, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	installations, err := u.installationRepo.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	groups := []*domain.CustomerInstallations{}
+	var (
+		group    *domain.CustomerInstallations
+		customer *domain.Customer
+	)
+
+	// Installations are sorted by customer, so each customer is loaded once
+	for _, installation := range installations {
+		if customer == nil || customer.ID != installation.CustomerID {
+			customer, err = u.customerRepo.GetByID(ctx, installation.CustomerID.Hex())
+			if err == domain.ErrNotFound {
+				customer = nil
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			group = nil
+		}
+
+		u.markHealth(installation, customer, now)
+		if !installation.Stale && !installation.OverLimit {
+			continue
+		}
+
+		if group == nil {
+			group = &domain.CustomerInstallations{
+				CustomerID:   customer.ID.Hex(),
+				CustomerName: customer.FullName,
+			}
+			groups = append(groups, group)
+		}
+		group.Installations = append(group.Installations, installation)
+	}
+
+	return groups, nil
+}
+
+// Update renames an installation or changes its seat limit
+func (u *installationUsecase) Update(ctx context.Context, id string, req *domain.UpdateInstallationRequest) (*domain.Installation, error) {
+	return u.change(ctx, id, func(ctx context.Context, installation *domain.Installation) error {
+		if req.ShopID != nil {
+			shopID, err := resolveShop(ctx, u.shopRepo, installation.CustomerID, *req.ShopID)
+			if err != nil {
+				return err
+			}
+			installation.ShopID = shopID
+		}
+		if req.Name != "" {
+			installation.Name = req.Name
+		}
+		if req.SeatLimit != nil {
+			installation.SeatLimit = req.SeatLimit
+			if *req.SeatLimit == 0 {
+				installation.SeatLimit = nil
+			}
+		}
+		return nil
+	})
+}
+
+// Reset unbinds an installation from its machine and issues a new activation code,
+// for example when the cafe replaces its server
+func (u *installationUsecase) Reset(ctx context.Context, id string) (*domain.Installation, error) {
+	return u.change(ctx, id, func(ctx context.Context, installation *domain.Installation) error {
+		code, err := newActivationCode()
+		if err != nil {
+			return err
+		}
+
+		installation.Status = domain.InstallationPending
+		installation.ActivationCode = code
+		installation.Fingerprint = ""
+		installation.TokenHash = ""
+		installation.ActivatedAt = nil
+		return nil
+	})
+}
+
+// change applies fn to an installation and saves it
+func (u *installationUsecase) change(ctx context.Context, id string, fn func(ctx context.Context, installation *domain.Installation) error) (*domain.Installation, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	installation, err := u.installationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fn(ctx, installation); err != nil {
+		return nil, err
+	}
+
+	if err := u.installationRepo.Update(ctx, installation); err != nil {
+		return nil, err
+	}
+
+	customer, err := u.customerRepo.GetByID(ctx, installation.CustomerID.Hex())
+	if err != nil && err != domain.ErrNotFound {
+		return nil, err
+	}
+	u.markHealth(installation, customer, time.Now())
+
+	return installation, nil
+}
+
+// Delete deletes an installation
+func (u *installationUsecase) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.installationRepo.Delete(ctx, id)
+}
+
+// Activate binds a pending installation to the machine of a client and returns its token.
+// Activating again from the same machine issues a new token, so a reinstall keeps working.
+func (u *installationUsecase) Activate(ctx context.Context, req *domain.ActivateInstallationRequest) (*domain.InstallationSession, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	installation, err := u.installationRepo.GetByActivationCode(ctx, normalizeActivationCode(req.ActivationCode))
+	if err == domain.ErrNotFound {
+		return nil, domain.ErrInvalidActivationCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if installation.Status == domain.InstallationActive && installation.Fingerprint != req.Fingerprint {
+		return nil, domain.ErrInstallationActivated
+	}
+
+	customer, err := u.customerRepo.GetByID(ctx, installation.CustomerID.Hex())
+	if err == domain.ErrNotFound {
+		return nil, domain.ErrInvalidActivationCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	installation.Status = domain.InstallationActive
+	installation.Fingerprint = req.Fingerprint
+	installation.TokenHash = hashToken(token)
+	if installation.ActivatedAt == nil {
+		installation.ActivatedAt = &now
+	}
+
+	if err := u.installationRepo.Update(ctx, installation); err != nil {
+		return nil, err
+	}
+	if err := u.installationRepo.RecordHeartbeat(ctx, installation.ID, req.Version, installation.ActiveWorkstations, now); err != nil {
+		return nil, err
+	}
+
+	session := u.session(installation, customer)
+	session.Token = token
+	return session, nil
+}
+
+// Heartbeat records the status reported by a client and enforces its seat limit.
+// The heartbeat is recorded even when the limit is exceeded; the session is then
+// returned together with ErrSeatLimitExceeded so the client can lock extra seats.
+func (u *installationUsecase) Heartbeat(ctx context.Context, req *domain.HeartbeatRequest) (*domain.InstallationSession, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	installation, err := u.installationRepo.GetByID(ctx, req.InstallationID)
+	if err == domain.ErrNotFound || err == domain.ErrInvalidID {
+		return nil, domain.ErrInvalidInstallationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if installation.Status != domain.InstallationActive ||
+		subtle.ConstantTimeCompare([]byte(installation.TokenHash), []byte(hashToken(req.Token))) != 1 {
+		return nil, domain.ErrInvalidInstallationToken
+	}
+
+	customer, err := u.customerRepo.GetByID(ctx, installation.CustomerID.Hex())
+	if err == domain.ErrNotFound {
+		return nil, domain.ErrInvalidInstallationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.installationRepo.RecordHeartbeat(ctx, installation.ID, req.Version, req.ActiveWorkstations, time.Now()); err != nil {
+		return nil, err
+	}
+
+	session := u.session(installation, customer)
+	if req.ActiveWorkstations > session.AllowedSeats {
+		return session, domain.ErrSeatLimitExceeded
+	}
+
+	return session, nil
+}
+
+// session builds the response sent to a client
+func (u *installationUsecase) session(installation *domain.Installation, customer *domain.Customer) *domain.InstallationSession {
+	return &domain.InstallationSession{
+		InstallationID:    installation.ID.Hex(),
+		AllowedSeats:      allowedSeats(installation, customer),
+		HeartbeatInterval: int(u.config.HeartbeatInterval / time.Second),
+	}
+}
+
+// markHealth computes the allowed seats, stale and over-limit flags of an installation
+func (u *installationUsecase) markHealth(installation *domain.Installation, customer *domain.Customer, now time.Time) {
+	installation.AllowedSeats = allowedSeats(installation, customer)
+
+	if installation.Status != domain.InstallationActive {
+		return
+	}
+	installation.OverLimit = installation.ActiveWorkstations > installation.AllowedSeats
+	installation.Stale = installation.LastSeenAt == nil || installation.LastSeenAt.Before(now.Add(-u.config.StaleAfter))
+}
+
+// allowedSeats returns the admin seat limit, or the seats of the customer's workstation range
+func allowedSeats(installation *domain.Installation, customer *domain.Customer) int {
+	if installation.SeatLimit != nil {
+		return *installation.SeatLimit
+	}
+	if customer == nil {
+		return 0
+	}
+	return domain.WorkstationRangeSeats[customer.WorkstationRange]
+}
+
+// newActivationCode generates a code like "K7PQM-2XRTA"
+func newActivationCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, 0, 11)
+	for i, b := range buf {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		code = append(code, activationCodeAlphabet[int(b)%len(activationCodeAlphabet)])
+	}
+	return string(code), nil
+}
+
+// normalizeActivationCode accepts codes typed in lower case or without the dash
+func normalizeActivationCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) == 10 {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken returns the SHA-256 of a token, so tokens are never stored in clear
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
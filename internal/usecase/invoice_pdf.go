@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/pdf"
+)
+
+// invoiceColumns are the columns of the item table
+var invoiceColumns = []tableColumn{
+	{"STT", 30, true},
+	{"Tên hàng hóa, dịch vụ", 220, false},
+	{"ĐVT", 50, false},
+	{"Số lượng", 50, true},
+	{"Đơn giá", 80, true},
+	{"Thành tiền", 85.28, true},
+}
+
+// invoicePage draws an invoice
+type invoicePage struct {
+	*documentPage
+}
+
+// renderPDF writes an invoice as a PDF
+func (u *invoiceUsecase) renderPDF(invoice *domain.Invoice, w io.Writer) error {
+	doc := pdf.New()
+	doc.SetFonts(u.regularFont, u.boldFont)
+	doc.SetTitle(invoiceTitle(invoice))
+	doc.AddPage()
+
+	p := &invoicePage{&documentPage{doc: doc, y: pageMargin, columns: invoiceColumns}}
+	p.seller(u.config)
+	p.title(invoice)
+	p.buyer(invoice)
+	p.items(invoice)
+	p.totals(invoice)
+	p.signatures("Người mua hàng", "Người bán hàng")
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func invoiceTitle(invoice *domain.Invoice) string {
+	if invoice.Number == "" {
+		return "Hóa đơn nháp"
+	}
+	return fmt.Sprintf("Hóa đơn %s số %s", invoice.Series, invoice.Number)
+}
+
+func (p *invoicePage) title(invoice *domain.Invoice) {
+	center := p.doc.Width() / 2
+
+	p.doc.SetFont(true, 16)
+	p.doc.TextCenter(center, p.y+16, "HÓA ĐƠN GIÁ TRỊ GIA TĂNG")
+	p.y += 24
+
+	p.doc.SetFont(false, 10)
+	if invoice.IssuedAt != nil {
+		p.doc.TextCenter(center, p.y+10, fmt.Sprintf("Ngày %02d tháng %02d năm %d",
+			invoice.IssuedAt.Day(), invoice.IssuedAt.Month(), invoice.IssuedAt.Year()))
+		p.y += pageLineHeight
+		p.doc.TextCenter(center, p.y+10, fmt.Sprintf("Ký hiệu: %s    Số: %s", invoice.Series, invoice.Number))
+		p.y += pageLineHeight
+	}
+
+	p.doc.SetFont(true, 11)
+	switch invoice.Status {
+	case domain.InvoiceDraft:
+		p.doc.TextCenter(center, p.y+12, "BẢN NHÁP - KHÔNG CÓ GIÁ TRỊ THANH TOÁN")
+		p.y += pageLineHeight + 2
+	case domain.InvoiceVoid:
+		p.doc.TextCenter(center, p.y+12, "HÓA ĐƠN ĐÃ HỦY")
+		p.y += pageLineHeight + 2
+		p.doc.SetFont(false, 10)
+		p.doc.TextCenter(center, p.y+10, "Lý do: "+invoice.VoidReason)
+		p.y += pageLineHeight
+	}
+	p.y += 12
+}
+
+func (p *invoicePage) buyer(invoice *domain.Invoice) {
+	p.line("Họ tên người mua hàng:", invoice.Buyer.Name)
+	p.line("Tên đơn vị:", invoice.Buyer.CompanyName)
+	p.line("Mã số thuế:", invoice.Buyer.TaxCode)
+	p.line("Địa chỉ:", invoice.Buyer.Address)
+	p.line("Điện thoại:", invoice.Buyer.Phone)
+	p.line("Email:", invoice.Buyer.Email)
+	p.y += 10
+}
+
+func (p *invoicePage) items(invoice *domain.Invoice) {
+	p.header()
+	for i, item := range invoice.Items {
+		p.row(false, []string{
+			fmt.Sprint(i + 1),
+			item.Description,
+			item.Unit,
+			formatVND(item.Quantity),
+			formatVND(item.UnitPrice),
+			formatVND(item.Amount),
+		})
+	}
+	p.y += 10
+}
+
+func (p *invoicePage) totals(invoice *domain.Invoice) {
+	p.amount("Cộng tiền hàng:", formatVND(invoice.Subtotal), false)
+	p.amount(fmt.Sprintf("Thuế suất GTGT: %d%%   Tiền thuế GTGT:", invoice.VATRate), formatVND(invoice.VATAmount), false)
+	p.amount("Tổng cộng tiền thanh toán:", formatVND(invoice.Total), true)
+	p.y += 4
+	p.line("Số tiền viết bằng chữ:", amountInWords(invoice.Total))
+	p.line("Ghi chú:", invoice.Note)
+	p.y += 20
+}
+
+var vietnameseDigits = [...]string{"không", "một", "hai", "ba", "bốn", "năm", "sáu", "bảy", "tám", "chín"}
+
+// readHundreds reads a number below 1000. full reads the zero hundreds of groups
+// that follow a higher group, as in "một triệu không trăm linh năm nghìn".
+func readHundreds(n int64, full bool) string {
+	hundreds, tens, units := n/100, n/10%10, n%10
+
+	words := []string{}
+	if full || hundreds > 0 {
+		words = append(words, vietnameseDigits[hundreds], "trăm")
+	}
+
+	switch {
+	case tens == 0 && units > 0 && len(words) > 0:
+		words = append(words, "linh")
+	case tens == 1:
+		words = append(words, "mười")
+	case tens > 1:
+		words = append(words, vietnameseDigits[tens], "mươi")
+	}
+
+	switch {
+	case units == 0:
+	case units == 1 && tens > 1:
+		words = append(words, "mốt")
+	case units == 4 && tens > 1:
+		words = append(words, "tư")
+	case units == 5 && tens > 0:
+		words = append(words, "lăm")
+	default:
+		words = append(words, vietnameseDigits[units])
+	}
+
+	return strings.Join(words, " ")
+}
+
+// amountInWords spells an amount in Vietnamese dong, as printed on invoices
+func amountInWords(amount int64) string {
+	if amount <= 0 {
+		return "Không đồng"
+	}
+
+	var groups []int64
+	for n := amount; n > 0; n /= 1000 {
+		groups = append(groups, n%1000)
+	}
+
+	scales := [...]string{"", "nghìn", "triệu"}
+	words := []string{}
+	for i := len(groups) - 1; i >= 0; i-- {
+		if groups[i] == 0 {
+			continue
+		}
+		words = append(words, readHundreds(groups[i], i < len(groups)-1))
+		if scale := strings.TrimSpace(scales[i%3] + strings.Repeat(" tỷ", i/3)); scale != "" {
+			words = append(words, scale)
+		}
+	}
+
+	text := strings.Join(words, " ") + " đồng"
+	first, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(first)) + text[size:]
+}
@@ -0,0 +1,461 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/config"
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/pdf"
+)
+
+type invoiceUsecase struct {
+	invoiceRepo      domain.InvoiceRepository
+	customerRepo     domain.CustomerRepository
+	subscriptionRepo domain.SubscriptionRepository
+	fileUsecase      domain.FileUsecase
+	commissions      domain.CommissionRecorder
+	config           *config.InvoiceConfig
+	regularFont      *pdf.Font
+	boldFont         *pdf.Font
+	contextTimeout   time.Duration
+}
+
+// NewInvoiceUsecase creates a new invoice usecase. Paid invoices earn commissions
+// through commissions. PDFs use the given fonts, or the standard PDF fonts without
+// Vietnamese diacritics when they are nil.
+func NewInvoiceUsecase(
+	repo domain.InvoiceRepository,
+	customerRepo domain.CustomerRepository,
+	subscriptionRepo domain.SubscriptionRepository,
+	fileUsecase domain.FileUsecase,
+	commissions domain.CommissionRecorder,
+	cfg *config.InvoiceConfig,
+	regularFont, boldFont *pdf.Font,
+	timeout time.Duration,
+) domain.InvoiceUsecase {
+	return &invoiceUsecase{
+		invoiceRepo:      repo,
+		customerRepo:     customerRepo,
+		subscriptionRepo: subscriptionRepo,
+		fileUsecase:      fileUsecase,
+		commissions:      commissions,
+		config:           cfg,
+		regularFont:      regularFont,
+		boldFont:         boldFont,
+		contextTimeout:   timeout,
+	}
+}
+
+// Create drafts an invoice for a customer
+func (u *invoiceUsecase) Create(ctx context.Context, customerID string, req *domain.CreateInvoiceRequest, actor *domain.Actor) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	vatRate := u.config.VATRate
+	if req.VATRate != nil {
+		vatRate = *req.VATRate
+	}
+
+	invoice := &domain.Invoice{
+		CustomerID: customer.ID,
+		Buyer:      mergeBuyer(req.Buyer, customerBuyer(customer)),
+		Items:      req.Items,
+		VATRate:    vatRate,
+		Status:     domain.InvoiceDraft,
+		Note:       req.Note,
+		CreatedBy:  actor.ID,
+	}
+	if err := computeInvoiceTotals(invoice); err != nil {
+		return nil, err
+	}
+
+	if err := u.invoiceRepo.Create(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// CreateForSubscription drafts the invoice of the next billing period of a subscription,
+// which starts at its renewal date
+func (u *invoiceUsecase) CreateForSubscription(ctx context.Context, subscriptionID string, actor *domain.Actor) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	subscription, err := u.subscriptionRepo.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status == domain.SubscriptionCancelled {
+		return nil, domain.ErrSubscriptionCancelled
+	}
+
+	customer, err := u.customerRepo.GetByID(ctx, subscription.CustomerID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	periodStart := subscription.NextRenewalAt
+	periodEnd := periodStart.AddDate(0, subscription.BillingCycle.Months(), 0).AddDate(0, 0, -1)
+
+	invoice := &domain.Invoice{
+		CustomerID:     customer.ID,
+		SubscriptionID: &subscription.ID,
+		Buyer:          customerBuyer(customer),
+		Items: []domain.InvoiceItem{{
+			Description: fmt.Sprintf("Gói %s (%s máy), kỳ %s - %s",
+				subscription.PlanName, subscription.WorkstationRange,
+				periodStart.Format("02/01/2006"), periodEnd.Format("02/01/2006")),
+			Unit:      billingCycleLabel(subscription.BillingCycle),
+			Quantity:  1,
+			UnitPrice: subscription.Price,
+		}},
+		VATRate:   u.config.VATRate,
+		Status:    domain.InvoiceDraft,
+		CreatedBy: actor.ID,
+	}
+	if err := computeInvoiceTotals(invoice); err != nil {
+		return nil, err
+	}
+
+	if err := u.invoiceRepo.Create(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// GetByID gets an invoice by ID
+func (u *invoiceUsecase) GetByID(ctx context.Context, id string) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.invoiceRepo.GetByID(ctx, id)
+}
+
+// GetAll gets invoices matching filter with pagination
+func (u *invoiceUsecase) GetAll(ctx context.Context, filter *domain.InvoiceFilter, page *domain.Pagination) ([]*domain.Invoice, *domain.PageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	invoices, err := u.invoiceRepo.GetAll(ctx, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(invoices); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, invoices[n-1].CreatedOn, invoices[n-1].ID)
+	}
+
+	count := func(ctx context.Context) (int64, error) {
+		return u.invoiceRepo.Count(ctx, filter)
+	}
+	if err := countPage(ctx, page, info, count, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return invoices, info, nil
+}
+
+// GetByCustomer gets the invoices of a customer, newest first
+func (u *invoiceUsecase) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	return u.invoiceRepo.GetByCustomer(ctx, customerID)
+}
+
+// Update edits a draft invoice
+func (u *invoiceUsecase) Update(ctx context.Context, id string, req *domain.UpdateInvoiceRequest) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	invoice, err := u.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != domain.InvoiceDraft {
+		return nil, domain.ErrInvoiceNotDraft
+	}
+
+	if req.Buyer != nil {
+		invoice.Buyer = *req.Buyer
+	}
+	if req.Items != nil {
+		invoice.Items = req.Items
+	}
+	if req.VATRate != nil {
+		invoice.VATRate = *req.VATRate
+	}
+	if req.Note != nil {
+		invoice.Note = *req.Note
+	}
+	if err := computeInvoiceTotals(invoice); err != nil {
+		return nil, err
+	}
+
+	if err := u.invoiceRepo.UpdateDraft(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// Issue numbers a draft invoice and stores its PDF. A PDF that fails to be stored
+// does not undo the issue; it can be generated again.
+func (u *invoiceUsecase) Issue(ctx context.Context, id string) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	invoice, err := u.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != domain.InvoiceDraft {
+		return nil, domain.ErrInvoiceNotDraft
+	}
+	if invoice.Buyer.Name == "" && invoice.Buyer.CompanyName == "" {
+		return nil, domain.ErrInvoiceBuyerRequired
+	}
+	if err := computeInvoiceTotals(invoice); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invoice.Series = u.series(now)
+	invoice.IssuedAt = &now
+
+	if err := u.invoiceRepo.Issue(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	if err := u.storePDF(ctx, invoice); err != nil {
+		log.Printf("Failed to store PDF of invoice %s: %v", invoice.ID.Hex(), err)
+	}
+
+	return invoice, nil
+}
+
+// MarkPaid records the payment of an issued invoice. A customer referred by a
+// reseller earns the reseller a commission on the amount before VAT.
+func (u *invoiceUsecase) MarkPaid(ctx context.Context, id string, req *domain.PayInvoiceRequest) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	invoice, err := u.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != domain.InvoiceIssued {
+		return nil, domain.ErrInvoiceStatus
+	}
+
+	paidAt := time.Now()
+	if req.PaidAt != nil {
+		paidAt = *req.PaidAt
+	}
+	invoice.Status = domain.InvoicePaid
+	invoice.PaidAt = &paidAt
+	invoice.PaymentRef = req.PaymentRef
+
+	if err := u.invoiceRepo.UpdateStatus(ctx, invoice, domain.InvoiceIssued); err != nil {
+		return nil, err
+	}
+
+	payment := &domain.ReferredPayment{
+		CustomerID: invoice.CustomerID,
+		Source:     domain.CommissionFromInvoice,
+		SourceID:   invoice.ID,
+		SourceRef:  invoice.Number,
+		PaymentRef: invoice.PaymentRef,
+		BaseAmount: invoice.Subtotal,
+		PaidAt:     paidAt,
+	}
+	if _, err := u.commissions.RecordPayment(ctx, payment); err != nil {
+		log.Printf("Failed to record the commission of invoice %s: %v", invoice.Number, err)
+	}
+
+	return invoice, nil
+}
+
+// Void cancels an issued or paid invoice. It keeps its number and its PDF is
+// generated again with the void mark. The commission a paid invoice earned is
+// cancelled unless it was paid out.
+func (u *invoiceUsecase) Void(ctx context.Context, id string, req *domain.VoidInvoiceRequest) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	invoice, err := u.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != domain.InvoiceIssued && invoice.Status != domain.InvoicePaid {
+		return nil, domain.ErrInvoiceStatus
+	}
+
+	from := invoice.Status
+	now := time.Now()
+	invoice.Status = domain.InvoiceVoid
+	invoice.VoidedAt = &now
+	invoice.VoidReason = req.Reason
+
+	if err := u.invoiceRepo.UpdateStatus(ctx, invoice, from); err != nil {
+		return nil, err
+	}
+
+	if from == domain.InvoicePaid {
+		if err := u.commissions.CancelPayment(ctx, domain.CommissionFromInvoice, invoice.ID, "Hóa đơn bị hủy: "+req.Reason); err != nil {
+			log.Printf("Failed to cancel the commission of invoice %s: %v", invoice.Number, err)
+		}
+	}
+
+	if err := u.storePDF(ctx, invoice); err != nil {
+		log.Printf("Failed to store PDF of invoice %s: %v", invoice.ID.Hex(), err)
+	}
+
+	return invoice, nil
+}
+
+// Delete deletes a draft invoice. Issued invoices are voided instead.
+func (u *invoiceUsecase) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.invoiceRepo.DeleteDraft(ctx, id)
+}
+
+// RenderPDF writes the PDF of an invoice without storing it, e.g. to preview a draft
+func (u *invoiceUsecase) RenderPDF(ctx context.Context, id string, w io.Writer) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	invoice, err := u.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoice, u.renderPDF(invoice, w)
+}
+
+// RegeneratePDF generates and stores the PDF of a numbered invoice again
+func (u *invoiceUsecase) RegeneratePDF(ctx context.Context, id string) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	invoice, err := u.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == domain.InvoiceDraft {
+		return nil, domain.ErrInvoiceStatus
+	}
+
+	if err := u.storePDF(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// storePDF renders an invoice, stores it as a file and replaces its previous PDF
+func (u *invoiceUsecase) storePDF(ctx context.Context, invoice *domain.Invoice) error {
+	var buf bytes.Buffer
+	if err := u.renderPDF(invoice, &buf); err != nil {
+		return err
+	}
+
+	// The PDF is a document of the customer, downloaded through signed links
+	name := fmt.Sprintf("invoice-%s-%s.pdf", invoice.Series, invoice.Number)
+	access := &domain.FileAccess{Visibility: domain.FileVisibilityCustomer, CustomerID: &invoice.CustomerID}
+	file, err := u.fileUsecase.Store(ctx, name, "application/pdf", domain.FileTypeDocument, access, &buf)
+	if err != nil {
+		return err
+	}
+
+	if err := u.invoiceRepo.SetFile(ctx, invoice.ID, file.ID); err != nil {
+		return err
+	}
+
+	previous := invoice.FileID
+	invoice.FileID = &file.ID
+	if previous != nil {
+		if err := u.fileUsecase.Delete(ctx, previous.Hex()); err != nil && err != domain.ErrNotFound {
+			log.Printf("Failed to delete previous PDF of invoice %s: %v", invoice.ID.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+// series returns the invoice series for an issue date, replacing {YY} with the year
+func (u *invoiceUsecase) series(issuedAt time.Time) string {
+	return strings.ReplaceAll(u.config.Series, "{YY}", fmt.Sprintf("%02d", issuedAt.Year()%100))
+}
+
+// maxInvoiceSubtotal bounds the subtotal of an invoice so that its VAT at any rate up to 100% fits an int64
+const maxInvoiceSubtotal = math.MaxInt64/100 - 1
+
+// computeInvoiceTotals computes the line amounts, subtotal, VAT rounded to the dong, and total.
+// Amounts that would overflow return ErrInvoiceAmountTooLarge.
+func computeInvoiceTotals(invoice *domain.Invoice) error {
+	invoice.Subtotal = 0
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+		if item.Quantity < 0 || item.UnitPrice < 0 {
+			return domain.ErrInvalidInput
+		}
+		if item.UnitPrice != 0 && item.Quantity > (maxInvoiceSubtotal-invoice.Subtotal)/item.UnitPrice {
+			return domain.ErrInvoiceAmountTooLarge
+		}
+		item.Amount = item.Quantity * item.UnitPrice
+		invoice.Subtotal += item.Amount
+	}
+	invoice.VATAmount = (invoice.Subtotal*int64(invoice.VATRate) + 50) / 100
+	invoice.Total = invoice.Subtotal + invoice.VATAmount
+	return nil
+}
+
+// customerBuyer returns the buyer details of a customer
+func customerBuyer(customer *domain.Customer) domain.InvoiceBuyer {
+	return domain.InvoiceBuyer{
+		Name:        customer.FullName,
+		CompanyName: customer.CompanyName,
+		TaxCode:     customer.TaxCode,
+		Address:     customer.Address,
+		Email:       customer.Email,
+		Phone:       customer.PhoneNumber,
+	}
+}
+
+// mergeBuyer fills the empty buyer fields from defaults
+func mergeBuyer(buyer, defaults domain.InvoiceBuyer) domain.InvoiceBuyer {
+	fill := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	fill(&buyer.Name, defaults.Name)
+	fill(&buyer.CompanyName, defaults.CompanyName)
+	fill(&buyer.TaxCode, defaults.TaxCode)
+	fill(&buyer.Address, defaults.Address)
+	fill(&buyer.Email, defaults.Email)
+	fill(&buyer.Phone, defaults.Phone)
+	return buyer
+}
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/license"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type licenseUsecase struct {
+	licenseRepo    domain.LicenseRepository
+	customerRepo   domain.CustomerRepository
+	shopRepo       domain.ShopRepository
+	signer         *license.Signer
+	contextTimeout time.Duration
+}
+
+// NewLicenseUsecase creates a new license usecase
+func NewLicenseUsecase(
+	repo domain.LicenseRepository,
+	customerRepo domain.CustomerRepository,
+	shopRepo domain.ShopRepository,
+	signer *license.Signer,
+	timeout time.Duration,
+) domain.LicenseUsecase {
+	return &licenseUsecase{
+		licenseRepo:    repo,
+		customerRepo:   customerRepo,
+		shopRepo:       shopRepo,
+		signer:         signer,
+		contextTimeout: timeout,
+	}
+}
+
+// Issue creates a license for a customer and signs its key
+func (u *licenseUsecase) Issue(ctx context.Context, customerID string, req *domain.IssueLicenseRequest, actor *domain.Actor) (*domain.License, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	if !req.ExpiresAt.After(now) {
+		return nil, domain.ErrInvalidLicenseExpiry
+	}
+
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	shopID, err := resolveShop(ctx, u.shopRepo, customer.ID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	lic := &domain.License{
+		ID:         primitive.NewObjectID(),
+		CustomerID: customer.ID,
+		ShopID:     shopID,
+		Plan:       req.Plan,
+		Seats:      req.Seats,
+		Status:     domain.LicenseActive,
+		IssuedAt:   now,
+		ExpiresAt:  req.ExpiresAt,
+		Note:       req.Note,
+		IssuedBy:   actor.ID,
+	}
+
+	if err := u.signKey(lic, now); err != nil {
+		return nil, err
+	}
+
+	if err := u.licenseRepo.Create(ctx, lic); err != nil {
+		return nil, err
+	}
+
+	return lic, nil
+}
+
+// GetByID gets a license by ID
+func (u *licenseUsecase) GetByID(ctx context.Context, id string) (*domain.License, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	lic, err := u.licenseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	markLicenseExpired(lic, time.Now())
+	return lic, nil
+}
+
+// GetByCustomer gets the licenses of a customer
+func (u *licenseUsecase) GetByCustomer(ctx context.Context, customerID string) ([]*domain.License, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	licenses, err := u.licenseRepo.GetByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, lic := range licenses {
+		markLicenseExpired(lic, now)
+	}
+
+	return licenses, nil
+}
+
+// Extend moves the expiry of a license, optionally changing its seats, and re-signs its key.
+// The previous key keeps verifying offline until its own expiry but fails online checks.
+func (u *licenseUsecase) Extend(ctx context.Context, id string, req *domain.ExtendLicenseRequest) (*domain.License, error) {
+	return u.change(ctx, id, func(lic *domain.License, now time.Time) error {
+		if !req.ExpiresAt.After(now) {
+			return domain.ErrInvalidLicenseExpiry
+		}
+
+		lic.ExpiresAt = req.ExpiresAt
+		if req.Seats > 0 {
+			lic.Seats = req.Seats
+		}
+		return u.signKey(lic, now)
+	})
+}
+
+// Suspend temporarily disables a license
+func (u *licenseUsecase) Suspend(ctx context.Context, id string, req *domain.LicenseStatusRequest) (*domain.License, error) {
+	return u.change(ctx, id, func(lic *domain.License, now time.Time) error {
+		lic.Status = domain.LicenseSuspended
+		lic.StatusReason = req.Reason
+		return nil
+	})
+}
+
+// Resume reactivates a suspended license
+func (u *licenseUsecase) Resume(ctx context.Context, id string) (*domain.License, error) {
+	return u.change(ctx, id, func(lic *domain.License, now time.Time) error {
+		lic.Status = domain.LicenseActive
+		lic.StatusReason = ""
+		return nil
+	})
+}
+
+// Revoke permanently disables a license
+func (u *licenseUsecase) Revoke(ctx context.Context, id string, req *domain.LicenseStatusRequest) (*domain.License, error) {
+	return u.change(ctx, id, func(lic *domain.License, now time.Time) error {
+		lic.Status = domain.LicenseRevoked
+		lic.StatusReason = req.Reason
+		return nil
+	})
+}
+
+// change applies fn to a license that is not revoked and saves it
+func (u *licenseUsecase) change(ctx context.Context, id string, fn func(lic *domain.License, now time.Time) error) (*domain.License, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	lic, err := u.licenseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if lic.Status == domain.LicenseRevoked {
+		return nil, domain.ErrLicenseRevoked
+	}
+
+	now := time.Now()
+	if err := fn(lic, now); err != nil {
+		return nil, err
+	}
+
+	if err := u.licenseRepo.Update(ctx, lic); err != nil {
+		return nil, err
+	}
+
+	markLicenseExpired(lic, now)
+	return lic, nil
+}
+
+// Verify checks a license key online: the signature, the current status and
+// whether the key is still the latest one issued for the license
+func (u *licenseUsecase) Verify(ctx context.Context, key string) (*domain.LicenseVerification, error) {
+	claims, err := license.Verify(u.signer.PublicKey(), key)
+	if err != nil {
+		return &domain.LicenseVerification{Valid: false}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	lic, err := u.licenseRepo.GetByID(ctx, claims.LicenseID)
+	if err == domain.ErrNotFound || err == domain.ErrInvalidID {
+		return &domain.LicenseVerification{Valid: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	markLicenseExpired(lic, time.Now())
+	current := lic.Key == key
+
+	result := &domain.LicenseVerification{
+		Valid:     current && lic.Status == domain.LicenseActive && !lic.Expired,
+		Status:    lic.Status,
+		Expired:   lic.Expired,
+		LicenseID: lic.ID.Hex(),
+		Plan:      lic.Plan,
+		Seats:     lic.Seats,
+		ExpiresAt: &lic.ExpiresAt,
+	}
+	return result, nil
+}
+
+// PublicKey returns the key cafe clients embed to verify license keys offline
+func (u *licenseUsecase) PublicKey() *domain.LicensePublicKey {
+	return &domain.LicensePublicKey{
+		Algorithm: "Ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(u.signer.PublicKey()),
+	}
+}
+
+// signKey signs the current terms of a license into its key
+func (u *licenseUsecase) signKey(lic *domain.License, now time.Time) error {
+	claims := &license.Claims{
+		LicenseID:  lic.ID.Hex(),
+		CustomerID: lic.CustomerID.Hex(),
+		Plan:       string(lic.Plan),
+		Seats:      lic.Seats,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  lic.ExpiresAt.Unix(),
+	}
+	if lic.ShopID != nil {
+		claims.ShopID = lic.ShopID.Hex()
+	}
+
+	key, err := u.signer.Sign(claims)
+	if err != nil {
+		return err
+	}
+
+	lic.Key = key
+	return nil
+}
+
+// markLicenseExpired flags licenses past their expiry date
+func markLicenseExpired(lic *domain.License, now time.Time) {
+	lic.Expired = !lic.ExpiresAt.After(now)
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+
+	"icafe-registration/internal/domain"
+)
+
+// countFunc counts the documents of a list query
+type countFunc func(ctx context.Context) (int64, error)
+
+// countPage fills the total of info according to the count mode of page.
+// A nil estimated func means the list is filtered and falls back to an exact count.
+func countPage(ctx context.Context, page *domain.Pagination, info *domain.PageInfo, exact, estimated countFunc) error {
+	var err error
+
+	switch page.Count {
+	case domain.CountNone:
+		return nil
+	case domain.CountEstimated:
+		if estimated != nil {
+			info.Total, err = estimated(ctx)
+			info.TotalEstimated = true
+			break
+		}
+		info.Total, err = exact(ctx)
+	default:
+		info.Total, err = exact(ctx)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	info.Counted = true
+	return nil
+}
@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxRenewAttempts bounds how often a paid renewal is retried when the subscription
+// changes between reading and saving it
+const maxRenewAttempts = 3
+
+type paymentUsecase struct {
+	paymentRepo      domain.PaymentRepository
+	subscriptionRepo domain.SubscriptionRepository
+	planRepo         domain.PlanRepository
+	customerRepo     domain.CustomerRepository
+	gateway          domain.PaymentGateway
+	commissions      domain.CommissionRecorder
+	expireAfter      time.Duration
+	contextTimeout   time.Duration
+}
+
+// NewPaymentUsecase creates a new payment usecase. Payment links expire after
+// expireAfter; creating payments fails with ErrPaymentUnavailable when gateway is nil.
+// Succeeded payments earn commissions through commissions.
+func NewPaymentUsecase(
+	repo domain.PaymentRepository,
+	subscriptionRepo domain.SubscriptionRepository,
+	planRepo domain.PlanRepository,
+	customerRepo domain.CustomerRepository,
+	gateway domain.PaymentGateway,
+	commissions domain.CommissionRecorder,
+	expireAfter time.Duration,
+	timeout time.Duration,
+) domain.PaymentUsecase {
+	return &paymentUsecase{
+		paymentRepo:      repo,
+		subscriptionRepo: subscriptionRepo,
+		planRepo:         planRepo,
+		customerRepo:     customerRepo,
+		gateway:          gateway,
+		commissions:      commissions,
+		expireAfter:      expireAfter,
+		contextTimeout:   timeout,
+	}
+}
+
+// Create creates a payment link for the next billing period of a subscription,
+// priced with the current plan prices
+func (u *paymentUsecase) Create(ctx context.Context, subscriptionID string, req *domain.CreatePaymentRequest, clientIP string, actor *domain.Actor) (*domain.Payment, error) {
+	if u.gateway == nil {
+		return nil, domain.ErrPaymentUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	subscription, err := u.subscriptionRepo.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status == domain.SubscriptionCancelled {
+		return nil, domain.ErrSubscriptionCancelled
+	}
+
+	// Renewing an existing plan is allowed even when it is no longer offered
+	plan, err := u.planRepo.GetByID(ctx, subscription.PlanID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	cycle := subscription.BillingCycle
+	if req.BillingCycle != "" {
+		cycle = req.BillingCycle
+	}
+	terms := *subscription
+	if err := applySubscriptionTerms(&terms, plan, cycle, subscription.WorkstationRange); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payment := &domain.Payment{
+		ID:               primitive.NewObjectID(),
+		Provider:         u.gateway.Name(),
+		CustomerID:       subscription.CustomerID,
+		SubscriptionID:   subscription.ID,
+		PlanID:           terms.PlanID,
+		PlanName:         terms.PlanName,
+		WorkstationRange: terms.WorkstationRange,
+		BillingCycle:     terms.BillingCycle,
+		Amount:           terms.Price,
+		Status:           domain.PaymentPending,
+		PeriodStart:      subscription.NextRenewalAt,
+		PeriodEnd:        subscription.NextRenewalAt.AddDate(0, cycle.Months(), 0),
+		ExpiresAt:        now.Add(u.expireAfter),
+		CreatedBy:        actor.ID,
+		CreatedOn:        now,
+	}
+	payment.TxnRef = payment.ID.Hex()
+	payment.Description = fmt.Sprintf("Gia hạn gói %s (%s máy) từ %s",
+		terms.PlanName, terms.WorkstationRange, payment.PeriodStart.Format("02/01/2006"))
+
+	payment.PaymentURL, err = u.gateway.PaymentURL(payment, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.paymentRepo.Create(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// GetByID gets a payment by ID
+func (u *paymentUsecase) GetByID(ctx context.Context, id string) (*domain.Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.paymentRepo.GetByID(ctx, id)
+}
+
+// GetAll gets payments matching filter with pagination
+func (u *paymentUsecase) GetAll(ctx context.Context, filter *domain.PaymentFilter, page *domain.Pagination) ([]*domain.Payment, *domain.PageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	payments, err := u.paymentRepo.GetAll(ctx, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(payments); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, payments[n-1].CreatedOn, payments[n-1].ID)
+	}
+
+	count := func(ctx context.Context) (int64, error) {
+		return u.paymentRepo.Count(ctx, filter)
+	}
+	if err := countPage(ctx, page, info, count, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return payments, info, nil
+}
+
+// GetByCustomer gets the payments of a customer, newest first
+func (u *paymentUsecase) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	return u.paymentRepo.GetByCustomer(ctx, customerID)
+}
+
+// HandleCallback records the outcome reported by the gateway. Each payment is recorded
+// once: repeated callbacks get ErrPaymentProcessed. A succeeded payment renews its
+// subscription for one period with the terms it was created with, and earns the
+// reseller who referred the customer a commission.
+func (u *paymentUsecase) HandleCallback(ctx context.Context, params url.Values) (*domain.Payment, error) {
+	if u.gateway == nil {
+		return nil, domain.ErrPaymentUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.gateway.VerifyCallback(params)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := u.paymentRepo.GetByTxnRef(ctx, result.TxnRef)
+	if err != nil {
+		return nil, err
+	}
+	if result.Amount != payment.Amount {
+		return nil, domain.ErrPaymentAmountMismatch
+	}
+	if payment.Status != domain.PaymentPending {
+		return nil, domain.ErrPaymentProcessed
+	}
+
+	payment.Status = domain.PaymentFailed
+	if result.Success {
+		payment.Status = domain.PaymentSucceeded
+	}
+	payment.TransactionNo = result.TransactionNo
+	payment.BankCode = result.BankCode
+	payment.ResponseCode = result.ResponseCode
+	if result.Success {
+		payment.PaidAt = result.PaidAt
+		if payment.PaidAt == nil {
+			now := time.Now()
+			payment.PaidAt = &now
+		}
+	}
+
+	if err := u.paymentRepo.Complete(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	if payment.Status == domain.PaymentSucceeded {
+		if err := u.renew(ctx, payment); err != nil {
+			// The money is received, the renewal has to be completed by hand
+			log.Printf("Payment %s succeeded but subscription %s was not renewed: %v",
+				payment.TxnRef, payment.SubscriptionID.Hex(), err)
+		}
+
+		referred := &domain.ReferredPayment{
+			CustomerID: payment.CustomerID,
+			Source:     domain.CommissionFromPayment,
+			SourceID:   payment.ID,
+			SourceRef:  payment.TxnRef,
+			BaseAmount: payment.Amount,
+			PaidAt:     *payment.PaidAt,
+		}
+		if _, err := u.commissions.RecordPayment(ctx, referred); err != nil {
+			log.Printf("Failed to record the commission of payment %s: %v", payment.TxnRef, err)
+		}
+	}
+
+	return payment, nil
+}
+
+// GetReturnResult verifies the result a payer is redirected back with and returns the
+// recorded payment. The payment is only recorded from the gateway callback, so it
+// may still be pending.
+func (u *paymentUsecase) GetReturnResult(ctx context.Context, params url.Values) (*domain.Payment, error) {
+	if u.gateway == nil {
+		return nil, domain.ErrPaymentUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.gateway.VerifyCallback(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.paymentRepo.GetByTxnRef(ctx, result.TxnRef)
+}
+
+// renew renews the subscription of a succeeded payment and records the period it covers,
+// which starts at the renewal date of the subscription when the callback arrives.
+// When the subscription changes meanwhile, e.g. the renewal job marks it past due,
+// the renewal is retried on the fresh subscription so the paid period is not lost.
+func (u *paymentUsecase) renew(ctx context.Context, payment *domain.Payment) error {
+	now := time.Now()
+
+	var subscription *domain.Subscription
+	for attempt := 1; ; attempt++ {
+		var err error
+		subscription, err = u.subscriptionRepo.GetByID(ctx, payment.SubscriptionID.Hex())
+		if err != nil {
+			return err
+		}
+		if subscription.Status == domain.SubscriptionCancelled {
+			return domain.ErrSubscriptionCancelled
+		}
+
+		subscription.PlanID = payment.PlanID
+		subscription.PlanName = payment.PlanName
+		subscription.WorkstationRange = payment.WorkstationRange
+		subscription.BillingCycle = payment.BillingCycle
+		subscription.Price = payment.Amount
+
+		renewalAt := subscription.NextRenewalAt
+		startNextPeriod(subscription, now)
+		err = u.subscriptionRepo.StartNextPeriod(ctx, subscription, renewalAt)
+		if err == nil {
+			break
+		}
+		if err != domain.ErrSubscriptionChanged || attempt == maxRenewAttempts {
+			return err
+		}
+	}
+
+	payment.PeriodStart = subscription.CurrentPeriodStart
+	payment.PeriodEnd = subscription.NextRenewalAt
+	payment.RenewedAt = &now
+	return u.paymentRepo.SetRenewal(ctx, payment)
+}
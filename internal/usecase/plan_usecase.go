@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+type planUsecase struct {
+	planRepo       domain.PlanRepository
+	contextTimeout time.Duration
+}
+
+// NewPlanUsecase creates a new plan usecase
+func NewPlanUsecase(repo domain.PlanRepository, timeout time.Duration) domain.PlanUsecase {
+	return &planUsecase{
+		planRepo:       repo,
+		contextTimeout: timeout,
+	}
+}
+
+// Create creates an active plan
+func (u *planUsecase) Create(ctx context.Context, req *domain.CreatePlanRequest) (*domain.Plan, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if err := checkPlanPrices(req.Prices); err != nil {
+		return nil, err
+	}
+	if err := checkSeatPrices(req.SeatPrices); err != nil {
+		return nil, err
+	}
+
+	plan := &domain.Plan{
+		Code:        req.Code,
+		Name:        req.Name,
+		Description: req.Description,
+		Prices:      req.Prices,
+		SeatPrices:  req.SeatPrices,
+		IsActive:    true,
+	}
+
+	if err := u.planRepo.Create(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// GetByID gets a plan by ID
+func (u *planUsecase) GetByID(ctx context.Context, id string) (*domain.Plan, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.planRepo.GetByID(ctx, id)
+}
+
+// GetAll gets the plans, optionally only the active ones
+func (u *planUsecase) GetAll(ctx context.Context, activeOnly bool) ([]*domain.Plan, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.planRepo.GetAll(ctx, activeOnly)
+}
+
+// Update updates a plan. Existing subscriptions keep their price until they renew.
+func (u *planUsecase) Update(ctx context.Context, id string, req *domain.UpdatePlanRequest) (*domain.Plan, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	plan, err := u.planRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		plan.Name = req.Name
+	}
+	if req.Description != "" {
+		plan.Description = req.Description
+	}
+	if req.Prices != nil {
+		if err := checkPlanPrices(req.Prices); err != nil {
+			return nil, err
+		}
+		plan.Prices = req.Prices
+	}
+	if req.SeatPrices != nil {
+		if err := checkSeatPrices(req.SeatPrices); err != nil {
+			return nil, err
+		}
+		plan.SeatPrices = req.SeatPrices
+	}
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+
+	if err := u.planRepo.Update(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// checkPlanPrices rejects price lists with a workstation range listed twice
+func checkPlanPrices(prices []domain.PlanPrice) error {
+	seen := make(map[string]bool, len(prices))
+	for _, price := range prices {
+		if seen[price.WorkstationRange] {
+			return domain.ErrDuplicatePlanPrice
+		}
+		seen[price.WorkstationRange] = true
+	}
+	return nil
+}
+
+// checkSeatPrices rejects per-seat bands whose limits do not increase or that do not
+// end with a single open-ended band. An empty list removes per-seat pricing.
+func checkSeatPrices(prices []domain.SeatPrice) error {
+	previous := 0
+	for i, price := range prices {
+		last := i == len(prices)-1
+		if last != (price.UpTo == 0) {
+			return domain.ErrInvalidSeatPrices
+		}
+		if !last && price.UpTo <= previous {
+			return domain.ErrInvalidSeatPrices
+		}
+		previous = price.UpTo
+	}
+	return nil
+}
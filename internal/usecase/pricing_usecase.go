@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+// quotePricer prices quotes from the plan catalog, the add-on modules and the promo codes
+type quotePricer struct {
+	planRepo  domain.PlanRepository
+	addOnRepo domain.AddOnRepository
+	promoRepo domain.PromoCodeRepository
+	vatRate   int
+	validFor  time.Duration
+}
+
+// price prices the terms of req at now. Quotes without a workstation range use
+// defaultRange. The quote is not stored and has no prospect.
+func (p *quotePricer) price(ctx context.Context, req *domain.QuoteRequest, defaultRange string, now time.Time) (*domain.Quote, error) {
+	plan, err := p.planRepo.GetByID(ctx, req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive {
+		return nil, domain.ErrPlanInactive
+	}
+
+	workstationRange := req.WorkstationRange
+	seats := req.Seats
+	switch {
+	case seats > 0 && workstationRange == "":
+		workstationRange = workstationRangeForSeats(seats)
+	case seats == 0:
+		if workstationRange == "" {
+			workstationRange = defaultRange
+		}
+		seats = domain.WorkstationRangeSeats[workstationRange]
+	}
+	if seats == 0 {
+		return nil, domain.ErrSeatsRequired
+	}
+
+	cycle := req.BillingCycle
+	if cycle == "" {
+		cycle = domain.BillingMonthly
+	}
+
+	quote := &domain.Quote{
+		PlanID:           plan.ID,
+		PlanName:         plan.Name,
+		WorkstationRange: workstationRange,
+		Seats:            seats,
+		BillingCycle:     cycle,
+		Months:           cycle.Months(),
+		DiscountPercent:  req.DiscountPercent,
+		VATRate:          p.vatRate,
+		Note:             req.Note,
+		ValidUntil:       now.Add(p.validFor),
+	}
+
+	quote.Lines, err = planLines(plan, workstationRange, seats)
+	if err != nil {
+		return nil, err
+	}
+
+	addOnLines, err := p.addOnLines(ctx, req.AddOns, seats)
+	if err != nil {
+		return nil, err
+	}
+	quote.Lines = append(quote.Lines, addOnLines...)
+
+	var promo *domain.PromoCode
+	if req.PromoCode != "" {
+		promo, err = p.promoRepo.GetByCode(ctx, normalizePromoCode(req.PromoCode))
+		if err == domain.ErrNotFound {
+			return nil, domain.ErrPromoCodeInvalid
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !promo.Usable(now) {
+			return nil, domain.ErrPromoCodeInvalid
+		}
+		quote.PromoCode = promo.Code
+	}
+
+	computeQuoteTotals(quote, promo)
+	return quote, nil
+}
+
+// planLines prices a plan for a number of seats: one line per per-seat band the seats
+// reach when the plan has bands, or the flat price of the workstation tier otherwise
+func planLines(plan *domain.Plan, workstationRange string, seats int) ([]domain.QuoteLine, error) {
+	if len(plan.SeatPrices) == 0 {
+		price, ok := plan.MonthlyPrice(workstationRange)
+		if !ok {
+			return nil, domain.ErrPlanPriceNotFound
+		}
+		return []domain.QuoteLine{{
+			Kind:        domain.QuoteLinePlan,
+			Code:        plan.Code,
+			Description: fmt.Sprintf("Gói %s (%s máy)", plan.Name, workstationRange),
+			Quantity:    1,
+			UnitPrice:   price,
+			Amount:      price,
+		}}, nil
+	}
+
+	lines := []domain.QuoteLine{}
+	from := 0
+	for _, band := range plan.SeatPrices {
+		to := band.UpTo
+		if to == 0 || to > seats {
+			to = seats
+		}
+		if to <= from {
+			break
+		}
+
+		quantity := int64(to - from)
+		lines = append(lines, domain.QuoteLine{
+			Kind:        domain.QuoteLinePlan,
+			Code:        plan.Code,
+			Description: fmt.Sprintf("Gói %s - máy %d đến %d", plan.Name, from+1, to),
+			Quantity:    quantity,
+			UnitPrice:   band.PricePerSeat,
+			Amount:      quantity * band.PricePerSeat,
+		})
+		from = to
+	}
+	return lines, nil
+}
+
+// addOnLines prices the requested add-on modules in the order they were requested
+func (p *quotePricer) addOnLines(ctx context.Context, codes []string, seats int) ([]domain.QuoteLine, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	unique := []string{}
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if !seen[code] {
+			seen[code] = true
+			unique = append(unique, code)
+		}
+	}
+
+	addOns, err := p.addOnRepo.GetByCodes(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]*domain.AddOn, len(addOns))
+	for _, addOn := range addOns {
+		byCode[addOn.Code] = addOn
+	}
+
+	lines := make([]domain.QuoteLine, 0, len(unique))
+	for _, code := range unique {
+		addOn, ok := byCode[code]
+		if !ok || !addOn.IsActive {
+			return nil, domain.ErrAddOnUnavailable
+		}
+
+		quantity := int64(1)
+		if addOn.PerSeat {
+			quantity = int64(seats)
+		}
+		lines = append(lines, domain.QuoteLine{
+			Kind:        domain.QuoteLineAddOn,
+			Code:        addOn.Code,
+			Description: "Module " + addOn.Name,
+			Quantity:    quantity,
+			UnitPrice:   addOn.MonthlyPrice,
+			Amount:      quantity * addOn.MonthlyPrice,
+		})
+	}
+	return lines, nil
+}
+
+// computeQuoteTotals computes the totals of a quote: the sales discount applies to the
+// subtotal of the billing period, the promo code to what remains and VAT to the rest.
+// Amounts are rounded to the dong.
+func computeQuoteTotals(quote *domain.Quote, promo *domain.PromoCode) {
+	quote.MonthlyAmount = 0
+	for _, line := range quote.Lines {
+		quote.MonthlyAmount += line.Amount
+	}
+	quote.Subtotal = quote.MonthlyAmount * int64(quote.Months)
+	quote.DiscountAmount = (quote.Subtotal*int64(quote.DiscountPercent) + 50) / 100
+
+	remaining := quote.Subtotal - quote.DiscountAmount
+	quote.PromoAmount = 0
+	if promo != nil {
+		if promo.PercentOff > 0 {
+			quote.PromoAmount = (remaining*int64(promo.PercentOff) + 50) / 100
+		} else {
+			quote.PromoAmount = min(promo.AmountOff, remaining)
+		}
+	}
+
+	taxable := remaining - quote.PromoAmount
+	quote.VATAmount = (taxable*int64(quote.VATRate) + 50) / 100
+	quote.Total = taxable + quote.VATAmount
+}
+
+// workstationRangeForSeats returns the workstation tier a number of seats falls in
+func workstationRangeForSeats(seats int) string {
+	switch {
+	case seats <= 10:
+		return "1-10"
+	case seats <= 20:
+		return "10-20"
+	case seats <= 50:
+		return "20-50"
+	default:
+		return "50+"
+	}
+}
+
+// normalizePromoCode accepts promo codes typed in any case
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+type pricingUsecase struct {
+	quotePricer
+	contextTimeout time.Duration
+}
+
+// NewPricingUsecase creates a new pricing usecase. Calculated quotes use vatRate
+// and are valid for validFor.
+func NewPricingUsecase(
+	planRepo domain.PlanRepository,
+	addOnRepo domain.AddOnRepository,
+	promoRepo domain.PromoCodeRepository,
+	vatRate int,
+	validFor time.Duration,
+	timeout time.Duration,
+) domain.PricingUsecase {
+	return &pricingUsecase{
+		quotePricer: quotePricer{
+			planRepo:  planRepo,
+			addOnRepo: addOnRepo,
+			promoRepo: promoRepo,
+			vatRate:   vatRate,
+			validFor:  validFor,
+		},
+		contextTimeout: timeout,
+	}
+}
+
+// CreateAddOn creates an active add-on module
+func (u *pricingUsecase) CreateAddOn(ctx context.Context, req *domain.CreateAddOnRequest) (*domain.AddOn, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	addOn := &domain.AddOn{
+		Code:         req.Code,
+		Name:         req.Name,
+		Description:  req.Description,
+		MonthlyPrice: req.MonthlyPrice,
+		PerSeat:      req.PerSeat,
+		IsActive:     true,
+	}
+
+	if err := u.addOnRepo.Create(ctx, addOn); err != nil {
+		return nil, err
+	}
+
+	return addOn, nil
+}
+
+// GetAddOns gets the add-on modules, optionally only the active ones
+func (u *pricingUsecase) GetAddOns(ctx context.Context, activeOnly bool) ([]*domain.AddOn, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.addOnRepo.GetAll(ctx, activeOnly)
+}
+
+// UpdateAddOn updates an add-on module. Quotes already made keep their price.
+func (u *pricingUsecase) UpdateAddOn(ctx context.Context, id string, req *domain.UpdateAddOnRequest) (*domain.AddOn, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	addOn, err := u.addOnRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		addOn.Name = req.Name
+	}
+	if req.Description != "" {
+		addOn.Description = req.Description
+	}
+	if req.MonthlyPrice != nil {
+		addOn.MonthlyPrice = *req.MonthlyPrice
+	}
+	if req.PerSeat != nil {
+		addOn.PerSeat = *req.PerSeat
+	}
+	if req.IsActive != nil {
+		addOn.IsActive = *req.IsActive
+	}
+
+	if err := u.addOnRepo.Update(ctx, addOn); err != nil {
+		return nil, err
+	}
+
+	return addOn, nil
+}
+
+// CreatePromoCode creates an active promo code, stored in upper case
+func (u *pricingUsecase) CreatePromoCode(ctx context.Context, req *domain.CreatePromoCodeRequest) (*domain.PromoCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if req.ValidFrom != nil && req.ValidUntil != nil && req.ValidUntil.Before(*req.ValidFrom) {
+		return nil, domain.ErrInvalidInput
+	}
+
+	promo := &domain.PromoCode{
+		Code:           normalizePromoCode(req.Code),
+		Description:    req.Description,
+		PercentOff:     req.PercentOff,
+		AmountOff:      req.AmountOff,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		MaxRedemptions: req.MaxRedemptions,
+		IsActive:       true,
+	}
+
+	if err := u.promoRepo.Create(ctx, promo); err != nil {
+		return nil, err
+	}
+
+	return promo, nil
+}
+
+// GetPromoCodes gets the promo codes, newest first
+func (u *pricingUsecase) GetPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.promoRepo.GetAll(ctx)
+}
+
+// UpdatePromoCode updates the validity, redemption limit and status of a promo code
+func (u *pricingUsecase) UpdatePromoCode(ctx context.Context, id string, req *domain.UpdatePromoCodeRequest) (*domain.PromoCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	promo, err := u.promoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != "" {
+		promo.Description = req.Description
+	}
+	if req.ValidFrom != nil {
+		promo.ValidFrom = req.ValidFrom
+	}
+	if req.ValidUntil != nil {
+		promo.ValidUntil = req.ValidUntil
+	}
+	if req.MaxRedemptions != nil {
+		promo.MaxRedemptions = *req.MaxRedemptions
+	}
+	if req.IsActive != nil {
+		promo.IsActive = *req.IsActive
+	}
+	if promo.ValidFrom != nil && promo.ValidUntil != nil && promo.ValidUntil.Before(*promo.ValidFrom) {
+		return nil, domain.ErrInvalidInput
+	}
+
+	if err := u.promoRepo.Update(ctx, promo); err != nil {
+		return nil, err
+	}
+
+	return promo, nil
+}
+
+// Calculate prices quote terms without storing a quote, for sales to try options
+func (u *pricingUsecase) Calculate(ctx context.Context, req *domain.QuoteRequest) (*domain.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.price(ctx, req, "", time.Now())
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/pdf"
+)
+
+// quoteColumns are the columns of the quote line table; prices are monthly
+var quoteColumns = []tableColumn{
+	{"STT", 30, true},
+	{"Nội dung", 240, false},
+	{"Số lượng", 55, true},
+	{"Đơn giá/tháng", 95, true},
+	{"Thành tiền/tháng", 95.28, true},
+}
+
+// quotePage draws a quote
+type quotePage struct {
+	*documentPage
+}
+
+// renderPDF writes a quote as a PDF
+func (u *quoteUsecase) renderPDF(quote *domain.Quote, w io.Writer) error {
+	doc := pdf.New()
+	doc.SetFonts(u.regularFont, u.boldFont)
+	doc.SetTitle(fmt.Sprintf("Báo giá %s v%d", quote.Prospect.FullName, quote.Version))
+	doc.AddPage()
+
+	p := &quotePage{&documentPage{doc: doc, y: pageMargin, columns: quoteColumns}}
+	p.seller(u.seller)
+	p.title(quote)
+	p.prospect(quote)
+	p.lines(quote)
+	p.totals(quote)
+	p.signatures("Khách hàng", "Đại diện bán hàng")
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func (p *quotePage) title(quote *domain.Quote) {
+	center := p.doc.Width() / 2
+
+	p.doc.SetFont(true, 16)
+	p.doc.TextCenter(center, p.y+16, "BÁO GIÁ DỊCH VỤ")
+	p.y += 24
+
+	p.doc.SetFont(false, 10)
+	p.doc.TextCenter(center, p.y+10, fmt.Sprintf("Phiên bản %d - Ngày %s - Hiệu lực đến %s",
+		quote.Version, quote.CreatedOn.Format("02/01/2006"), quote.ValidUntil.Format("02/01/2006")))
+	p.y += pageLineHeight
+
+	p.doc.SetFont(true, 11)
+	switch {
+	case quote.Status == domain.QuoteAccepted && quote.AcceptedAt != nil:
+		p.doc.TextCenter(center, p.y+12, "ĐÃ CHẤP NHẬN NGÀY "+quote.AcceptedAt.Format("02/01/2006"))
+		p.y += pageLineHeight + 2
+	case quote.Status == domain.QuoteSuperseded:
+		p.doc.TextCenter(center, p.y+12, "ĐÃ ĐƯỢC THAY THẾ BẰNG BÁO GIÁ MỚI HƠN")
+		p.y += pageLineHeight + 2
+	case quote.Expired:
+		p.doc.TextCenter(center, p.y+12, "BÁO GIÁ ĐÃ HẾT HIỆU LỰC")
+		p.y += pageLineHeight + 2
+	}
+	p.y += 12
+}
+
+func (p *quotePage) prospect(quote *domain.Quote) {
+	p.line("Kính gửi:", quote.Prospect.FullName)
+	p.line("Địa chỉ:", quote.Prospect.Address)
+	p.line("Điện thoại:", quote.Prospect.PhoneNumber)
+	p.line("Email:", quote.Prospect.Email)
+	p.y += 6
+	p.line("Gói dịch vụ:", quote.PlanName)
+	p.line("Số máy:", fmt.Sprintf("%d (%s)", quote.Seats, quote.WorkstationRange))
+	p.line("Chu kỳ thanh toán:", fmt.Sprintf("%d tháng", quote.Months))
+	p.y += 10
+}
+
+func (p *quotePage) lines(quote *domain.Quote) {
+	p.header()
+	for i, line := range quote.Lines {
+		p.row(false, []string{
+			fmt.Sprint(i + 1),
+			line.Description,
+			formatVND(line.Quantity),
+			formatVND(line.UnitPrice),
+			formatVND(line.Amount),
+		})
+	}
+	p.y += 10
+}
+
+func (p *quotePage) totals(quote *domain.Quote) {
+	p.amount("Cộng mỗi tháng:", formatVND(quote.MonthlyAmount), false)
+	p.amount(fmt.Sprintf("Cộng %d tháng:", quote.Months), formatVND(quote.Subtotal), false)
+	if quote.DiscountAmount > 0 {
+		p.amount(fmt.Sprintf("Chiết khấu %d%%:", quote.DiscountPercent), formatVND(-quote.DiscountAmount), false)
+	}
+	if quote.PromoAmount > 0 {
+		p.amount(fmt.Sprintf("Mã khuyến mãi %s:", quote.PromoCode), formatVND(-quote.PromoAmount), false)
+	}
+	p.amount(fmt.Sprintf("Thuế GTGT %d%%:", quote.VATRate), formatVND(quote.VATAmount), false)
+	p.amount("Tổng cộng mỗi kỳ:", formatVND(quote.Total), true)
+	p.y += 4
+	p.line("Số tiền viết bằng chữ:", amountInWords(quote.Total))
+	p.line("Ghi chú:", quote.Note)
+	p.y += 20
+}
@@ -0,0 +1,356 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"icafe-registration/internal/config"
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/pdf"
+)
+
+type quoteUsecase struct {
+	quotePricer
+	quoteRepo        domain.QuoteRepository
+	registrationRepo domain.RegistrationRepository
+	customerRepo     domain.CustomerRepository
+	activityRepo     domain.ActivityRepository
+	notifier         domain.Notifier
+	config           *config.QuoteConfig
+	seller           *config.InvoiceConfig
+	regularFont      *pdf.Font
+	boldFont         *pdf.Font
+	contextTimeout   time.Duration
+}
+
+// NewQuoteUsecase creates a new quote usecase. Quotes are priced with the VAT rate and
+// printed with the seller details of the invoice configuration; PDFs use the given
+// fonts, or the standard PDF fonts without Vietnamese diacritics when they are nil.
+func NewQuoteUsecase(
+	repo domain.QuoteRepository,
+	registrationRepo domain.RegistrationRepository,
+	customerRepo domain.CustomerRepository,
+	activityRepo domain.ActivityRepository,
+	planRepo domain.PlanRepository,
+	addOnRepo domain.AddOnRepository,
+	promoRepo domain.PromoCodeRepository,
+	notifier domain.Notifier,
+	cfg *config.QuoteConfig,
+	seller *config.InvoiceConfig,
+	regularFont, boldFont *pdf.Font,
+	timeout time.Duration,
+) domain.QuoteUsecase {
+	return &quoteUsecase{
+		quotePricer: quotePricer{
+			planRepo:  planRepo,
+			addOnRepo: addOnRepo,
+			promoRepo: promoRepo,
+			vatRate:   seller.VATRate,
+			validFor:  cfg.ValidFor,
+		},
+		quoteRepo:        repo,
+		registrationRepo: registrationRepo,
+		customerRepo:     customerRepo,
+		activityRepo:     activityRepo,
+		notifier:         notifier,
+		config:           cfg,
+		seller:           seller,
+		regularFont:      regularFont,
+		boldFont:         boldFont,
+		contextTimeout:   timeout,
+	}
+}
+
+// Create prices a new version of the quote of a registration. The earlier versions
+// that are still open are superseded.
+func (u *quoteUsecase) Create(ctx context.Context, registrationID string, req *domain.QuoteRequest, actor *domain.Actor) (*domain.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	registration, err := u.registrationRepo.GetByID(ctx, registrationID)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted, err := u.quoteRepo.HasAccepted(ctx, registration.ID)
+	if err != nil {
+		return nil, err
+	}
+	if accepted {
+		return nil, domain.ErrQuoteAccepted
+	}
+
+	now := time.Now()
+	quote, err := u.price(ctx, req, registration.WorkstationRange, now)
+	if err != nil {
+		return nil, err
+	}
+
+	quote.RegistrationID = registration.ID
+	quote.Status = domain.QuoteDraft
+	quote.Prospect = domain.QuoteProspect{
+		FullName:    registration.FullName,
+		Email:       registration.Email,
+		PhoneNumber: registration.PhoneNumber,
+		Address:     registration.Address,
+	}
+	quote.CreatedBy = actor.ID
+
+	if err := u.quoteRepo.Create(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	if err := u.quoteRepo.Supersede(ctx, registration.ID, quote.ID); err != nil {
+		return nil, err
+	}
+
+	markQuoteExpired(quote, now)
+	return quote, nil
+}
+
+// GetByID gets a quote by ID
+func (u *quoteUsecase) GetByID(ctx context.Context, id string) (*domain.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	quote, err := u.quoteRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	markQuoteExpired(quote, time.Now())
+	return quote, nil
+}
+
+// GetByRegistration gets the quotes of a registration, latest version first
+func (u *quoteUsecase) GetByRegistration(ctx context.Context, registrationID string) ([]*domain.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.registrationRepo.GetByID(ctx, registrationID); err != nil {
+		return nil, err
+	}
+
+	quotes, err := u.quoteRepo.GetByRegistration(ctx, registrationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, quote := range quotes {
+		markQuoteExpired(quote, now)
+	}
+	return quotes, nil
+}
+
+// Send emails an open quote to its prospect with a new link to view and accept it.
+// Sending again replaces the link, so earlier links stop working.
+func (u *quoteUsecase) Send(ctx context.Context, id string) (*domain.SentQuote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	quote, err := u.quoteRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != domain.QuoteDraft && quote.Status != domain.QuoteSent {
+		return nil, domain.ErrQuoteNotOpen
+	}
+
+	now := time.Now()
+	if now.After(quote.ValidUntil) {
+		return nil, domain.ErrQuoteExpired
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	quote.TokenHash = hashToken(token)
+	quote.SentAt = &now
+
+	if err := u.quoteRepo.MarkSent(ctx, quote); err != nil {
+		return nil, err
+	}
+	quote.Status = domain.QuoteSent
+
+	sent := &domain.SentQuote{Quote: quote, AcceptURL: u.config.LinkURL + token}
+	if err := u.notifier.Notify(ctx, quoteNotification(sent)); err != nil {
+		return nil, err
+	}
+
+	return sent, nil
+}
+
+// RenderPDF writes a quote as a PDF
+func (u *quoteUsecase) RenderPDF(ctx context.Context, id string, w io.Writer) (*domain.Quote, error) {
+	quote, err := u.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return quote, u.renderPDF(quote, w)
+}
+
+// GetByToken gets the quote a link was sent for
+func (u *quoteUsecase) GetByToken(ctx context.Context, token string) (*domain.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	quote, err := u.quoteRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	markQuoteExpired(quote, time.Now())
+	return quote, nil
+}
+
+// RenderPDFByToken writes the quote a link was sent for as a PDF
+func (u *quoteUsecase) RenderPDFByToken(ctx context.Context, token string, w io.Writer) (*domain.Quote, error) {
+	quote, err := u.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return quote, u.renderPDF(quote, w)
+}
+
+// Accept accepts the quote a link was sent for and converts its prospect into a
+// customer: the customer with the prospect's email or phone, or a new one. The promo
+// code of the quote is redeemed. Accepting an accepted quote again returns it unchanged.
+func (u *quoteUsecase) Accept(ctx context.Context, token string) (*domain.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	quote, err := u.quoteRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case quote.Status == domain.QuoteAccepted:
+		return quote, nil
+	case quote.Status != domain.QuoteSent:
+		return nil, domain.ErrQuoteNotOpen
+	case time.Now().After(quote.ValidUntil):
+		return nil, domain.ErrQuoteExpired
+	}
+
+	customer, created, err := u.convert(ctx, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	if quote.PromoCode != "" {
+		if err := u.promoRepo.Redeem(ctx, quote.PromoCode); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	quote.AcceptedAt = &now
+	quote.CustomerID = &customer.ID
+	if err := u.quoteRepo.Accept(ctx, quote); err != nil {
+		if quote.PromoCode != "" {
+			if releaseErr := u.promoRepo.Release(ctx, quote.PromoCode); releaseErr != nil {
+				log.Printf("Failed to release promo code %s of quote %s: %v", quote.PromoCode, quote.ID.Hex(), releaseErr)
+			}
+		}
+		return nil, err
+	}
+	quote.Status = domain.QuoteAccepted
+
+	if err := u.registrationRepo.MarkConverted(ctx, quote.RegistrationID, customer.ID, now); err != nil {
+		log.Printf("Failed to mark registration %s as converted: %v", quote.RegistrationID.Hex(), err)
+	}
+	u.recordAcceptance(ctx, quote, customer, created)
+
+	return quote, nil
+}
+
+// convert returns the customer with the prospect's email or phone, or creates one.
+// created reports whether the customer is new.
+func (u *quoteUsecase) convert(ctx context.Context, quote *domain.Quote) (customer *domain.Customer, created bool, err error) {
+	customer, err = u.customerRepo.GetByEmail(ctx, quote.Prospect.Email)
+	if err == nil {
+		return customer, false, nil
+	}
+	if err != domain.ErrNotFound {
+		return nil, false, err
+	}
+
+	customer, err = u.customerRepo.GetByPhone(ctx, quote.Prospect.PhoneNumber)
+	if err == nil {
+		return customer, false, nil
+	}
+	if err != domain.ErrNotFound {
+		return nil, false, err
+	}
+
+	// The new customer keeps the reseller the registration was referred by
+	registration, err := u.registrationRepo.GetByID(ctx, quote.RegistrationID.Hex())
+	if err != nil && err != domain.ErrNotFound {
+		return nil, false, err
+	}
+
+	customer = &domain.Customer{
+		FullName:         quote.Prospect.FullName,
+		PhoneNumber:      quote.Prospect.PhoneNumber,
+		Email:            quote.Prospect.Email,
+		Address:          quote.Prospect.Address,
+		WorkstationRange: quote.WorkstationRange,
+		Note:             fmt.Sprintf("Chấp nhận báo giá v%d – Máy: %s", quote.Version, quote.WorkstationRange),
+	}
+	if registration != nil {
+		customer.ResellerID = registration.ResellerID
+	}
+	if err := u.customerRepo.Create(ctx, customer); err != nil {
+		return nil, false, err
+	}
+
+	return customer, true, nil
+}
+
+// recordAcceptance adds the accepted quote to the customer's timeline
+func (u *quoteUsecase) recordAcceptance(ctx context.Context, quote *domain.Quote, customer *domain.Customer, created bool) {
+	content := fmt.Sprintf("Khách hàng chấp nhận báo giá v%d: gói %s, %d máy, %s VND/%s",
+		quote.Version, quote.PlanName, quote.Seats, formatVND(quote.Total), billingCycleLabel(quote.BillingCycle))
+	if created {
+		content += " (tạo khách hàng từ đăng ký)"
+	}
+
+	activity := &domain.CustomerActivity{
+		CustomerID: customer.ID,
+		Type:       domain.ActivityStatusChange,
+		Content:    content,
+	}
+	if err := u.activityRepo.Create(ctx, activity); err != nil {
+		log.Printf("Failed to record acceptance of quote %s: %v", quote.ID.Hex(), err)
+	}
+}
+
+// markQuoteExpired flags open quotes past their validity
+func markQuoteExpired(quote *domain.Quote, now time.Time) {
+	quote.Expired = (quote.Status == domain.QuoteDraft || quote.Status == domain.QuoteSent) &&
+		now.After(quote.ValidUntil)
+}
+
+func quoteNotification(sent *domain.SentQuote) *domain.Notification {
+	quote := sent.Quote
+	return &domain.Notification{
+		Name:    quote.Prospect.FullName,
+		Email:   quote.Prospect.Email,
+		Phone:   quote.Prospect.PhoneNumber,
+		Subject: fmt.Sprintf("Báo giá gói %s cho %d máy", quote.PlanName, quote.Seats),
+		Body: fmt.Sprintf(
+			"Xin chào %s,\n\nChúng tôi gửi bạn báo giá gói %s cho %d máy.\nTổng cộng: %s VND/%s (đã gồm thuế GTGT).\nBáo giá có hiệu lực đến ngày %s.\n\nXem chi tiết và chấp nhận báo giá tại:\n%s",
+			quote.Prospect.FullName, quote.PlanName, quote.Seats,
+			formatVND(quote.Total), billingCycleLabel(quote.BillingCycle),
+			quote.ValidUntil.Format("02/01/2006"),
+			sent.AcceptURL,
+		),
+	}
+}
@@ -3,25 +3,32 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/export"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type registrationUsecase struct {
 	registrationRepo domain.RegistrationRepository
 	customerRepo     domain.CustomerRepository
+	resellerRepo     domain.ResellerRepository
 	contextTimeout   time.Duration
 }
 
 func NewRegistrationUsecase(
 	repo domain.RegistrationRepository,
 	custRepo domain.CustomerRepository,
+	resellerRepo domain.ResellerRepository,
 	timeout time.Duration,
 ) domain.RegistrationUsecase {
 	return &registrationUsecase{
 		registrationRepo: repo,
 		customerRepo:     custRepo,
+		resellerRepo:     resellerRepo,
 		contextTimeout:   timeout,
 	}
 }
@@ -52,18 +59,33 @@ func (u *registrationUsecase) Create(
 		return nil, err
 	}
 
+	if err := resolveAddress(req.StructuredAddress); err != nil {
+		return nil, err
+	}
+	address := addressText(req.Address, req.StructuredAddress)
+
+	resellerID, err := u.referrer(ctx, req.Source)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create customer (PHỤC VỤ ADMIN)
 	customer := &domain.Customer{
-		FullName:         req.FullName,
-		PhoneNumber:      req.PhoneNumber,
-		Email:            req.Email,
-		Address:          req.Address,
-		WorkstationRange: req.WorkstationRange,
-		Note:             fmt.Sprintf("Web đăng ký – Máy: %s", req.WorkstationRange),
-		IsActive:         true,
+		FullName:          req.FullName,
+		PhoneNumber:       req.PhoneNumber,
+		Email:             req.Email,
+		Address:           address,
+		StructuredAddress: req.StructuredAddress,
+		WorkstationRange:  req.WorkstationRange,
+		Note:              fmt.Sprintf("Web đăng ký – Máy: %s", req.WorkstationRange),
+		IsActive:          true,
+		ResellerID:        resellerID,
 	}
 
 	if err := u.customerRepo.Create(ctx, customer); err != nil {
+		if err == domain.ErrPhoneAlreadyExists {
+			return nil, fmt.Errorf("PHONE_ALREADY_EXISTS")
+		}
 		return nil, err
 	}
 
@@ -71,13 +93,18 @@ func (u *registrationUsecase) Create(
 
 	// Log registration
 	registration := &domain.Registration{
-		FullName:         req.FullName,
-		PhoneNumber:      req.PhoneNumber,
-		Email:            req.Email,
-		Address:          req.Address,
-		WorkstationRange: req.WorkstationRange,
-		CreatedOn:        now,
-		ModifiedOn:       now,
+		FullName:          req.FullName,
+		PhoneNumber:       req.PhoneNumber,
+		Email:             req.Email,
+		Address:           address,
+		StructuredAddress: req.StructuredAddress,
+		WorkstationRange:  req.WorkstationRange,
+		ResellerID:        resellerID,
+		CreatedOn:         now,
+		ModifiedOn:        now,
+	}
+	if !req.Source.IsEmpty() {
+		registration.Source = req.Source
 	}
 
 	if err := u.registrationRepo.Create(ctx, registration); err != nil {
@@ -87,6 +114,28 @@ func (u *registrationUsecase) Create(
 	return registration, nil
 }
 
+// referrer returns the active reseller of the referral code of a registration source.
+// An unknown code is kept on the source but attributes the registration to nobody.
+func (u *registrationUsecase) referrer(ctx context.Context, source *domain.ReferralSource) (*primitive.ObjectID, error) {
+	if source == nil || source.ReferralCode == "" {
+		return nil, nil
+	}
+	source.ReferralCode = normalizeReferralCode(source.ReferralCode)
+
+	reseller, err := u.resellerRepo.GetByCode(ctx, source.ReferralCode)
+	if err == domain.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !reseller.IsActive {
+		return nil, nil
+	}
+
+	return &reseller.ID, nil
+}
+
 // Các hàm bổ trợ khác giữ nguyên như bản bạn đã viết...
 func (u *registrationUsecase) GetByID(ctx context.Context, id string) (*domain.Registration, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
@@ -94,12 +143,48 @@ func (u *registrationUsecase) GetByID(ctx context.Context, id string) (*domain.R
 	return u.registrationRepo.GetByID(ctx, id)
 }
 
-func (u *registrationUsecase) GetAll(ctx context.Context, limit, offset int64) ([]*domain.Registration, int64, error) {
+func (u *registrationUsecase) GetAll(ctx context.Context, filter *domain.RegistrationFilter, page *domain.Pagination) ([]*domain.Registration, *domain.PageInfo, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
-	regs, _ := u.registrationRepo.GetAll(ctx, limit, offset)
-	total, _ := u.registrationRepo.Count(ctx)
-	return regs, total, nil
+	regs, err := u.registrationRepo.GetAll(ctx, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(regs); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, regs[n-1].CreatedOn, regs[n-1].ID)
+	}
+
+	count := func(ctx context.Context) (int64, error) {
+		return u.registrationRepo.Count(ctx, filter)
+	}
+	var estimated countFunc
+	if filter.IsEmpty() {
+		estimated = u.registrationRepo.EstimatedCount
+	}
+	if err := countPage(ctx, page, info, count, estimated); err != nil {
+		return nil, nil, err
+	}
+	return regs, info, nil
+}
+
+// Export streams registrations matching filter to w as CSV or XLSX.
+// It is bound to the request context only: large exports outlive contextTimeout.
+func (u *registrationUsecase) Export(ctx context.Context, filter *domain.RegistrationFilter, req *domain.ExportRequest, w io.Writer) error {
+	columns, err := selectColumns(registrationExportColumns, req.Columns)
+	if err != nil {
+		return err
+	}
+
+	writer, err := export.NewWriter(export.Format(req.Format), w)
+	if err != nil {
+		return err
+	}
+
+	return exportRows(writer, columns, func(fn func(*domain.Registration) error) error {
+		return u.registrationRepo.Iterate(ctx, filter, fn)
+	})
 }
 
 func (u *registrationUsecase) Update(ctx context.Context, id string, req *domain.UpdateRegistrationRequest) (*domain.Registration, error) {
@@ -116,13 +201,66 @@ func (u *registrationUsecase) Update(ctx context.Context, id string, req *domain
 	if req.PhoneNumber != "" {
 		existing.PhoneNumber = req.PhoneNumber
 	}
+	if req.StructuredAddress != nil {
+		if err := resolveAddress(req.StructuredAddress); err != nil {
+			return nil, err
+		}
+		existing.StructuredAddress = req.StructuredAddress
+		existing.Address = addressText(req.Address, req.StructuredAddress)
+	} else if req.Address != "" {
+		existing.Address = req.Address
+	}
 
 	err = u.registrationRepo.Update(ctx, id, existing)
 	return existing, err
 }
 
-func (u *registrationUsecase) Delete(ctx context.Context, id string) error {
+// Delete moves a registration to the trash
+func (u *registrationUsecase) Delete(ctx context.Context, id string, deletedBy string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.registrationRepo.Delete(ctx, id, deletedBy)
+}
+
+// GetTrash gets registrations in the trash with pagination
+func (u *registrationUsecase) GetTrash(ctx context.Context, page *domain.Pagination) ([]*domain.Registration, *domain.PageInfo, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
-	return u.registrationRepo.Delete(ctx, id)
+
+	items, err := u.registrationRepo.GetDeleted(ctx, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(items); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, items[n-1].CreatedOn, items[n-1].ID)
+	}
+
+	if err := countPage(ctx, page, info, u.registrationRepo.CountDeleted, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return items, info, nil
+}
+
+// Restore moves a registration out of the trash
+func (u *registrationUsecase) Restore(ctx context.Context, id string) (*domain.Registration, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if err := u.registrationRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return u.registrationRepo.GetByID(ctx, id)
+}
+
+// Purge permanently deletes registrations trashed before the given time
+func (u *registrationUsecase) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.registrationRepo.Purge(ctx, before)
 }
@@ -0,0 +1,425 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/domain"
+	"icafe-registration/pkg/semver"
+	"icafe-registration/pkg/update"
+)
+
+// releaseProductPattern is the form of product names, which appear in URLs
+var releaseProductPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type releaseUsecase struct {
+	releaseRepo    domain.ReleaseRepository
+	fileUsecase    domain.FileUsecase
+	signer         *update.Signer
+	downloadURL    string
+	manifestTTL    time.Duration
+	contextTimeout time.Duration
+}
+
+// NewReleaseUsecase creates a new release usecase. Builds are downloaded from
+// downloadURL followed by their file ID, and update manifests signed by signer
+// are valid for manifestTTL.
+func NewReleaseUsecase(
+	releaseRepo domain.ReleaseRepository,
+	fileUsecase domain.FileUsecase,
+	signer *update.Signer,
+	downloadURL string,
+	manifestTTL time.Duration,
+	timeout time.Duration,
+) domain.ReleaseUsecase {
+	return &releaseUsecase{
+		releaseRepo:    releaseRepo,
+		fileUsecase:    fileUsecase,
+		signer:         signer,
+		downloadURL:    downloadURL,
+		manifestTTL:    manifestTTL,
+		contextTimeout: timeout,
+	}
+}
+
+// Create creates a draft release of a product
+func (u *releaseUsecase) Create(ctx context.Context, req *domain.CreateReleaseRequest, actor *domain.Actor) (*domain.Release, error) {
+	if !releaseProductPattern.MatchString(req.Product) {
+		return nil, domain.ErrInvalidReleaseProduct
+	}
+	version, err := releaseVersion(req.Version, req.Channel)
+	if err != nil {
+		return nil, err
+	}
+	minimum, err := minimumVersion(req.MinimumVersion, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files without a recorded checksum are hashed here, which is not bound to the timeout
+	assets, err := u.assets(ctx, req.Assets)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	release := &domain.Release{
+		Product:        req.Product,
+		Version:        version.String(),
+		Channel:        req.Channel,
+		Changelog:      strings.TrimSpace(req.Changelog),
+		MinimumVersion: minimum,
+		Assets:         assets,
+		CreatedBy:      actor.ID,
+	}
+	if err := u.releaseRepo.Create(ctx, release); err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// Get gets the release of a product version. The version may carry a leading "v".
+func (u *releaseUsecase) Get(ctx context.Context, product string, version string) (*domain.Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	parsed, err := semver.Parse(version)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	return u.releaseRepo.GetByVersion(ctx, product, parsed.String())
+}
+
+// GetAll gets the releases, newest first, optionally of one product
+func (u *releaseUsecase) GetAll(ctx context.Context, product string) ([]*domain.Release, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.releaseRepo.GetAll(ctx, product)
+}
+
+// Update updates a release. Clients may already have installed a published
+// release, so only its changelog and minimum version can change.
+func (u *releaseUsecase) Update(ctx context.Context, product string, version string, req *domain.UpdateReleaseRequest) (*domain.Release, error) {
+	release, err := u.Get(ctx, product, version)
+	if err != nil {
+		return nil, err
+	}
+	parsed, _ := semver.Parse(release.Version)
+
+	if release.PublishedAt != nil && ((req.Channel != "" && req.Channel != release.Channel) || req.Assets != nil) {
+		return nil, domain.ErrReleasePublished
+	}
+	if req.Channel != "" {
+		if _, err := releaseVersion(release.Version, req.Channel); err != nil {
+			return nil, err
+		}
+		release.Channel = req.Channel
+	}
+	if req.Changelog != nil {
+		release.Changelog = strings.TrimSpace(*req.Changelog)
+	}
+	if req.MinimumVersion != nil {
+		if release.MinimumVersion, err = minimumVersion(*req.MinimumVersion, parsed); err != nil {
+			return nil, err
+		}
+	}
+	if req.Assets != nil {
+		if release.Assets, err = u.assets(ctx, req.Assets); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	if err := u.releaseRepo.Update(ctx, release); err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// Publish makes a release visible to clients. Publishing again keeps the
+// original publication time.
+func (u *releaseUsecase) Publish(ctx context.Context, product string, version string) (*domain.Release, error) {
+	release, err := u.Get(ctx, product, version)
+	if err != nil {
+		return nil, err
+	}
+	if release.PublishedAt != nil {
+		return release, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	release.PublishedAt = &now
+	if err := u.releaseRepo.Update(ctx, release); err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// Delete deletes a release, withdrawing it from clients when it was published.
+// Its files are kept.
+func (u *releaseUsecase) Delete(ctx context.Context, product string, version string) error {
+	release, err := u.Get(ctx, product, version)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.releaseRepo.Delete(ctx, release.ID)
+}
+
+// Latest returns the newest published release of a product for a platform.
+// Beta clients also receive stable releases. With the current version of the
+// client, it tells whether an update is available and whether it is mandatory.
+func (u *releaseUsecase) Latest(
+	ctx context.Context,
+	product string,
+	channel domain.ReleaseChannel,
+	platform domain.ReleasePlatform,
+	current string,
+) (*domain.ReleaseCheck, error) {
+
+	var currentVersion *semver.Version
+	if current != "" {
+		parsed, err := semver.Parse(current)
+		if err != nil {
+			return nil, domain.ErrInvalidReleaseVersion
+		}
+		currentVersion = &parsed
+	}
+
+	release, version, err := u.latest(ctx, product, channel, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &domain.ReleaseCheck{
+		Product:         release.Product,
+		Channel:         release.Channel,
+		Platform:        platform,
+		Version:         release.Version,
+		Changelog:       release.Changelog,
+		MinimumVersion:  release.MinimumVersion,
+		Asset:           *releaseAsset(release, platform),
+		PublishedAt:     *release.PublishedAt,
+		UpdateAvailable: currentVersion == nil,
+	}
+	if currentVersion != nil {
+		check.CurrentVersion = currentVersion.String()
+		check.UpdateAvailable = semver.Compare(version, *currentVersion) > 0
+		if minimum, err := semver.Parse(release.MinimumVersion); err == nil {
+			check.Mandatory = semver.Compare(*currentVersion, minimum) < 0
+		}
+	}
+
+	return check, nil
+}
+
+// Manifest returns the signed update manifest of the latest release of a
+// product for a platform
+func (u *releaseUsecase) Manifest(
+	ctx context.Context,
+	product string,
+	channel domain.ReleaseChannel,
+	platform domain.ReleasePlatform,
+) (*domain.SignedReleaseManifest, error) {
+
+	release, _, err := u.latest(ctx, product, channel, platform)
+	if err != nil {
+		return nil, err
+	}
+	asset := releaseAsset(release, platform)
+
+	now := time.Now()
+	expiresAt := now.Add(u.manifestTTL).Truncate(time.Second)
+	signed, err := u.signer.Sign(&update.Manifest{
+		Product:        release.Product,
+		Channel:        string(channel),
+		Platform:       string(platform),
+		ReleaseVersion: release.Version,
+		MinimumVersion: release.MinimumVersion,
+		URL:            asset.URL,
+		FileName:       asset.FileName,
+		Size:           asset.Size,
+		SHA256:         asset.SHA256,
+		PublishedAt:    release.PublishedAt.Unix(),
+		IssuedAt:       now.Unix(),
+		ExpiresAt:      expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SignedReleaseManifest{Manifest: signed, ExpiresAt: expiresAt}, nil
+}
+
+// PublicKey returns the public key that verifies update manifests
+func (u *releaseUsecase) PublicKey() *domain.ReleasePublicKey {
+	return &domain.ReleasePublicKey{
+		Algorithm: "Ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(u.signer.PublicKey()),
+	}
+}
+
+// latest finds the newest published release of a product for a channel and platform
+func (u *releaseUsecase) latest(
+	ctx context.Context,
+	product string,
+	channel domain.ReleaseChannel,
+	platform domain.ReleasePlatform,
+) (*domain.Release, semver.Version, error) {
+
+	channels := []domain.ReleaseChannel{domain.ReleaseChannelStable}
+	switch channel {
+	case domain.ReleaseChannelStable:
+	case domain.ReleaseChannelBeta:
+		channels = append(channels, domain.ReleaseChannelBeta)
+	default:
+		return nil, semver.Version{}, domain.ErrInvalidInput
+	}
+	if platform == "" {
+		return nil, semver.Version{}, domain.ErrInvalidInput
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	releases, err := u.releaseRepo.GetPublished(ctx, product, channels, platform)
+	if err != nil {
+		return nil, semver.Version{}, err
+	}
+
+	var latest *domain.Release
+	var latestVersion semver.Version
+	for _, release := range releases {
+		version, err := semver.Parse(release.Version)
+		if err != nil {
+			continue
+		}
+		if latest == nil || semver.Compare(version, latestVersion) > 0 {
+			latest, latestVersion = release, version
+		}
+	}
+	if latest == nil {
+		return nil, semver.Version{}, domain.ErrNotFound
+	}
+
+	return latest, latestVersion, nil
+}
+
+// assets resolves the files of the builds of a release, with their checksums
+func (u *releaseUsecase) assets(ctx context.Context, reqs []domain.ReleaseAssetRequest) ([]domain.ReleaseAsset, error) {
+	assets := make([]domain.ReleaseAsset, 0, len(reqs))
+	seen := map[domain.ReleasePlatform]bool{}
+	for _, req := range reqs {
+		if seen[req.Platform] {
+			return nil, domain.ErrDuplicateReleasePlatform
+		}
+		seen[req.Platform] = true
+
+		file, err := u.fileUsecase.GetByID(ctx, req.FileID)
+		if err == domain.ErrInvalidID || err == domain.ErrNotFound {
+			return nil, domain.ErrReleaseFileNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Clients download builds without a signed link
+		if !file.IsPublic() {
+			return nil, domain.ErrReleaseFileNotFound
+		}
+		if file.IsQuarantined() {
+			return nil, domain.ErrFileQuarantined
+		}
+
+		sum := file.SHA256
+		if sum == "" {
+			if sum, err = u.checksum(ctx, file); err != nil {
+				return nil, err
+			}
+		}
+
+		name := file.OriginalName
+		if name == "" {
+			name = file.FileName
+		}
+		assets = append(assets, domain.ReleaseAsset{
+			Platform: req.Platform,
+			FileID:   file.ID,
+			FileName: name,
+			Size:     file.Size,
+			SHA256:   sum,
+			URL:      u.downloadURL + file.ID.Hex(),
+		})
+	}
+	return assets, nil
+}
+
+// checksum hashes the stored content of a file uploaded before checksums were recorded
+func (u *releaseUsecase) checksum(ctx context.Context, file *domain.File) (string, error) {
+	content, _, err := u.fileUsecase.Open(ctx, file.FilePath)
+	if err == domain.ErrNotFound {
+		return "", domain.ErrReleaseFileNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, content); err != nil {
+		return "", fmt.Errorf("hash file %s: %w", file.ID.Hex(), err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// releaseVersion parses the version of a release. Pre-releases such as
+// 2.5.0-beta.1 are only published on the beta channel.
+func releaseVersion(version string, channel domain.ReleaseChannel) (semver.Version, error) {
+	parsed, err := semver.Parse(version)
+	if err != nil || (parsed.IsPrerelease() && channel != domain.ReleaseChannelBeta) {
+		return semver.Version{}, domain.ErrInvalidReleaseVersion
+	}
+	return parsed, nil
+}
+
+// minimumVersion parses the minimum supported version of a release, which
+// cannot be above the release itself. Empty means none.
+func minimumVersion(minimum string, version semver.Version) (string, error) {
+	if strings.TrimSpace(minimum) == "" {
+		return "", nil
+	}
+	parsed, err := semver.Parse(minimum)
+	if err != nil || semver.Compare(parsed, version) > 0 {
+		return "", domain.ErrInvalidReleaseVersion
+	}
+	return parsed.String(), nil
+}
+
+// releaseAsset returns the build of a release for a platform
+func releaseAsset(release *domain.Release, platform domain.ReleasePlatform) *domain.ReleaseAsset {
+	for i := range release.Assets {
+		if release.Assets[i].Platform == platform {
+			return &release.Assets[i]
+		}
+	}
+	return &domain.ReleaseAsset{}
+}
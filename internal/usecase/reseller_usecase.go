@@ -0,0 +1,536 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"icafe-registration/internal/config"
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxReferralCodeAttempts bounds the retries when a generated referral code is taken
+const maxReferralCodeAttempts = 5
+
+type resellerUsecase struct {
+	resellerRepo     domain.ResellerRepository
+	ruleRepo         domain.CommissionRuleRepository
+	commissionRepo   domain.CommissionRepository
+	userRepo         domain.UserRepository
+	customerRepo     domain.CustomerRepository
+	registrationRepo domain.RegistrationRepository
+	paymentRepo      domain.PaymentRepository
+	config           *config.ResellerConfig
+	contextTimeout   time.Duration
+}
+
+// NewResellerUsecase creates a new reseller usecase. Referral links are built from
+// the configured link URL.
+func NewResellerUsecase(
+	repo domain.ResellerRepository,
+	ruleRepo domain.CommissionRuleRepository,
+	commissionRepo domain.CommissionRepository,
+	userRepo domain.UserRepository,
+	customerRepo domain.CustomerRepository,
+	registrationRepo domain.RegistrationRepository,
+	paymentRepo domain.PaymentRepository,
+	cfg *config.ResellerConfig,
+	timeout time.Duration,
+) domain.ResellerUsecase {
+	return &resellerUsecase{
+		resellerRepo:     repo,
+		ruleRepo:         ruleRepo,
+		commissionRepo:   commissionRepo,
+		userRepo:         userRepo,
+		customerRepo:     customerRepo,
+		registrationRepo: registrationRepo,
+		paymentRepo:      paymentRepo,
+		config:           cfg,
+		contextTimeout:   timeout,
+	}
+}
+
+// Create creates an active reseller for a user account of the reseller role. The
+// referral code is stored in upper case and generated when none is given.
+func (u *resellerUsecase) Create(ctx context.Context, req *domain.CreateResellerRequest) (*domain.Reseller, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	user, err := u.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != domain.RoleReseller {
+		return nil, domain.ErrResellerRoleRequired
+	}
+
+	reseller := &domain.Reseller{
+		UserID:      user.ID,
+		Name:        req.Name,
+		CompanyName: req.CompanyName,
+		PhoneNumber: req.PhoneNumber,
+		Email:       req.Email,
+		BankAccount: req.BankAccount,
+		Note:        req.Note,
+		IsActive:    true,
+	}
+
+	if req.Code != "" {
+		reseller.Code = normalizeReferralCode(req.Code)
+		err = u.resellerRepo.Create(ctx, reseller)
+	} else {
+		for attempt := 0; attempt < maxReferralCodeAttempts; attempt++ {
+			if reseller.Code, err = generateReferralCode(); err != nil {
+				return nil, err
+			}
+			if err = u.resellerRepo.Create(ctx, reseller); err != domain.ErrResellerCodeExists {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return u.withLink(reseller), nil
+}
+
+// GetByID gets a reseller by ID
+func (u *resellerUsecase) GetByID(ctx context.Context, id string) (*domain.Reseller, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	reseller, err := u.resellerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.withLink(reseller), nil
+}
+
+// GetAll gets the resellers sorted by name
+func (u *resellerUsecase) GetAll(ctx context.Context) ([]*domain.Reseller, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	resellers, err := u.resellerRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reseller := range resellers {
+		u.withLink(reseller)
+	}
+	return resellers, nil
+}
+
+// Update updates the profile and status of a reseller. Deactivated resellers keep
+// their leads and commissions but new registrations are no longer attributed to them.
+func (u *resellerUsecase) Update(ctx context.Context, id string, req *domain.UpdateResellerRequest) (*domain.Reseller, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	reseller, err := u.resellerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		reseller.Name = req.Name
+	}
+	if req.CompanyName != nil {
+		reseller.CompanyName = *req.CompanyName
+	}
+	if req.PhoneNumber != "" {
+		reseller.PhoneNumber = req.PhoneNumber
+	}
+	if req.Email != nil {
+		reseller.Email = *req.Email
+	}
+	if req.BankAccount != nil {
+		reseller.BankAccount = *req.BankAccount
+	}
+	if req.Note != nil {
+		reseller.Note = *req.Note
+	}
+	if req.IsActive != nil {
+		reseller.IsActive = *req.IsActive
+	}
+
+	if err := u.resellerRepo.Update(ctx, reseller); err != nil {
+		return nil, err
+	}
+
+	return u.withLink(reseller), nil
+}
+
+// GetReferral gets the active reseller of a referral code, case insensitively
+func (u *resellerUsecase) GetReferral(ctx context.Context, code string) (*domain.ReferralInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	reseller, err := u.resellerRepo.GetByCode(ctx, normalizeReferralCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if !reseller.IsActive {
+		return nil, domain.ErrNotFound
+	}
+
+	return &domain.ReferralInfo{
+		Code:        reseller.Code,
+		Name:        reseller.Name,
+		CompanyName: reseller.CompanyName,
+	}, nil
+}
+
+// CreateRule creates an active commission rule, for one reseller or, without a
+// reseller, for every reseller without their own rule
+func (u *resellerUsecase) CreateRule(ctx context.Context, req *domain.CreateCommissionRuleRequest) (*domain.CommissionRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	rule := &domain.CommissionRule{
+		Name:        req.Name,
+		Percent:     req.Percent,
+		FixedAmount: req.FixedAmount,
+		MaxPayments: req.MaxPayments,
+		IsActive:    true,
+	}
+
+	if req.ResellerID != "" {
+		reseller, err := u.resellerRepo.GetByID(ctx, req.ResellerID)
+		if err != nil {
+			return nil, err
+		}
+		rule.ResellerID = &reseller.ID
+	}
+
+	if err := u.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// GetRules gets the commission rules, newest first
+func (u *resellerUsecase) GetRules(ctx context.Context) ([]*domain.CommissionRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.ruleRepo.GetAll(ctx)
+}
+
+// UpdateRule updates a commission rule. Commissions already earned keep their amount.
+func (u *resellerUsecase) UpdateRule(ctx context.Context, id string, req *domain.UpdateCommissionRuleRequest) (*domain.CommissionRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	rule, err := u.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		rule.Name = req.Name
+	}
+	if req.Percent != nil {
+		rule.Percent = *req.Percent
+	}
+	if req.FixedAmount != nil {
+		rule.FixedAmount = *req.FixedAmount
+	}
+	if req.MaxPayments != nil {
+		rule.MaxPayments = *req.MaxPayments
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	if rule.Percent == 0 && rule.FixedAmount == 0 {
+		return nil, domain.ErrInvalidInput
+	}
+
+	if err := u.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// GetCommissions gets commissions matching filter with pagination
+func (u *resellerUsecase) GetCommissions(ctx context.Context, filter *domain.CommissionFilter, page *domain.Pagination) ([]*domain.Commission, *domain.PageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.listCommissions(ctx, filter, page)
+}
+
+func (u *resellerUsecase) listCommissions(ctx context.Context, filter *domain.CommissionFilter, page *domain.Pagination) ([]*domain.Commission, *domain.PageInfo, error) {
+	commissions, err := u.commissionRepo.GetAll(ctx, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(commissions); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, commissions[n-1].CreatedOn, commissions[n-1].ID)
+	}
+
+	count := func(ctx context.Context) (int64, error) {
+		return u.commissionRepo.Count(ctx, filter)
+	}
+	if err := countPage(ctx, page, info, count, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return commissions, info, nil
+}
+
+// PayCommission records the payout of a pending commission
+func (u *resellerUsecase) PayCommission(ctx context.Context, id string, req *domain.PayCommissionRequest, actor *domain.Actor) (*domain.Commission, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	commission, err := u.commissionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if commission.Status != domain.CommissionPending {
+		return nil, domain.ErrCommissionStatus
+	}
+
+	paidAt := time.Now()
+	if req.PaidAt != nil {
+		paidAt = *req.PaidAt
+	}
+	commission.Status = domain.CommissionPaid
+	commission.PaidAt = &paidAt
+	commission.PayoutRef = req.PayoutRef
+	commission.PaidBy = actor.ID
+
+	if err := u.commissionRepo.UpdateStatus(ctx, commission, domain.CommissionPending); err != nil {
+		return nil, err
+	}
+
+	return commission, nil
+}
+
+// CancelCommission cancels a pending commission
+func (u *resellerUsecase) CancelCommission(ctx context.Context, id string, req *domain.CancelCommissionRequest) (*domain.Commission, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	commission, err := u.commissionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if commission.Status != domain.CommissionPending {
+		return nil, domain.ErrCommissionStatus
+	}
+
+	commission.Status = domain.CommissionCancelled
+	commission.CancelReason = req.Reason
+
+	if err := u.commissionRepo.UpdateStatus(ctx, commission, domain.CommissionPending); err != nil {
+		return nil, err
+	}
+
+	return commission, nil
+}
+
+// RecordPayment records the commission earned on a payment of a customer referred by
+// an active reseller, with the reseller's commission rule. It returns nil when the
+// payment earns nothing: the customer was not referred, there is no active rule, the
+// rule's payment limit is reached, or the invoice was settled with an online payment
+// that earned the commission already.
+func (u *resellerUsecase) RecordPayment(ctx context.Context, payment *domain.ReferredPayment) (*domain.Commission, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, payment.CustomerID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if customer.ResellerID == nil {
+		return nil, nil
+	}
+
+	reseller, err := u.resellerRepo.GetByID(ctx, customer.ResellerID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if !reseller.IsActive {
+		return nil, nil
+	}
+
+	if payment.PaymentRef != "" {
+		online, err := u.paymentRepo.GetByTxnRef(ctx, payment.PaymentRef)
+		if err != nil && err != domain.ErrNotFound {
+			return nil, err
+		}
+		if online != nil && online.Status == domain.PaymentSucceeded && online.CustomerID == customer.ID {
+			return nil, nil
+		}
+	}
+
+	rule, err := u.ruleRepo.GetActive(ctx, reseller.ID)
+	if err == domain.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rule.MaxPayments > 0 {
+		earned, err := u.commissionRepo.CountEarned(ctx, reseller.ID, customer.ID)
+		if err != nil {
+			return nil, err
+		}
+		if earned >= int64(rule.MaxPayments) {
+			return nil, nil
+		}
+	}
+
+	commission := &domain.Commission{
+		ResellerID:   reseller.ID,
+		RuleID:       rule.ID,
+		CustomerID:   customer.ID,
+		CustomerName: customer.FullName,
+		Source:       payment.Source,
+		SourceID:     payment.SourceID,
+		SourceRef:    payment.SourceRef,
+		BaseAmount:   payment.BaseAmount,
+		Percent:      rule.Percent,
+		FixedAmount:  rule.FixedAmount,
+		Amount:       commissionAmount(payment.BaseAmount, rule),
+		Status:       domain.CommissionPending,
+		EarnedAt:     payment.PaidAt,
+	}
+
+	if err := u.commissionRepo.Create(ctx, commission); err != nil {
+		return nil, err
+	}
+
+	return commission, nil
+}
+
+// CancelPayment cancels the pending commission earned on a voided payment. A
+// commission already paid out is left for an admin to settle.
+func (u *resellerUsecase) CancelPayment(ctx context.Context, source domain.CommissionSource, sourceID primitive.ObjectID, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.commissionRepo.CancelBySource(ctx, source, sourceID, reason)
+}
+
+// GetProfile gets the reseller of the signed in user
+func (u *resellerUsecase) GetProfile(ctx context.Context, actor *domain.Actor) (*domain.Reseller, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	reseller, err := u.resellerRepo.GetByUserID(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.withLink(reseller), nil
+}
+
+// GetLeads gets the registrations referred by the signed in reseller, newest first
+func (u *resellerUsecase) GetLeads(ctx context.Context, actor *domain.Actor, page *domain.Pagination) ([]*domain.ReferralLead, *domain.PageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	reseller, err := u.resellerRepo.GetByUserID(ctx, actor.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filter := &domain.RegistrationFilter{ResellerID: &reseller.ID}
+	registrations, err := u.registrationRepo.GetAll(ctx, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(registrations); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, registrations[n-1].CreatedOn, registrations[n-1].ID)
+	}
+
+	count := func(ctx context.Context) (int64, error) {
+		return u.registrationRepo.Count(ctx, filter)
+	}
+	if err := countPage(ctx, page, info, count, nil); err != nil {
+		return nil, nil, err
+	}
+
+	// Resellers see who they referred and how far they got, not their contact details
+	leads := make([]*domain.ReferralLead, 0, len(registrations))
+	for _, registration := range registrations {
+		lead := &domain.ReferralLead{
+			ID:               registration.ID,
+			FullName:         registration.FullName,
+			WorkstationRange: registration.WorkstationRange,
+			Converted:        registration.ConvertedAt != nil,
+			ConvertedAt:      registration.ConvertedAt,
+			CreatedOn:        registration.CreatedOn,
+		}
+		if registration.Source != nil {
+			lead.UTMCampaign = registration.Source.UTMCampaign
+		}
+		leads = append(leads, lead)
+	}
+
+	return leads, info, nil
+}
+
+// GetEarnings gets the commissions of the signed in reseller with pagination, and
+// their totals by status
+func (u *resellerUsecase) GetEarnings(ctx context.Context, actor *domain.Actor, status domain.CommissionStatus, page *domain.Pagination) ([]*domain.Commission, *domain.CommissionSummary, *domain.PageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	reseller, err := u.resellerRepo.GetByUserID(ctx, actor.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	commissions, info, err := u.listCommissions(ctx, &domain.CommissionFilter{ResellerID: &reseller.ID, Status: status}, page)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	summary, err := u.commissionRepo.Summarize(ctx, reseller.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return commissions, summary, info, nil
+}
+
+// withLink fills the referral link of a reseller
+func (u *resellerUsecase) withLink(reseller *domain.Reseller) *domain.Reseller {
+	reseller.ReferralLink = u.config.LinkURL + reseller.Code
+	return reseller
+}
+
+// commissionAmount returns the percentage of base set by rule, rounded to the
+// nearest dong, plus its fixed amount
+func commissionAmount(base int64, rule *domain.CommissionRule) int64 {
+	return (base*int64(rule.Percent)+50)/100 + rule.FixedAmount
+}
+
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// generateReferralCode returns a random 8 character referral code
+func generateReferralCode() (string, error) {
+	code, err := randomHex(4)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(code), nil
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+const (
+	// scanBatch is how many files a scan run handles at most
+	scanBatch = 20
+
+	// scanStaleAfter is how long a file can stay scanning before another run
+	// assumes the first one died and scans it again
+	scanStaleAfter = 30 * time.Minute
+
+	// scanMaxAttempts is how many times a file is scanned before it is marked
+	// failed, for content the scanner keeps rejecting
+	scanMaxAttempts = 3
+)
+
+type scanUsecase struct {
+	fileRepo       domain.FileRepository
+	userRepo       domain.UserRepository
+	storage        domain.FileStorage
+	scanner        domain.Scanner
+	notifier       domain.Notifier
+	contextTimeout time.Duration
+}
+
+// NewScanUsecase creates a new usecase scanning quarantined uploads for
+// malware with scanner, nil when scanning is disabled. Admins are notified of
+// infected files.
+func NewScanUsecase(
+	fileRepo domain.FileRepository,
+	userRepo domain.UserRepository,
+	storage domain.FileStorage,
+	scanner domain.Scanner,
+	notifier domain.Notifier,
+	timeout time.Duration,
+) domain.ScanUsecase {
+	return &scanUsecase{
+		fileRepo:       fileRepo,
+		userRepo:       userRepo,
+		storage:        storage,
+		scanner:        scanner,
+		notifier:       notifier,
+		contextTimeout: timeout,
+	}
+}
+
+// ScanPending scans the files waiting for it, oldest first. A scan error stops
+// the run, as the scanner is likely down, and the file is scanned again on the
+// next run; after scanMaxAttempts it is marked failed and stays quarantined.
+func (u *scanUsecase) ScanPending(ctx context.Context) (*domain.ScanReport, error) {
+	report := &domain.ScanReport{}
+	if u.scanner == nil {
+		return report, nil
+	}
+
+	for i := 0; i < scanBatch; i++ {
+		claimCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+		file, err := u.fileRepo.ClaimScan(claimCtx, time.Now().Add(-scanStaleAfter))
+		cancel()
+		if err == domain.ErrNotFound {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+
+		scan := &domain.FileScan{Attempts: file.Scan.Attempts}
+		signature, scanErr := u.scanFile(ctx, file)
+		switch {
+		case scanErr == nil && signature == "":
+			scan.Status = domain.ScanStatusClean
+		case scanErr == nil:
+			scan.Status = domain.ScanStatusInfected
+			scan.Signature = signature
+		case ctx.Err() != nil:
+			// Stopped by a shutdown, the attempt does not count
+			scan.Status = domain.ScanStatusPending
+			scan.Attempts--
+		case scan.Attempts < scanMaxAttempts:
+			scan.Status = domain.ScanStatusPending
+			scan.Error = scanErr.Error()
+		default:
+			log.Printf("Failed to scan file %s for malware: %v", file.ID.Hex(), scanErr)
+			scan.Status = domain.ScanStatusFailed
+			scan.Error = scanErr.Error()
+		}
+
+		setCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), u.contextTimeout)
+		err = u.fileRepo.SetScan(setCtx, file.ID, scan)
+		cancel()
+		if err != nil && err != domain.ErrNotFound {
+			return report, err
+		}
+
+		switch scan.Status {
+		case domain.ScanStatusClean:
+			report.Clean++
+		case domain.ScanStatusInfected:
+			report.Infected++
+			u.alert(context.WithoutCancel(ctx), file, signature)
+		case domain.ScanStatusFailed:
+			report.Failed++
+		default:
+			return report, fmt.Errorf("scan file %s: %w", file.ID.Hex(), scanErr)
+		}
+	}
+	return report, nil
+}
+
+// scanFile streams the stored content of a file to the scanner
+func (u *scanUsecase) scanFile(ctx context.Context, file *domain.File) (string, error) {
+	content, _, err := u.storage.Get(ctx, file.FilePath)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	return u.scanner.Scan(ctx, content)
+}
+
+// alert notifies the active admins of an infected file
+func (u *scanUsecase) alert(ctx context.Context, file *domain.File, signature string) {
+	log.Printf("ALERT: file %s (%s) is infected with %s and stays quarantined", file.ID.Hex(), file.OriginalName, signature)
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	admins, err := u.userRepo.GetActiveByRole(ctx, domain.RoleAdmin)
+	if err != nil {
+		log.Printf("Failed to get the admins to alert of infected file %s: %v", file.ID.Hex(), err)
+		return
+	}
+
+	for _, admin := range admins {
+		if admin.Email == "" {
+			continue
+		}
+		if err := u.notifier.Notify(ctx, infectedFileNotification(admin, file, signature)); err != nil {
+			log.Printf("Failed to alert %s of infected file %s: %v", admin.Username, file.ID.Hex(), err)
+		}
+	}
+}
+
+// Rescan quarantines a file until it is scanned again on the next run, after
+// a failure or to check a file stored before scanning was enabled
+func (u *scanUsecase) Rescan(ctx context.Context, fileID string) (*domain.File, error) {
+	if u.scanner == nil {
+		return nil, domain.ErrScanDisabled
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	file, err := u.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.Scan != nil && file.Scan.Status == domain.ScanStatusScanning &&
+		file.Scan.UpdatedOn.After(time.Now().Add(-scanStaleAfter)) {
+		return nil, domain.ErrScanInProgress
+	}
+
+	file.Scan = &domain.FileScan{Status: domain.ScanStatusPending}
+	if err := u.fileRepo.SetScan(ctx, file.ID, file.Scan); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func infectedFileNotification(admin *domain.User, file *domain.File, signature string) *domain.Notification {
+	return &domain.Notification{
+		Name:    admin.FullName,
+		Email:   admin.Email,
+		Subject: fmt.Sprintf("Cảnh báo: file %s nhiễm mã độc", file.OriginalName),
+		Body: fmt.Sprintf(
+			"Xin chào %s,\n\nFile %s (ID %s, %s, upload lúc %s) bị phát hiện nhiễm mã độc %s.\nFile đã bị cách ly và không thể tải về.\n\nVui lòng kiểm tra và xóa file nếu cần.",
+			admin.FullName, file.OriginalName, file.ID.Hex(), file.FileType,
+			file.CreatedOn.Format("15:04 02/01/2006"), signature,
+		),
+	}
+}
@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type shopUsecase struct {
+	shopRepo         domain.ShopRepository
+	customerRepo     domain.CustomerRepository
+	fileRepo         domain.FileRepository
+	licenseRepo      domain.LicenseRepository
+	installationRepo domain.InstallationRepository
+	contextTimeout   time.Duration
+}
+
+// NewShopUsecase creates a new shop usecase
+func NewShopUsecase(
+	repo domain.ShopRepository,
+	customerRepo domain.CustomerRepository,
+	fileRepo domain.FileRepository,
+	licenseRepo domain.LicenseRepository,
+	installationRepo domain.InstallationRepository,
+	timeout time.Duration,
+) domain.ShopUsecase {
+	return &shopUsecase{
+		shopRepo:         repo,
+		customerRepo:     customerRepo,
+		fileRepo:         fileRepo,
+		licenseRepo:      licenseRepo,
+		installationRepo: installationRepo,
+		contextTimeout:   timeout,
+	}
+}
+
+// Create creates a shop under a customer
+func (u *shopUsecase) Create(ctx context.Context, customerID string, req *domain.CreateShopRequest) (*domain.Shop, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveAddress(&req.Address); err != nil {
+		return nil, err
+	}
+
+	shop := &domain.Shop{
+		CustomerID:       customer.ID,
+		Name:             req.Name,
+		Address:          req.Address,
+		WorkstationCount: req.WorkstationCount,
+		OpeningHours:     req.OpeningHours,
+		Contact:          req.Contact,
+		Note:             req.Note,
+	}
+
+	if err := u.shopRepo.Create(ctx, shop); err != nil {
+		return nil, err
+	}
+
+	return shop, nil
+}
+
+// GetByID gets a shop of a customer
+func (u *shopUsecase) GetByID(ctx context.Context, customerID, id string) (*domain.Shop, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.getShop(ctx, customerID, id)
+}
+
+// GetByCustomer gets the shops of a customer
+func (u *shopUsecase) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Shop, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	return u.shopRepo.GetByCustomer(ctx, customerID)
+}
+
+// Update updates a shop of a customer
+func (u *shopUsecase) Update(ctx context.Context, customerID, id string, req *domain.UpdateShopRequest) (*domain.Shop, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	shop, err := u.getShop(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		shop.Name = req.Name
+	}
+	if req.Address != nil {
+		if err := resolveAddress(req.Address); err != nil {
+			return nil, err
+		}
+		shop.Address = *req.Address
+	}
+	if req.WorkstationCount != nil {
+		shop.WorkstationCount = *req.WorkstationCount
+	}
+	if req.OpeningHours != nil {
+		shop.OpeningHours = *req.OpeningHours
+	}
+	if req.Contact != nil {
+		shop.Contact = *req.Contact
+	}
+	if req.Note != "" {
+		shop.Note = req.Note
+	}
+
+	if err := u.shopRepo.Update(ctx, shop); err != nil {
+		return nil, err
+	}
+
+	return shop, nil
+}
+
+// Delete deletes a shop and detaches its licenses, installations and files
+func (u *shopUsecase) Delete(ctx context.Context, customerID, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	shop, err := u.getShop(ctx, customerID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := u.shopRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if err := u.licenseRepo.DetachShop(ctx, shop.ID); err != nil {
+		return err
+	}
+	if err := u.installationRepo.DetachShop(ctx, shop.ID); err != nil {
+		return err
+	}
+	return u.fileRepo.DetachShop(ctx, shop.ID)
+}
+
+// GetFiles gets the files attached to a shop
+func (u *shopUsecase) GetFiles(ctx context.Context, customerID, id string) ([]*domain.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	shop, err := u.getShop(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.fileRepo.GetByShop(ctx, shop.ID)
+}
+
+// AttachFile attaches an uploaded file to a shop
+func (u *shopUsecase) AttachFile(ctx context.Context, customerID, id, fileID string) (*domain.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	shop, err := u.getShop(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.fileRepo.SetShop(ctx, fileID, &shop.ID); err != nil {
+		return nil, err
+	}
+
+	return u.fileRepo.GetByID(ctx, fileID)
+}
+
+// DetachFile detaches a file from a shop
+func (u *shopUsecase) DetachFile(ctx context.Context, customerID, id, fileID string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	shop, err := u.getShop(ctx, customerID, id)
+	if err != nil {
+		return err
+	}
+
+	file, err := u.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	if file.ShopID == nil || *file.ShopID != shop.ID {
+		return domain.ErrNotFound
+	}
+
+	return u.fileRepo.SetShop(ctx, fileID, nil)
+}
+
+// getShop gets a shop and checks that it belongs to the customer
+func (u *shopUsecase) getShop(ctx context.Context, customerID, id string) (*domain.Shop, error) {
+	customerObjectID, err := primitive.ObjectIDFromHex(customerID)
+	if err != nil {
+		return nil, domain.ErrInvalidID
+	}
+
+	shop, err := u.shopRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if shop.CustomerID != customerObjectID {
+		return nil, domain.ErrNotFound
+	}
+
+	return shop, nil
+}
+
+// resolveShop returns the ID of a shop of the customer, or nil when shopID is empty
+func resolveShop(ctx context.Context, shopRepo domain.ShopRepository, customerID primitive.ObjectID, shopID string) (*primitive.ObjectID, error) {
+	if shopID == "" {
+		return nil, nil
+	}
+
+	shop, err := shopRepo.GetByID(ctx, shopID)
+	if err == domain.ErrNotFound || err == domain.ErrInvalidID {
+		return nil, domain.ErrShopNotInCustomer
+	}
+	if err != nil {
+		return nil, err
+	}
+	if shop.CustomerID != customerID {
+		return nil, domain.ErrShopNotInCustomer
+	}
+
+	return &shop.ID, nil
+}
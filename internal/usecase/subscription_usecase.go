@@ -0,0 +1,443 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+type subscriptionUsecase struct {
+	subscriptionRepo domain.SubscriptionRepository
+	planRepo         domain.PlanRepository
+	customerRepo     domain.CustomerRepository
+	notifier         domain.Notifier
+	reminderDays     []int
+	contextTimeout   time.Duration
+}
+
+// NewSubscriptionUsecase creates a new subscription usecase. Renewal reminders are
+// sent the given numbers of days before a renewal date.
+func NewSubscriptionUsecase(
+	repo domain.SubscriptionRepository,
+	planRepo domain.PlanRepository,
+	customerRepo domain.CustomerRepository,
+	notifier domain.Notifier,
+	reminderDays []int,
+	timeout time.Duration,
+) domain.SubscriptionUsecase {
+	days := append([]int(nil), reminderDays...)
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+
+	return &subscriptionUsecase{
+		subscriptionRepo: repo,
+		planRepo:         planRepo,
+		customerRepo:     customerRepo,
+		notifier:         notifier,
+		reminderDays:     days,
+		contextTimeout:   timeout,
+	}
+}
+
+// Create subscribes a customer to a plan, starting with a trial when trial days are given
+func (u *subscriptionUsecase) Create(ctx context.Context, customerID string, req *domain.CreateSubscriptionRequest, actor *domain.Actor) (*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The unique index of the repository settles concurrent requests, this only
+	// answers early
+	_, err = u.subscriptionRepo.GetCurrentByCustomer(ctx, customer.ID)
+	if err == nil {
+		return nil, domain.ErrSubscriptionExists
+	}
+	if err != domain.ErrNotFound {
+		return nil, err
+	}
+
+	plan, err := u.getActivePlan(ctx, req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	workstationRange := req.WorkstationRange
+	if workstationRange == "" {
+		workstationRange = customer.WorkstationRange
+	}
+
+	now := time.Now()
+	subscription := &domain.Subscription{
+		CustomerID:         customer.ID,
+		Status:             domain.SubscriptionActive,
+		CurrentPeriodStart: now,
+		CreatedBy:          actor.ID,
+	}
+	if err := applySubscriptionTerms(subscription, plan, req.BillingCycle, workstationRange); err != nil {
+		return nil, err
+	}
+
+	if req.TrialDays > 0 {
+		trialEndsAt := now.AddDate(0, 0, req.TrialDays)
+		subscription.Status = domain.SubscriptionTrial
+		subscription.TrialEndsAt = &trialEndsAt
+		subscription.NextRenewalAt = trialEndsAt
+	} else {
+		subscription.NextRenewalAt = now.AddDate(0, req.BillingCycle.Months(), 0)
+	}
+
+	if err := u.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	u.markRenewalDue(subscription, now)
+	return subscription, nil
+}
+
+// GetByID gets a subscription by ID
+func (u *subscriptionUsecase) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	subscription, err := u.subscriptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.markRenewalDue(subscription, time.Now())
+	return subscription, nil
+}
+
+// GetByCustomer gets the subscriptions of a customer, newest first
+func (u *subscriptionUsecase) GetByCustomer(ctx context.Context, customerID string) ([]*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := u.subscriptionRepo.GetByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		u.markRenewalDue(subscription, now)
+	}
+
+	return subscriptions, nil
+}
+
+// GetUpcoming gets the subscriptions renewing within the given number of days,
+// including past due ones, soonest first. It defaults to the longest reminder period.
+func (u *subscriptionUsecase) GetUpcoming(ctx context.Context, days int) ([]*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if days <= 0 {
+		days = u.reminderWindow()
+	}
+
+	now := time.Now()
+	subscriptions, err := u.subscriptionRepo.GetRenewingBefore(ctx, now.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subscription := range subscriptions {
+		u.markRenewalDue(subscription, now)
+	}
+
+	return subscriptions, nil
+}
+
+// Renew records the payment of the next billing period. The new period starts at the
+// previous renewal date, so late renewals do not extend the subscription for free.
+// Changed terms, and the current plan price, apply from the new period. Of two
+// renewals of the same period, the second fails with ErrSubscriptionChanged.
+func (u *subscriptionUsecase) Renew(ctx context.Context, id string, req *domain.RenewSubscriptionRequest) (*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	subscription, err := u.subscriptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status == domain.SubscriptionCancelled {
+		return nil, domain.ErrSubscriptionCancelled
+	}
+
+	var plan *domain.Plan
+	if req.PlanID != "" && req.PlanID != subscription.PlanID.Hex() {
+		plan, err = u.getActivePlan(ctx, req.PlanID)
+	} else {
+		// Renewing an existing plan is allowed even when it is no longer offered
+		plan, err = u.planRepo.GetByID(ctx, subscription.PlanID.Hex())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cycle := subscription.BillingCycle
+	if req.BillingCycle != "" {
+		cycle = req.BillingCycle
+	}
+	workstationRange := subscription.WorkstationRange
+	if req.WorkstationRange != "" {
+		workstationRange = req.WorkstationRange
+	}
+	if err := applySubscriptionTerms(subscription, plan, cycle, workstationRange); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	renewalAt := subscription.NextRenewalAt
+	startNextPeriod(subscription, now)
+
+	if err := u.subscriptionRepo.StartNextPeriod(ctx, subscription, renewalAt); err != nil {
+		return nil, err
+	}
+
+	u.markRenewalDue(subscription, now)
+	return subscription, nil
+}
+
+// Cancel cancels a subscription
+func (u *subscriptionUsecase) Cancel(ctx context.Context, id string, req *domain.CancelSubscriptionRequest) (*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	subscription, err := u.subscriptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status == domain.SubscriptionCancelled {
+		return nil, domain.ErrSubscriptionCancelled
+	}
+
+	now := time.Now()
+	subscription.Status = domain.SubscriptionCancelled
+	subscription.CancelledAt = &now
+	subscription.CancelReason = req.Reason
+
+	if err := u.subscriptionRepo.Cancel(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// ProcessRenewals marks the subscriptions whose renewal date has passed as past due
+// and sends the renewal reminders that are due. Each reminder is sent once per period;
+// when the job misses a day only the latest reminder is sent. Failed notifications
+// are retried on the next run.
+func (u *subscriptionUsecase) ProcessRenewals(ctx context.Context) (*domain.RenewalReport, error) {
+	now := time.Now()
+
+	listCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	subscriptions, err := u.subscriptionRepo.GetRenewingBefore(listCtx, now.AddDate(0, 0, u.reminderWindow()))
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.RenewalReport{}
+	for _, subscription := range subscriptions {
+		if subscription.Status == domain.SubscriptionPastDue {
+			continue
+		}
+		if err := u.processRenewal(ctx, subscription, now, report); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// processRenewal handles one subscription of the renewal check
+func (u *subscriptionUsecase) processRenewal(ctx context.Context, subscription *domain.Subscription, now time.Time, report *domain.RenewalReport) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, subscription.CustomerID.Hex())
+	if err == domain.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !subscription.NextRenewalAt.After(now) {
+		// A renewal saved since the list was read wins, there is nothing to do
+		err := u.subscriptionRepo.MarkPastDue(ctx, subscription.ID, subscription.NextRenewalAt)
+		if err == domain.ErrSubscriptionChanged {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		subscription.Status = domain.SubscriptionPastDue
+		report.PastDue++
+
+		if err := u.notifier.Notify(ctx, pastDueNotification(customer, subscription)); err != nil {
+			log.Printf("Failed to notify customer %s of past due subscription %s: %v", customer.ID.Hex(), subscription.ID.Hex(), err)
+			report.Failed++
+		}
+		return nil
+	}
+
+	daysLeft := int(math.Ceil(subscription.NextRenewalAt.Sub(now).Hours() / 24))
+	due := []int{}
+	for _, days := range u.reminderDays {
+		if days >= daysLeft && !containsInt(subscription.RemindersSent, days) {
+			due = append(due, days)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	if err := u.notifier.Notify(ctx, renewalNotification(customer, subscription, daysLeft)); err != nil {
+		log.Printf("Failed to send renewal reminder of subscription %s: %v", subscription.ID.Hex(), err)
+		report.Failed++
+		return nil
+	}
+
+	if err := u.subscriptionRepo.AddRemindersSent(ctx, subscription.ID, subscription.NextRenewalAt, due); err != nil {
+		return err
+	}
+	report.Reminded++
+
+	return nil
+}
+
+// getActivePlan gets a plan that can be subscribed to
+func (u *subscriptionUsecase) getActivePlan(ctx context.Context, id string) (*domain.Plan, error) {
+	plan, err := u.planRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive {
+		return nil, domain.ErrPlanInactive
+	}
+	return plan, nil
+}
+
+// reminderWindow returns the number of days before a renewal date the first reminder is sent
+func (u *subscriptionUsecase) reminderWindow() int {
+	if len(u.reminderDays) == 0 {
+		return 0
+	}
+	return u.reminderDays[0]
+}
+
+// markRenewalDue flags the subscriptions renewing within the reminder window, or past due
+func (u *subscriptionUsecase) markRenewalDue(subscription *domain.Subscription, now time.Time) {
+	subscription.RenewalDue = subscription.Status != domain.SubscriptionCancelled &&
+		subscription.NextRenewalAt.Before(now.AddDate(0, 0, u.reminderWindow()))
+}
+
+// applySubscriptionTerms sets the plan, billing cycle and workstation range of a
+// subscription and prices its billing period
+func applySubscriptionTerms(subscription *domain.Subscription, plan *domain.Plan, cycle domain.BillingCycle, workstationRange string) error {
+	monthlyPrice, ok := plan.MonthlyPrice(workstationRange)
+	if !ok {
+		return domain.ErrPlanPriceNotFound
+	}
+
+	subscription.PlanID = plan.ID
+	subscription.PlanName = plan.Name
+	subscription.WorkstationRange = workstationRange
+	subscription.BillingCycle = cycle
+	subscription.Price = monthlyPrice * int64(cycle.Months())
+	return nil
+}
+
+// startNextPeriod renews a subscription for one billing period from its renewal date.
+// A late renewal does not move the period, so it may still be past due.
+func startNextPeriod(subscription *domain.Subscription, now time.Time) {
+	subscription.CurrentPeriodStart = subscription.NextRenewalAt
+	subscription.NextRenewalAt = subscription.CurrentPeriodStart.AddDate(0, subscription.BillingCycle.Months(), 0)
+	subscription.LastRenewedAt = &now
+	subscription.RemindersSent = nil
+	subscription.Status = domain.SubscriptionActive
+	if !subscription.NextRenewalAt.After(now) {
+		subscription.Status = domain.SubscriptionPastDue
+	}
+}
+
+func renewalNotification(customer *domain.Customer, subscription *domain.Subscription, daysLeft int) *domain.Notification {
+	return &domain.Notification{
+		CustomerID: customer.ID.Hex(),
+		Name:       customer.FullName,
+		Email:      customer.Email,
+		Phone:      customer.PhoneNumber,
+		Subject:    fmt.Sprintf("Nhắc gia hạn gói %s", subscription.PlanName),
+		Body: fmt.Sprintf(
+			"Xin chào %s,\n\nGói %s (%s máy) của bạn đến hạn gia hạn vào ngày %s, còn %d ngày.\nPhí gia hạn: %s VND/%s.\n\nVui lòng liên hệ chúng tôi để gia hạn.",
+			customer.FullName, subscription.PlanName, subscription.WorkstationRange,
+			subscription.NextRenewalAt.Format("02/01/2006"), daysLeft,
+			formatVND(subscription.Price), billingCycleLabel(subscription.BillingCycle),
+		),
+	}
+}
+
+func pastDueNotification(customer *domain.Customer, subscription *domain.Subscription) *domain.Notification {
+	return &domain.Notification{
+		CustomerID: customer.ID.Hex(),
+		Name:       customer.FullName,
+		Email:      customer.Email,
+		Phone:      customer.PhoneNumber,
+		Subject:    fmt.Sprintf("Gói %s đã quá hạn gia hạn", subscription.PlanName),
+		Body: fmt.Sprintf(
+			"Xin chào %s,\n\nGói %s (%s máy) của bạn đã quá hạn gia hạn từ ngày %s.\nPhí gia hạn: %s VND/%s.\n\nVui lòng liên hệ chúng tôi để tiếp tục sử dụng dịch vụ.",
+			customer.FullName, subscription.PlanName, subscription.WorkstationRange,
+			subscription.NextRenewalAt.Format("02/01/2006"),
+			formatVND(subscription.Price), billingCycleLabel(subscription.BillingCycle),
+		),
+	}
+}
+
+func billingCycleLabel(cycle domain.BillingCycle) string {
+	switch cycle {
+	case domain.BillingQuarterly:
+		return "quý"
+	case domain.BillingYearly:
+		return "năm"
+	default:
+		return "tháng"
+	}
+}
+
+// formatVND formats an amount with dots as thousands separators, e.g. 1.500.000
+func formatVND(amount int64) string {
+	s := strconv.FormatInt(amount, 10)
+	sign := ""
+	if amount < 0 {
+		sign, s = "-", s[1:]
+	}
+
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "." + s[i:]
+	}
+	return sign + s
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
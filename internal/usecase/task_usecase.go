@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"icafe-registration/internal/domain"
+)
+
+type taskUsecase struct {
+	taskRepo       domain.TaskRepository
+	customerRepo   domain.CustomerRepository
+	userRepo       domain.UserRepository
+	contextTimeout time.Duration
+}
+
+// NewTaskUsecase creates a new follow-up task usecase
+func NewTaskUsecase(
+	repo domain.TaskRepository,
+	customerRepo domain.CustomerRepository,
+	userRepo domain.UserRepository,
+	timeout time.Duration,
+) domain.TaskUsecase {
+	return &taskUsecase{
+		taskRepo:       repo,
+		customerRepo:   customerRepo,
+		userRepo:       userRepo,
+		contextTimeout: timeout,
+	}
+}
+
+// Create schedules a follow-up task on a customer
+func (u *taskUsecase) Create(ctx context.Context, customerID string, req *domain.CreateTaskRequest, actor *domain.Actor) (*domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	assigneeID := req.AssigneeID
+	if assigneeID == "" {
+		assigneeID = actor.ID
+	} else if err := u.checkAssignee(ctx, assigneeID); err != nil {
+		return nil, err
+	}
+
+	task := &domain.Task{
+		CustomerID: customer.ID,
+		Title:      req.Title,
+		Note:       req.Note,
+		DueAt:      req.DueAt,
+		AssigneeID: assigneeID,
+		Status:     domain.TaskOpen,
+		CreatedBy:  actor.ID,
+	}
+
+	if err := u.taskRepo.Create(ctx, task); err != nil {
+		return nil, err
+	}
+
+	markOverdue(task, time.Now())
+	return task, nil
+}
+
+// GetAll gets tasks matching filter, most urgent first.
+// Tasks are ordered by due date, so only offset pagination is supported.
+func (u *taskUsecase) GetAll(ctx context.Context, filter *domain.TaskFilter, page *domain.Pagination) ([]*domain.Task, *domain.PageInfo, error) {
+	if page.Cursor != nil {
+		return nil, nil, domain.ErrInvalidCursor
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if filter.CustomerID != "" {
+		if _, err := u.customerRepo.GetByID(ctx, filter.CustomerID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tasks, err := u.taskRepo.GetAll(ctx, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		markOverdue(task, now)
+	}
+
+	info := &domain.PageInfo{}
+	count := func(ctx context.Context) (int64, error) {
+		return u.taskRepo.Count(ctx, filter)
+	}
+	if err := countPage(ctx, page, info, count, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return tasks, info, nil
+}
+
+// Update updates a task. Only its assignee, its creator or an admin may update it.
+func (u *taskUsecase) Update(ctx context.Context, id string, req *domain.UpdateTaskRequest, actor *domain.Actor) (*domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.IsAdmin() && task.AssigneeID != actor.ID && task.CreatedBy != actor.ID {
+		return nil, domain.ErrForbidden
+	}
+
+	if req.Title != "" {
+		task.Title = req.Title
+	}
+	if req.Note != "" {
+		task.Note = req.Note
+	}
+	if req.DueAt != nil {
+		task.DueAt = *req.DueAt
+	}
+	if req.AssigneeID != "" && req.AssigneeID != task.AssigneeID {
+		if err := u.checkAssignee(ctx, req.AssigneeID); err != nil {
+			return nil, err
+		}
+		task.AssigneeID = req.AssigneeID
+	}
+
+	if req.Status != "" && req.Status != task.Status {
+		task.Status = req.Status
+		if task.Status == domain.TaskDone {
+			now := time.Now()
+			task.CompletedAt = &now
+			task.CompletedBy = actor.ID
+		} else {
+			task.CompletedAt = nil
+			task.CompletedBy = ""
+		}
+	}
+
+	if err := u.taskRepo.Update(ctx, task); err != nil {
+		return nil, err
+	}
+
+	markOverdue(task, time.Now())
+	return task, nil
+}
+
+// Delete deletes a task. Only its creator or an admin may delete it.
+func (u *taskUsecase) Delete(ctx context.Context, id string, actor *domain.Actor) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !actor.IsAdmin() && task.CreatedBy != actor.ID {
+		return domain.ErrForbidden
+	}
+
+	return u.taskRepo.Delete(ctx, id)
+}
+
+// checkAssignee ensures a task is assigned to an existing active user
+func (u *taskUsecase) checkAssignee(ctx context.Context, userID string) error {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err == domain.ErrNotFound || err == domain.ErrInvalidID {
+		return domain.ErrInvalidInput
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return domain.ErrInvalidInput
+	}
+	return nil
+}
+
+// markOverdue flags open tasks whose due date has passed
+func markOverdue(task *domain.Task, now time.Time) {
+	task.Overdue = task.Status == domain.TaskOpen && task.DueAt.Before(now)
+}
@@ -0,0 +1,471 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"icafe-registration/internal/config"
+	"icafe-registration/internal/domain"
+)
+
+const (
+	// uploadPurgeBatch is how many expired uploads are removed per query
+	uploadPurgeBatch = 100
+
+	// uploadKeyBytes is the size of the key resuming an anonymous upload
+	uploadKeyBytes = 24
+
+	// uploadCompleteLease bounds how long a request may take to turn a complete
+	// upload into a file before another request may retry
+	uploadCompleteLease = time.Hour
+)
+
+type uploadUsecase struct {
+	uploadRepo     domain.UploadRepository
+	fileUsecase    domain.FileUsecase
+	storage        domain.FileStorage
+	uploadConfig   *config.UploadConfig
+	contextTimeout time.Duration
+}
+
+// NewUploadUsecase creates a new resumable upload usecase. Bytes are staged in
+// storage until an upload is complete, then stored as a file through fileUsecase.
+// Nothing is kept in the API process, so any replica may serve any request.
+func NewUploadUsecase(
+	uploadRepo domain.UploadRepository,
+	fileUsecase domain.FileUsecase,
+	storage domain.FileStorage,
+	uploadConfig *config.UploadConfig,
+	timeout time.Duration,
+) domain.UploadUsecase {
+	return &uploadUsecase{
+		uploadRepo:     uploadRepo,
+		fileUsecase:    fileUsecase,
+		storage:        storage,
+		uploadConfig:   uploadConfig,
+		contextTimeout: timeout,
+	}
+}
+
+// Create starts an upload of a known, non-zero length. Sizes over the limit and
+// program names for documents or videos are refused before any byte is sent, and
+// installers are only taken from staff. An anonymous creator gets a key in
+// upload.Key, needed to resume the upload.
+func (u *uploadUsecase) Create(ctx context.Context, req *domain.CreateUploadRequest, actor *domain.Actor) (*domain.Upload, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	switch req.FileType {
+	case "":
+		req.FileType = domain.FileTypeDocument
+	case domain.FileTypeDocument, domain.FileTypeVideo, domain.FileTypeInstaller:
+	default:
+		return nil, domain.ErrInvalidInput
+	}
+	if req.FileType == domain.FileTypeInstaller && !actor.IsStaff() {
+		return nil, domain.ErrForbidden
+	}
+	if req.Length <= 0 {
+		return nil, domain.ErrInvalidInput
+	}
+	if req.Length > u.uploadConfig.MaxFileSize {
+		return nil, domain.ErrFileTooLarge
+	}
+
+	if req.Access != nil {
+		switch req.Access.Visibility {
+		case domain.FileVisibilityPublic, domain.FileVisibilityPrivate:
+		case domain.FileVisibilityCustomer:
+			if req.Access.CustomerID == nil {
+				return nil, domain.ErrInvalidInput
+			}
+		default:
+			return nil, domain.ErrInvalidInput
+		}
+	}
+
+	name := originalFileName(req.FileName)
+	if req.FileType != domain.FileTypeInstaller && isExecutableName(name) {
+		return nil, domain.ErrInvalidFileType
+	}
+
+	upload := &domain.Upload{
+		FileType:  req.FileType,
+		FileName:  name,
+		Metadata:  req.Metadata,
+		Access:    req.Access,
+		Length:    req.Length,
+		ExpiresAt: time.Now().Add(u.uploadConfig.ResumableExpiry),
+	}
+	if actor != nil && actor.ID != "" {
+		upload.CreatedBy = actor.ID
+	} else {
+		key, err := randomHex(uploadKeyBytes)
+		if err != nil {
+			return nil, err
+		}
+		upload.Key, upload.KeyHash = key, hashToken(key)
+	}
+	if err := u.uploadRepo.Create(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// GetByID gets an upload of the actor by ID
+func (u *uploadUsecase) GetByID(ctx context.Context, id, key string, actor *domain.Actor) (*domain.Upload, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.getOwned(ctx, id, key, actor)
+}
+
+// getOwned gets an upload its creator or staff resume. Uploads of someone else
+// are reported as not found, so their IDs cannot be probed.
+func (u *uploadUsecase) getOwned(ctx context.Context, id, key string, actor *domain.Actor) (*domain.Upload, error) {
+	upload, err := u.uploadRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canResumeUpload(upload, key, actor) {
+		return nil, domain.ErrNotFound
+	}
+	return upload, nil
+}
+
+// canResumeUpload reports whether actor, or the holder of key, created upload or is staff
+func canResumeUpload(upload *domain.Upload, key string, actor *domain.Actor) bool {
+	if actor.IsStaff() {
+		return true
+	}
+	if upload.CreatedBy != "" {
+		return actor != nil && actor.ID == upload.CreatedBy
+	}
+	return upload.KeyHash != "" && key != "" &&
+		subtle.ConstantTimeCompare([]byte(upload.KeyHash), []byte(hashToken(key))) == 1
+}
+
+// Append writes a PATCH body at offset, which must be the current offset of the
+// upload. Without a checksum the bytes received before an interrupted connection
+// are kept, so the client resumes after them; with a checksum the whole body is
+// discarded unless it matches. The file is created when the last byte arrives.
+func (u *uploadUsecase) Append(
+	ctx context.Context,
+	id, key string,
+	actor *domain.Actor,
+	offset int64,
+	checksum *domain.UploadChecksum,
+	body io.Reader,
+) (*domain.Upload, error) {
+
+	var sum hash.Hash
+	if checksum != nil {
+		if sum = newChecksumHash(checksum.Algorithm); sum == nil {
+			return nil, domain.ErrUploadChecksumAlgorithm
+		}
+	}
+
+	upload, err := u.GetByID(ctx, id, key, actor)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, domain.ErrUploadExpired
+	}
+	if offset != upload.Offset {
+		return nil, domain.ErrUploadOffsetMismatch
+	}
+	if upload.FileID != nil {
+		return upload, nil
+	}
+
+	// The body is spooled first, so what arrived before a dropped connection can
+	// still be stored as a part of known size
+	spool, err := os.CreateTemp("", "tus-part-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	var dst io.Writer = spool
+	if sum != nil {
+		dst = io.MultiWriter(spool, sum)
+	}
+
+	// Read one byte more than remains to detect bodies past the upload length
+	remaining := upload.Length - offset
+	n, copyErr := io.Copy(dst, io.LimitReader(body, remaining+1))
+	if n > remaining {
+		return nil, domain.ErrFileTooLarge
+	}
+	if copyErr == nil && sum != nil && !bytes.Equal(sum.Sum(nil), checksum.Sum) {
+		copyErr = domain.ErrUploadChecksumMismatch
+	}
+	if copyErr != nil && sum != nil {
+		return nil, copyErr
+	}
+
+	// Record what arrived even when the connection dropped, the client resumes from there
+	storeCtx := context.WithoutCancel(ctx)
+	if n > 0 {
+		if err := u.addPart(storeCtx, upload, offset, spool, n); err != nil {
+			return nil, err
+		}
+	}
+	if copyErr != nil {
+		return nil, copyErr
+	}
+
+	// Refuse disallowed content as soon as its type can be detected, the file
+	// usecase checks it again on completion
+	if offset < sniffLength && upload.Offset >= sniffLength && upload.Offset < upload.Length {
+		if err := u.checkType(storeCtx, upload); err != nil {
+			removeCtx, cancel := context.WithTimeout(storeCtx, u.contextTimeout)
+			defer cancel()
+			u.remove(removeCtx, upload)
+			return nil, err
+		}
+	}
+
+	if upload.Offset == upload.Length {
+		if err := u.complete(storeCtx, upload); err != nil {
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+// addPart stores n spooled bytes as the part of an upload starting at offset. Part
+// keys are unique, and the part is only recorded while the upload is still at
+// offset: of concurrent requests at the same offset, on any replica, one wins and
+// the others drop their part and get ErrUploadOffsetMismatch.
+func (u *uploadUsecase) addPart(ctx context.Context, upload *domain.Upload, offset int64, spool io.ReadSeeker, n int64) error {
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	suffix, err := randomHex(8)
+	if err != nil {
+		return err
+	}
+	part := domain.UploadPart{
+		Key:  fmt.Sprintf("%s%020d-%s", uploadPartPrefix(upload), offset, suffix),
+		Size: n,
+	}
+	if err := u.storage.Put(ctx, part.Key, io.LimitReader(spool, n), n, "application/octet-stream"); err != nil {
+		return err
+	}
+
+	recordCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	if err := u.uploadRepo.AddPart(recordCtx, upload.ID, offset, part); err != nil {
+		u.removePart(ctx, part.Key)
+		return err
+	}
+
+	upload.Parts = append(upload.Parts, part)
+	upload.Offset = offset + n
+	return nil
+}
+
+// checkType detects the type of an upload from its first bytes
+func (u *uploadUsecase) checkType(ctx context.Context, upload *domain.Upload) error {
+	content := u.openParts(ctx, upload)
+	defer content.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+
+	if !isAllowedType(u.uploadConfig, detectContentType(head[:n], upload.FileName), upload.FileName, upload.FileType) {
+		return domain.ErrInvalidFileType
+	}
+	return nil
+}
+
+// complete stores the staged parts as a file and links it to the upload. A lease
+// on the upload keeps concurrent requests from creating the file twice.
+func (u *uploadUsecase) complete(ctx context.Context, upload *domain.Upload) error {
+	lockCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	err := u.uploadRepo.Lock(lockCtx, upload.ID, time.Now().Add(uploadCompleteLease))
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	content := u.openParts(ctx, upload)
+	file, err := u.fileUsecase.Ingest(ctx, upload.FileName, content, upload.Length, upload.FileType, upload.Access)
+	content.Close()
+
+	dbCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	if err != nil {
+		if err == domain.ErrInvalidFileType || err == domain.ErrFileTooLarge || err == domain.ErrFileCustomerNotFound {
+			u.remove(dbCtx, upload)
+		} else if unlockErr := u.uploadRepo.Unlock(dbCtx, upload.ID); unlockErr != nil {
+			log.Printf("Failed to unlock upload %s: %v", upload.ID.Hex(), unlockErr)
+		}
+		return err
+	}
+
+	if err := u.uploadRepo.Complete(dbCtx, upload.ID, file.ID); err != nil {
+		return err
+	}
+	upload.FileID = &file.ID
+
+	for _, part := range upload.Parts {
+		u.removePart(ctx, part.Key)
+	}
+	upload.Parts = nil
+	return nil
+}
+
+// Terminate cancels an upload and discards its staged bytes. The file of a
+// completed upload is kept.
+func (u *uploadUsecase) Terminate(ctx context.Context, id, key string, actor *domain.Actor) error {
+	upload, err := u.GetByID(ctx, id, key, actor)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.remove(ctx, upload)
+}
+
+// PurgeExpired removes the uploads past their expiry with their staged bytes
+func (u *uploadUsecase) PurgeExpired(ctx context.Context) (int64, error) {
+	var purged int64
+	for {
+		listCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+		uploads, err := u.uploadRepo.GetExpired(listCtx, time.Now(), uploadPurgeBatch)
+		cancel()
+		if err != nil {
+			return purged, err
+		}
+
+		for _, upload := range uploads {
+			removeCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+			err := u.remove(removeCtx, upload)
+			cancel()
+			if err != nil && err != domain.ErrNotFound {
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(uploads) < uploadPurgeBatch {
+			return purged, nil
+		}
+	}
+}
+
+// remove deletes an upload record, then its staged bytes. A request still
+// appending finds the record gone and drops its part.
+func (u *uploadUsecase) remove(ctx context.Context, upload *domain.Upload) error {
+	if err := u.uploadRepo.Delete(ctx, upload.ID); err != nil {
+		return err
+	}
+	u.removeParts(ctx, upload)
+	return nil
+}
+
+// removeParts deletes every part stored for an upload, including parts of
+// requests that failed before recording them
+func (u *uploadUsecase) removeParts(ctx context.Context, upload *domain.Upload) {
+	parts, err := u.storage.List(ctx, uploadPartPrefix(upload))
+	if err != nil {
+		log.Printf("Failed to list the parts of upload %s: %v", upload.ID.Hex(), err)
+		return
+	}
+	for _, part := range parts {
+		u.removePart(ctx, part.Key)
+	}
+}
+
+// removePart deletes a staged part, logging failures as the part is garbage by then
+func (u *uploadUsecase) removePart(ctx context.Context, key string) {
+	if err := u.storage.Delete(ctx, key); err != nil && err != domain.ErrNotFound {
+		log.Printf("Failed to remove upload part %s: %v", key, err)
+	}
+}
+
+// openParts reads the staged parts of an upload in order
+func (u *uploadUsecase) openParts(ctx context.Context, upload *domain.Upload) io.ReadCloser {
+	return &partsReader{ctx: ctx, storage: u.storage, parts: upload.Parts}
+}
+
+// uploadPartPrefix returns the storage prefix of the parts of an upload
+func uploadPartPrefix(upload *domain.Upload) string {
+	return "uploads/" + upload.ID.Hex() + "/"
+}
+
+// partsReader reads parts one after the other, opening each when the previous one ends
+type partsReader struct {
+	ctx     context.Context
+	storage domain.FileStorage
+	parts   []domain.UploadPart
+	current io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			body, _, err := r.storage.Get(r.ctx, r.parts[0].Key)
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.parts = body, r.parts[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Close()
+}
+
+// newChecksumHash returns the hash of a tus checksum algorithm, nil when unsupported
+func newChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "sha1":
+		return sha1.New()
+	case "md5":
+		return md5.New()
+	case "sha256":
+		return sha256.New()
+	}
+	return nil
+}
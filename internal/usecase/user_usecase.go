@@ -87,21 +87,25 @@ func (u *userUsecase) GetByID(ctx context.Context, id string) (*domain.User, err
 }
 
 // GetAll gets all users with pagination
-func (u *userUsecase) GetAll(ctx context.Context, limit, offset int64) ([]*domain.User, int64, error) {
+func (u *userUsecase) GetAll(ctx context.Context, page *domain.Pagination) ([]*domain.User, *domain.PageInfo, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	users, err := u.userRepo.GetAll(ctx, limit, offset)
+	users, err := u.userRepo.GetAll(ctx, page)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
-	total, err := u.userRepo.Count(ctx)
-	if err != nil {
-		return nil, 0, err
+	info := &domain.PageInfo{}
+	if n := len(users); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, users[n-1].CreatedOn, users[n-1].ID)
+	}
+
+	if err := countPage(ctx, page, info, u.userRepo.Count, u.userRepo.EstimatedCount); err != nil {
+		return nil, nil, err
 	}
 
-	return users, total, nil
+	return users, info, nil
 }
 
 // Update updates a user
@@ -217,10 +221,52 @@ func (u *userUsecase) ChangePassword(ctx context.Context, id string, req *domain
 	return u.userRepo.Update(ctx, id, user)
 }
 
-// Delete deletes a user
-func (u *userUsecase) Delete(ctx context.Context, id string) error {
+// Delete moves a user to the trash
+func (u *userUsecase) Delete(ctx context.Context, id string, deletedBy string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.userRepo.Delete(ctx, id, deletedBy)
+}
+
+// GetTrash gets users in the trash with pagination
+func (u *userUsecase) GetTrash(ctx context.Context, page *domain.Pagination) ([]*domain.User, *domain.PageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	items, err := u.userRepo.GetDeleted(ctx, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &domain.PageInfo{}
+	if n := len(items); n > 0 {
+		info.NextCursor = page.NextCursorAfter(n, items[n-1].CreatedOn, items[n-1].ID)
+	}
+
+	if err := countPage(ctx, page, info, u.userRepo.CountDeleted, nil); err != nil {
+		return nil, nil, err
+	}
+
+	return items, info, nil
+}
+
+// Restore moves a user out of the trash
+func (u *userUsecase) Restore(ctx context.Context, id string) (*domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if err := u.userRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return u.userRepo.GetByID(ctx, id)
+}
+
+// Purge permanently deletes users trashed before the given time
+func (u *userUsecase) Purge(ctx context.Context, before time.Time) (int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	return u.userRepo.Delete(ctx, id)
+	return u.userRepo.Purge(ctx, before)
 }
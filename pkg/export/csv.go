@@ -0,0 +1,36 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// utf8BOM makes Excel detect UTF-8 so Vietnamese names are not garbled
+const utf8BOM = "\xEF\xBB\xBF"
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: csv.NewWriter(w)}, nil
+}
+
+// WriteRow writes a CSV record
+func (w *csvWriter) WriteRow(values []string) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = sanitizeCell(v)
+	}
+
+	return w.writer.Write(record)
+}
+
+// Close flushes buffered data
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
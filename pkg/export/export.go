@@ -0,0 +1,62 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Format represents an export file format
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for an unknown export format
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Writer writes tabular rows to an export file.
+// Rows are flushed as they are written so large exports never sit in memory.
+type Writer interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+// NewWriter creates a row writer for the given format
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns the MIME type of the format
+func ContentType(format Format) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// numericLike matches values such as phone numbers that legitimately start with + or -
+var numericLike = regexp.MustCompile(`^[+-]?[0-9][0-9 .()-]*$`)
+
+// sanitizeCell neutralizes values that spreadsheet applications would evaluate as formulas
+func sanitizeCell(value string) string {
+	if value == "" || numericLike.MatchString(value) {
+		return value
+	}
+	if strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+// Package imaging decodes, resizes and strips the metadata of JPEG, PNG and
+// GIF images in pure Go.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif" // registers GIF decoding
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+)
+
+// MaxPixels bounds the images that are decoded, a small compressed file can
+// otherwise claim enough pixels to exhaust memory
+const MaxPixels = 50_000_000
+
+// ErrTooLarge is returned when an image has more than MaxPixels pixels
+var ErrTooLarge = errors.New("image has too many pixels")
+
+// Decode decodes an image and turns it upright as its EXIF orientation says.
+// Animated GIFs decode to their first frame.
+func Decode(data []byte) (*image.RGBA, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return Orient(toRGBA(img), Orientation(data)), nil
+}
+
+// Fit scales an image down to fit within a maxSize square, keeping its aspect
+// ratio. Every source pixel is averaged in, so thumbnails do not alias. Images
+// that fit already are returned as they are.
+func Fit(img *image.RGBA, maxSize int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+
+	dw, dh := maxSize, maxSize
+	if w >= h {
+		dh = max(1, int(math.Round(float64(h)*float64(maxSize)/float64(w))))
+	} else {
+		dw = max(1, int(math.Round(float64(w)*float64(maxSize)/float64(h))))
+	}
+	return resize(img, dw, dh)
+}
+
+// toRGBA converts an image to premultiplied RGBA with its origin at zero
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return rgba
+}
+
+// contribution lists the weights of the source pixels, from start, that make
+// up one resized pixel
+type contribution struct {
+	start   int
+	weights []float32
+}
+
+// contributions computes box filter weights to shrink srcLen pixels to dstLen:
+// each resized pixel averages the source pixels it covers, partly covered ones
+// by their coverage
+func contributions(srcLen, dstLen int) []contribution {
+	scale := float64(srcLen) / float64(dstLen)
+	out := make([]contribution, dstLen)
+	for i := range out {
+		lo := float64(i) * scale
+		hi := lo + scale
+		first := int(lo)
+		last := min(int(math.Ceil(hi)), srcLen)
+
+		weights := make([]float32, last-first)
+		for j := first; j < last; j++ {
+			cover := math.Min(hi, float64(j+1)) - math.Max(lo, float64(j))
+			weights[j-first] = float32(cover / scale)
+		}
+		out[i] = contribution{start: first, weights: weights}
+	}
+	return out
+}
+
+// resize shrinks an image to dw x dh, horizontally then vertically
+func resize(src *image.RGBA, dw, dh int) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	xs := contributions(w, dw)
+	ys := contributions(h, dh)
+
+	tmp := make([]float32, dw*h*4)
+	for y := 0; y < h; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, c := range xs {
+			var r, g, b, a float32
+			for k, weight := range c.weights {
+				p := row[(c.start+k)*4:]
+				r += float32(p[0]) * weight
+				g += float32(p[1]) * weight
+				b += float32(p[2]) * weight
+				a += float32(p[3]) * weight
+			}
+			t := tmp[(y*dw+x)*4:]
+			t[0], t[1], t[2], t[3] = r, g, b, a
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y, c := range ys {
+		for x := 0; x < dw; x++ {
+			var r, g, b, a float32
+			for k, weight := range c.weights {
+				t := tmp[((c.start+k)*dw+x)*4:]
+				r += t[0] * weight
+				g += t[1] * weight
+				b += t[2] * weight
+				a += t[3] * weight
+			}
+			// Premultiplied colors cannot exceed alpha
+			alpha := clamp(a)
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0] = min(clamp(r), alpha)
+			d[1] = min(clamp(g), alpha)
+			d[2] = min(clamp(b), alpha)
+			d[3] = alpha
+		}
+	}
+	return dst
+}
+
+func clamp(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
@@ -0,0 +1,173 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image/jpeg"
+	"io"
+)
+
+// maxJPEGHeader bounds the segments buffered before the image data of a JPEG
+const maxJPEGHeader = 4 << 20
+
+// pngMetadataChunks are the PNG chunks dropped by StripMetadata
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true, // Exif
+	"tEXt": true, // text, including XMP
+	"zTXt": true,
+	"iTXt": true,
+}
+
+// StripMetadata removes the Exif, XMP and IPTC metadata of a JPEG or PNG
+// while it is read, as it may hold GPS positions and camera serials. A JPEG
+// that is not upright is turned upright and re-encoded, since it loses the
+// orientation. Other content, and content that cannot be parsed, is read
+// unchanged.
+func StripMetadata(r io.Reader, contentType string) (io.Reader, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(r)
+	case "image/png":
+		return &pngStripper{br: bufio.NewReader(r)}, nil
+	}
+	return r, nil
+}
+
+// stripJPEG buffers the segments before the image data, drops the APP1 (Exif,
+// XMP) and APP13 (IPTC) ones, and streams the image data after them
+func stripJPEG(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	var raw, kept bytes.Buffer
+	unchanged := func() (io.Reader, error) {
+		return io.MultiReader(&raw, br), nil
+	}
+
+	read := func(n int) ([]byte, error) {
+		buf := make([]byte, n)
+		m, err := io.ReadFull(br, buf)
+		raw.Write(buf[:m])
+		return buf, err
+	}
+
+	soi, err := read(2)
+	if err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return unchangedOr(unchanged, err)
+	}
+	kept.Write(soi)
+
+	orientation := 1
+	for raw.Len() <= maxJPEGHeader {
+		marker, err := read(2)
+		if err != nil || marker[0] != 0xFF {
+			return unchangedOr(unchanged, err)
+		}
+
+		switch {
+		case marker[1] == 0xDA: // start of scan, the image data follows
+			kept.Write(marker)
+			if orientation != 1 {
+				return reorientJPEG(raw.Bytes(), kept.Bytes(), br, orientation)
+			}
+			return io.MultiReader(&kept, br), nil
+		case marker[1] == 0xD9 || marker[1] == 0x01 || (marker[1] >= 0xD0 && marker[1] <= 0xD7):
+			kept.Write(marker) // no length
+			continue
+		}
+
+		length, err := read(2)
+		if err != nil || binary.BigEndian.Uint16(length) < 2 {
+			return unchangedOr(unchanged, err)
+		}
+		payload, err := read(int(binary.BigEndian.Uint16(length)) - 2)
+		if err != nil {
+			return unchangedOr(unchanged, err)
+		}
+
+		switch marker[1] {
+		case 0xE1:
+			if o := exifOrientation(payload); o != 0 {
+				orientation = o
+			}
+			continue
+		case 0xED:
+			continue
+		}
+		kept.Write(marker)
+		kept.Write(length)
+		kept.Write(payload)
+	}
+	return unchanged()
+}
+
+// unchangedOr reads the content unchanged when it is truncated, and fails on
+// other read errors
+func unchangedOr(unchanged func() (io.Reader, error), err error) (io.Reader, error) {
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return unchanged()
+}
+
+// reorientJPEG turns a JPEG upright and re-encodes it without metadata. When
+// it cannot be decoded, it is only stripped.
+func reorientJPEG(header, stripped []byte, rest io.Reader, orientation int) (io.Reader, error) {
+	body, err := io.ReadAll(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := Decode(append(header, body...))
+	if err != nil {
+		return io.MultiReader(bytes.NewReader(stripped), bytes.NewReader(body)), nil
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// pngStripper copies PNG chunks, skipping the metadata ones
+type pngStripper struct {
+	br        *bufio.Reader
+	started   bool
+	remaining int64 // bytes left of the signature or chunk being copied
+}
+
+func (s *pngStripper) Read(p []byte) (int, error) {
+	for s.remaining == 0 {
+		if !s.started {
+			s.started = true
+			s.remaining = 8 // signature
+			break
+		}
+
+		header, err := s.br.Peek(8)
+		if len(header) < 8 {
+			if len(header) == 0 || (err != nil && err != io.EOF) {
+				return 0, err
+			}
+			s.remaining = int64(len(header)) // trailing bytes
+			break
+		}
+
+		// length, type, data, CRC
+		size := int64(binary.BigEndian.Uint32(header[:4])) + 12
+		if pngMetadataChunks[string(header[4:8])] {
+			if _, err := s.br.Discard(int(size)); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		s.remaining = size
+	}
+
+	if int64(len(p)) > s.remaining {
+		p = p[:s.remaining]
+	}
+	n, err := s.br.Read(p)
+	s.remaining -= int64(n)
+	return n, err
+}
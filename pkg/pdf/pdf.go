@@ -0,0 +1,453 @@
+// Package pdf writes simple single-column PDF documents such as invoices.
+// Text uses an embedded TrueType font when one is given, so Vietnamese renders
+// with its diacritics; otherwise it falls back to the standard Helvetica fonts
+// with diacritics removed.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A4 page size in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Document is a PDF document under construction. Coordinates are in points
+// from the top-left corner of the page.
+type Document struct {
+	width, height float64
+	title         string
+	pages         []*bytes.Buffer
+	page          *bytes.Buffer
+	regular, bold face
+	font          face
+	size          float64
+}
+
+// New creates an A4 portrait document with the standard fonts
+func New() *Document {
+	d := &Document{width: A4Width, height: A4Height, size: 10}
+	d.SetFonts(nil, nil)
+	return d
+}
+
+// SetFonts sets the regular and bold fonts. A nil font falls back to Helvetica.
+func (d *Document) SetFonts(regular, bold *Font) {
+	d.regular = newFace("F1", regular, helvetica)
+	d.bold = newFace("F2", bold, helveticaBold)
+	d.font = d.regular
+}
+
+// SetTitle sets the document title shown by viewers
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// Width returns the page width
+func (d *Document) Width() float64 {
+	return d.width
+}
+
+// Height returns the page height
+func (d *Document) Height() float64 {
+	return d.height
+}
+
+// AddPage starts a new page
+func (d *Document) AddPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+// SetFont selects the regular or bold font and its size
+func (d *Document) SetFont(bold bool, size float64) {
+	d.font = d.regular
+	if bold {
+		d.font = d.bold
+	}
+	d.size = size
+}
+
+// TextWidth returns the width of s in the current font
+func (d *Document) TextWidth(s string) float64 {
+	return d.font.width(norm.NFC.String(s)) * d.size / 1000
+}
+
+// Text draws s with its baseline starting at (x, y)
+func (d *Document) Text(x, y float64, s string) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	s = norm.NFC.String(s)
+	fmt.Fprintf(d.page, "BT /%s %s Tf %s %s Td %s Tj ET\n",
+		d.font.resource(), num(d.size), num(x), num(d.height-y), d.font.encode(s))
+}
+
+// TextRight draws s ending at x
+func (d *Document) TextRight(x, y float64, s string) {
+	d.Text(x-d.TextWidth(s), y, s)
+}
+
+// TextCenter draws s centred on x
+func (d *Document) TextCenter(x, y float64, s string) {
+	d.Text(x-d.TextWidth(s)/2, y, s)
+}
+
+// Wrap splits s into lines no wider than width in the current font
+func (d *Document) Wrap(s string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && d.TextWidth(candidate) > width {
+				lines = append(lines, line)
+				candidate = word
+			}
+			line = candidate
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Line draws a line
+func (d *Document) Line(x1, y1, x2, y2, lineWidth float64) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.page, "%s w %s %s m %s %s l S\n",
+		num(lineWidth), num(x1), num(d.height-y1), num(x2), num(d.height-y2))
+}
+
+// Rect draws the outline of a rectangle whose top-left corner is (x, y)
+func (d *Document) Rect(x, y, w, h, lineWidth float64) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.page, "%s w %s %s %s %s re S\n",
+		num(lineWidth), num(x), num(d.height-y-h), num(w), num(h))
+}
+
+// WriteTo writes the document
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	objects := &objectWriter{}
+	catalog := objects.reserve()
+	pages := objects.reserve()
+	info := objects.reserve()
+
+	fonts := map[string]int{}
+	for _, f := range []face{d.regular, d.bold} {
+		fonts[f.resource()] = f.write(objects)
+	}
+
+	var resources strings.Builder
+	resources.WriteString("<< /Font <<")
+	for _, name := range []string{d.regular.resource(), d.bold.resource()} {
+		fmt.Fprintf(&resources, " /%s %d 0 R", name, fonts[name])
+	}
+	resources.WriteString(" >> >>")
+
+	kids := make([]string, 0, len(d.pages))
+	for _, content := range d.pages {
+		page := objects.reserve()
+		stream := objects.stream("", content.Bytes())
+		objects.set(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pages, num(d.width), num(d.height), resources.String(), stream))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	objects.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	objects.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	objects.set(info, fmt.Sprintf("<< /Producer (icafe-registration) /Title %s >>", textString(d.title)))
+
+	return objects.writeTo(w, catalog, info)
+}
+
+// face is a font as used by one document
+type face interface {
+	resource() string
+	encode(s string) string
+	width(s string) float64
+	write(objects *objectWriter) int
+}
+
+func newFace(resource string, font *Font, fallback *standardFont) face {
+	if font == nil {
+		return &standardFace{name: resource, font: fallback}
+	}
+	return &trueTypeFace{name: resource, font: font, used: map[uint16]rune{}}
+}
+
+// trueTypeFace embeds the used glyphs of a TrueType font as a CID font
+type trueTypeFace struct {
+	name string
+	font *Font
+	used map[uint16]rune
+}
+
+func (f *trueTypeFace) resource() string {
+	return f.name
+}
+
+func (f *trueTypeFace) encode(s string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		gid := f.font.glyph(r)
+		if gid != 0 {
+			f.used[gid] = r
+		}
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+func (f *trueTypeFace) width(s string) float64 {
+	total := 0
+	for _, r := range s {
+		total += f.font.advance(f.font.glyph(r))
+	}
+	return float64(total)
+}
+
+func (f *trueTypeFace) write(objects *objectWriter) int {
+	gids := make([]int, 0, len(f.used))
+	for gid := range f.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	// Subset fonts are named with a tag derived from their glyphs
+	crc := crc32.NewIEEE()
+	for _, gid := range gids {
+		fmt.Fprint(crc, gid, ",")
+	}
+	sum := crc.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	baseName := string(tag) + "+" + pdfName(f.font.name)
+
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, f.font.advance(uint16(gid)))
+	}
+
+	fontFile := f.font.subset(f.used)
+	file := objects.stream(fmt.Sprintf("/Length1 %d", len(fontFile)), fontFile)
+
+	descriptor := objects.add(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		baseName, f.font.bbox[0], f.font.bbox[1], f.font.bbox[2], f.font.bbox[3],
+		f.font.ascent, f.font.descent, f.font.ascent, file))
+
+	cidFont := objects.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+		baseName, descriptor, widths.String()))
+
+	toUnicode := objects.stream("", f.toUnicode(gids))
+
+	return objects.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		baseName, cidFont, toUnicode))
+}
+
+// toUnicode builds the CMap that lets viewers copy and search the text
+func (f *trueTypeFace) toUnicode(gids []int) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	// bfchar blocks hold at most 100 entries
+	for start := 0; start < len(gids); start += 100 {
+		end := start + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", gid)
+			for _, unit := range utf16.Encode([]rune{f.used[uint16(gid)]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// standardFace uses one of the standard Type 1 fonts every viewer has
+type standardFace struct {
+	name string
+	font *standardFont
+}
+
+func (f *standardFace) resource() string {
+	return f.name
+}
+
+func (f *standardFace) encode(s string) string {
+	return literalString(asciiFold(s))
+}
+
+func (f *standardFace) width(s string) float64 {
+	total := 0
+	for _, r := range asciiFold(s) {
+		total += f.font.widths[r-' ']
+	}
+	return float64(total)
+}
+
+func (f *standardFace) write(objects *objectWriter) int {
+	return objects.add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.font.name))
+}
+
+// asciiFold removes diacritics and replaces the remaining non-ASCII characters,
+// since the standard fonts cannot show Vietnamese letters
+func asciiFold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r == 'đ':
+			b.WriteByte('d')
+		case r == 'Đ':
+			b.WriteByte('D')
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// objectWriter collects the numbered objects of a PDF file
+type objectWriter struct {
+	bodies [][]byte
+}
+
+// reserve allocates an object number whose body is set later
+func (o *objectWriter) reserve() int {
+	o.bodies = append(o.bodies, nil)
+	return len(o.bodies)
+}
+
+func (o *objectWriter) set(id int, body string) {
+	o.bodies[id-1] = []byte(body)
+}
+
+func (o *objectWriter) add(body string) int {
+	id := o.reserve()
+	o.set(id, body)
+	return id
+}
+
+// stream adds a Flate-compressed stream with optional extra dictionary entries
+func (o *objectWriter) stream(extra string, data []byte) int {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "<< /Length %d /Filter /FlateDecode %s>>\nstream\n", compressed.Len(), extra)
+	body.Write(compressed.Bytes())
+	body.WriteString("\nendstream")
+
+	id := o.reserve()
+	o.bodies[id-1] = body.Bytes()
+	return id
+}
+
+func (o *objectWriter) writeTo(w io.Writer, root, info int) (int64, error) {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	offsets := make([]int, len(o.bodies))
+	for i, body := range o.bodies {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(o.bodies)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(o.bodies)+1, root, info, xref)
+
+	return out.WriteTo(w)
+}
+
+// num formats a coordinate without a trailing zero fraction
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// literalString escapes an ASCII string as a PDF literal string
+func literalString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return "(" + r.Replace(s) + ")"
+}
+
+// textString encodes a string for the document information as UTF-16 with a byte order mark
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// pdfName keeps the characters allowed in a PDF name without escaping
+func pdfName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r > ' ' && r < 127 && !strings.ContainsRune("()<>[]{}/%#", r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "Font"
+	}
+	return b.String()
+}
@@ -0,0 +1,462 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+)
+
+// ErrInvalidFont is returned when a font file is not a TrueType font this package can embed
+var ErrInvalidFont = errors.New("invalid or unsupported TrueType font")
+
+// Font is a parsed TrueType font. It is safe to share between documents;
+// each document embeds only the glyphs it uses.
+type Font struct {
+	name       string
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	numGlyphs  int
+	longLoca   bool
+	advances   []uint16
+	cmap       map[rune]uint16
+	tables     map[string][]byte
+}
+
+// ParseTrueType parses a TrueType (glyf outline) font file
+func ParseTrueType(data []byte) (*Font, error) {
+	if len(data) < 12 {
+		return nil, ErrInvalidFont
+	}
+	if v := binary.BigEndian.Uint32(data); v != 0x00010000 && v != 0x74727565 { // 1.0 or 'true'
+		return nil, ErrInvalidFont
+	}
+
+	f := &Font{tables: map[string][]byte{}}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, ErrInvalidFont
+		}
+		tag := string(data[rec : rec+4])
+		offset := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, ErrInvalidFont
+		}
+		f.tables[tag] = data[offset : offset+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if _, ok := f.tables[tag]; !ok {
+			return nil, fmt.Errorf("%w: missing %s table", ErrInvalidFont, tag)
+		}
+	}
+
+	head := f.tables["head"]
+	hhea := f.tables["hhea"]
+	maxp := f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, ErrInvalidFont
+	}
+
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, ErrInvalidFont
+	}
+	for i := range f.bbox {
+		f.bbox[i] = f.scale(int(int16(binary.BigEndian.Uint16(head[36+2*i:]))))
+	}
+	f.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+	f.ascent = f.scale(int(int16(binary.BigEndian.Uint16(hhea[4:]))))
+	f.descent = f.scale(int(int16(binary.BigEndian.Uint16(hhea[6:]))))
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	if err := f.parseMetrics(int(binary.BigEndian.Uint16(hhea[34:]))); err != nil {
+		return nil, err
+	}
+	if err := f.parseCmap(); err != nil {
+		return nil, err
+	}
+	f.name = f.parseName()
+
+	return f, nil
+}
+
+// Name returns the PostScript name of the font
+func (f *Font) Name() string {
+	return f.name
+}
+
+// scale converts font units to thousandths of an em, as PDF widths use
+func (f *Font) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+func (f *Font) parseMetrics(numberOfHMetrics int) error {
+	hmtx := f.tables["hmtx"]
+	if numberOfHMetrics == 0 || len(hmtx) < 4*numberOfHMetrics {
+		return ErrInvalidFont
+	}
+
+	f.advances = make([]uint16, f.numGlyphs)
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		i := gid
+		if i >= numberOfHMetrics {
+			i = numberOfHMetrics - 1
+		}
+		f.advances[gid] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+	return nil
+}
+
+// parseCmap reads the Unicode character map, preferring the full-repertoire format 12
+func (f *Font) parseCmap() error {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return ErrInvalidFont
+	}
+
+	var bmp, full []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables; i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			return ErrInvalidFont
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if offset+4 > len(cmap) {
+			continue
+		}
+
+		sub := cmap[offset:]
+		format := binary.BigEndian.Uint16(sub)
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		switch {
+		case unicode && format == 12:
+			full = sub
+		case unicode && format == 4:
+			bmp = sub
+		}
+	}
+
+	f.cmap = map[rune]uint16{}
+	switch {
+	case full != nil:
+		return f.parseCmap12(full)
+	case bmp != nil:
+		return f.parseCmap4(bmp)
+	}
+	return fmt.Errorf("%w: no Unicode cmap", ErrInvalidFont)
+}
+
+func (f *Font) parseCmap4(sub []byte) error {
+	if len(sub) < 14 {
+		return ErrInvalidFont
+	}
+	segCount := int(binary.BigEndian.Uint16(sub[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + 2*segCount + 2
+	idDeltas := startCodes + 2*segCount
+	idRangeOffsets := idDeltas + 2*segCount
+	if idRangeOffsets+2*segCount > len(sub) {
+		return ErrInvalidFont
+	}
+
+	for i := 0; i < segCount; i++ {
+		end := int(binary.BigEndian.Uint16(sub[endCodes+2*i:]))
+		start := int(binary.BigEndian.Uint16(sub[startCodes+2*i:]))
+		delta := int(binary.BigEndian.Uint16(sub[idDeltas+2*i:]))
+		rangeOffset := int(binary.BigEndian.Uint16(sub[idRangeOffsets+2*i:]))
+
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var gid int
+			if rangeOffset == 0 {
+				gid = (c + delta) & 0xFFFF
+			} else {
+				addr := idRangeOffsets + 2*i + rangeOffset + 2*(c-start)
+				if addr+2 > len(sub) {
+					continue
+				}
+				gid = int(binary.BigEndian.Uint16(sub[addr:]))
+				if gid != 0 {
+					gid = (gid + delta) & 0xFFFF
+				}
+			}
+			if gid != 0 && gid < f.numGlyphs {
+				f.cmap[rune(c)] = uint16(gid)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Font) parseCmap12(sub []byte) error {
+	if len(sub) < 16 {
+		return ErrInvalidFont
+	}
+	numGroups := int(binary.BigEndian.Uint32(sub[12:]))
+	if 16+12*numGroups > len(sub) {
+		return ErrInvalidFont
+	}
+
+	for i := 0; i < numGroups; i++ {
+		group := sub[16+12*i:]
+		start := binary.BigEndian.Uint32(group)
+		end := binary.BigEndian.Uint32(group[4:])
+		startGlyph := binary.BigEndian.Uint32(group[8:])
+		if end < start || end > 0x10FFFF {
+			continue
+		}
+
+		for c := start; c <= end; c++ {
+			gid := startGlyph + (c - start)
+			if gid < uint32(f.numGlyphs) {
+				f.cmap[rune(c)] = uint16(gid)
+			}
+		}
+	}
+	return nil
+}
+
+// parseName reads the PostScript name (name ID 6), falling back to a generic name
+func (f *Font) parseName() string {
+	name := f.tables["name"]
+	if len(name) < 6 {
+		return "Font"
+	}
+
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	storage := int(binary.BigEndian.Uint16(name[4:]))
+	for i := 0; i < count; i++ {
+		rec := 6 + 12*i
+		if rec+12 > len(name) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(name[rec:])
+		nameID := binary.BigEndian.Uint16(name[rec+6:])
+		length := int(binary.BigEndian.Uint16(name[rec+8:]))
+		offset := storage + int(binary.BigEndian.Uint16(name[rec+10:]))
+		if nameID != 6 || offset+length > len(name) {
+			continue
+		}
+
+		raw := name[offset : offset+length]
+		switch platform {
+		case 1:
+			return string(raw)
+		case 0, 3:
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[2*j:])
+			}
+			return string(utf16.Decode(units))
+		}
+	}
+	return "Font"
+}
+
+// glyph returns the glyph of a rune, or the missing glyph 0
+func (f *Font) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// advance returns the advance width of a glyph in thousandths of an em
+func (f *Font) advance(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return f.scale(int(f.advances[gid]))
+}
+
+// glyphData returns the outline of a glyph in the glyf table
+func (f *Font) glyphData(gid int) []byte {
+	loca := f.tables["loca"]
+	glyf := f.tables["glyf"]
+
+	var start, end int
+	if f.longLoca {
+		if 4*gid+8 > len(loca) {
+			return nil
+		}
+		start = int(binary.BigEndian.Uint32(loca[4*gid:]))
+		end = int(binary.BigEndian.Uint32(loca[4*gid+4:]))
+	} else {
+		if 2*gid+4 > len(loca) {
+			return nil
+		}
+		start = 2 * int(binary.BigEndian.Uint16(loca[2*gid:]))
+		end = 2 * int(binary.BigEndian.Uint16(loca[2*gid+2:]))
+	}
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// components returns the glyphs a composite glyph is built from
+func components(data []byte) []int {
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+
+	const (
+		argsAreWords    = 0x0001
+		haveScale       = 0x0008
+		moreComponents  = 0x0020
+		haveXYScale     = 0x0040
+		haveTwoByTwo    = 0x0080
+		componentHeader = 4
+	)
+
+	var gids []int
+	for pos := 10; pos+componentHeader <= len(data); {
+		flags := binary.BigEndian.Uint16(data[pos:])
+		gids = append(gids, int(binary.BigEndian.Uint16(data[pos+2:])))
+		pos += componentHeader
+
+		if flags&argsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			pos += 2
+		case flags&haveXYScale != 0:
+			pos += 4
+		case flags&haveTwoByTwo != 0:
+			pos += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return gids
+}
+
+// subset builds a font file that keeps only the outlines of the given glyphs and
+// the composite glyphs they depend on. Glyph IDs are unchanged, so text can be
+// encoded with the original IDs.
+func (f *Font) subset(used map[uint16]rune) []byte {
+	keep := map[int]bool{0: true}
+	queue := []int{}
+	for gid := range used {
+		queue = append(queue, int(gid))
+	}
+	for len(queue) > 0 {
+		gid := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if keep[gid] || gid >= f.numGlyphs {
+			continue
+		}
+		keep[gid] = true
+		queue = append(queue, components(f.glyphData(gid))...)
+	}
+	for _, gid := range components(f.glyphData(0)) {
+		keep[gid] = true
+	}
+
+	var glyf bytes.Buffer
+	loca := make([]byte, 4*(f.numGlyphs+1))
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(loca[4*gid:], uint32(glyf.Len()))
+		if keep[gid] {
+			glyf.Write(f.glyphData(gid))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*f.numGlyphs:], uint32(glyf.Len()))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0) // checkSumAdjustment, set below
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": f.tables["hhea"],
+		"maxp": f.tables["maxp"],
+		"hmtx": f.tables["hmtx"],
+		"cmap": f.tables["cmap"],
+		"loca": loca,
+		"glyf": glyf.Bytes(),
+	}
+	for _, tag := range []string{"cvt ", "fpgm", "prep"} {
+		if t, ok := f.tables[tag]; ok {
+			tables[tag] = t
+		}
+	}
+
+	return buildFont(tables)
+}
+
+// buildFont writes a TrueType font file from its tables
+func buildFont(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	var out bytes.Buffer
+	header := make([]byte, 12+16*numTables)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(16*numTables-searchRange))
+
+	offset := len(header)
+	headOffset := 0
+	for i, tag := range tags {
+		data := tables[tag]
+		rec := header[12+16*i:]
+		copy(rec, tag)
+		binary.BigEndian.PutUint32(rec[4:], checksum(data))
+		binary.BigEndian.PutUint32(rec[8:], uint32(offset))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(data)))
+		if tag == "head" {
+			headOffset = offset
+		}
+		offset += (len(data) + 3) &^ 3
+	}
+
+	out.Write(header)
+	for _, tag := range tags {
+		data := tables[tag]
+		out.Write(data)
+		for i := len(data); i%4 != 0; i++ {
+			out.WriteByte(0)
+		}
+	}
+
+	font := out.Bytes()
+	binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-checksum(font))
+	return font
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -16,9 +16,11 @@ type Response struct {
 
 // Meta represents pagination metadata
 type Meta struct {
-	Total  int64 `json:"total"`
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	Total          *int64 `json:"total,omitempty"`
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+	Limit          int64  `json:"limit"`
+	Offset         int64  `json:"offset"`
+	NextCursor     string `json:"next_cursor,omitempty"`
 }
 
 // Success sends a success response
@@ -0,0 +1,170 @@
+// Package semver parses and orders semantic versions (https://semver.org).
+package semver
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersion is returned when a version is not MAJOR.MINOR.PATCH with
+// optional -prerelease and +build parts
+var ErrInvalidVersion = errors.New("invalid semantic version")
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string // dot separated identifiers after "-", empty for a release
+	Build      string   // after "+", ignored when ordering
+}
+
+// Parse parses a semantic version. A leading "v" is accepted, as in git tags.
+func Parse(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	var v Version
+	s, build, hasBuild := strings.Cut(s, "+")
+	v.Build = build
+	core, prerelease, hasPrerelease := strings.Cut(s, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return Version{}, ErrInvalidVersion
+	}
+	numbers := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, ok := parseNumber(part)
+		if !ok {
+			return Version{}, ErrInvalidVersion
+		}
+		*numbers[i] = n
+	}
+
+	if hasPrerelease {
+		v.Prerelease = strings.Split(prerelease, ".")
+		for _, id := range v.Prerelease {
+			if !validIdentifier(id) {
+				return Version{}, ErrInvalidVersion
+			}
+			if isNumeric(id) && len(id) > 1 && id[0] == '0' {
+				return Version{}, ErrInvalidVersion
+			}
+		}
+	}
+	if hasBuild {
+		for _, id := range strings.Split(v.Build, ".") {
+			if !validIdentifier(id) {
+				return Version{}, ErrInvalidVersion
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// IsPrerelease reports whether v is a pre-release such as 2.1.0-beta.1
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// String returns the canonical form of v, without a leading "v"
+func (v Version) String() string {
+	s := strconv.FormatUint(v.Major, 10) + "." + strconv.FormatUint(v.Minor, 10) + "." + strconv.FormatUint(v.Patch, 10)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as a orders before, the same as or after b.
+// Pre-releases order before their release and build metadata is ignored.
+func Compare(a, b Version) int {
+	for _, pair := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifier(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a.Prerelease) < len(b.Prerelease):
+		return -1
+	case len(a.Prerelease) > len(b.Prerelease):
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifier orders pre-release identifiers: numeric ones numerically and
+// before alphanumeric ones, which order as ASCII
+func compareIdentifier(a, b string) int {
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+	switch {
+	case aNumeric && bNumeric:
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// parseNumber parses a version number, which has no leading zeros
+func parseNumber(s string) (uint64, bool) {
+	if !isNumeric(s) || (len(s) > 1 && s[0] == '0') {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	return n, err == nil
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func validIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
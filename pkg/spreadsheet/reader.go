@@ -0,0 +1,100 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Format represents a spreadsheet file format
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for an unknown spreadsheet format
+	ErrUnsupportedFormat = errors.New("unsupported spreadsheet format")
+
+	// ErrInvalidFile is returned when the file cannot be parsed
+	ErrInvalidFile = errors.New("invalid spreadsheet file")
+
+	// ErrStop can be returned by a row callback to stop reading without error
+	ErrStop = errors.New("stop reading")
+)
+
+// RowFunc is called for every row; num is the 1-based row number in the sheet
+type RowFunc func(num int, values []string) error
+
+// FormatFromFilename detects the format from a file extension
+func FormatFromFilename(name string) (Format, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(lower, ".xlsx"):
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ReadRows reads the rows of the first sheet of a CSV or XLSX file.
+// XLSX needs random access to the zip directory, hence io.ReaderAt and size.
+func ReadRows(format Format, r io.ReaderAt, size int64, fn RowFunc) error {
+	var err error
+	switch format {
+	case FormatCSV:
+		err = readCSV(io.NewSectionReader(r, 0, size), fn)
+	case FormatXLSX:
+		err = readXLSX(r, size, fn)
+	default:
+		return ErrUnsupportedFormat
+	}
+
+	if err == ErrStop {
+		return nil
+	}
+	return err
+}
+
+// readCSV reads a comma or semicolon separated file, skipping a UTF-8 BOM
+func readCSV(r io.Reader, fn RowFunc) error {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xEF\xBB\xBF" {
+		br.Discard(3)
+	}
+
+	// Excel with a Vietnamese locale saves CSV with ';' as separator
+	comma := ','
+	if line, _ := br.Peek(br.Buffered()); len(line) > 0 {
+		first := string(line)
+		if i := strings.IndexByte(first, '\n'); i >= 0 {
+			first = first[:i]
+		}
+		if strings.Count(first, ";") > strings.Count(first, ",") {
+			comma = ';'
+		}
+	}
+
+	reader := csv.NewReader(br)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+
+	for num := 1; ; num++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ErrInvalidFile
+		}
+		if err := fn(num, record); err != nil {
+			return err
+		}
+	}
+}
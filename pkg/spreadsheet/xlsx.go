@@ -0,0 +1,289 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// readXLSX streams the rows of the first worksheet of a workbook
+func readXLSX(r io.ReaderAt, size int64, fn RowFunc) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return ErrInvalidFile
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return err
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return err
+		}
+	}
+
+	sheet, ok := files[sheetPath]
+	if !ok {
+		return ErrInvalidFile
+	}
+	return readSheet(sheet, shared, fn)
+}
+
+// firstSheetPath resolves the part name of the first sheet through the workbook relationships
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(files, "xl/workbook.xml", &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return "", ErrInvalidFile
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", ErrInvalidFile
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return "", ErrInvalidFile
+}
+
+// decodePart unmarshals a small XML part of the package
+func decodePart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return ErrInvalidFile
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// readSharedStrings loads the shared string table
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var shared []string
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return shared, nil
+		}
+		if err != nil {
+			return nil, ErrInvalidFile
+		}
+
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "si" {
+			text, err := readText(dec, "si")
+			if err != nil {
+				return nil, err
+			}
+			shared = append(shared, text)
+		}
+	}
+}
+
+// readText concatenates the <t> elements until the end of the enclosing element,
+// which covers both plain and rich text runs
+func readText(dec *xml.Decoder, end string) (string, error) {
+	var sb strings.Builder
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", ErrInvalidFile
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "t" {
+				inText = true
+			} else if t.Name.Local == "rPh" {
+				// Phonetic runs are not part of the displayed value
+				if err := dec.Skip(); err != nil {
+					return "", ErrInvalidFile
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "t" {
+				inText = false
+			} else if t.Name.Local == end {
+				return sb.String(), nil
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+}
+
+// readSheet streams rows from the sheet XML without building the whole document
+func readSheet(f *zip.File, shared []string, fn RowFunc) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(rc)
+	var (
+		row    []string
+		rowNum int
+	)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ErrInvalidFile
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = row[:0]
+				rowNum++
+				if n, err := strconv.Atoi(attr(t, "r")); err == nil {
+					rowNum = n
+				}
+			case "c":
+				col := len(row)
+				if ref := attr(t, "r"); ref != "" && columnIndex(ref) >= col {
+					col = columnIndex(ref)
+				}
+				value, err := readCell(dec, attr(t, "t"), shared)
+				if err != nil {
+					return err
+				}
+				for len(row) < col {
+					row = append(row, "")
+				}
+				row = append(row, value)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				values := make([]string, len(row))
+				copy(values, row)
+				if err := fn(rowNum, values); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// readCell reads the value of a <c> element according to its type attribute
+func readCell(dec *xml.Decoder, cellType string, shared []string) (string, error) {
+	if cellType == "inlineStr" {
+		return readText(dec, "c")
+	}
+
+	var raw string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", ErrInvalidFile
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "v" {
+				var v string
+				if err := dec.DecodeElement(&v, &t); err != nil {
+					return "", ErrInvalidFile
+				}
+				raw = v
+			}
+		case xml.EndElement:
+			if t.Name.Local == "c" {
+				return cellValue(cellType, raw, shared), nil
+			}
+		}
+	}
+}
+
+// cellValue converts a raw cell value to text
+func cellValue(cellType, raw string, shared []string) string {
+	switch cellType {
+	case "s":
+		i, err := strconv.Atoi(raw)
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "b":
+		if raw == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "", "n":
+		// Large integers such as phone numbers may be stored in exponent form
+		if strings.ContainsAny(raw, "eE") {
+			if f, err := strconv.ParseFloat(raw, 64); err == nil {
+				return strconv.FormatFloat(f, 'f', -1, 64)
+			}
+		}
+		return raw
+	default:
+		return raw
+	}
+}
+
+// columnIndex converts a cell reference such as "AB12" to a zero-based column index
+func columnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
+
+// attr returns the value of an attribute by local name
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+// Package update signs and verifies the update manifests of the cafe client.
+//
+// A signed manifest has the same form as a license key: base64url(manifest JSON)
+// + "." + base64url(Ed25519 signature). The client verifies it with the release
+// public key before downloading, then checks the download against SHA256, so a
+// tampered mirror or proxy cannot push a build.
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Version is the manifest format written by Sign
+const Version = 1
+
+var (
+	// ErrInvalidManifest is returned when a manifest is malformed or its signature does not match
+	ErrInvalidManifest = errors.New("invalid update manifest")
+
+	// ErrInvalidSigningKey is returned when a signing key cannot be decoded
+	ErrInvalidSigningKey = errors.New("invalid release signing key")
+)
+
+// Manifest describes the build a client on a channel and platform should run
+type Manifest struct {
+	Version        int    `json:"v"`
+	Product        string `json:"product"`
+	Channel        string `json:"channel"`
+	Platform       string `json:"platform"`
+	ReleaseVersion string `json:"version"`
+	MinimumVersion string `json:"min_version,omitempty"` // clients below must update before running
+	URL            string `json:"url"`
+	FileName       string `json:"file_name"`
+	Size           int64  `json:"size"`
+	SHA256         string `json:"sha256"` // hex digest of the build
+	PublishedAt    int64  `json:"published_at"`
+	IssuedAt       int64  `json:"iat"`
+	ExpiresAt      int64  `json:"exp"` // clients refuse older manifests, so a stale one cannot be replayed
+}
+
+// Expired reports whether the manifest is past its expiry at now
+func (m *Manifest) Expired(now time.Time) bool {
+	return now.Unix() >= m.ExpiresAt
+}
+
+// Signer signs manifests with an Ed25519 private key
+type Signer struct {
+	privateKey ed25519.PrivateKey
+}
+
+// NewSigner creates a signer from a base64 encoded Ed25519 seed (32 bytes) or private key (64 bytes)
+func NewSigner(encoded string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, ErrInvalidSigningKey
+	}
+
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return &Signer{privateKey: ed25519.NewKeyFromSeed(raw)}, nil
+	case ed25519.PrivateKeySize:
+		return &Signer{privateKey: ed25519.PrivateKey(raw)}, nil
+	default:
+		return nil, ErrInvalidSigningKey
+	}
+}
+
+// GenerateSigner creates a signer with a random key.
+// Clients cannot pin its public key across restarts, so it is meant for development.
+func GenerateSigner() (*Signer, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{privateKey: privateKey}, nil
+}
+
+// PublicKey returns the public key that verifies the manifests of this signer
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Sign encodes and signs a manifest
+func (s *Signer) Sign(manifest *Manifest) (string, error) {
+	manifest.Version = Version
+
+	payload, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+
+	signature := ed25519.Sign(s.privateKey, payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks the signature of a signed manifest and returns it.
+// Expiry is not checked; use Manifest.Expired.
+func Verify(publicKey ed25519.PublicKey, signed string) (*Manifest, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(strings.TrimSpace(signed), ".")
+	if !ok {
+		return nil, ErrInvalidManifest
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidManifest
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, ErrInvalidManifest
+	}
+
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return nil, ErrInvalidManifest
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(payload, &manifest); err != nil || manifest.Version != Version {
+		return nil, ErrInvalidManifest
+	}
+
+	return &manifest, nil
+}
@@ -1,9 +1,14 @@
 package validator
 
 import (
+	"regexp"
+
 	"github.com/go-playground/validator/v10"
 )
 
+// taxCodePattern matches a Vietnamese tax code: 10 digits, with a 3-digit branch suffix for dependent units
+var taxCodePattern = regexp.MustCompile(`^\d{10}(-\d{3})?$`)
+
 // CustomValidator holds the validator instance
 type CustomValidator struct {
 	validator *validator.Validate
@@ -11,8 +16,13 @@ type CustomValidator struct {
 
 // NewValidator creates a new validator instance
 func NewValidator() *CustomValidator {
+	v := validator.New()
+	v.RegisterValidation("taxcode", func(fl validator.FieldLevel) bool {
+		return taxCodePattern.MatchString(fl.Field().String())
+	})
+
 	return &CustomValidator{
-		validator: validator.New(),
+		validator: v,
 	}
 }
 
@@ -39,6 +49,8 @@ func GetValidationErrors(err error) map[string]string {
 				errors[field] = field + " is too short"
 			case "max":
 				errors[field] = field + " is too long"
+			case "taxcode":
+				errors[field] = field + " must be a 10-digit tax code, optionally followed by -XXX"
 			default:
 				errors[field] = field + " is invalid"
 			}
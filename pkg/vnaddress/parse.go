@@ -0,0 +1,173 @@
+package vnaddress
+
+import "strings"
+
+// Level represents the deepest administrative level matched by the parser
+type Level int
+
+const (
+	LevelNone Level = iota
+	LevelProvince
+)
+
+// String returns the level name
+func (l Level) String() string {
+	switch l {
+	case LevelProvince:
+		return "province"
+	default:
+		return ""
+	}
+}
+
+// districtPrefixes and wardPrefixes recognise district and ward names by their unit type
+var (
+	districtPrefixes = [][]string{{"quan"}, {"huyen"}, {"thi", "xa"}, {"thanh", "pho"}, {"tp"}, {"tx"}, {"q"}}
+	wardPrefixes     = [][]string{{"phuong"}, {"xa"}, {"thi", "tran"}, {"dac", "khu"}, {"tt"}, {"p"}}
+)
+
+// ParseResult represents a parsed free-text address
+type ParseResult struct {
+	Address Address
+
+	// Matched is the deepest level found in the dataset. The district and ward
+	// are filled with the names written in the text, which are not checked.
+	Matched Level
+}
+
+// Parse converts a comma separated free-text address, written from the street
+// to the province, into a structured address. It is best-effort: the province is
+// the rightmost part naming a province, the parts before it starting with a
+// district or ward type ("Quận", "P.", ...) become the district and ward, and
+// whatever is left becomes the street.
+func (d *Dataset) Parse(text string) *ParseResult {
+	result := &ParseResult{Address: Address{Version: d.Version}}
+
+	parts := splitAddress(text)
+	var p *Province
+	pi := len(parts) - 1
+	for ; pi >= 0; pi-- {
+		if p = d.FindProvince(parts[pi]); p != nil {
+			break
+		}
+	}
+	if p == nil {
+		result.Address.Street = strings.Join(parts, ", ")
+		return result
+	}
+
+	result.Address.ProvinceCode, result.Address.Province = p.Code, p.FullName()
+	result.Matched = LevelProvince
+	rest := parts[:pi]
+
+	if d.HasDistricts() {
+		rest = parseDistrict(rest, result)
+	} else {
+		rest = parseWard(rest, true, result)
+	}
+
+	result.Address.Street = strings.Join(rest, ", ")
+	return result
+}
+
+// parseDistrict picks the district and ward of a three-level address and returns
+// the remaining parts
+func parseDistrict(rest []string, result *ParseResult) []string {
+	if len(rest) > 0 && hasTypePrefix(rest[len(rest)-1], districtPrefixes) {
+		result.Address.District = expandType(rest[len(rest)-1])
+		rest = rest[:len(rest)-1]
+	}
+
+	return parseWard(rest, false, result)
+}
+
+// parseWard picks the ward among the last parts and returns the remaining parts.
+// With skipDistrict, a district name written before the province is skipped, as
+// addresses written before the district level was removed still contain one.
+func parseWard(rest []string, skipDistrict bool, result *ParseResult) []string {
+	candidates := []int{len(rest) - 1}
+	if skipDistrict && len(rest) > 1 && hasTypePrefix(rest[len(rest)-1], districtPrefixes) {
+		candidates = append(candidates, len(rest)-2)
+	}
+
+	for _, i := range candidates {
+		if i < 0 {
+			break
+		}
+		if hasTypePrefix(rest[i], wardPrefixes) {
+			result.Address.Ward = expandType(rest[i])
+			return rest[:i]
+		}
+	}
+
+	return rest
+}
+
+// typeAbbreviations maps the abbreviated unit types to their full form
+var typeAbbreviations = map[string]string{
+	"tp": "Thành phố",
+	"tx": "Thị xã",
+	"tt": "Thị trấn",
+	"q":  "Quận",
+	"p":  "Phường",
+}
+
+// expandType spells out an abbreviated unit type, e.g. "P. Bến Nghé" or "Q1"
+func expandType(name string) string {
+	end := strings.IndexFunc(name, func(r rune) bool {
+		return r == ' ' || r == '.' || (r >= '0' && r <= '9')
+	})
+	if end <= 0 {
+		return name
+	}
+
+	full, ok := typeAbbreviations[strings.ToLower(name[:end])]
+	if !ok {
+		return name
+	}
+
+	rest := strings.TrimLeft(name[end:], ". ")
+	if rest == "" {
+		return name
+	}
+	return full + " " + rest
+}
+
+// splitAddress splits a free-text address on commas, semicolons and line breaks
+func splitAddress(text string) []string {
+	raw := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n' || r == '\r'
+	})
+
+	parts := make([]string, 0, len(raw))
+	for _, part := range raw {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// hasTypePrefix reports whether s starts with one of the unit type prefixes,
+// including the glued number abbreviations "Q1" and "P12"
+func hasTypePrefix(s string, prefixes [][]string) bool {
+	fields := keyFields(s)
+	if len(fields) < 2 {
+		if len(fields) == 1 && len(fields[0]) > 1 && isDigits(fields[0][1:]) {
+			first := fields[0][:1]
+			for _, prefix := range prefixes {
+				if len(prefix) == 1 && prefix[0] == first {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, prefix := range prefixes {
+		if len(fields) > len(prefix) && hasFields(fields, prefix) {
+			return true
+		}
+	}
+	return false
+}
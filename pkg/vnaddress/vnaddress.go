@@ -0,0 +1,384 @@
+// Package vnaddress provides the Vietnamese administrative units from embedded,
+// versioned datasets, with name lookup and a best-effort free-text address parser.
+//
+// Each dataset is stored in data/<version>.json. Version 2024 has three levels
+// (province, district, ward). Version 2025 follows the July 2025 reorganisation:
+// provinces were merged and the district level was removed. Every 2025 province
+// lists the 2024 province codes it was merged from and keeps the code of the
+// province whose name it kept, so a code means the same area in both versions.
+//
+// The datasets list the provinces only. District and ward names are kept as
+// given, tidied but not checked, and have no code.
+package vnaddress
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+//go:embed data/*.json
+var dataFS embed.FS
+
+var (
+	// ErrUnknownVersion is returned for a dataset version that is not embedded
+	ErrUnknownVersion = errors.New("unknown administrative dataset version")
+
+	// ErrUnknownProvince is returned when a province code or name is not in the dataset
+	ErrUnknownProvince = errors.New("unknown province")
+
+	// ErrUnknownDistrict is returned for a district in a dataset without a district level
+	ErrUnknownDistrict = errors.New("unknown district")
+)
+
+// Unit represents an administrative unit
+type Unit struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// FullName returns the name prefixed with the unit type, e.g. "Quận 1"
+func (u *Unit) FullName() string {
+	return u.Type + " " + u.Name
+}
+
+// Province represents a province or centrally-run city
+type Province struct {
+	Unit
+	MergedFrom []string `json:"merged_from,omitempty"`
+}
+
+// Dataset represents one version of the administrative units
+type Dataset struct {
+	Version       string     `json:"version"`
+	EffectiveFrom string     `json:"effective_from,omitempty"`
+	ValidUntil    string     `json:"valid_until,omitempty"`
+	Supersedes    string     `json:"supersedes,omitempty"`
+	Levels        int        `json:"levels"`
+	Provinces     []Province `json:"provinces"`
+
+	provinces map[string]*Province
+}
+
+// HasDistricts reports whether the dataset has a district level
+func (d *Dataset) HasDistricts() bool {
+	return d.Levels > 2
+}
+
+// Province finds a province by code
+func (d *Dataset) Province(code string) *Province {
+	for i := range d.Provinces {
+		if d.Provinces[i].Code == code {
+			return &d.Provinces[i]
+		}
+	}
+	return nil
+}
+
+// FindProvince finds a province by name or alias. The names of the provinces
+// it was merged from are accepted too.
+func (d *Dataset) FindProvince(name string) *Province {
+	return d.provinces[nameKey(name)]
+}
+
+// HistoricalCodes returns the province codes, in this and older versions,
+// of the area now covered by the given province
+func (d *Dataset) HistoricalCodes(code string) []string {
+	seen := map[string]bool{code: true}
+	codes := []string{code}
+
+	for current := d; current != nil; current = loaded.byVersion[current.Supersedes] {
+		for _, c := range codes {
+			p := current.Province(c)
+			if p == nil {
+				continue
+			}
+			for _, old := range p.MergedFrom {
+				if !seen[old] {
+					seen[old] = true
+					codes = append(codes, old)
+				}
+			}
+		}
+	}
+
+	return codes
+}
+
+// Address represents an address resolved against a dataset
+type Address struct {
+	Street       string
+	Ward         string
+	District     string
+	ProvinceCode string
+	Province     string
+	Version      string
+}
+
+// Resolve checks the province of an address against the dataset and fills in its
+// code and full name. The province can be given by code or by name; a code takes
+// precedence. District and ward names are only tidied.
+func (d *Dataset) Resolve(a *Address) error {
+	a.Version = d.Version
+
+	p := d.Province(a.ProvinceCode)
+	if a.ProvinceCode == "" {
+		p = d.FindProvince(a.Province)
+	}
+	if p == nil {
+		return ErrUnknownProvince
+	}
+	a.ProvinceCode, a.Province = p.Code, p.FullName()
+
+	a.District = expandType(strings.Join(strings.Fields(a.District), " "))
+	a.Ward = expandType(strings.Join(strings.Fields(a.Ward), " "))
+	if a.District != "" && !d.HasDistricts() {
+		return ErrUnknownDistrict
+	}
+
+	return nil
+}
+
+// Versions returns the embedded datasets, oldest first
+func Versions() []*Dataset {
+	load()
+	return loaded.ordered
+}
+
+// Latest returns the most recent dataset
+func Latest() *Dataset {
+	load()
+	return loaded.ordered[len(loaded.ordered)-1]
+}
+
+// Get returns the dataset of a version, or the latest dataset for an empty version
+func Get(version string) (*Dataset, error) {
+	if version == "" {
+		return Latest(), nil
+	}
+
+	load()
+	d, ok := loaded.byVersion[version]
+	if !ok {
+		return nil, ErrUnknownVersion
+	}
+	return d, nil
+}
+
+// Successor returns the province of the latest dataset that now covers a province
+// of an older version
+func Successor(version, code string) *Province {
+	d, err := Get(version)
+	if err != nil {
+		return nil
+	}
+
+	latest := Latest()
+	for d != latest {
+		next := nextVersion(d)
+		if next == nil {
+			return nil
+		}
+		code = successorCode(next, code)
+		if code == "" {
+			return nil
+		}
+		d = next
+	}
+
+	return d.Province(code)
+}
+
+// CurrentProvince returns the province of the latest dataset covering a province
+// code of any version
+func CurrentProvince(code string) *Province {
+	versions := Versions()
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Province(code) != nil {
+			return Successor(versions[i].Version, code)
+		}
+	}
+	return nil
+}
+
+// nextVersion returns the dataset that supersedes d
+func nextVersion(d *Dataset) *Dataset {
+	for _, candidate := range loaded.ordered {
+		if candidate.Supersedes == d.Version {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// successorCode returns the code of the province of d merged from an older code
+func successorCode(d *Dataset, old string) string {
+	for _, p := range d.Provinces {
+		for _, code := range p.MergedFrom {
+			if code == old {
+				return p.Code
+			}
+		}
+	}
+	return ""
+}
+
+// datasets holds the loaded datasets
+type datasets struct {
+	byVersion map[string]*Dataset
+	ordered   []*Dataset
+}
+
+var (
+	loadOnce sync.Once
+	loaded   datasets
+)
+
+// load parses the embedded datasets once. The files are part of the binary,
+// so a malformed file is a programming error.
+func load() {
+	loadOnce.Do(func() {
+		entries, err := dataFS.ReadDir("data")
+		if err != nil {
+			panic(err)
+		}
+
+		loaded.byVersion = make(map[string]*Dataset, len(entries))
+		for _, entry := range entries {
+			raw, err := dataFS.ReadFile(path.Join("data", entry.Name()))
+			if err != nil {
+				panic(err)
+			}
+
+			var d Dataset
+			if err := json.Unmarshal(raw, &d); err != nil {
+				panic("vnaddress: " + entry.Name() + ": " + err.Error())
+			}
+			d.index()
+
+			loaded.byVersion[d.Version] = &d
+			loaded.ordered = append(loaded.ordered, &d)
+		}
+
+		sort.Slice(loaded.ordered, func(i, j int) bool {
+			return loaded.ordered[i].Version < loaded.ordered[j].Version
+		})
+
+		// Accept the names of merged provinces, e.g. "Bình Dương" in version 2025
+		for _, d := range loaded.ordered {
+			prev := loaded.byVersion[d.Supersedes]
+			if prev == nil {
+				continue
+			}
+			for i := range d.Provinces {
+				p := &d.Provinces[i]
+				for _, code := range p.MergedFrom {
+					if old := prev.Province(code); old != nil {
+						addNames(d.provinces, p, &old.Unit)
+					}
+				}
+			}
+		}
+	})
+}
+
+// index builds the name lookups of the dataset
+func (d *Dataset) index() {
+	d.provinces = make(map[string]*Province, len(d.Provinces))
+	for i := range d.Provinces {
+		p := &d.Provinces[i]
+		addNames(d.provinces, p, &p.Unit)
+	}
+}
+
+// addNames indexes the names of unit under value, keeping existing entries
+func addNames(index map[string]*Province, value *Province, unit *Unit) {
+	keys := []string{nameKey(unit.Name), nameKey(unit.FullName())}
+	for _, alias := range unit.Aliases {
+		keys = append(keys, nameKey(alias))
+	}
+	for _, key := range keys {
+		if _, ok := index[key]; !ok && key != "" {
+			index[key] = value
+		}
+	}
+}
+
+// typePrefixes lists the unit type words stripped from names, longest first
+var typePrefixes = [][]string{
+	{"thanh", "pho"}, {"thi", "xa"}, {"thi", "tran"}, {"dac", "khu"},
+	{"tinh"}, {"quan"}, {"huyen"}, {"phuong"}, {"xa"}, {"tp"}, {"tx"}, {"tt"}, {"q"}, {"p"},
+}
+
+// nameKey folds a unit name for comparison: lower case, no diacritics or
+// punctuation, and without a leading unit type ("Quận", "TP.", "P.", ...)
+func nameKey(name string) string {
+	fields := keyFields(name)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Abbreviations glued to a number, e.g. "Q1" or "P.12"
+	if first := fields[0]; len(first) > 1 && (first[0] == 'q' || first[0] == 'p') && isDigits(first[1:]) {
+		fields[0] = first[1:]
+		return strings.Join(fields, " ")
+	}
+	if len(fields) > 1 && (fields[0] == "q" || fields[0] == "p") && isDigits(fields[1]) {
+		return strings.Join(fields[1:], " ")
+	}
+
+	for _, prefix := range typePrefixes {
+		if len(fields) > len(prefix) && hasFields(fields, prefix) {
+			fields = fields[len(prefix):]
+			break
+		}
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// keyFields lower-cases s, removes diacritics and splits it on anything that is
+// not a letter or digit
+func keyFields(s string) []string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			r = 'd'
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+	return strings.Fields(b.String())
+}
+
+// hasFields reports whether fields starts with prefix
+func hasFields(fields, prefix []string) bool {
+	for i, p := range prefix {
+		if fields[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}